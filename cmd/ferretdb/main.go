@@ -120,8 +120,10 @@ var cli struct {
 
 		EnableNewAuth bool `default:"false" help:"Experimental: enable new authentication."`
 
-		BatchSize            int `default:"100" help:"Experimental: maximum insertion batch size."`
-		MaxBsonObjectSizeMiB int `default:"16"  help:"Experimental: maximum BSON object size in MiB."`
+		BatchSize                 int `default:"100" help:"Experimental: maximum insertion batch size."`
+		MaxBsonObjectSizeMiB      int `default:"16"  help:"Experimental: maximum BSON object size in MiB."`
+		GraphLookupMaxMemoryMiB   int `default:"100" help:"Experimental: maximum memory used by $graphLookup stage in MiB."`
+		BlockingStageMaxMemoryMiB int `default:"100" help:"Experimental: maximum memory used by $sort and $group stages and find sorting without limit in MiB."`
 
 		Telemetry struct {
 			URL            string        `default:"https://beacon.ferretdb.com/" help:"Telemetry: reporting URL."`
@@ -270,6 +272,34 @@ func setupState() *state.Provider {
 	return sp
 }
 
+// spillDir returns the directory for temporary files of $sort and $group stages.
+//
+// It is empty if the process state directory is not set,
+// so the default directory for temporary files is used.
+// Temporary files left by the previous run are removed.
+func spillDir(logger *slog.Logger) string {
+	dir := cli.StateDir
+	if dir == "" || dir == "-" {
+		return ""
+	}
+
+	res, err := filepath.Abs(filepath.Join(dir, "tmp"))
+	if err != nil {
+		log.Fatalf("Failed to get path for temporary files: %s.", err)
+	}
+
+	// the pattern is valid, so only nil error is possible
+	files, _ := filepath.Glob(filepath.Join(res, "ferretdb-sort-*"))
+
+	for _, f := range files {
+		if err = os.Remove(f); err != nil {
+			logger.Warn("Failed to remove old temporary file", logging.Error(err))
+		}
+	}
+
+	return res
+}
+
 // setupMetrics setups Prometheus metrics registerer with some metrics.
 func setupMetrics(stateProvider *state.Provider) prometheus.Registerer {
 	r := prometheus.DefaultRegisterer
@@ -512,6 +542,7 @@ func run() {
 		StateProvider: stateProvider,
 		TCPHost:       cli.Listen.Addr,
 		ReplSetName:   cli.ReplSetName,
+		SpillDir:      spillDir(logger),
 
 		SetupDatabase: cli.Setup.Database,
 		SetupUsername: cli.Setup.Username,
@@ -527,13 +558,15 @@ func run() {
 		MySQLURL: mySQLFlags.MySQLURL,
 
 		TestOpts: registry.TestOpts{
-			DisablePushdown:         cli.Test.DisablePushdown,
-			EnableNestedPushdown:    cli.Test.EnableNestedPushdown,
-			CappedCleanupInterval:   cli.Test.CappedCleanup.Interval,
-			CappedCleanupPercentage: cli.Test.CappedCleanup.Percentage,
-			EnableNewAuth:           cli.Test.EnableNewAuth,
-			BatchSize:               cli.Test.BatchSize,
-			MaxBsonObjectSizeBytes:  cli.Test.MaxBsonObjectSizeMiB * 1024 * 1024,
+			DisablePushdown:             cli.Test.DisablePushdown,
+			EnableNestedPushdown:        cli.Test.EnableNestedPushdown,
+			CappedCleanupInterval:       cli.Test.CappedCleanup.Interval,
+			CappedCleanupPercentage:     cli.Test.CappedCleanup.Percentage,
+			EnableNewAuth:               cli.Test.EnableNewAuth,
+			BatchSize:                   cli.Test.BatchSize,
+			MaxBsonObjectSizeBytes:      cli.Test.MaxBsonObjectSizeMiB * 1024 * 1024,
+			GraphLookupMaxMemoryBytes:   cli.Test.GraphLookupMaxMemoryMiB * 1024 * 1024,
+			BlockingStageMaxMemoryBytes: cli.Test.BlockingStageMaxMemoryMiB * 1024 * 1024,
 		},
 	})
 	if err != nil {
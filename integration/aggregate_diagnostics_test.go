@@ -0,0 +1,469 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+
+	"github.com/FerretDB/FerretDB/integration/setup"
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+func TestAggregateCurrentOp(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t)
+
+	admin := collection.Database().Client().Database("admin")
+
+	cursor, err := admin.Aggregate(ctx, bson.A{
+		bson.D{{"$currentOp", bson.D{{"allUsers", true}}}},
+		bson.D{{"$match", bson.D{{"command.comment", t.Name()}}}},
+	}, options.Aggregate().SetComment(t.Name()))
+	require.NoError(t, err)
+
+	res := FetchAll(t, ctx, cursor)
+	require.Len(t, res, 1)
+
+	doc := ConvertDocument(t, res[0])
+
+	assert.Equal(t, "op", must.NotFail(doc.Get("type")))
+	assert.Equal(t, true, must.NotFail(doc.Get("active")))
+	assert.Equal(t, "command", must.NotFail(doc.Get("op")))
+	assert.Equal(t, "admin.$cmd.aggregate", must.NotFail(doc.Get("ns")))
+	assert.NotNil(t, must.NotFail(doc.Get("opid")))
+	assert.NotNil(t, must.NotFail(doc.Get("microsecs_running")))
+
+	command, ok := must.NotFail(doc.Get("command")).(*types.Document)
+	require.True(t, ok)
+	assert.Equal(t, "aggregate", command.Command())
+}
+
+func TestAggregateListLocalSessions(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t)
+
+	client := collection.Database().Client()
+
+	sess, err := client.StartSession()
+	require.NoError(t, err)
+
+	defer sess.EndSession(ctx)
+
+	err = mongo.WithSession(ctx, sess, func(sctx mongo.SessionContext) error {
+		return collection.Database().RunCommand(sctx, bson.D{{"ping", int32(1)}}).Err()
+	})
+	require.NoError(t, err)
+
+	var sessID bson.D
+	require.NoError(t, bson.Unmarshal(sess.ID(), &sessID))
+
+	cursor, err := client.Database("admin").Aggregate(ctx, bson.A{
+		bson.D{{"$listLocalSessions", bson.D{{"allUsers", true}}}},
+		bson.D{{"$match", bson.D{{"_id.id", sessID[0].Value}}}},
+	})
+	require.NoError(t, err)
+
+	res := FetchAll(t, ctx, cursor)
+	require.Len(t, res, 1)
+
+	doc := ConvertDocument(t, res[0])
+
+	id, ok := must.NotFail(doc.Get("_id")).(*types.Document)
+	require.True(t, ok)
+	assert.True(t, id.Has("uid"))
+	assert.True(t, doc.Has("lastUse"))
+}
+
+func TestAggregateIndexStats(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t, shareddata.Scalars)
+
+	_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{"v", 1}}})
+	require.NoError(t, err)
+
+	_, err = collection.Find(ctx, bson.D{{"v", int32(42)}})
+	require.NoError(t, err)
+
+	cursor, err := collection.Aggregate(ctx, bson.A{
+		bson.D{{"$indexStats", bson.D{}}},
+		bson.D{{"$sort", bson.D{{"name", 1}}}},
+	})
+	require.NoError(t, err)
+
+	res := ConvertDocuments(t, FetchAll(t, ctx, cursor))
+	require.Len(t, res, 2)
+
+	assert.Equal(t, "_id_", must.NotFail(res[0].Get("name")))
+	assert.Equal(t, "v_1", must.NotFail(res[1].Get("name")))
+
+	for _, doc := range res {
+		assert.True(t, doc.Has("host"))
+		assert.True(t, doc.Has("spec"))
+	}
+
+	key, ok := must.NotFail(res[1].Get("key")).(*types.Document)
+	require.True(t, ok)
+	assert.Equal(t, must.NotFail(types.NewDocument("v", int32(1))), key)
+
+	accesses, ok := must.NotFail(res[1].Get("accesses")).(*types.Document)
+	require.True(t, ok)
+	assert.EqualValues(t, 1, must.NotFail(accesses.Get("ops")))
+	assert.True(t, accesses.Has("since"))
+
+	// counters of the recreated index start from zero
+	_, err = collection.Indexes().DropOne(ctx, "v_1")
+	require.NoError(t, err)
+
+	_, err = collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{"v", 1}}})
+	require.NoError(t, err)
+
+	cursor, err = collection.Aggregate(ctx, bson.A{
+		bson.D{{"$indexStats", bson.D{}}},
+		bson.D{{"$match", bson.D{{"name", "v_1"}}}},
+	})
+	require.NoError(t, err)
+
+	res = ConvertDocuments(t, FetchAll(t, ctx, cursor))
+	require.Len(t, res, 1)
+
+	accesses, ok = must.NotFail(res[0].Get("accesses")).(*types.Document)
+	require.True(t, ok)
+	assert.EqualValues(t, 0, must.NotFail(accesses.Get("ops")))
+}
+
+func TestAggregateDiagnosticsErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t)
+
+	for name, tc := range map[string]struct { //nolint:vet // used for test only
+		command  bson.D          // required, command to run
+		database *mongo.Database // defaults to collection.Database()
+
+		err        *mongo.CommandError // required
+		altMessage string              // optional, alternative error message
+		skip       string              // optional, skip test with a specified reason
+	}{
+		"CurrentOpNotAdmin": {
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$currentOp", bson.D{}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    73,
+				Name:    "InvalidNamespace",
+				Message: "$currentOp must be run against the 'admin' database with {aggregate: 1}",
+			},
+		},
+		"CurrentOpCollection": {
+			database: collection.Database().Client().Database("admin"),
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$currentOp", bson.D{}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    73,
+				Name:    "InvalidNamespace",
+				Message: "$currentOp must be run against the 'admin' database with {aggregate: 1}",
+			},
+		},
+		"CurrentOpNotFirst": {
+			database: collection.Database().Client().Database("admin"),
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{
+					bson.D{{"$currentOp", bson.D{}}},
+					bson.D{{"$currentOp", bson.D{}}},
+				}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    40602,
+				Name:    "Location40602",
+				Message: "$currentOp is only valid as the first stage in a pipeline",
+			},
+		},
+		"CurrentOpNotDocument": {
+			database: collection.Database().Client().Database("admin"),
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$currentOp", "foo"}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    9,
+				Name:    "FailedToParse",
+				Message: "$currentOp options must be specified in an object, but found: string",
+			},
+		},
+		"CurrentOpUnknownOption": {
+			database: collection.Database().Client().Database("admin"),
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$currentOp", bson.D{{"foo", true}}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    9,
+				Name:    "FailedToParse",
+				Message: "Unrecognized option 'foo' in $currentOp stage.",
+			},
+		},
+		"CurrentOpAllUsersNotBool": {
+			database: collection.Database().Client().Database("admin"),
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$currentOp", bson.D{{"allUsers", int32(1)}}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    9,
+				Name:    "FailedToParse",
+				Message: "The 'allUsers' parameter of the $currentOp stage must be a boolean value, but found: int",
+			},
+		},
+		"ListLocalSessionsCollection": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$listLocalSessions", bson.D{}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    73,
+				Name:    "InvalidNamespace",
+				Message: "$listLocalSessions must be run against the database with {aggregate: 1}, not a collection",
+			},
+		},
+		"ListLocalSessionsUnknownField": {
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$listLocalSessions", bson.D{{"foo", true}}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    40415,
+				Name:    "Location40415",
+				Message: "BSON field '$listLocalSessions.foo' is an unknown field.",
+			},
+		},
+		"ListLocalSessionsAllUsersNotBool": {
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$listLocalSessions", bson.D{{"allUsers", "true"}}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    14,
+				Name:    "TypeMismatch",
+				Message: "BSON field '$listLocalSessions.allUsers' is the wrong type 'string', expected type 'bool'",
+			},
+		},
+		"IndexStatsNotEmpty": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$indexStats", bson.D{{"foo", int32(1)}}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    28803,
+				Name:    "Location28803",
+				Message: "The $indexStats stage specification must be an empty object",
+			},
+		},
+		"IndexStatsNotFirst": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{
+					bson.D{{"$match", bson.D{}}},
+					bson.D{{"$indexStats", bson.D{}}},
+				}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    40602,
+				Name:    "Location40602",
+				Message: "$indexStats is only valid as the first stage in a pipeline",
+			},
+		},
+		"IndexStatsAgnostic": {
+			command: bson.D{
+				{"aggregate", int32(1)},
+				{"pipeline", bson.A{bson.D{{"$indexStats", bson.D{}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    73,
+				Name:    "InvalidNamespace",
+				Message: "{aggregate: 1} is not valid for '$indexStats'; a collection is required.",
+			},
+		},
+		"CurrentOpInFacet": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$facet", bson.D{
+					{"ops", bson.A{bson.D{{"$currentOp", bson.D{}}}}},
+				}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    40600,
+				Name:    "Location40600",
+				Message: "$currentOp is not allowed to be used within a $facet stage",
+			},
+		},
+		"CurrentOpInUnionWith": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$unionWith", bson.D{
+					{"coll", collection.Name()},
+					{"pipeline", bson.A{bson.D{{"$currentOp", bson.D{}}}}},
+				}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    31441,
+				Name:    "Location31441",
+				Message: "$currentOp is not allowed within a $unionWith's sub-pipeline",
+			},
+		},
+		"ListLocalSessionsInLookup": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$lookup", bson.D{
+					{"from", collection.Name()},
+					{"pipeline", bson.A{bson.D{{"$listLocalSessions", bson.D{}}}}},
+					{"as", "sessions"},
+				}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    51047,
+				Name:    "Location51047",
+				Message: "$listLocalSessions is not allowed to be used within a $lookup stage",
+			},
+		},
+		"IndexStatsInLookup": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$lookup", bson.D{
+					{"from", collection.Name()},
+					{"pipeline", bson.A{bson.D{{"$indexStats", bson.D{}}}}},
+					{"as", "stats"},
+				}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    51047,
+				Name:    "Location51047",
+				Message: "$indexStats is not allowed to be used within a $lookup stage",
+			},
+		},
+		"IndexStatsInFacetInLookup": {
+			command: bson.D{
+				{"aggregate", collection.Name()},
+				{"pipeline", bson.A{bson.D{{"$lookup", bson.D{
+					{"from", collection.Name()},
+					{"pipeline", bson.A{bson.D{{"$facet", bson.D{
+						{"stats", bson.A{bson.D{{"$indexStats", bson.D{}}}}},
+					}}}}},
+					{"as", "stats"},
+				}}}}},
+				{"cursor", bson.D{}},
+			},
+			err: &mongo.CommandError{
+				Code:    40600,
+				Name:    "Location40600",
+				Message: "$indexStats is not allowed to be used within a $facet stage",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.skip != "" {
+				t.Skip(tc.skip)
+			}
+
+			require.NotNil(t, tc.err, "err must not be nil")
+
+			db := tc.database
+			if db == nil {
+				db = collection.Database()
+			}
+
+			var res bson.D
+			err := db.RunCommand(ctx, tc.command).Decode(&res)
+			AssertEqualAltCommandError(t, *tc.err, tc.altMessage, err)
+			require.Nil(t, res)
+		})
+	}
+}
+
+func TestAggregateDiagnosticsAllUsersUnauthorized(t *testing.T) {
+	t.Parallel()
+
+	s := setup.SetupWithOpts(t, nil)
+	ctx, db := s.Ctx, s.Collection.Database()
+	username, password := "diagnostics_user", "diagnostics_password"
+
+	err := db.RunCommand(ctx, bson.D{
+		{"createUser", username},
+		{"roles", bson.A{}},
+		{"pwd", password},
+		{"mechanisms", bson.A{"SCRAM-SHA-256"}},
+	}).Err()
+	require.NoError(t, err)
+
+	credential := options.Credential{
+		AuthMechanism: "SCRAM-SHA-256",
+		AuthSource:    db.Name(),
+		Username:      username,
+		Password:      password,
+	}
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(s.MongoDBURI).SetAuth(credential))
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, client.Disconnect(ctx))
+	})
+
+	admin := client.Database("admin")
+
+	for name, stage := range map[string]bson.D{
+		"CurrentOp":         {{"$currentOp", bson.D{{"allUsers", true}}}},
+		"ListLocalSessions": {{"$listLocalSessions", bson.D{{"allUsers", true}}}},
+	} {
+		name, stage := name, stage
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := admin.Aggregate(ctx, bson.A{stage})
+			AssertMatchesCommandError(t, mongo.CommandError{Code: 13, Name: "Unauthorized"}, err)
+		})
+	}
+}
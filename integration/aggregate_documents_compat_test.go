@@ -37,6 +37,7 @@ import (
 type aggregateStagesCompatTestCase struct {
 	pipeline bson.A         // required, unspecified $sort appends bson.D{{"$sort", bson.D{{"_id", 1}}}} for non empty pipeline.
 	maxTime  *time.Duration // optional, leave nil for unset maxTime
+	let      bson.D         // optional, leave nil for unset let
 
 	resultType     compatTestCaseResultType // defaults to nonEmptyResult
 	resultPushdown resultPushdown           // defaults to noPushdown
@@ -96,6 +97,10 @@ func testAggregateStagesCompatWithProviders(t *testing.T, providers shareddata.P
 				opts.SetMaxTime(*tc.maxTime)
 			}
 
+			if tc.let != nil {
+				opts.SetLet(tc.let)
+			}
+
 			var nonEmptyResults bool
 			for i := range targetCollections {
 				targetCollection := targetCollections[i]
@@ -1243,6 +1248,199 @@ func TestAggregateCompatGroupSum(t *testing.T) {
 	testAggregateStagesCompatWithProviders(t, providers, testCases)
 }
 
+func TestAggregateCompatGroupAccumulators(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.SmallDoubles,
+		shareddata.Strings,
+		shareddata.Composites,
+		shareddata.Mixed,
+		shareddata.DocumentsDocuments,
+		shareddata.Nulls,
+	}
+
+	// group returns a pipeline that groups all documents sorted by _id with given accumulators,
+	// and applies projection if it is not nil
+	group := func(accumulators bson.D, projection bson.D) bson.A {
+		pipeline := bson.A{
+			bson.D{{"$sort", bson.D{{"_id", 1}}}},
+			bson.D{{"$group", append(bson.D{{"_id", nil}}, accumulators...)}},
+		}
+
+		if projection != nil {
+			pipeline = append(pipeline, bson.D{{"$project", projection}})
+		}
+
+		return pipeline
+	}
+
+	// round rounds the result of floating point computation
+	round := func(field string) bson.D {
+		return bson.D{{"$round", bson.A{field, 10}}}
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Avg": {
+			pipeline: group(
+				bson.D{{"avg", bson.D{{"$avg", "$v"}}}},
+				bson.D{{"avg", round("$avg")}},
+			),
+		},
+		"MinMax": {
+			pipeline: group(bson.D{
+				{"min", bson.D{{"$min", "$v"}}},
+				{"max", bson.D{{"$max", "$v"}}},
+			}, nil),
+		},
+		"MinMaxNonExistent": {
+			pipeline: group(bson.D{
+				{"min", bson.D{{"$min", "$non-existent"}}},
+				{"max", bson.D{{"$max", "$non-existent"}}},
+			}, nil),
+		},
+		"FirstLast": {
+			pipeline: group(bson.D{
+				{"first", bson.D{{"$first", "$v"}}},
+				{"last", bson.D{{"$last", "$v"}}},
+			}, nil),
+		},
+		"Push": {
+			pipeline: group(bson.D{{"push", bson.D{{"$push", "$v"}}}}, nil),
+		},
+		"PushDocument": {
+			pipeline: group(bson.D{{"push", bson.D{{"$push", bson.D{{"id", "$_id"}, {"v", "$v"}}}}}}, nil),
+		},
+		"AddToSet": {
+			// the order of $addToSet result is unspecified
+			pipeline: group(
+				bson.D{
+					{"set", bson.D{{"$addToSet", "$v"}}},
+					{"push", bson.D{{"$push", "$v"}}},
+				},
+				bson.D{
+					{"size", bson.D{{"$size", "$set"}}},
+					{"equals", bson.D{{"$setEquals", bson.A{"$set", "$push"}}}},
+				},
+			),
+		},
+		"MergeObjects": {
+			pipeline: group(bson.D{{"merged", bson.D{{"$mergeObjects", "$v"}}}}, nil),
+		},
+		"StdDev": {
+			pipeline: group(
+				bson.D{
+					{"pop", bson.D{{"$stdDevPop", "$v"}}},
+					{"samp", bson.D{{"$stdDevSamp", "$v"}}},
+				},
+				bson.D{
+					{"pop", round("$pop")},
+					{"samp", round("$samp")},
+				},
+			),
+		},
+		"FirstNLastN": {
+			pipeline: group(bson.D{
+				{"first", bson.D{{"$firstN", bson.D{{"input", "$v"}, {"n", 2}}}}},
+				{"last", bson.D{{"$lastN", bson.D{{"input", "$v"}, {"n", int64(2)}}}}},
+				{"all", bson.D{{"$firstN", bson.D{{"input", "$v"}, {"n", 100.0}}}}},
+			}, nil),
+		},
+		"MinNMaxN": {
+			pipeline: group(bson.D{
+				{"min", bson.D{{"$minN", bson.D{{"input", "$v"}, {"n", 3}}}}},
+				{"max", bson.D{{"$maxN", bson.D{{"input", "$v"}, {"n", bson.D{{"$add", bson.A{1, 2}}}}}}}},
+			}, nil),
+		},
+		"TopBottom": {
+			pipeline: group(bson.D{
+				{"top", bson.D{{"$top", bson.D{{"output", bson.A{"$_id", "$v"}}, {"sortBy", bson.D{{"_id", 1}}}}}}},
+				{"bottom", bson.D{{"$bottom", bson.D{{"output", "$v"}, {"sortBy", bson.D{{"_id", 1}}}}}}},
+			}, nil),
+		},
+		"TopNBottomN": {
+			pipeline: group(bson.D{
+				{"top", bson.D{{"$topN", bson.D{
+					{"output", "$_id"},
+					{"sortBy", bson.D{{"_id", -1}}},
+					{"n", 2},
+				}}}},
+				{"bottom", bson.D{{"$bottomN", bson.D{
+					{"output", bson.D{{"id", "$_id"}, {"v", "$v"}}},
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"n", 3},
+				}}}},
+			}, nil),
+		},
+		"UnaryOperator": {
+			pipeline:   group(bson.D{{"push", bson.D{{"$push", bson.A{"$v", "$v"}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NNotDocument": {
+			pipeline:   group(bson.D{{"first", bson.D{{"$firstN", "$v"}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NUnknownArgument": {
+			pipeline: group(bson.D{{"first", bson.D{{"$firstN", bson.D{
+				{"input", "$v"},
+				{"n", 1},
+				{"unknown", 1},
+			}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NMissingN": {
+			pipeline:   group(bson.D{{"last", bson.D{{"$lastN", bson.D{{"input", "$v"}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NMissingInput": {
+			pipeline:   group(bson.D{{"min", bson.D{{"$minN", bson.D{{"n", 1}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NNotNumeric": {
+			pipeline:   group(bson.D{{"max", bson.D{{"$maxN", bson.D{{"input", "$v"}, {"n", "1"}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NNotIntegral": {
+			pipeline:   group(bson.D{{"max", bson.D{{"$maxN", bson.D{{"input", "$v"}, {"n", 1.5}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"NNotPositive": {
+			pipeline:   group(bson.D{{"first", bson.D{{"$firstN", bson.D{{"input", "$v"}, {"n", 0}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"TopNotDocument": {
+			pipeline:   group(bson.D{{"top", bson.D{{"$top", "$v"}}}}, nil),
+			resultType: emptyResult,
+		},
+		"TopUnknownArgument": {
+			pipeline: group(bson.D{{"top", bson.D{{"$top", bson.D{
+				{"output", "$v"},
+				{"sortBy", bson.D{{"_id", 1}}},
+				{"n", 1},
+			}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"TopNMissingN": {
+			pipeline: group(bson.D{{"top", bson.D{{"$topN", bson.D{
+				{"output", "$v"},
+				{"sortBy", bson.D{{"_id", 1}}},
+			}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"BottomMissingOutput": {
+			pipeline:   group(bson.D{{"bottom", bson.D{{"$bottom", bson.D{{"sortBy", bson.D{{"_id", 1}}}}}}}}, nil),
+			resultType: emptyResult,
+		},
+		"BottomMissingSortBy": {
+			pipeline:   group(bson.D{{"bottom", bson.D{{"$bottom", bson.D{{"output", "$v"}}}}}}, nil),
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
 func TestAggregateCompatMatch(t *testing.T) {
 	t.Parallel()
 
@@ -2501,3 +2699,2845 @@ func TestAggregateCompatUnset(t *testing.T) {
 	}
 	testAggregateStagesCompat(t, testCases)
 }
+
+func TestAggregateCompatFacet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Count": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"count", bson.A{bson.D{{"$count", "v"}}}},
+				}}},
+			},
+		},
+		"MultipleFacets": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"count", bson.A{bson.D{{"$count", "v"}}}},
+					{"first", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+						bson.D{{"$limit", 1}},
+					}},
+					{"ids", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", -1}}}},
+						bson.D{{"$project", bson.D{{"_id", 1}}}},
+					}},
+				}}},
+			},
+		},
+		"ModifyingStages": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"set", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+						bson.D{{"$set", bson.D{{"v", "foo"}}}},
+					}},
+					{"unchanged", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+					}},
+				}}},
+			},
+		},
+		"ModifyingLastFacet": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"unchanged", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+					}},
+					{"set", bson.A{
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+						bson.D{{"$set", bson.D{{"v", "foo"}}}},
+					}},
+				}}},
+			},
+		},
+		"Match": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"match", bson.A{
+						bson.D{{"$match", bson.D{{"v", 42}}}},
+						bson.D{{"$sort", bson.D{{"_id", 1}}}},
+					}},
+				}}},
+			},
+		},
+		"AfterFacet": {
+			pipeline: bson.A{
+				bson.D{{"$facet", bson.D{
+					{"ids", bson.A{bson.D{{"$project", bson.D{{"_id", 1}}}}}},
+				}}},
+				bson.D{{"$unwind", "$ids"}},
+				bson.D{{"$sort", bson.D{{"ids._id", 1}}}},
+			},
+		},
+		"InvalidType": {
+			pipeline:   bson.A{bson.D{{"$facet", 1}}},
+			resultType: emptyResult,
+		},
+		"Empty": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{}}}},
+			resultType: emptyResult,
+		},
+		"InvalidArgumentType": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"count", "invalid"}}}}},
+			resultType: emptyResult,
+		},
+		"EmptySubPipeline": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"count", bson.A{}}}}}},
+			resultType: emptyResult,
+		},
+		"InvalidSubPipelineElement": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"count", bson.A{1}}}}}},
+			resultType: emptyResult,
+		},
+		"EmptyName": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"", bson.A{bson.D{{"$count", "v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"DollarName": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"$count", bson.A{bson.D{{"$count", "v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"DotName": {
+			pipeline:   bson.A{bson.D{{"$facet", bson.D{{"v.count", bson.A{bson.D{{"$count", "v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"NestedFacet": {
+			pipeline: bson.A{bson.D{{"$facet", bson.D{
+				{"nested", bson.A{bson.D{{"$facet", bson.D{{"count", bson.A{bson.D{{"$count", "v"}}}}}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"CollStats": {
+			pipeline: bson.A{bson.D{{"$facet", bson.D{
+				{"stats", bson.A{bson.D{{"$collStats", bson.D{}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"DocumentsInUnionWith": {
+			pipeline: bson.A{bson.D{{"$facet", bson.D{
+				{"union", bson.A{bson.D{{"$unionWith", bson.D{
+					{"pipeline", bson.A{bson.D{{"$documents", bson.A{bson.D{{"v", 1}}}}}}},
+				}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"DocumentsInNestedLookup": {
+			pipeline: bson.A{bson.D{{"$facet", bson.D{
+				{"joined", bson.A{bson.D{{"$lookup", bson.D{
+					{"from", "unused"},
+					{"pipeline", bson.A{bson.D{{"$unionWith", bson.D{
+						{"pipeline", bson.A{bson.D{{"$documents", bson.A{bson.D{{"v", 1}}}}}}},
+					}}}}},
+					{"as", "joined"},
+				}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"InvalidStage": {
+			pipeline: bson.A{bson.D{{"$facet", bson.D{
+				{"count", bson.A{bson.D{{"$count", 1}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatBucket(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Int64s,
+		shareddata.Doubles,
+		shareddata.Strings,
+		shareddata.Nulls,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Default": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{-100, 0, 42, 1000}},
+				{"default", "other"},
+			}}}},
+		},
+		"Output": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{-100, 0, 42, 1000}},
+				{"default", "other"},
+				{"output", bson.D{
+					{"count", bson.D{{"$count", bson.D{}}}},
+					{"sum", bson.D{{"$sum", "$v"}}},
+				}},
+			}}}},
+		},
+		"Strings": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{"", "foo", "zzz"}},
+				{"default", int32(-1)},
+			}}}},
+		},
+		"DefaultBelowBoundaries": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, 42}},
+				{"default", -1},
+			}}}},
+		},
+		"GroupByOperator": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", bson.D{{"$sum", "$v"}}},
+				{"boundaries", bson.A{0, 42, 1000}},
+				{"default", "other"},
+			}}}},
+		},
+		"NoDefault": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{math.Inf(-1), math.Inf(1)}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline:   bson.A{bson.D{{"$bucket", "invalid"}}},
+			resultType: emptyResult,
+		},
+		"MissingGroupBy": {
+			pipeline:   bson.A{bson.D{{"$bucket", bson.D{{"boundaries", bson.A{0, 42}}}}}},
+			resultType: emptyResult,
+		},
+		"MissingBoundaries": {
+			pipeline:   bson.A{bson.D{{"$bucket", bson.D{{"groupBy", "$v"}}}}},
+			resultType: emptyResult,
+		},
+		"InvalidGroupBy": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "v"},
+				{"boundaries", bson.A{0, 42}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"BoundariesNotArray": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", 42},
+			}}}},
+			resultType: emptyResult,
+		},
+		"SingleBoundary": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{42}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"BoundariesNotSorted": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{42, 0}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"BoundariesMixedTypes": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, "foo"}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"BoundariesExpression": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, "$v"}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"DefaultInRange": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, 42}},
+				{"default", 1},
+			}}}},
+			resultType: emptyResult,
+		},
+		"OutputNotDocument": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, 42}},
+				{"default", "other"},
+				{"output", 42},
+			}}}},
+			resultType: emptyResult,
+		},
+		"UnknownOption": {
+			pipeline: bson.A{bson.D{{"$bucket", bson.D{
+				{"groupBy", "$v"},
+				{"boundaries", bson.A{0, 42}},
+				{"unknown", 1},
+			}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatBucketAuto(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Int64s,
+		shareddata.Strings,
+		shareddata.Nulls,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Buckets": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 3},
+			}}}},
+		},
+		"SingleBucket": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 1},
+			}}}},
+		},
+		"MoreBucketsThanDocuments": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$_id"},
+				{"buckets", 100},
+			}}}},
+		},
+		"Output": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 2},
+				{"output", bson.D{
+					{"count", bson.D{{"$count", bson.D{}}}},
+					{"sum", bson.D{{"$sum", "$v"}}},
+				}},
+			}}}},
+		},
+		"InvalidType": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", "invalid"}}},
+			resultType: emptyResult,
+		},
+		"MissingBuckets": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"groupBy", "$v"}}}}},
+			resultType: emptyResult,
+		},
+		"MissingGroupBy": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"buckets", 2}}}}},
+			resultType: emptyResult,
+		},
+		"InvalidGroupBy": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"groupBy", "v"}, {"buckets", 2}}}}},
+			resultType: emptyResult,
+		},
+		"BucketsString": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"groupBy", "$v"}, {"buckets", "2"}}}}},
+			resultType: emptyResult,
+		},
+		"BucketsFraction": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"groupBy", "$v"}, {"buckets", 2.5}}}}},
+			resultType: emptyResult,
+		},
+		"BucketsNegative": {
+			pipeline:   bson.A{bson.D{{"$bucketAuto", bson.D{{"groupBy", "$v"}, {"buckets", -1}}}}},
+			resultType: emptyResult,
+		},
+		"UnknownGranularity": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 2},
+				{"granularity", "unknown"},
+			}}}},
+			resultType: emptyResult,
+		},
+		"GranularityNotString": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 2},
+				{"granularity", 1},
+			}}}},
+			resultType: emptyResult,
+		},
+		"UnknownOption": {
+			pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 2},
+				{"unknown", 1},
+			}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatBucketAutoGranularity(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{}
+
+	for _, granularity := range []string{
+		"R5", "R10", "R20", "R40", "R80", "1-2-5",
+		"E6", "E12", "E24", "E48", "E96", "E192", "POWERSOF2",
+	} {
+		testCases[granularity] = aggregateStagesCompatTestCase{
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$gte", 0}}}}}},
+				bson.D{{"$bucketAuto", bson.D{
+					{"groupBy", "$v"},
+					{"buckets", 3},
+					{"granularity", granularity},
+				}}},
+			},
+		}
+	}
+
+	testCases["ContiguousBoundaries"] = aggregateStagesCompatTestCase{
+		pipeline: bson.A{
+			bson.D{{"$match", bson.D{{"v", bson.D{{"$gt", 0}}}}}},
+			bson.D{{"$bucketAuto", bson.D{
+				{"groupBy", "$v"},
+				{"buckets", 5},
+				{"granularity", "1-2-5"},
+			}}},
+		},
+	}
+
+	testCases["NonNumeric"] = aggregateStagesCompatTestCase{
+		pipeline: bson.A{bson.D{{"$bucketAuto", bson.D{
+			{"groupBy", "$v"},
+			{"buckets", 3},
+			{"granularity", "R5"},
+		}}}},
+		resultType: emptyResult,
+	}
+
+	testAggregateStagesCompatWithProviders(t, shareddata.Providers{shareddata.Int32s, shareddata.Int64s}, testCases)
+}
+
+func TestAggregateCompatReplaceRoot(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Document": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", bson.D{{"id", "$_id"}, {"value", "$v"}}}}}},
+			},
+		},
+		"DocumentMissingField": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", bson.D{{"id", "$_id"}, {"missing", "$non-existent"}}}}}},
+			},
+		},
+		"Path": {
+			pipeline: bson.A{
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", "$v"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline: bson.A{
+				bson.D{{"$replaceRoot", "$v"}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingNewRoot": {
+			pipeline: bson.A{
+				bson.D{{"$replaceRoot", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownField": {
+			pipeline: bson.A{
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", "$$ROOT"}, {"unknown", 1}}}},
+			},
+			resultType: emptyResult,
+		},
+		"NonExistent": {
+			pipeline: bson.A{
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", "$non-existent"}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatReplaceRootDocuments(t *testing.T) {
+	t.Parallel()
+
+	providers := shareddata.Providers{
+		shareddata.DocumentsDocuments,
+		shareddata.DocumentsDoubles,
+		shareddata.DocumentsStrings,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Path": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceRoot", bson.D{{"newRoot", "$v"}}}},
+			},
+		},
+		"ReplaceWith": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceWith", "$v"}},
+			},
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatReplaceWith(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Document": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceWith", bson.D{{"id", "$_id"}, {"value", "$v"}}}},
+			},
+		},
+		"Array": {
+			pipeline: bson.A{
+				bson.D{{"$sort", bson.D{{"_id", 1}}}},
+				bson.D{{"$replaceWith", bson.D{{"id", "$_id"}, {"values", bson.A{"$v", "$non-existent"}}}}},
+			},
+		},
+		"Path": {
+			pipeline: bson.A{
+				bson.D{{"$replaceWith", "$v"}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline: bson.A{
+				bson.D{{"$replaceWith", 1}},
+			},
+			resultType: emptyResult,
+		},
+		"NonExistent": {
+			pipeline: bson.A{
+				bson.D{{"$replaceWith", "$non-existent"}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatSortByCount(t *testing.T) {
+	t.Parallel()
+
+	// documents with the same count are sorted by _id for deterministic results
+	sortCount := bson.D{{"$sort", bson.D{{"count", -1}, {"_id", 1}}}}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Path": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", "$v"}},
+				sortCount,
+			},
+		},
+		"NonExistent": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", "$non-existent"}},
+				sortCount,
+			},
+		},
+		"Expression": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", bson.D{{"$type", "$v"}}}},
+				sortCount,
+			},
+		},
+		"NonPathString": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", "v"}},
+			},
+			resultType: emptyResult,
+		},
+		"EmptyDocument": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"NonExpressionDocument": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", bson.D{{"v", 1}}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline: bson.A{
+				bson.D{{"$sortByCount", 1}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatSample(t *testing.T) {
+	t.Parallel()
+
+	// documents are selected randomly, so only the number of documents is compared
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"One": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", 1}}}},
+				bson.D{{"$count", "v"}},
+			},
+		},
+		"All": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", 1000}}}},
+				bson.D{{"$count", "v"}},
+			},
+		},
+		"Double": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", 2.5}}}},
+				bson.D{{"$count", "v"}},
+			},
+		},
+		"AfterMatch": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$exists", true}}}}}},
+				bson.D{{"$sample", bson.D{{"size", 2}}}},
+				bson.D{{"$count", "v"}},
+			},
+		},
+		"Zero": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", 0}}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline: bson.A{
+				bson.D{{"$sample", 1}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingSize": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"SizeString": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", "1"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"SizeNegative": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", -1}}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownOption": {
+			pipeline: bson.A{
+				bson.D{{"$sample", bson.D{{"size", 1}, {"unknown", 1}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatSetWindowFields(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Sum": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"output", bson.D{{"total", bson.D{{"$sum", "$v"}}}}},
+				}}},
+			},
+		},
+		"Count": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"partitionBy", "$v"},
+					{"output", bson.D{{"count", bson.D{{"$count", bson.D{}}}}}},
+				}}},
+			},
+		},
+		"RunningSum": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"total", bson.D{
+						{"$sum", "$v"},
+						{"window", bson.D{{"documents", bson.A{"unbounded", "current"}}}},
+					}}}},
+				}}},
+			},
+		},
+		"MovingSum": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"total", bson.D{
+						{"$sum", "$v"},
+						{"window", bson.D{{"documents", bson.A{-1, 1}}}},
+					}}}},
+				}}},
+			},
+		},
+		"DocumentNumber": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"n", bson.D{{"$documentNumber", bson.D{}}}}}},
+				}}},
+			},
+		},
+		"Rank": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"partitionBy", "$v"},
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{
+						{"rank", bson.D{{"$rank", bson.D{}}}},
+						{"denseRank", bson.D{{"$denseRank", bson.D{}}}},
+					}},
+				}}},
+			},
+		},
+		"Shift": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"prev", bson.D{{"$shift", bson.D{
+						{"output", "$_id"},
+						{"by", -1},
+						{"default", "none"},
+					}}}}}},
+				}}},
+			},
+		},
+		"RankWithoutSortBy": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"output", bson.D{{"rank", bson.D{{"$rank", bson.D{}}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"DerivativeWithoutWindow": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"d", bson.D{{"$derivative", bson.D{{"input", "$v"}}}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidBounds": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"total", bson.D{
+						{"$sum", "$v"},
+						{"window", bson.D{{"documents", bson.A{1, -1}}}},
+					}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownFunction": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"output", bson.D{{"v", bson.D{{"$unknown", "$v"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownField": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{{"unknown", 1}}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingOutput": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"NotDocument": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", 1}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatSetWindowFieldsNumbers(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Int64s,
+		shareddata.Doubles,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"RangeSum": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$type", "number"}}}}}},
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"v", 1}}},
+					{"output", bson.D{{"total", bson.D{
+						{"$sum", "$v"},
+						{"window", bson.D{{"range", bson.A{-10, 10}}}},
+					}}}},
+				}}},
+			},
+		},
+		"ExpMovingAvg": {
+			pipeline: bson.A{
+				bson.D{{"$setWindowFields", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"avg", bson.D{{"$expMovingAvg", bson.D{
+						{"input", "$v"},
+						{"alpha", 0.5},
+					}}}}}},
+				}}},
+			},
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatFill(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Value": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"output", bson.D{{"v", bson.D{{"value", "filled"}}}}},
+				}}},
+			},
+		},
+		"Locf": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"v", bson.D{{"method", "locf"}}}}},
+				}}},
+			},
+		},
+		"LocfPartitionByFields": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"partitionByFields", bson.A{"v"}},
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"missing", bson.D{{"method", "locf"}}}}},
+				}}},
+			},
+		},
+		"PartitionByConflict": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"partitionBy", "$v"},
+					{"partitionByFields", bson.A{"v"}},
+					{"output", bson.D{{"v", bson.D{{"value", 0}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"LinearWithoutSortBy": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"output", bson.D{{"v", bson.D{{"method", "linear"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidMethod": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{
+					{"sortBy", bson.D{{"_id", 1}}},
+					{"output", bson.D{{"v", bson.D{{"method", "unknown"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingOutput": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownField": {
+			pipeline: bson.A{
+				bson.D{{"$fill", bson.D{{"unknown", 1}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatDensify(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Int64s,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Full": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$gte", -5}, {"$lte", 42}}}}}},
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 5}, {"bounds", "full"}}},
+				}}},
+			},
+		},
+		"Explicit": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$gte", -5}, {"$lte", 42}}}}}},
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 10}, {"bounds", bson.A{-20, 20}}}},
+				}}},
+			},
+		},
+		"StepNotPositive": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 0}, {"bounds", "full"}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidBoundsString": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 1}, {"bounds", "unknown"}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"PartitionWithoutFields": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 1}, {"bounds", "partition"}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnsortedBounds": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{
+					{"field", "v"},
+					{"range", bson.D{{"step", 1}, {"bounds", bson.A{10, 0}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingRange": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{{"field", "v"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"DollarField": {
+			pipeline: bson.A{
+				bson.D{{"$densify", bson.D{
+					{"field", "$v"},
+					{"range", bson.D{{"step", 1}, {"bounds", "full"}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatDocuments(t *testing.T) {
+	t.Parallel()
+
+	s := setup.SetupCompatWithOpts(t, &setup.SetupCompatOpts{
+		Providers: []shareddata.Provider{shareddata.Int32s},
+	})
+	ctx, targetCollection, compatCollection := s.Ctx, s.TargetCollections[0], s.CompatCollections[0]
+
+	testCases := map[string]struct {
+		pipeline   bson.A
+		resultType compatTestCaseResultType
+	}{
+		"Literal": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{
+					bson.D{{"_id", 1}, {"v", "foo"}},
+					bson.D{{"_id", 2}, {"v", bson.A{1, 2}}},
+				}}},
+			},
+		},
+		"Expression": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{
+					bson.D{{"_id", 1}, {"v", bson.D{{"$type", "foo"}}}},
+				}}},
+			},
+		},
+		"Stages": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{
+					bson.D{{"_id", 1}, {"v", 42}},
+					bson.D{{"_id", 2}, {"v", 43}},
+					bson.D{{"_id", 3}, {"v", 44}},
+				}}},
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$gt", 42}}}}}},
+				bson.D{{"$sort", bson.D{{"_id", -1}}}},
+			},
+		},
+		"Lookup": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{bson.D{{"_id", 1}, {"v", int32(42)}}}}},
+				bson.D{{"$lookup", bson.D{
+					{"from", targetCollection.Name()},
+					{"localField", "v"},
+					{"foreignField", "v"},
+					{"as", "joined"},
+				}}},
+			},
+		},
+		"Empty": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{}}},
+			},
+			resultType: emptyResult,
+		},
+		"NotArray": {
+			pipeline: bson.A{
+				bson.D{{"$documents", 1}},
+			},
+			resultType: emptyResult,
+		},
+		"NotDocument": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{1}}},
+			},
+			resultType: emptyResult,
+		},
+		"NotFirst": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{bson.D{{"_id", 1}}}}},
+				bson.D{{"$documents", bson.A{bson.D{{"_id", 2}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"NoDocumentsStage": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{}}},
+			},
+			resultType: emptyResult,
+		},
+		"EmptyPipeline": {
+			pipeline:   bson.A{},
+			resultType: emptyResult,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			targetCursor, targetErr := targetCollection.Database().Aggregate(ctx, tc.pipeline)
+			compatCursor, compatErr := compatCollection.Database().Aggregate(ctx, tc.pipeline)
+
+			if targetCursor != nil {
+				defer targetCursor.Close(ctx)
+			}
+			if compatCursor != nil {
+				defer compatCursor.Close(ctx)
+			}
+
+			if targetErr != nil {
+				t.Logf("Target error: %v", targetErr)
+				t.Logf("Compat error: %v", compatErr)
+
+				// error messages are intentionally not compared
+				AssertMatchesCommandError(t, compatErr, targetErr)
+
+				return
+			}
+			require.NoError(t, compatErr, "compat error; target returned no error")
+
+			targetRes := FetchAll(t, ctx, targetCursor)
+			compatRes := FetchAll(t, ctx, compatCursor)
+
+			AssertEqualDocumentsSlice(t, compatRes, targetRes)
+
+			if tc.resultType == emptyResult {
+				assert.Empty(t, targetRes)
+				return
+			}
+
+			assert.NotEmpty(t, targetRes)
+		})
+	}
+}
+
+func TestAggregateCompatDocumentsCollection(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Documents": {
+			pipeline: bson.A{
+				bson.D{{"$documents", bson.A{bson.D{{"_id", 1}}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatProjectCond(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Array": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.A{"$v", "truthy", "falsy"}}}},
+				}}},
+			},
+		},
+		"Document": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.D{
+						{"if", bson.D{{"$gt", bson.A{"$v", 0}}}},
+						{"then", "$v"},
+						{"else", "non-positive"},
+					}}}},
+				}}},
+			},
+		},
+		"Nested": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.A{
+						bson.D{{"$eq", bson.A{"$v", nil}}},
+						"null",
+						bson.D{{"$cond", bson.A{bson.D{{"$lt", bson.A{"$v", 0}}}, "negative", "other"}}},
+					}}}},
+				}}},
+			},
+		},
+		"InvalidArgsLen": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.A{"$v", 1}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingElse": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.D{{"if", true}, {"then", 1}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownParameter": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cond", bson.D{{"$cond", bson.D{{"if", true}, {"then", 1}, {"else", 2}, {"unknown", 3}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatProjectComparison(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Operators": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cmp", bson.D{{"$cmp", bson.A{"$v", 42}}}},
+					{"eq", bson.D{{"$eq", bson.A{"$v", 42}}}},
+					{"ne", bson.D{{"$ne", bson.A{"$v", 42}}}},
+					{"gt", bson.D{{"$gt", bson.A{"$v", 42}}}},
+					{"gte", bson.D{{"$gte", bson.A{"$v", 42}}}},
+					{"lt", bson.D{{"$lt", bson.A{"$v", 42}}}},
+					{"lte", bson.D{{"$lte", bson.A{"$v", 42}}}},
+				}}},
+			},
+		},
+		"DifferentTypes": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cmp", bson.D{{"$cmp", bson.A{"$v", "foo"}}}},
+					{"lt", bson.D{{"$lt", bson.A{"$v", bson.D{}}}}},
+				}}},
+			},
+		},
+		"Array": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"eq", bson.D{{"$eq", bson.A{"$v", bson.A{42}}}}},
+					{"gt", bson.D{{"$gt", bson.A{"$v", bson.A{}}}}},
+				}}},
+			},
+		},
+		"Missing": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"eq", bson.D{{"$eq", bson.A{"$non-existent", nil}}}},
+				}}},
+			},
+		},
+		"InvalidArgsLen": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"eq", bson.D{{"$eq", bson.A{"$v"}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateCompatProjectArithmetic(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Int64s,
+		shareddata.Doubles,
+		shareddata.SmallDoubles,
+		shareddata.Nulls,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"AddSubtractMultiply": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"add", bson.D{{"$add", bson.A{"$v", 1, int64(2), 0.5}}}},
+					{"addOverflow", bson.D{{"$add", bson.A{"$v", math.MaxInt32}}}},
+					{"subtract", bson.D{{"$subtract", bson.A{"$v", 1}}}},
+					{"subtractOverflow", bson.D{{"$subtract", bson.A{"$v", int64(math.MaxInt64)}}}},
+					{"multiply", bson.D{{"$multiply", bson.A{"$v", 2}}}},
+					{"multiplyOverflow", bson.D{{"$multiply", bson.A{"$v", math.MaxInt32}}}},
+				}}},
+			},
+		},
+		"DivideMod": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"divide", bson.D{{"$divide", bson.A{"$v", 4}}}},
+					{"mod", bson.D{{"$mod", bson.A{"$v", 3}}}},
+					{"modDouble", bson.D{{"$mod", bson.A{"$v", 2.5}}}},
+				}}},
+			},
+		},
+		"Unary": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"abs", bson.D{{"$abs", "$v"}}},
+					{"ceil", bson.D{{"$ceil", "$v"}}},
+					{"floor", bson.D{{"$floor", "$v"}}},
+					{"exp", bson.D{{"$exp", bson.D{{"$divide", bson.A{"$v", 1e308}}}}}},
+				}}},
+			},
+		},
+		"Pow": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"square", bson.D{{"$pow", bson.A{"$v", 2}}}},
+					{"zero", bson.D{{"$pow", bson.A{"$v", 0}}}},
+					{"base", bson.D{{"$pow", bson.A{2, bson.D{{"$mod", bson.A{"$v", 70}}}}}}},
+				}}},
+			},
+		},
+		"RoundTrunc": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"round", bson.D{{"$round", "$v"}}},
+					{"roundPlace", bson.D{{"$round", bson.A{"$v", 2}}}},
+					{"roundNegativePlace", bson.D{{"$round", bson.A{"$v", -1}}}},
+					{"trunc", bson.D{{"$trunc", "$v"}}},
+					{"truncPlace", bson.D{{"$trunc", bson.A{"$v", 1}}}},
+					{"truncNegativePlace", bson.D{{"$trunc", bson.A{"$v", -2}}}},
+				}}},
+			},
+		},
+		"Missing": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"add", bson.D{{"$add", bson.A{"$v", "$non-existent"}}}},
+					{"divide", bson.D{{"$divide", bson.A{"$non-existent", 0}}}},
+					{"abs", bson.D{{"$abs", "$non-existent"}}},
+				}}},
+			},
+		},
+		"DivideByZero": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"divide", bson.D{{"$divide", bson.A{"$v", 0}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"ModByZero": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"mod", bson.D{{"$mod", bson.A{"$v", 0}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidArgsLen": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"subtract", bson.D{{"$subtract", bson.A{"$v"}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"RoundPlaceOutOfRange": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"round", bson.D{{"$round", bson.A{"$v", 101}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectArithmeticErrors(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Strings,
+		shareddata.Bools,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Add": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$add", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Subtract": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$subtract", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Multiply": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$multiply", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Divide": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$divide", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Abs": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$abs", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"Sqrt": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$sqrt", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"Log": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$log", bson.A{"$v", 10}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Pow": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$pow", bson.A{"$v", 2}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectTrigonometry(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.SmallDoubles,
+		shareddata.Nulls,
+	}
+
+	// results are rounded as they may differ in the last digits between math library implementations
+	round := func(expr bson.D) bson.D {
+		return bson.D{{"$round", bson.A{expr, 10}}}
+	}
+
+	// nan is an expression evaluated to NaN
+	nan := bson.D{{"$add", bson.A{math.Inf(1), math.Inf(-1)}}}
+
+	// unit is an expression evaluated to a value in (-1, 1) for any number
+	unit := bson.D{{"$divide", bson.A{"$v", bson.D{{"$add", bson.A{bson.D{{"$abs", "$v"}}, 1}}}}}}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Trigonometric": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"sin", round(bson.D{{"$sin", "$v"}})},
+				{"cos", round(bson.D{{"$cos", "$v"}})},
+				{"tan", round(bson.D{{"$tan", "$v"}})},
+				{"atan", round(bson.D{{"$atan", "$v"}})},
+				{"atan2", round(bson.D{{"$atan2", bson.A{"$v", -2}}})},
+			}}}},
+		},
+		"Inverse": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"asin", round(bson.D{{"$asin", unit}})},
+				{"acos", round(bson.D{{"$acos", unit}})},
+				{"atanh", round(bson.D{{"$atanh", unit}})},
+				{"asinBound", bson.D{{"$asin", 1}}},
+				{"acosBound", bson.D{{"$acos", int64(-1)}}},
+				{"atanhBound", bson.D{{"$atanh", -1.0}}},
+				{"acoshBound", bson.D{{"$acosh", 1}}},
+			}}}},
+		},
+		"Hyperbolic": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"sinh", round(bson.D{{"$sinh", unit}})},
+				{"cosh", round(bson.D{{"$cosh", unit}})},
+				{"tanh", round(bson.D{{"$tanh", "$v"}})},
+				{"asinh", round(bson.D{{"$asinh", "$v"}})},
+				{"acosh", round(bson.D{{"$acosh", bson.D{{"$add", bson.A{bson.D{{"$abs", "$v"}}, 1}}}}})},
+			}}}},
+		},
+		"Angle": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"radians", bson.D{{"$degreesToRadians", "$v"}}},
+				{"degrees", bson.D{{"$radiansToDegrees", "$v"}}},
+				{"roundtrip", round(bson.D{{"$radiansToDegrees", bson.D{{"$degreesToRadians", "$v"}}}})},
+			}}}},
+		},
+		"NaN": {
+			// NaN values can't be passed in the command or returned, so they are produced and checked by expressions
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"sin", bson.D{{"$type", bson.D{{"$sin", nan}}}}},
+				{"acos", bson.D{{"$eq", bson.A{bson.D{{"$acos", nan}}, nan}}}},
+				{"acosh", bson.D{{"$eq", bson.A{bson.D{{"$acosh", nan}}, nan}}}},
+				{"atan2", bson.D{{"$eq", bson.A{bson.D{{"$atan2", bson.A{nan, "$v"}}}, nan}}}},
+			}}}},
+		},
+		"Infinity": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"atan", bson.D{{"$atan", math.Inf(1)}}},
+				{"tanh", bson.D{{"$tanh", math.Inf(-1)}}},
+				{"cosh", bson.D{{"$cosh", math.Inf(-1)}}},
+				{"acosh", bson.D{{"$acosh", math.Inf(1)}}},
+			}}}},
+		},
+		"SinInfinity": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$sin", math.Inf(1)}}}}}}},
+			resultType: emptyResult,
+		},
+		"AcosOutOfBounds": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$acos", bson.D{{"$add", bson.A{"$v", 1.5}}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"AtanhOutOfBounds": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$atanh", 2}}}}}}},
+			resultType: emptyResult,
+		},
+		"AcoshOutOfBounds": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$acosh", 0.5}}}}}}},
+			resultType: emptyResult,
+		},
+		"NotNumeric": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$cos", "foo"}}}}}}},
+			resultType: emptyResult,
+		},
+		"Atan2NotNumeric": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$atan2", bson.A{"$v", "foo"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Atan2OneArgument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$atan2", bson.A{"$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatAddFieldsDateArithmetic(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.DateTimes,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"AddSubtract": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{
+					{"add", bson.D{{"$add", bson.A{"$v", 1000}}}},
+					{"addDouble", bson.D{{"$add", bson.A{1.6, "$v"}}}},
+					{"subtract", bson.D{{"$subtract", bson.A{"$v", int64(60000)}}}},
+					{"diff", bson.D{{"$subtract", bson.A{"$v", time.Date(2021, 11, 1, 10, 18, 42, 0, time.UTC)}}}},
+				}}},
+			},
+		},
+		"AddTwoDates": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{
+					{"add", bson.D{{"$add", bson.A{"$v", "$v"}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"SubtractDateFromNumber": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{
+					{"subtract", bson.D{{"$subtract", bson.A{1, "$v"}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectString(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Strings,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"ConcatCase": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"concat", bson.D{{"$concat", bson.A{"$v", "-", "$v"}}}},
+					{"concatNull", bson.D{{"$concat", bson.A{"$v", nil}}}},
+					{"concatEmpty", bson.D{{"$concat", bson.A{}}}},
+					{"upper", bson.D{{"$toUpper", "$v"}}},
+					{"lower", bson.D{{"$toLower", bson.D{{"$concat", bson.A{"$v", "ÀB"}}}}}},
+					{"lowerNumber", bson.D{{"$toLower", 42.5}}},
+					{"cmp", bson.D{{"$strcasecmp", bson.A{"$v", "FOO"}}}},
+				}}},
+			},
+		},
+		"MissingField": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"concat", bson.D{{"$concat", bson.A{"$v", "$missing"}}}},
+					{"lower", bson.D{{"$toLower", "$missing"}}},
+					{"upper", bson.D{{"$toUpper", "$missing"}}},
+					{"cmp", bson.D{{"$strcasecmp", bson.A{"$missing", ""}}}},
+					{"split", bson.D{{"$split", bson.A{"$missing", ","}}}},
+				}}},
+			},
+		},
+		"Length": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cp", bson.D{{"$strLenCP", bson.D{{"$concat", bson.A{"$v", "héllo"}}}}}},
+					{"bytes", bson.D{{"$strLenBytes", bson.D{{"$concat", bson.A{"$v", "héllo"}}}}}},
+				}}},
+			},
+		},
+		"Substr": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cp", bson.D{{"$substrCP", bson.A{"$v", 1, 2}}}},
+					{"cpUnicode", bson.D{{"$substrCP", bson.A{"héllo wörld", 1, 7}}}},
+					{"cpOutOfRange", bson.D{{"$substrCP", bson.A{"$v", 10, 2}}}},
+					{"bytes", bson.D{{"$substrBytes", bson.A{"$v", 0, 2}}}},
+					{"bytesNegativeLength", bson.D{{"$substrBytes", bson.A{"$v", 1, -1}}}},
+					{"bytesUnicode", bson.D{{"$substrBytes", bson.A{"héllo", 1, 2}}}},
+					{"substr", bson.D{{"$substr", bson.A{"$v", 1, 1}}}},
+				}}},
+			},
+		},
+		"SubstrBytesContinuation": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"bytes", bson.D{{"$substrBytes", bson.A{bson.D{{"$concat", bson.A{"$v", "é"}}}, 0, 1}}}},
+					{"bytesStart", bson.D{{"$substrBytes", bson.A{"é", 1, 1}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"SubstrCPNegativeLength": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cp", bson.D{{"$substrCP", bson.A{"$v", 0, -1}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"Trim": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"trim", bson.D{{"$trim", bson.D{{"input", bson.D{{"$concat", bson.A{"\t  ", "$v", " \u00a0"}}}}}}}},
+					{"ltrim", bson.D{{"$ltrim", bson.D{{"input", "$v"}, {"chars", "fo"}}}}},
+					{"rtrim", bson.D{{"$rtrim", bson.D{{"input", "$v"}, {"chars", "o3"}}}}},
+					{"trimUnicode", bson.D{{"$trim", bson.D{{"input", "ééaéé"}, {"chars", "é"}}}}},
+					{"trimNullChars", bson.D{{"$trim", bson.D{{"input", "$v"}, {"chars", nil}}}}},
+				}}},
+			},
+		},
+		"TrimUnknownArgument": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"trim", bson.D{{"$trim", bson.D{{"input", "$v"}, {"foo", "bar"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"TrimMissingInput": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"trim", bson.D{{"$trim", bson.D{{"chars", "a"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"TrimNotDocument": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"trim", bson.D{{"$trim", "$v"}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"Split": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"split", bson.D{{"$split", bson.A{"$v", "o"}}}},
+					{"splitUnicode", bson.D{{"$split", bson.A{"a-é-b", "é"}}}},
+				}}},
+			},
+		},
+		"SplitEmptyDelimiter": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"split", bson.D{{"$split", bson.A{"$v", ""}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"IndexOf": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"bytes", bson.D{{"$indexOfBytes", bson.A{"$v", "o"}}}},
+					{"bytesStart", bson.D{{"$indexOfBytes", bson.A{"$v", "o", 2}}}},
+					{"bytesEnd", bson.D{{"$indexOfBytes", bson.A{"$v", "o", 0, 2}}}},
+					{"bytesEmpty", bson.D{{"$indexOfBytes", bson.A{"$v", ""}}}},
+					{"cp", bson.D{{"$indexOfCP", bson.A{bson.D{{"$concat", bson.A{"é", "$v"}}}, "o"}}}},
+					{"cpStart", bson.D{{"$indexOfCP", bson.A{"héllo", "l", 3}}}},
+					{"cpStartOutOfRange", bson.D{{"$indexOfCP", bson.A{"$v", "o", 100}}}},
+				}}},
+			},
+		},
+		"IndexOfNegativeStart": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"bytes", bson.D{{"$indexOfBytes", bson.A{"$v", "o", -1}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"IndexOfInvalidArgsLen": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"cp", bson.D{{"$indexOfCP", bson.A{"$v"}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"Replace": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"one", bson.D{{"$replaceOne", bson.D{{"input", "$v"}, {"find", "o"}, {"replacement", "ö"}}}}},
+					{"all", bson.D{{"$replaceAll", bson.D{{"input", "$v"}, {"find", "o"}, {"replacement", "ö"}}}}},
+					{"null", bson.D{{"$replaceAll", bson.D{{"input", "$v"}, {"find", nil}, {"replacement", "a"}}}}},
+				}}},
+			},
+		},
+		"ReplaceMissingFind": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"one", bson.D{{"$replaceOne", bson.D{{"input", "$v"}, {"replacement", "a"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"ReplaceFindNotString": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"all", bson.D{{"$replaceAll", bson.D{{"input", "$v"}, {"find", 1}, {"replacement", "a"}}}}},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectStringErrors(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Bools,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Concat": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$concat", bson.A{"a", "$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"StrLenCP": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$strLenCP", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"StrLenBytes": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$strLenBytes", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"StrLenCPMissing": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$strLenCP", "$missing"}}}}}}},
+			resultType: emptyResult,
+		},
+		"StrLenBytesMissing": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$strLenBytes", "$missing"}}}}}}},
+			resultType: emptyResult,
+		},
+		"Split": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$split", bson.A{"$v", ","}}}}}}}},
+			resultType: emptyResult,
+		},
+		"IndexOfCP": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$indexOfCP", bson.A{"$v", "a"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Trim": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$trim", bson.D{{"input", "$v"}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"SubstrCPStart": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$substrCP", bson.A{"abc", "$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"SubstrBytesLength": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$substrBytes", bson.A{"abc", 0, "$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectDate(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.DateTimes,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Parts": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"year", bson.D{{"$year", "$v"}}},
+				{"month", bson.D{{"$month", "$v"}}},
+				{"dayOfMonth", bson.D{{"$dayOfMonth", "$v"}}},
+				{"dayOfYear", bson.D{{"$dayOfYear", "$v"}}},
+				{"dayOfWeek", bson.D{{"$dayOfWeek", "$v"}}},
+				{"hour", bson.D{{"$hour", "$v"}}},
+				{"minute", bson.D{{"$minute", "$v"}}},
+				{"second", bson.D{{"$second", "$v"}}},
+				{"millisecond", bson.D{{"$millisecond", "$v"}}},
+				{"week", bson.D{{"$week", "$v"}}},
+				{"isoWeek", bson.D{{"$isoWeek", "$v"}}},
+				{"isoWeekYear", bson.D{{"$isoWeekYear", "$v"}}},
+				{"isoDayOfWeek", bson.D{{"$isoDayOfWeek", "$v"}}},
+			}}}},
+		},
+		"PartsTimezone": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"olson", bson.D{{"$hour", bson.D{{"date", "$v"}, {"timezone", "America/New_York"}}}}},
+				{"offset", bson.D{{"$hour", bson.D{{"date", "$v"}, {"timezone", "+05:30"}}}}},
+				{"day", bson.D{{"$dayOfMonth", bson.D{{"date", "$v"}, {"timezone", "-1000"}}}}},
+			}}}},
+		},
+		"PartsArray": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$year", bson.A{"$v"}}}}}}}},
+		},
+		"ToString": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", bson.D{{"$in", bson.A{"datetime", "datetime-epoch", "datetime-null"}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"default", bson.D{{"$dateToString", bson.D{{"date", "$v"}}}}},
+					{"format", bson.D{{"$dateToString", bson.D{
+						{"date", "$v"},
+						{"format", "%d/%m/%Y %H:%M:%S.%L %j %w %u %U %V %G %z %Z %%"},
+						{"timezone", "Europe/Berlin"},
+					}}}},
+					{"onNull", bson.D{{"$dateToString", bson.D{{"date", "$v"}, {"onNull", "none"}}}}},
+				}}},
+			},
+		},
+		"ToStringYearOutOfRange": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$dateToString", bson.D{{"date", "$v"}, {"timezone", "+01"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"ToParts": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"natural", bson.D{{"$dateToParts", bson.D{{"date", "$v"}, {"timezone", "Asia/Tokyo"}}}}},
+				{"iso", bson.D{{"$dateToParts", bson.D{{"date", "$v"}, {"iso8601", true}}}}},
+			}}}},
+		},
+		"FromParts": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"natural", bson.D{{"$dateFromParts", bson.D{
+					{"year", 2021}, {"month", 14}, {"day", 31}, {"hour", 25}, {"millisecond", 1500},
+				}}}},
+				{"iso", bson.D{{"$dateFromParts", bson.D{
+					{"isoWeekYear", 2021}, {"isoWeek", 53}, {"isoDayOfWeek", 7}, {"timezone", "+03"},
+				}}}},
+			}}}},
+		},
+		"FromString": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"iso", bson.D{{"$dateFromString", bson.D{{"dateString", "2021-11-01T10:18:42.123Z"}}}}},
+				{"timezone", bson.D{{"$dateFromString", bson.D{
+					{"dateString", "2021-11-01 10:18"},
+					{"timezone", "Europe/London"},
+				}}}},
+				{"format", bson.D{{"$dateFromString", bson.D{
+					{"dateString", "01/11/2021 10:18:42"},
+					{"format", "%d/%m/%Y %H:%M:%S"},
+				}}}},
+				{"onError", bson.D{{"$dateFromString", bson.D{
+					{"dateString", "not a date"},
+					{"onError", "error"},
+				}}}},
+				{"onNull", bson.D{{"$dateFromString", bson.D{
+					{"dateString", nil},
+					{"onNull", "null"},
+				}}}},
+			}}}},
+		},
+		"FromStringInvalid": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$dateFromString", bson.D{{"dateString", "2021-13-01"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"AddSubtract": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", bson.D{{"$in", bson.A{"datetime", "datetime-epoch", "datetime-null"}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"month", bson.D{{"$dateAdd", bson.D{{"startDate", "$v"}, {"unit", "month"}, {"amount", 3}}}}},
+					{"day", bson.D{{"$dateAdd", bson.D{
+						{"startDate", "$v"}, {"unit", "day"}, {"amount", 200}, {"timezone", "America/New_York"},
+					}}}},
+					{"hour", bson.D{{"$dateSubtract", bson.D{{"startDate", "$v"}, {"unit", "hour"}, {"amount", int64(25)}}}}},
+					{"quarter", bson.D{{"$dateSubtract", bson.D{{"startDate", "$v"}, {"unit", "quarter"}, {"amount", 2.0}}}}},
+				}}},
+			},
+		},
+		"Diff": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", bson.D{{"$in", bson.A{"datetime", "datetime-epoch", "datetime-null"}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"year", bson.D{{"$dateDiff", bson.D{
+						{"startDate", "$v"}, {"endDate", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)}, {"unit", "year"},
+					}}}},
+					{"week", bson.D{{"$dateDiff", bson.D{
+						{"startDate", "$v"}, {"endDate", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+						{"unit", "week"}, {"startOfWeek", "mon"},
+					}}}},
+					{"hour", bson.D{{"$dateDiff", bson.D{
+						{"startDate", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)}, {"endDate", "$v"},
+						{"unit", "hour"}, {"timezone", "Asia/Kolkata"},
+					}}}},
+				}}},
+			},
+		},
+		"Trunc": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", bson.D{{"$in", bson.A{"datetime", "datetime-epoch", "datetime-null"}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"month", bson.D{{"$dateTrunc", bson.D{{"date", "$v"}, {"unit", "month"}, {"binSize", 5}}}}},
+					{"week", bson.D{{"$dateTrunc", bson.D{{"date", "$v"}, {"unit", "week"}, {"startOfWeek", "Friday"}}}}},
+					{"hour", bson.D{{"$dateTrunc", bson.D{
+						{"date", "$v"}, {"unit", "hour"}, {"binSize", 6}, {"timezone", "Australia/Adelaide"},
+					}}}},
+				}}},
+			},
+		},
+		"TruncUnknownUnit": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$dateTrunc", bson.D{{"date", "$v"}, {"unit", "decade"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"UnknownTimezone": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$year", bson.D{{"date", "$v"}, {"timezone", "Mars/Olympus"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"InvalidFormat": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$dateToString", bson.D{{"date", "$v"}, {"format", "%Q"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectDateErrors(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Year": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$year", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"DateToString": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$dateToString", bson.D{{"date", "$v"}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"DateAdd": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$dateAdd", bson.D{
+				{"startDate", "$v"}, {"unit", "day"}, {"amount", 1},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"DateTrunc": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$dateTrunc", bson.D{
+				{"date", "$v"}, {"unit", "day"},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectArray(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.ArrayInt32s,
+		shareddata.ArrayStrings,
+		shareddata.ArrayDoubles,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Elements": {
+			pipeline: bson.A{
+				// MongoDB returns missing values for elements out of bounds
+				bson.D{{"$match", bson.D{{"v.0", bson.D{{"$exists", true}}}}}},
+				bson.D{{"$project", bson.D{
+					{"elemAt", bson.D{{"$arrayElemAt", bson.A{"$v", 0}}}},
+					{"elemAtNegative", bson.D{{"$arrayElemAt", bson.A{"$v", -1}}}},
+					{"first", bson.D{{"$first", "$v"}}},
+					{"last", bson.D{{"$last", "$v"}}},
+				}}},
+			},
+		},
+		"Size": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"size", bson.D{{"$size", "$v"}}},
+				{"isArray", bson.D{{"$isArray", "$v"}}},
+				{"reverse", bson.D{{"$reverseArray", "$v"}}},
+			}}}},
+		},
+		"ConcatArrays": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$concatArrays", bson.A{"$v", bson.A{int32(1), "a"}, "$v"}}}},
+				{"null", bson.D{{"$concatArrays", bson.A{"$v", "$missing"}}}},
+			}}}},
+		},
+		"In": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"int", bson.D{{"$in", bson.A{int64(42), "$v"}}}},
+				{"string", bson.D{{"$in", bson.A{"b", "$v"}}}},
+			}}}},
+		},
+		"IndexOfArray": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"index", bson.D{{"$indexOfArray", bson.A{"$v", 42.0}}}},
+				{"start", bson.D{{"$indexOfArray", bson.A{"$v", int32(42), 1}}}},
+				{"end", bson.D{{"$indexOfArray", bson.A{"$v", int32(43), 0, 1}}}},
+				{"null", bson.D{{"$indexOfArray", bson.A{"$missing", 1}}}},
+			}}}},
+		},
+		"Slice": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"first", bson.D{{"$slice", bson.A{"$v", 2}}}},
+				{"last", bson.D{{"$slice", bson.A{"$v", -2}}}},
+				{"position", bson.D{{"$slice", bson.A{"$v", 1, 3}}}},
+				{"negativePosition", bson.D{{"$slice", bson.A{"$v", -10, 2}}}},
+			}}}},
+		},
+		"Range": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"range", bson.D{{"$range", bson.A{0, bson.D{{"$size", "$v"}}}}}},
+				{"step", bson.D{{"$range", bson.A{10, -5, -4}}}},
+			}}}},
+		},
+		"Map": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"this", bson.D{{"$map", bson.D{{"input", "$v"}, {"in", bson.A{"$$this"}}}}}},
+				{"as", bson.D{{"$map", bson.D{
+					{"input", "$v"},
+					{"as", "elem"},
+					{"in", bson.D{{"$indexOfArray", bson.A{"$v", "$$elem"}}}},
+				}}}},
+				{"nested", bson.D{{"$map", bson.D{
+					{"input", bson.A{1, 2}},
+					{"as", "outer"},
+					{"in", bson.D{{"$map", bson.D{
+						{"input", "$v"},
+						{"in", bson.A{"$$outer", "$$this"}},
+					}}}},
+				}}}},
+			}}}},
+		},
+		"Filter": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$filter", bson.D{
+					{"input", "$v"},
+					{"as", "item"},
+					{"cond", bson.D{{"$gt", bson.A{"$$item", 42}}}},
+				}}}},
+				{"limit", bson.D{{"$filter", bson.D{
+					{"input", "$v"},
+					{"cond", true},
+					{"limit", 2},
+				}}}},
+			}}}},
+		},
+		"Reduce": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$reduce", bson.D{
+					{"input", "$v"},
+					{"initialValue", bson.A{}},
+					{"in", bson.D{{"$concatArrays", bson.A{bson.A{"$$this"}, "$$value"}}}},
+				}}}},
+				{"count", bson.D{{"$reduce", bson.D{
+					{"input", "$v"},
+					{"initialValue", 0},
+					{"in", bson.D{{"$add", bson.A{"$$value", 1}}}},
+				}}}},
+			}}}},
+		},
+		"SortArray": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"asc", bson.D{{"$sortArray", bson.D{{"input", "$v"}, {"sortBy", 1}}}}},
+				{"desc", bson.D{{"$sortArray", bson.D{{"input", "$v"}, {"sortBy", -1}}}}},
+			}}}},
+		},
+		"Zip": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"shortest", bson.D{{"$zip", bson.D{{"inputs", bson.A{"$v", bson.A{1, 2}}}}}}},
+				{"longest", bson.D{{"$zip", bson.D{
+					{"inputs", bson.A{"$v", bson.A{1, 2}}},
+					{"useLongestLength", true},
+					{"defaults", bson.A{"a", "b"}},
+				}}}},
+			}}}},
+		},
+		"ArrayToObject": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"pairs", bson.D{{"$arrayToObject", bson.D{{"$map", bson.D{
+					{"input", "$v"},
+					{"in", bson.A{bson.D{{"$toLower", "$$this"}}, "$$this"}},
+				}}}}}},
+				{"kv", bson.D{{"$arrayToObject", bson.A{bson.A{
+					bson.D{{"k", "a"}, {"v", 1}},
+					bson.D{{"k", "b"}, {"v", 2}},
+					bson.D{{"k", "a"}, {"v", 3}},
+				}}}}},
+			}}}},
+		},
+		"MissingField": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"isArray", bson.D{{"$isArray", "$missing"}}},
+				{"reverse", bson.D{{"$reverseArray", "$missing"}}},
+				{"elemAt", bson.D{{"$arrayElemAt", bson.A{"$missing", 0}}}},
+				{"concat", bson.D{{"$concatArrays", bson.A{"$v", "$missing"}}}},
+				{"slice", bson.D{{"$slice", bson.A{"$missing", 1}}}},
+			}}}},
+		},
+		"SizeNotArray": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$size", "$missing"}}}}}}},
+			resultType: emptyResult,
+		},
+		"MapInvalidVariable": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$map", bson.D{
+				{"input", "$v"},
+				{"as", "Invalid"},
+				{"in", "$$Invalid"},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"InMissing": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$in", bson.A{1, "$missing"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"RangeMissing": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$range", bson.A{0, "$missing"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"RangeStepZero": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$range", bson.A{0, bson.D{{"$size", "$v"}}, 0}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectArrayObjects(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"ObjectToArray": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", primitive.Regex{Pattern: "^document"}}}}},
+				bson.D{{"$project", bson.D{
+					{"res", bson.D{{"$objectToArray", "$v"}}},
+					{"roundtrip", bson.D{{"$arrayToObject", bson.D{{"$objectToArray", "$v"}}}}},
+				}}},
+			},
+		},
+		"SortArrayBy": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", "array-documents"}}}},
+				bson.D{{"$project", bson.D{
+					{"res", bson.D{{"$sortArray", bson.D{{"input", "$v"}, {"sortBy", bson.D{{"field", -1}}}}}}},
+				}}},
+			},
+		},
+		"IsArray": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$isArray", "$v"}}}}}}},
+		},
+		"ObjectToArrayNotDocument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$objectToArray", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"ArrayToObjectInvalid": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$arrayToObject", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectConditional(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Scalars,
+		shareddata.Int32s,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"And": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$and", bson.A{
+					bson.D{{"$gte", bson.A{"$v", 0}}},
+					bson.D{{"$lt", bson.A{"$v", 42}}},
+				}}}},
+			}}}},
+		},
+		"AndEmpty": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$and", bson.A{}}}}}}}},
+		},
+		"Or": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$or", bson.A{"$v", bson.D{{"$eq", bson.A{"$foo", "bar"}}}}}}},
+			}}}},
+		},
+		"OrEmpty": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$or", bson.A{}}}}}}}},
+		},
+		"Not": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$not", bson.A{"$v"}}}}}}}},
+		},
+		"NotArgsInvalidLen": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$not", bson.A{"$v", "$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"IfNull": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$ifNull", bson.A{"$v", "default"}}}},
+			}}}},
+		},
+		"IfNullMultiple": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$ifNull", bson.A{"$foo", nil, "$v", "default"}}}},
+			}}}},
+		},
+		"IfNullArgsInvalidLen": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$ifNull", bson.A{"$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"Switch": {
+			pipeline: bson.A{
+				// MongoDB compares missing values as less than null
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$exists", true}}}}}},
+				bson.D{{"$project", bson.D{
+					{"res", bson.D{{"$switch", bson.D{
+						{"branches", bson.A{
+							bson.D{{"case", bson.D{{"$eq", bson.A{"$v", nil}}}}, {"then", "null"}},
+							bson.D{{"case", bson.D{{"$lt", bson.A{"$v", 0}}}}, {"then", "negative"}},
+							bson.D{{"case", bson.D{{"$eq", bson.A{bson.D{{"$type", "$v"}}, "string"}}}}, {"then", "string"}},
+						}},
+						{"default", "other"},
+					}}}},
+				}}},
+			},
+		},
+		"SwitchNoMatchingBranch": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$switch", bson.D{
+					{"branches", bson.A{bson.D{{"case", false}, {"then", "never"}}}},
+				}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"SwitchMissingBranches": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$switch", bson.D{{"default", "$v"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"SwitchBranchMissingThen": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$switch", bson.D{
+					{"branches", bson.A{bson.D{{"case", true}}}},
+				}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"SwitchUnknownArgument": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$switch", bson.D{
+					{"branches", bson.A{bson.D{{"case", true}, {"then", "$v"}}}},
+					{"foo", "bar"},
+				}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"MatchExpr": {
+			pipeline: bson.A{bson.D{{"$match", bson.D{{"$expr", bson.D{{"$or", bson.A{
+				bson.D{{"$and", bson.A{
+					bson.D{{"$gt", bson.A{"$v", 0}}},
+					bson.D{{"$lte", bson.A{"$v", 42}}},
+				}}},
+				bson.D{{"$not", bson.A{bson.D{{"$ifNull", bson.A{"$v", false}}}}}},
+			}}}}}}}},
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectConvert(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Scalars,
+		shareddata.Composites,
+	}
+
+	convert := func(to any) bson.D {
+		return bson.D{{"$convert", bson.D{
+			{"input", "$v"},
+			{"to", to},
+			{"onError", "error"},
+			{"onNull", "null"},
+		}}}
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Convert": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"double", convert("double")},
+				{"string", convert("string")},
+				{"objectId", convert("objectId")},
+				{"bool", convert("bool")},
+				{"date", convert("date")},
+				{"int", convert("int")},
+				{"long", convert("long")},
+			}}}},
+		},
+		"ConvertSameType": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"v", bson.D{{"$exists", true}}}}}},
+				bson.D{{"$project", bson.D{{"res", convert(bson.D{{"$type", "$v"}})}}}},
+			},
+		},
+		"ConvertNumericType": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"int", convert(int32(16))},
+				{"long", convert(18.0)},
+			}}}},
+		},
+		"ConvertNullType": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", convert(nil)}}}}},
+		},
+		"ConvertUnknownType": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", convert("foo")}}}}},
+			resultType: emptyResult,
+		},
+		"ConvertInvalidNumericType": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", convert(int32(100))}}}}},
+			resultType: emptyResult,
+		},
+		"ConvertFractionalType": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", convert(1.5)}}}}},
+			resultType: emptyResult,
+		},
+		"ConvertInvalidTypeArgument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", convert(true)}}}}},
+			resultType: emptyResult,
+		},
+		"ConvertNoOnError": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$convert", bson.D{{"input", "$v"}, {"to", "int"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"ConvertMissingTo": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$convert", bson.D{{"input", "$v"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"ConvertUnknownArgument": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$convert", bson.D{{"input", "$v"}, {"to", "int"}, {"foo", "bar"}}}}},
+			}}}},
+			resultType: emptyResult,
+		},
+		"ConvertInvalidSpec": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$convert", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"ToType": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$isNumber", "$v"}}}}}},
+				bson.D{{"$project", bson.D{
+					{"bool", bson.D{{"$toBool", "$v"}}},
+					{"double", bson.D{{"$toDouble", "$v"}}},
+					{"string", bson.D{{"$toString", "$v"}}},
+				}}},
+			},
+		},
+		"ToTypeStrings": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", bson.D{{"$in", bson.A{"string-double", "string-whole"}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"double", bson.D{{"$toDouble", "$v"}}},
+					{"long", bson.D{{"$toLong", bson.D{{"$toDouble", "$v"}}}}},
+					{"int", bson.D{{"$toInt", bson.D{{"$toDouble", "$v"}}}}},
+				}}},
+			},
+		},
+		"ToDate": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$in", bson.A{
+					bson.D{{"$type", "$v"}},
+					bson.A{"date", "objectId", "timestamp"},
+				}}}}}}},
+				bson.D{{"$project", bson.D{{"res", bson.D{{"$toDate", "$v"}}}}}},
+			},
+		},
+		"ToIntFailure": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$toInt", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"ToObjectIdFailure": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$toObjectId", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"ToStringArgsInvalidLen": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$toString", bson.A{"$v", "$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"IsNumber": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$isNumber", "$v"}}}}}}},
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectSet(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.ArrayInt32s,
+		shareddata.ArrayStrings,
+		shareddata.ArrayDoubles,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Union": {
+			// sorted as MongoDB does not guarantee the order of set elements
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$sortArray", bson.D{
+					{"input", bson.D{{"$setUnion", bson.A{"$v", bson.A{int32(42), int32(1), int32(1)}}}}},
+					{"sortBy", 1},
+				}}}},
+				{"empty", bson.D{{"$setUnion", bson.A{}}}},
+				{"null", bson.D{{"$setUnion", bson.A{"$v", "$missing"}}}},
+			}}}},
+		},
+		"Intersection": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$sortArray", bson.D{
+					{"input", bson.D{{"$setIntersection", bson.A{"$v", bson.A{int64(42), 43.0, "b", "c"}}}}},
+					{"sortBy", 1},
+				}}}},
+				{"self", bson.D{{"$size", bson.D{{"$setIntersection", bson.A{"$v", "$v"}}}}}},
+				{"null", bson.D{{"$setIntersection", bson.A{"$missing", "$v"}}}},
+			}}}},
+		},
+		"Difference": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$setDifference", bson.A{"$v", bson.A{int32(42), "a"}}}}},
+				{"empty", bson.D{{"$setDifference", bson.A{"$v", "$v"}}}},
+				{"null", bson.D{{"$setDifference", bson.A{"$v", nil}}}},
+			}}}},
+		},
+		"Equals": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"self", bson.D{{"$setEquals", bson.A{"$v", bson.D{{"$reverseArray", "$v"}}}}}},
+				{"union", bson.D{{"$setEquals", bson.A{"$v", bson.D{{"$setUnion", bson.A{"$v"}}}, "$v"}}}},
+				{"other", bson.D{{"$setEquals", bson.A{"$v", bson.A{int32(42)}}}}},
+			}}}},
+		},
+		"IsSubset": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"self", bson.D{{"$setIsSubset", bson.A{"$v", "$v"}}}},
+				{"empty", bson.D{{"$setIsSubset", bson.A{bson.A{}, "$v"}}}},
+				{"other", bson.D{{"$setIsSubset", bson.A{bson.A{int32(42), "a"}, "$v"}}}},
+			}}}},
+		},
+		"ElementsTrue": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"all", bson.D{{"$allElementsTrue", bson.A{"$v"}}}},
+				{"any", bson.D{{"$anyElementTrue", bson.A{"$v"}}}},
+				{"allZero", bson.D{{"$allElementsTrue", bson.A{bson.A{int32(1), int32(0)}}}}},
+				{"anyEmpty", bson.D{{"$anyElementTrue", bson.A{bson.A{}}}}},
+			}}}},
+		},
+		"UnionNotArray": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setUnion", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"DifferenceNotArray": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setDifference", bson.A{"$v", "a"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"EqualsOneArgument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setEquals", bson.A{"$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"EqualsNull": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setEquals", bson.A{"$v", "$missing"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"IsSubsetNull": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setIsSubset", bson.A{"$missing", "$v"}}}}}}}},
+			resultType: emptyResult,
+		},
+		"AllElementsTrueNotArray": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$allElementsTrue", "$missing"}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectObject(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"MergeObjects": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", primitive.Regex{Pattern: "^document"}}}}},
+				bson.D{{"$project", bson.D{
+					{"res", bson.D{{"$mergeObjects", bson.A{bson.D{{"foo", "bar"}, {"v", 1}}, "$v", nil, "$missing"}}}},
+					{"override", bson.D{{"$mergeObjects", bson.A{"$v", bson.D{{"foo", "baz"}}}}}},
+					{"empty", bson.D{{"$mergeObjects", bson.A{}}}},
+				}}},
+			},
+		},
+		"GetField": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"shorthand", bson.D{{"$getField", "v"}}},
+				{"literal", bson.D{{"$getField", bson.D{{"$literal", "_id"}}}}},
+				{"input", bson.D{{"$getField", bson.D{
+					{"field", bson.D{{"$literal", "$dollar"}}},
+					{"input", bson.D{{"$literal", bson.D{{"$dollar", int32(42)}}}}},
+				}}}},
+				// MongoDB returns missing value for fields that do not exist
+				{"missing", bson.D{{"$ifNull", bson.A{
+					bson.D{{"$getField", bson.D{{"field", "foo"}, {"input", "$v"}}}},
+					"default",
+				}}}},
+			}}}},
+		},
+		"SetField": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$setField", bson.D{
+					{"field", "new"},
+					{"input", "$$ROOT"},
+					{"value", "$v"},
+				}}}},
+				{"dollar", bson.D{{"$setField", bson.D{
+					{"field", bson.D{{"$literal", "$dollar"}}},
+					{"input", bson.D{{"foo", "bar"}}},
+					{"value", int32(42)},
+				}}}},
+				{"remove", bson.D{{"$setField", bson.D{
+					{"field", "_id"},
+					{"input", "$$ROOT"},
+					{"value", "$$REMOVE"},
+				}}}},
+				{"null", bson.D{{"$setField", bson.D{{"field", "a"}, {"input", nil}, {"value", 1}}}}},
+			}}}},
+		},
+		"UnsetField": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"res", bson.D{{"$unsetField", bson.D{{"field", "v"}, {"input", "$$ROOT"}}}}},
+				{"notExisting", bson.D{{"$unsetField", bson.D{{"field", "foo"}, {"input", bson.D{{"a", 1}}}}}}},
+			}}}},
+		},
+		"MergeObjectsNotDocument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$mergeObjects", bson.A{"$v", 1}}}}}}}},
+			resultType: emptyResult,
+		},
+		"GetFieldFieldPath": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$getField", "$v"}}}}}}},
+			resultType: emptyResult,
+		},
+		"GetFieldNotString": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$getField", int32(1)}}}}}}},
+			resultType: emptyResult,
+		},
+		"GetFieldUnknownArgument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$getField", bson.D{{"field", "v"}, {"foo", 1}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"SetFieldMissingValue": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setField", bson.D{{"field", "v"}, {"input", "$$ROOT"}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"SetFieldInputNotDocument": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$setField", bson.D{
+				{"field", "a"},
+				{"input", int32(1)},
+				{"value", int32(1)},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"UnsetFieldValue": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$unsetField", bson.D{
+				{"field", "v"},
+				{"input", "$$ROOT"},
+				{"value", int32(1)},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectMisc(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Scalars,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Literal": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"string", bson.D{{"$literal", "$v"}}},
+				{"array", bson.D{{"$literal", bson.A{int32(1), "$v"}}}},
+				{"singleElementArray", bson.D{{"$literal", bson.A{int32(1)}}}},
+				{"document", bson.D{{"$literal", bson.D{{"$add", bson.A{int32(1), int32(2)}}}}}},
+			}}}},
+		},
+		"Rand": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"type", bson.D{{"$type", bson.D{{"$rand", bson.D{}}}}}},
+				{"lt", bson.D{{"$lt", bson.A{bson.D{{"$rand", bson.D{}}}, int32(1)}}}},
+				{"gte", bson.D{{"$gte", bson.A{bson.D{{"$rand", bson.D{}}}, int32(0)}}}},
+			}}}},
+		},
+		"BSONSize": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"_id", primitive.Regex{Pattern: "^document"}}}}},
+				bson.D{{"$project", bson.D{
+					{"root", bson.D{{"$bsonSize", "$$ROOT"}}},
+					{"v", bson.D{{"$bsonSize", "$v"}}},
+					{"null", bson.D{{"$bsonSize", "$missing"}}},
+				}}},
+			},
+		},
+		"BinarySize": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$in", bson.A{bson.D{{"$type", "$v"}}, bson.A{"string", "binData"}}}}}}}},
+				bson.D{{"$project", bson.D{
+					{"res", bson.D{{"$binarySize", "$v"}}},
+					{"multibyte", bson.D{{"$binarySize", "ä"}}},
+					{"null", bson.D{{"$binarySize", "$missing"}}},
+				}}},
+			},
+		},
+		"RandArgument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$rand", bson.D{{"a", 1}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"BSONSizeNotDocument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$bsonSize", int32(1)}}}}}}},
+			resultType: emptyResult,
+		},
+		"BinarySizeInvalidType": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$binarySize", int32(1)}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatProjectRegex(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Strings,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Match": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"prefix", bson.D{{"$regexMatch", bson.D{{"input", "$v"}, {"regex", "^f"}}}}},
+				{"options", bson.D{{"$regexMatch", bson.D{{"input", "FOO"}, {"regex", "^fo+$"}, {"options", "i"}}}}},
+				{"regex", bson.D{{"$regexMatch", bson.D{{"input", "$v"}, {"regex", primitive.Regex{Pattern: `^\d+$`}}}}}},
+				{"null", bson.D{{"$regexMatch", bson.D{{"input", "foo"}, {"regex", "$missing"}}}}},
+			}}}},
+		},
+		"Find": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"captures", bson.D{{"$regexFind", bson.D{{"input", "$v"}, {"regex", "(o)(x)?"}}}}},
+				{"idx", bson.D{{"$regexFind", bson.D{{"input", "ää foo"}, {"regex", "f(o+)"}}}}},
+				{"regex", bson.D{{"$regexFind", bson.D{{"input", "$v"}, {"regex", primitive.Regex{Pattern: `\.(\d+)`}}}}}},
+				{"noMatch", bson.D{{"$regexFind", bson.D{{"input", "bar"}, {"regex", "o"}}}}},
+			}}}},
+		},
+		"FindAll": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{
+				{"all", bson.D{{"$regexFindAll", bson.D{{"input", "$v"}, {"regex", "[o2]"}}}}},
+				{"captures", bson.D{{"$regexFindAll", bson.D{{"input", "a1b22c333ä4"}, {"regex", `(\d)(\d)?`}}}}},
+				{"multiline", bson.D{{"$regexFindAll", bson.D{
+					{"input", "foo\nbar\nbaz"},
+					{"regex", primitive.Regex{Pattern: "^ba.$", Options: "m"}},
+				}}}},
+				{"null", bson.D{{"$regexFindAll", bson.D{{"input", "$missing"}, {"regex", "o"}}}}},
+			}}}},
+		},
+		"NotDocument": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexFind", "foo"}}}}}}},
+			resultType: emptyResult,
+		},
+		"UnknownArgument": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexMatch", bson.D{
+				{"input", "$v"},
+				{"regex", "o"},
+				{"foo", 1},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"MissingRegex": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexFindAll", bson.D{{"input", "$v"}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"InputNotString": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexFind", bson.D{{"input", 42}, {"regex", "o"}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"RegexInvalidType": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexFind", bson.D{{"input", "$v"}, {"regex", 42}}}}}}}}},
+			resultType: emptyResult,
+		},
+		"OptionsConflict": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexFind", bson.D{
+				{"input", "$v"},
+				{"regex", primitive.Regex{Pattern: "o", Options: "i"}},
+				{"options", "i"},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"InvalidOption": {
+			pipeline: bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexMatch", bson.D{
+				{"input", "$v"},
+				{"regex", "o"},
+				{"options", "z"},
+			}}}}}}}},
+			resultType: emptyResult,
+		},
+		"InvalidRegex": {
+			pipeline:   bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexMatch", bson.D{{"input", "$v"}, {"regex", "(o"}}}}}}}}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateCompatRedact(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Composites,
+		shareddata.ArrayDocuments,
+		shareddata.Scalars,
+	}
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Keep": {
+			pipeline: bson.A{
+				bson.D{{"$redact", "$$KEEP"}},
+			},
+		},
+		"Prune": {
+			pipeline: bson.A{
+				bson.D{{"$redact", "$$PRUNE"}},
+			},
+			resultType: emptyResult,
+		},
+		"Descend": {
+			pipeline: bson.A{
+				bson.D{{"$redact", "$$DESCEND"}},
+			},
+		},
+		"Cond": {
+			pipeline: bson.A{
+				bson.D{{"$redact", bson.D{{"$cond", bson.D{
+					{"if", bson.D{{"$eq", bson.A{"$foo", nil}}}},
+					{"then", "$$DESCEND"},
+					{"else", "$$PRUNE"},
+				}}}}},
+			},
+		},
+		"CondTopLevel": {
+			pipeline: bson.A{
+				bson.D{{"$redact", bson.D{{"$cond", bson.A{
+					bson.D{{"$eq", bson.A{bson.D{{"$type", "$v"}}, "object"}}},
+					"$$KEEP",
+					"$$PRUNE",
+				}}}}},
+			},
+		},
+		"InvalidReturn": {
+			pipeline: bson.A{
+				bson.D{{"$redact", 1}},
+			},
+			resultType: emptyResult,
+		},
+		"Missing": {
+			pipeline: bson.A{
+				bson.D{{"$redact", "$non-existent"}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
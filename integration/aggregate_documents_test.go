@@ -16,6 +16,7 @@ package integration
 
 import (
 	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +24,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/FerretDB/FerretDB/integration/setup"
 	"github.com/FerretDB/FerretDB/integration/shareddata"
@@ -602,6 +604,86 @@ func TestAggregateProjectErrors(t *testing.T) {
 	}
 }
 
+func TestAggregateProjectMissingFieldErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t, shareddata.Scalars)
+
+	for name, tc := range map[string]struct {
+		expression bson.D // required, projected expression evaluated for a missing field
+
+		err        *mongo.CommandError // required
+		altMessage string              // optional, alternative error message
+		skip       string              // optional, skip test with a specified reason
+	}{
+		"Size": {
+			expression: bson.D{{"$size", "$missing"}},
+			err: &mongo.CommandError{
+				Code:    17124,
+				Name:    "Location17124",
+				Message: "The argument to $size must be an array, but was of type: missing",
+			},
+		},
+		"In": {
+			expression: bson.D{{"$in", bson.A{1, "$missing"}}},
+			err: &mongo.CommandError{
+				Code:    40081,
+				Name:    "Location40081",
+				Message: "$in requires an array as a second argument, found: missing",
+			},
+		},
+		"Range": {
+			expression: bson.D{{"$range", bson.A{0, "$missing"}}},
+			err: &mongo.CommandError{
+				Code:    34445,
+				Name:    "Location34445",
+				Message: "$range requires a numeric ending value, found value of type: missing",
+			},
+		},
+		"AllElementsTrue": {
+			expression: bson.D{{"$allElementsTrue", "$missing"}},
+			err: &mongo.CommandError{
+				Code:    17040,
+				Name:    "Location17040",
+				Message: "$allElementsTrue's argument must be an array, but is missing",
+			},
+		},
+		"StrLenCP": {
+			expression: bson.D{{"$strLenCP", "$missing"}},
+			err: &mongo.CommandError{
+				Code:    34471,
+				Name:    "Location34471",
+				Message: "$strLenCP requires a string argument, found: missing",
+			},
+		},
+		"StrLenBytes": {
+			expression: bson.D{{"$strLenBytes", "$missing"}},
+			err: &mongo.CommandError{
+				Code:    34473,
+				Name:    "Location34473",
+				Message: "$strLenBytes requires a string argument, found: missing",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if tc.skip != "" {
+				t.Skip(tc.skip)
+			}
+
+			require.NotNil(t, tc.expression, "expression must not be nil")
+			require.NotNil(t, tc.err, "err must not be nil")
+
+			pipeline := bson.A{
+				bson.D{{"$match", bson.D{{"_id", "int32"}}}},
+				bson.D{{"$project", bson.D{{"res", tc.expression}}}},
+			}
+
+			_, err := collection.Aggregate(ctx, pipeline)
+			AssertEqualAltCommandError(t, *tc.err, tc.altMessage, err)
+		})
+	}
+}
+
 func TestAggregateProject(t *testing.T) {
 	t.Parallel()
 
@@ -648,6 +730,65 @@ func TestAggregateProject(t *testing.T) {
 	}
 }
 
+func TestAggregateProjectRegexUnsupported(t *testing.T) {
+	t.Parallel()
+
+	ctx, collection := setup.Setup(t, shareddata.Strings)
+
+	for name, tc := range map[string]struct {
+		regex string // required, regular expression
+
+		err *mongo.CommandError // required
+	}{
+		"Lookahead": {
+			regex: "f(?=o)",
+			err: &mongo.CommandError{
+				Code:    238,
+				Name:    "NotImplemented",
+				Message: "Invalid $project :: caused by :: $regexMatch does not support lookaround assertions in regular expressions",
+			},
+		},
+		"AtomicGroup": {
+			regex: "(?>fo)o",
+			err: &mongo.CommandError{
+				Code:    238,
+				Name:    "NotImplemented",
+				Message: "Invalid $project :: caused by :: $regexMatch does not support atomic groups in regular expressions",
+			},
+		},
+		"Backreference": {
+			regex: `(o)\1`,
+			err: &mongo.CommandError{
+				Code:    238,
+				Name:    "NotImplemented",
+				Message: "Invalid $project :: caused by :: $regexMatch does not support backreferences in regular expressions",
+			},
+		},
+		"PossessiveQuantifier": {
+			regex: "fo++",
+			err: &mongo.CommandError{
+				Code:    238,
+				Name:    "NotImplemented",
+				Message: "Invalid $project :: caused by :: $regexMatch does not support possessive quantifiers in regular expressions",
+			},
+		},
+	} {
+		t.Run(name, func(tt *testing.T) {
+			tt.Parallel()
+
+			t := setup.FailsForMongoDB(tt, "MongoDB supports all PCRE features")
+
+			pipeline := bson.A{bson.D{{"$project", bson.D{{"res", bson.D{{"$regexMatch", bson.D{
+				{"input", "$v"},
+				{"regex", tc.regex},
+			}}}}}}}}
+
+			_, err := collection.Aggregate(ctx, pipeline)
+			AssertEqualCommandError(t, *tc.err, err)
+		})
+	}
+}
+
 func TestAggregateSetErrors(t *testing.T) {
 	t.Parallel()
 
@@ -931,6 +1072,268 @@ func TestAggregateSortErrors(t *testing.T) {
 	}
 }
 
+func TestAggregateBlockingStagesMaxMemory(t *testing.T) {
+	t.Parallel()
+
+	s := setup.SetupWithOpts(t, &setup.SetupOpts{
+		BackendOptions: &setup.BackendOpts{BlockingStageMaxMemoryBytes: 16 * 1024},
+	})
+	ctx, collection := s.Ctx, s.Collection
+
+	// about 100 KiB of documents, so several sorted runs are written to disk
+	docs := make([]any, 100)
+	for i := range docs {
+		docs[i] = bson.D{{"_id", int32(i)}, {"v", int32(i % 7)}, {"s", strings.Repeat("x", 1000)}}
+	}
+
+	_, err := collection.InsertMany(ctx, docs)
+	require.NoError(t, err)
+
+	sortPipeline := bson.A{
+		bson.D{{"$sort", bson.D{{"v", 1}, {"_id", -1}}}},
+		bson.D{{"$project", bson.D{{"_id", 1}}}},
+	}
+
+	// accumulation states of all groups do not fit into the memory limit
+	groupPipeline := bson.A{
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+		bson.D{{"$group", bson.D{
+			{"_id", "$v"},
+			{"count", bson.D{{"$sum", 1}}},
+			{"ids", bson.D{{"$push", "$_id"}}},
+			{"s", bson.D{{"$push", "$s"}}},
+		}}},
+		bson.D{{"$set", bson.D{{"s", bson.D{{"$size", "$s"}}}}}},
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+	}
+
+	// accumulation state of a single group does not fit into the memory limit
+	singleGroupPipeline := bson.A{
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+		bson.D{{"$group", bson.D{
+			{"_id", nil},
+			{"first", bson.D{{"$first", "$_id"}}},
+			{"last", bson.D{{"$last", "$_id"}}},
+			{"max", bson.D{{"$max", "$v"}}},
+			{"ids", bson.D{{"$push", "$_id"}}},
+			{"s", bson.D{{"$push", "$s"}}},
+		}}},
+		bson.D{{"$set", bson.D{{"s", bson.D{{"$size", "$s"}}}}}},
+	}
+
+	t.Run("SortNoDiskUse", func(tt *testing.T) {
+		t := setup.FailsForMongoDB(tt, "memory limit of blocking stages is only configurable for FerretDB")
+
+		_, err := collection.Aggregate(ctx, sortPipeline, options.Aggregate().SetAllowDiskUse(false))
+
+		expected := mongo.CommandError{
+			Code: 292,
+			Name: "QueryExceededMemoryLimitNoDiskUseAllowed",
+			Message: "Sort exceeded memory limit of 16384 bytes, but did not opt in to external sorting. " +
+				"Aborting operation. Pass allowDiskUse:true to opt in.",
+		}
+		AssertEqualCommandError(t, expected, err)
+	})
+
+	t.Run("Sort", func(t *testing.T) {
+		cursor, err := collection.Aggregate(ctx, sortPipeline, options.Aggregate().SetAllowDiskUse(true))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		var expected []bson.D
+
+		for v := int32(0); v < 7; v++ {
+			for id := int32(99); id >= 0; id-- {
+				if id%7 == v {
+					expected = append(expected, bson.D{{"_id", id}})
+				}
+			}
+		}
+
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("SortLimit", func(t *testing.T) {
+		// only limited number of documents is kept in memory
+		pipeline := bson.A{sortPipeline[0], bson.D{{"$limit", 3}}, sortPipeline[1]}
+
+		cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(false))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		assert.Equal(t, []bson.D{{{"_id", int32(98)}}, {{"_id", int32(91)}}, {{"_id", int32(84)}}}, res)
+	})
+
+	t.Run("GroupNoDiskUse", func(tt *testing.T) {
+		t := setup.FailsForMongoDB(tt, "memory limit of blocking stages is only configurable for FerretDB")
+
+		_, err := collection.Aggregate(ctx, bson.A{groupPipeline[1]}, options.Aggregate().SetAllowDiskUse(false))
+
+		expected := mongo.CommandError{
+			Code:    292,
+			Name:    "QueryExceededMemoryLimitNoDiskUseAllowed",
+			Message: "Exceeded memory limit for $group, but didn't allow external sort. Pass allowDiskUse:true to opt in.",
+		}
+		AssertEqualCommandError(t, expected, err)
+	})
+
+	t.Run("Group", func(t *testing.T) {
+		cursor, err := collection.Aggregate(ctx, groupPipeline, options.Aggregate().SetAllowDiskUse(true))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		var expected []bson.D
+
+		for v := int32(0); v < 7; v++ {
+			var ids bson.A
+
+			for id := v; id < 100; id += 7 {
+				ids = append(ids, id)
+			}
+
+			expected = append(expected, bson.D{{"_id", v}, {"count", int32(len(ids))}, {"ids", ids}, {"s", int32(len(ids))}})
+		}
+
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("GroupLowCardinality", func(t *testing.T) {
+		// documents of the group do not fit into the memory limit, but its accumulation state does
+		pipeline := bson.A{bson.D{{"$group", bson.D{
+			{"_id", nil},
+			{"total", bson.D{{"$sum", "$v"}}},
+			{"count", bson.D{{"$sum", 1}}},
+			{"avg", bson.D{{"$avg", "$_id"}}},
+		}}}}
+
+		cursor, err := collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(false))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		var total int32
+		for i := int32(0); i < 100; i++ {
+			total += i % 7
+		}
+
+		expected := []bson.D{{{"_id", nil}, {"total", total}, {"count", int32(100)}, {"avg", 49.5}}}
+		assert.Equal(t, expected, res)
+	})
+
+	t.Run("GroupSingleNoDiskUse", func(tt *testing.T) {
+		t := setup.FailsForMongoDB(tt, "memory limit of blocking stages is only configurable for FerretDB")
+
+		_, err := collection.Aggregate(ctx, bson.A{singleGroupPipeline[1]}, options.Aggregate().SetAllowDiskUse(false))
+
+		expected := mongo.CommandError{
+			Code:    292,
+			Name:    "QueryExceededMemoryLimitNoDiskUseAllowed",
+			Message: "Exceeded memory limit for $group, but didn't allow external sort. Pass allowDiskUse:true to opt in.",
+		}
+		AssertEqualCommandError(t, expected, err)
+	})
+
+	t.Run("GroupSingle", func(t *testing.T) {
+		cursor, err := collection.Aggregate(ctx, singleGroupPipeline, options.Aggregate().SetAllowDiskUse(true))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		ids := make(bson.A, 100)
+		for i := range ids {
+			ids[i] = int32(i)
+		}
+
+		expected := []bson.D{{
+			{"_id", nil},
+			{"first", int32(0)},
+			{"last", int32(99)},
+			{"max", int32(6)},
+			{"ids", ids},
+			{"s", int32(100)},
+		}}
+		assert.Equal(t, expected, res)
+	})
+
+	findOpts := options.Find().SetSort(bson.D{{"v", 1}, {"_id", -1}}).SetProjection(bson.D{{"_id", 1}})
+
+	t.Run("FindNoDiskUse", func(tt *testing.T) {
+		t := setup.FailsForMongoDB(tt, "memory limit of blocking stages is only configurable for FerretDB")
+
+		_, err := collection.Find(ctx, bson.D{}, findOpts, options.Find().SetAllowDiskUse(false))
+
+		expected := mongo.CommandError{
+			Code: 292,
+			Name: "QueryExceededMemoryLimitNoDiskUseAllowed",
+			Message: "Executor error during find command :: caused by :: Sort exceeded memory limit of 16384 bytes, " +
+				"but did not opt in to external sorting.",
+		}
+		AssertEqualCommandError(t, expected, err)
+	})
+
+	t.Run("FindLimit", func(t *testing.T) {
+		cursor, err := collection.Find(ctx, bson.D{}, findOpts, options.Find().SetLimit(2))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		assert.Equal(t, []bson.D{{{"_id", int32(98)}}, {{"_id", int32(91)}}}, res)
+	})
+
+	t.Run("FindSkipLimit", func(t *testing.T) {
+		cursor, err := collection.Find(ctx, bson.D{}, findOpts, options.Find().SetSkip(1).SetLimit(2))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		assert.Equal(t, []bson.D{{{"_id", int32(91)}}, {{"_id", int32(84)}}}, res)
+	})
+
+	t.Run("FindAndModify", func(t *testing.T) {
+		// only the first document is kept in memory
+		res := collection.FindOneAndUpdate(
+			ctx,
+			bson.D{},
+			bson.D{{"$set", bson.D{{"found", true}}}},
+			options.FindOneAndUpdate().SetSort(bson.D{{"v", 1}, {"_id", -1}}),
+		)
+
+		var doc bson.D
+		require.NoError(t, res.Decode(&doc))
+		assert.Equal(t, bson.E{"_id", int32(98)}, doc[0])
+	})
+
+	t.Run("Find", func(t *testing.T) {
+		cursor, err := collection.Find(ctx, bson.D{}, findOpts, options.Find().SetAllowDiskUse(true))
+		require.NoError(t, err)
+
+		var res []bson.D
+		require.NoError(t, cursor.All(ctx, &res))
+
+		var expected []bson.D
+
+		for v := int32(0); v < 7; v++ {
+			for id := int32(99); id >= 0; id-- {
+				if id%7 == v {
+					expected = append(expected, bson.D{{"_id", id}})
+				}
+			}
+		}
+
+		assert.Equal(t, expected, res)
+	})
+}
+
 func TestAggregateCommandMaxTimeMSErrors(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,261 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+func TestAggregateCompatGraphLookup(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+		shareddata.Nulls,
+		shareddata.Composites,
+		shareddata.ArrayDocuments,
+	}
+
+	// order of found documents is not specified, so they are unwound and sorted
+	graphLookup := func(spec bson.D) bson.A {
+		return bson.A{
+			bson.D{{"$graphLookup", spec}},
+			bson.D{{"$unwind", bson.D{{"path", "$found"}, {"preserveNullAndEmptyArrays", true}}}},
+			bson.D{{"$project", bson.D{{"found._id", 1}, {"found.depth", 1}}}},
+			bson.D{{"$sort", bson.D{{"_id", 1}, {"found._id", 1}}}},
+		}
+	}
+
+	testCases := map[string]aggregateCollectionCompatTestCase{
+		"Value": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"depthField", "depth"},
+				})
+			},
+		},
+		"FromIDToValue": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$_id"},
+					{"connectFromField", "v"},
+					{"connectToField", "_id"},
+					{"as", "found"},
+					{"depthField", "depth"},
+				})
+			},
+		},
+		"MaxDepth": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$_id"},
+					{"connectFromField", "v"},
+					{"connectToField", "_id"},
+					{"as", "found"},
+					{"maxDepth", 0},
+					{"depthField", "depth"},
+				})
+			},
+		},
+		"RestrictSearchWithMatch": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"restrictSearchWithMatch", bson.D{{"_id", bson.D{{"$type", "string"}}}}},
+				})
+			},
+		},
+		"StartWithArray": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", bson.A{"$_id", "$v"}},
+					{"connectFromField", "v"},
+					{"connectToField", "_id"},
+					{"as", "found"},
+				})
+			},
+		},
+		"StartWithMissing": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$missing"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+				})
+			},
+		},
+		"DotNotation": {
+			pipeline: func(coll string) bson.A {
+				return graphLookup(bson.D{
+					{"from", coll},
+					{"startWith", "$v.field"},
+					{"connectFromField", "v.field"},
+					{"connectToField", "v.field"},
+					{"as", "found"},
+				})
+			},
+		},
+		"NonExistentCollection": {
+			pipeline: func(string) bson.A {
+				return graphLookup(bson.D{
+					{"from", "non-existent"},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+				})
+			},
+		},
+		"NotDocument": {
+			pipeline: func(string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", "invalid"}}}
+			},
+			resultType: emptyResult,
+		},
+		"MissingRequired": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"as", "found"},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"UnknownArgument": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"unknown", 1},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"NonStringArgument": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", 1},
+					{"connectToField", "v"},
+					{"as", "found"},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"MaxDepthNotNumber": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"maxDepth", "1"},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"MaxDepthNegative": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"maxDepth", -1},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"MaxDepthFraction": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"maxDepth", 1.5},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"RestrictSearchWithMatchNotDocument": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"restrictSearchWithMatch", 1},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"RestrictSearchWithMatchExpr": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+					{"restrictSearchWithMatch", bson.D{{"$expr", true}}},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+		"DollarPrefixedField": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{bson.D{{"$graphLookup", bson.D{
+					{"from", coll},
+					{"startWith", "$v"},
+					{"connectFromField", "$v"},
+					{"connectToField", "v"},
+					{"as", "found"},
+				}}}}
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateCollectionCompat(t, providers, testCases)
+}
@@ -0,0 +1,490 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/FerretDB/FerretDB/integration/setup"
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+// aggregateCollectionCompatTestCase describes compatibility test case
+// of aggregation stages accessing other collections by name.
+type aggregateCollectionCompatTestCase struct {
+	// required, coll is the name of the aggregated collection,
+	// unspecified $sort appends bson.D{{"$sort", bson.D{{"_id", 1}}}}
+	pipeline func(coll string) bson.A
+
+	resultType compatTestCaseResultType // defaults to nonEmptyResult
+	skip       string                   // always skip this test case, must have issue number mentioned
+}
+
+// testAggregateCollectionCompat tests aggregation stages accessing other collections with given providers.
+//
+// Target and compat collections have the same name, so the pipeline can refer to it.
+func testAggregateCollectionCompat(t *testing.T, providers shareddata.Providers, testCases map[string]aggregateCollectionCompatTestCase) { //nolint:lll // for readability
+	t.Helper()
+
+	require.NotEmpty(t, providers)
+
+	s := setup.SetupCompatWithOpts(t, &setup.SetupCompatOpts{
+		Providers: providers,
+	})
+	ctx, targetCollections, compatCollections := s.Ctx, s.TargetCollections, s.CompatCollections
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+
+			if tc.skip != "" {
+				t.Skip(tc.skip)
+			}
+
+			require.NotNil(t, tc.pipeline, "pipeline should be set")
+
+			var nonEmptyResults bool
+			for i := range targetCollections {
+				targetCollection := targetCollections[i]
+				compatCollection := compatCollections[i]
+				t.Run(targetCollection.Name(), func(t *testing.T) {
+					t.Helper()
+
+					require.Equal(t, compatCollection.Name(), targetCollection.Name())
+
+					pipeline := tc.pipeline(targetCollection.Name())
+
+					var hasSortStage bool
+					for _, stage := range pipeline {
+						stage, ok := stage.(bson.D)
+						if !ok {
+							continue
+						}
+
+						if _, hasSortStage = stage.Map()["$sort"]; hasSortStage {
+							break
+						}
+					}
+
+					if !hasSortStage && len(pipeline) > 0 {
+						pipeline = append(pipeline, bson.D{{"$sort", bson.D{{"_id", 1}}}})
+					}
+
+					targetCursor, targetErr := targetCollection.Aggregate(ctx, pipeline)
+					compatCursor, compatErr := compatCollection.Aggregate(ctx, pipeline)
+
+					if targetCursor != nil {
+						defer targetCursor.Close(ctx)
+					}
+					if compatCursor != nil {
+						defer compatCursor.Close(ctx)
+					}
+
+					if targetErr != nil {
+						t.Logf("Target error: %v", targetErr)
+						t.Logf("Compat error: %v", compatErr)
+
+						// error messages are intentionally not compared
+						AssertMatchesCommandError(t, compatErr, targetErr)
+
+						return
+					}
+					require.NoError(t, compatErr, "compat error; target returned no error")
+
+					targetRes := FetchAll(t, ctx, targetCursor)
+					compatRes := FetchAll(t, ctx, compatCursor)
+
+					AssertEqualDocumentsSlice(t, compatRes, targetRes)
+
+					if len(targetRes) > 0 || len(compatRes) > 0 {
+						nonEmptyResults = true
+					}
+				})
+			}
+
+			switch tc.resultType {
+			case nonEmptyResult:
+				assert.True(t, nonEmptyResults, "expected non-empty results")
+			case emptyResult:
+				assert.False(t, nonEmptyResults, "expected empty results")
+			default:
+				t.Fatalf("unknown result type %v", tc.resultType)
+			}
+		})
+	}
+}
+
+func TestAggregateCompatLookup(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+		shareddata.Nulls,
+		shareddata.Composites,
+		shareddata.ArrayDocuments,
+	}
+
+	testCases := map[string]aggregateCollectionCompatTestCase{
+		"LocalForeign": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"foreignField", "v"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LocalForeignID": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "_id"},
+						{"foreignField", "_id"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LocalForeignDotNotation": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v.field"},
+						{"foreignField", "v.field"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"MissingLocalField": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "missing"},
+						{"foreignField", "v"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"AsDotNotation": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "_id"},
+						{"foreignField", "_id"},
+						{"as", "v.joined"},
+					}}},
+				}
+			},
+		},
+		"NonExistentCollection": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", "non-existent"},
+						{"localField", "v"},
+						{"foreignField", "v"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"Pipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"pipeline", bson.A{
+							bson.D{{"$sort", bson.D{{"_id", 1}}}},
+							bson.D{{"$limit", 2}},
+							bson.D{{"$project", bson.D{{"_id", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LocalForeignPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"foreignField", "v"},
+						{"pipeline", bson.A{
+							bson.D{{"$count", "count"}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LetPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$v"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$sort", bson.D{{"_id", 1}}}},
+							bson.D{{"$limit", 1}},
+							bson.D{{"$addFields", bson.D{{"type", bson.D{{"$type", "$$local"}}}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LetPipelineMatch": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$v"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$local"}}}}}}},
+							bson.D{{"$project", bson.D{{"_id", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LetPipelineRedact": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$_id"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$redact", bson.D{{"$cond", bson.A{
+								bson.D{{"$eq", bson.A{"$_id", "$$local"}}},
+								"$$KEEP",
+								"$$PRUNE",
+							}}}}},
+							bson.D{{"$project", bson.D{{"_id", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"LetPipelineNested": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$_id"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$local"}}}}}}},
+							bson.D{{"$lookup", bson.D{
+								{"from", coll},
+								{"let", bson.D{{"outer", "$$local"}}},
+								{"pipeline", bson.A{
+									bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$outer"}}}}}}},
+									bson.D{{"$project", bson.D{{"_id", 1}}}},
+								}},
+								{"as", "nested"},
+							}}},
+							bson.D{{"$project", bson.D{{"_id", 1}, {"nested", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+		},
+		"Batches": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$set", bson.D{{"r", bson.D{{"$range", bson.A{0, 250}}}}}}},
+					bson.D{{"$unwind", "$r"}},
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "r"},
+						{"foreignField", "v"},
+						{"pipeline", bson.A{
+							bson.D{{"$project", bson.D{{"_id", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+					bson.D{{"$sort", bson.D{{"_id", 1}, {"r", 1}}}},
+				}
+			},
+		},
+		"BatchesID": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$set", bson.D{{"r", bson.D{{"$map", bson.D{
+						{"input", bson.D{{"$range", bson.A{0, 250}}}},
+						{"in", bson.D{{"$toString", "$$this"}}},
+					}}}}}}},
+					bson.D{{"$unwind", "$r"}},
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "r"},
+						{"foreignField", "_id"},
+						{"pipeline", bson.A{
+							bson.D{{"$project", bson.D{{"_id", 1}}}},
+						}},
+						{"as", "joined"},
+					}}},
+					bson.D{{"$sort", bson.D{{"_id", 1}, {"r", 1}}}},
+				}
+			},
+		},
+		"LetVariableDotNotation": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$$ROOT"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$limit", 1}},
+							bson.D{{"$project", bson.D{{"type", bson.D{{"$type", "$$local.v"}}}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			skip: "https://github.com/FerretDB/FerretDB/issues/2275",
+		},
+		"LetUndefinedVariable": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"local", "$v"}}},
+						{"pipeline", bson.A{
+							bson.D{{"$project", bson.D{{"type", bson.D{{"$type", "$$undefined"}}}}}},
+						}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+			skip:       "https://github.com/FerretDB/FerretDB/issues/2275",
+		},
+		"LetInvalidVariableName": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"let", bson.D{{"Local", "$v"}}},
+						{"pipeline", bson.A{}},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"MissingAs": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"foreignField", "v"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"MissingForeignField": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"MissingPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"UnknownArgument": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"foreignField", "v"},
+						{"as", "joined"},
+						{"unknown", "v"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidType": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", "invalid"}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidAsType": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"localField", "v"},
+						{"foreignField", "v"},
+						{"as", 1},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidPipelineType": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$lookup", bson.D{
+						{"from", coll},
+						{"pipeline", "invalid"},
+						{"as", "joined"},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateCollectionCompat(t, providers, testCases)
+}
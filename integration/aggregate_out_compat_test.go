@@ -0,0 +1,390 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/FerretDB/FerretDB/integration/setup"
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+// aggregateOutCompatTestCase describes compatibility test case of aggregation stages writing to collections.
+type aggregateOutCompatTestCase struct {
+	// required, coll is the name of the aggregated collection,
+	// out is the name of the output collection that does not exist before the pipeline runs
+	pipeline func(coll, out string) bson.A
+
+	skip string // always skip this test case, must have issue number mentioned
+}
+
+// testAggregateOutCompat tests aggregation stages writing to collections with given providers.
+//
+// Each test case uses its own collections, and both the aggregated and the output collections
+// are compared after the pipeline runs.
+func testAggregateOutCompat(t *testing.T, providers shareddata.Providers, testCases map[string]aggregateOutCompatTestCase) { //nolint:lll // for readability
+	t.Helper()
+
+	require.NotEmpty(t, providers)
+
+	for name, tc := range testCases {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Helper()
+
+			if tc.skip != "" {
+				t.Skip(tc.skip)
+			}
+
+			t.Parallel()
+
+			require.NotNil(t, tc.pipeline, "pipeline should be set")
+
+			s := setup.SetupCompatWithOpts(t, &setup.SetupCompatOpts{
+				Providers: providers,
+			})
+			ctx, targetCollections, compatCollections := s.Ctx, s.TargetCollections, s.CompatCollections
+
+			for i := range targetCollections {
+				targetCollection := targetCollections[i]
+				compatCollection := compatCollections[i]
+				t.Run(targetCollection.Name(), func(t *testing.T) {
+					t.Helper()
+
+					require.Equal(t, compatCollection.Name(), targetCollection.Name())
+
+					out := targetCollection.Name() + "_out"
+					pipeline := tc.pipeline(targetCollection.Name(), out)
+
+					targetCursor, targetErr := targetCollection.Aggregate(ctx, pipeline)
+					compatCursor, compatErr := compatCollection.Aggregate(ctx, pipeline)
+
+					if targetCursor != nil {
+						defer targetCursor.Close(ctx)
+					}
+					if compatCursor != nil {
+						defer compatCursor.Close(ctx)
+					}
+
+					if targetErr != nil {
+						t.Logf("Target error: %v", targetErr)
+						t.Logf("Compat error: %v", compatErr)
+
+						// error messages are intentionally not compared
+						AssertMatchesCommandError(t, compatErr, targetErr)
+
+						return
+					}
+					require.NoError(t, compatErr, "compat error; target returned no error")
+
+					// stages writing to collections do not return documents
+					assert.Empty(t, FetchAll(t, ctx, targetCursor))
+					assert.Empty(t, FetchAll(t, ctx, compatCursor))
+
+					AssertEqualDocumentsSlice(t, FindAll(t, ctx, compatCollection), FindAll(t, ctx, targetCollection))
+
+					targetOut := targetCollection.Database().Collection(out)
+					compatOut := compatCollection.Database().Collection(out)
+					AssertEqualDocumentsSlice(t, FindAll(t, ctx, compatOut), FindAll(t, ctx, targetOut))
+				})
+			}
+		})
+	}
+}
+
+func TestAggregateCompatOut(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateOutCompatTestCase{
+		"Out": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$out", out}}}
+			},
+		},
+		"Project": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$project", bson.D{{"v", 0}}}},
+					bson.D{{"$out", out}},
+				}
+			},
+		},
+		"Empty": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$match", bson.D{{"_id", "not-found"}}}},
+					bson.D{{"$out", out}},
+				}
+			},
+		},
+		"ReplaceSource": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{
+					bson.D{{"$sort", bson.D{{"_id", -1}}}},
+					bson.D{{"$limit", 2}},
+					bson.D{{"$out", coll}},
+				}
+			},
+		},
+		"DuplicateID": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$project", bson.D{{"_id", bson.D{{"$sum", bson.A{1}}}}}}},
+					bson.D{{"$out", out}},
+				}
+			},
+		},
+		"NotLast": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$out", out}},
+					bson.D{{"$match", bson.D{}}},
+				}
+			},
+		},
+		"InvalidType": {
+			pipeline: func(_, _ string) bson.A {
+				return bson.A{bson.D{{"$out", int32(1)}}}
+			},
+		},
+		"DocumentMissingDB": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$out", bson.D{{"coll", out}}}}}
+			},
+		},
+		"InvalidName": {
+			pipeline: func(_, _ string) bson.A {
+				return bson.A{bson.D{{"$out", "$invalid"}}}
+			},
+		},
+		"SpecialCollection": {
+			pipeline: func(_, _ string) bson.A {
+				return bson.A{bson.D{{"$out", "system.out"}}}
+			},
+		},
+		"InLookup": {
+			pipeline: func(coll, out string) bson.A {
+				return bson.A{bson.D{{"$lookup", bson.D{
+					{"from", coll},
+					{"pipeline", bson.A{bson.D{{"$out", out}}}},
+					{"as", "joined"},
+				}}}}
+			},
+		},
+		"InFacet": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$facet", bson.D{
+					{"out", bson.A{bson.D{{"$out", out}}}},
+				}}}}
+			},
+		},
+	}
+
+	testAggregateOutCompat(t, providers, testCases)
+}
+
+func TestAggregateCompatMerge(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+		shareddata.Composites,
+	}
+
+	testCases := map[string]aggregateOutCompatTestCase{
+		"String": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", out}}}
+			},
+		},
+		"Into": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}}}}}
+			},
+		},
+		"IntoDocument": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", bson.D{{"coll", out}}}}}}}
+			},
+		},
+		"WhenMatchedMerge": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{
+					bson.D{{"$project", bson.D{{"v", 0}}}},
+					bson.D{{"$addFields", bson.D{{"merged", true}}}},
+					bson.D{{"$merge", bson.D{{"into", coll}, {"whenMatched", "merge"}}}},
+				}
+			},
+		},
+		"WhenMatchedReplace": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{
+					bson.D{{"$project", bson.D{{"v", 0}}}},
+					bson.D{{"$addFields", bson.D{{"replaced", true}}}},
+					bson.D{{"$merge", bson.D{{"into", coll}, {"whenMatched", "replace"}}}},
+				}
+			},
+		},
+		"WhenMatchedKeepExisting": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{
+					bson.D{{"$project", bson.D{{"v", 0}}}},
+					bson.D{{"$merge", bson.D{{"into", coll}, {"whenMatched", "keepExisting"}}}},
+				}
+			},
+		},
+		"WhenMatchedFail": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", coll}, {"whenMatched", "fail"}}}}}
+			},
+		},
+		"WhenMatchedPipeline": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{
+					{"into", coll},
+					{"whenMatched", bson.A{
+						bson.D{{"$addFields", bson.D{{"sum", bson.D{{"$sum", bson.A{"$$new.v", int32(1)}}}}}}},
+					}},
+				}}}}
+			},
+		},
+		"WhenMatchedPipelineLet": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{
+					{"into", coll},
+					{"let", bson.D{{"x", int32(42)}}},
+					{"whenMatched", bson.A{
+						bson.D{{"$set", bson.D{{"sum", bson.D{{"$sum", bson.A{"$$x", int32(1)}}}}}}},
+					}},
+				}}}}
+			},
+		},
+		"WhenMatchedPipelineNotAllowed": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{
+					{"into", coll},
+					{"whenMatched", bson.A{bson.D{{"$match", bson.D{}}}}},
+				}}}}
+			},
+		},
+		"WhenMatchedInvalid": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", coll}, {"whenMatched", "invalid"}}}}}
+			},
+		},
+		"WhenNotMatchedDiscard": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"whenNotMatched", "discard"}}}}}
+			},
+		},
+		"WhenNotMatchedFail": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"whenNotMatched", "fail"}}}}}
+			},
+		},
+		"Batches": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$limit", 1}},
+					bson.D{{"$project", bson.D{{"n", bson.D{{"$range", bson.A{0, 250}}}}}}},
+					bson.D{{"$unwind", "$n"}},
+					bson.D{{"$set", bson.D{{"_id", bson.D{{"$toString", bson.D{{"$mod", bson.A{"$n", 150}}}}}}}}},
+					bson.D{{"$merge", bson.D{{"into", out}, {"whenMatched", "merge"}}}},
+				}
+			},
+		},
+		"BatchesNumbers": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$limit", 1}},
+					bson.D{{"$project", bson.D{{"n", bson.D{{"$range", bson.A{0, 250}}}}}}},
+					bson.D{{"$unwind", "$n"}},
+					bson.D{{"$set", bson.D{{"_id", bson.D{{"$mod", bson.A{"$n", 150}}}}}}},
+					bson.D{{"$merge", bson.D{{"into", out}, {"whenMatched", "merge"}}}},
+				}
+			},
+		},
+		"LetWithoutPipeline": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"let", bson.D{{"x", int32(1)}}}}}}}
+			},
+		},
+		"OnID": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"on", bson.A{"_id"}}}}}}
+			},
+		},
+		"OnNotUnique": {
+			pipeline: func(coll, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", coll}, {"on", "v"}}}}}
+			},
+		},
+		"OnEmpty": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"on", bson.A{}}}}}}
+			},
+		},
+		"OnInvalidType": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"on", int32(1)}}}}}
+			},
+		},
+		"MissingInto": {
+			pipeline: func(_, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"on", "_id"}}}}}
+			},
+		},
+		"UnknownField": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{bson.D{{"$merge", bson.D{{"into", out}, {"unknown", true}}}}}
+			},
+		},
+		"InvalidType": {
+			pipeline: func(_, _ string) bson.A {
+				return bson.A{bson.D{{"$merge", int32(1)}}}
+			},
+		},
+		"NotLast": {
+			pipeline: func(_, out string) bson.A {
+				return bson.A{
+					bson.D{{"$merge", out}},
+					bson.D{{"$match", bson.D{}}},
+				}
+			},
+		},
+		"InLookup": {
+			pipeline: func(coll, out string) bson.A {
+				return bson.A{bson.D{{"$lookup", bson.D{
+					{"from", coll},
+					{"pipeline", bson.A{bson.D{{"$merge", out}}}},
+					{"as", "joined"},
+				}}}}
+			},
+		},
+	}
+
+	testAggregateOutCompat(t, providers, testCases)
+}
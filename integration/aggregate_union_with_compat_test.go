@@ -0,0 +1,220 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package integration
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/FerretDB/FerretDB/integration/shareddata"
+)
+
+func TestAggregateCompatUnionWith(t *testing.T) {
+	t.Parallel()
+
+	providers := []shareddata.Provider{
+		shareddata.Int32s,
+		shareddata.Strings,
+		shareddata.Composites,
+		shareddata.ArrayDocuments,
+	}
+
+	// documents from the other collection are marked to sort them after the same input documents
+	sortUnion := bson.D{{"$sort", bson.D{{"_id", 1}, {"union", 1}}}}
+
+	testCases := map[string]aggregateCollectionCompatTestCase{
+		"String": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", coll}},
+				}
+			},
+		},
+		"Coll": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{{"coll", coll}}}},
+				}
+			},
+		},
+		"Pipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{
+							bson.D{{"$addFields", bson.D{{"union", true}}}},
+						}},
+					}}},
+					sortUnion,
+				}
+			},
+		},
+		"PipelineLimit": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$match", bson.D{{"v", bson.D{{"$exists", true}}}}}},
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{
+							bson.D{{"$sort", bson.D{{"_id", -1}}}},
+							bson.D{{"$limit", 1}},
+							bson.D{{"$addFields", bson.D{{"union", true}}}},
+						}},
+					}}},
+					sortUnion,
+				}
+			},
+		},
+		"EmptyPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{{"coll", coll}, {"pipeline", bson.A{}}}}},
+				}
+			},
+		},
+		"Nested": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{
+							bson.D{{"$unionWith", coll}},
+							bson.D{{"$addFields", bson.D{{"union", true}}}},
+						}},
+					}}},
+					sortUnion,
+				}
+			},
+		},
+		"Documents": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"pipeline", bson.A{
+							bson.D{{"$documents", bson.A{
+								bson.D{{"_id", "documents"}, {"union", true}},
+								bson.D{{"_id", "expression"}, {"v", bson.D{{"$type", "foo"}}}, {"union", true}},
+							}}},
+						}},
+					}}},
+					sortUnion,
+				}
+			},
+		},
+		"DocumentsNotFirst": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{
+							bson.D{{"$match", bson.D{}}},
+							bson.D{{"$documents", bson.A{bson.D{{"_id", "documents"}}}}},
+						}},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"Count": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", coll}},
+					bson.D{{"$count", "n"}},
+				}
+			},
+		},
+		"NonExistent": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", "non-existent"}},
+				}
+			},
+		},
+		"InvalidType": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", 1}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidCollType": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{{"coll", 1}}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidPipelineType": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{{"coll", coll}, {"pipeline", "invalid"}}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"InvalidCollName": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", "$invalid"}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"MissingColl": {
+			pipeline: func(string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"UnknownField": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{{"coll", coll}, {"unknown", 1}}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"OutInPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{bson.D{{"$out", coll + "_out"}}}},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+		"MergeInPipeline": {
+			pipeline: func(coll string) bson.A {
+				return bson.A{
+					bson.D{{"$unionWith", bson.D{
+						{"coll", coll},
+						{"pipeline", bson.A{bson.D{{"$merge", coll + "_out"}}}},
+					}}},
+				}
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateCollectionCompat(t, providers, testCases)
+}
@@ -32,7 +32,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 			pipeline: bson.A{
 				bson.D{{"$addFields", bson.D{{"field", "$$ROOT"}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1413",
 		},
 		"GroupID": {
 			pipeline: bson.A{
@@ -40,7 +39,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 				bson.D{{"$group", bson.D{{"_id", "$$ROOT"}}}},
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupIDTwice": {
 			pipeline: bson.A{
@@ -49,7 +47,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 				bson.D{{"$group", bson.D{{"_id", "$$ROOT"}}}},
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupIDFieldID": {
 			pipeline: bson.A{
@@ -57,7 +54,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 				bson.D{{"$group", bson.D{{"_id", "$$ROOT._id"}}}},
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupIDFieldValue": {
 			pipeline: bson.A{
@@ -76,14 +72,12 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 				bson.D{{"$group", bson.D{{"_id", "$$ROOT.v.foo"}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupIDFieldArrayDotNotation": {
 			pipeline: bson.A{
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 				bson.D{{"$group", bson.D{{"_id", "$$ROOT.v.0"}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupIDExpression": {
 			pipeline: bson.A{
@@ -93,7 +87,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 				}}},
 				bson.D{{"$sort", bson.D{{"_id", 1}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"GroupSumAccumulator": {
 			pipeline: bson.A{
@@ -111,7 +104,6 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 					{"sum", bson.D{{"$sum", "$$ROOT"}}},
 				}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"ProjectTypeOperator": {
 			pipeline: bson.A{
@@ -119,13 +111,11 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 					{"type", bson.D{{"$type", "$$ROOT"}}},
 				}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1992",
 		},
 		"Set": {
 			pipeline: bson.A{
 				bson.D{{"$set", bson.D{{"field", "$$ROOT"}}}},
 			},
-			skip: "https://github.com/FerretDB/FerretDB/issues/1413",
 		},
 		"Unwind": {
 			pipeline: bson.A{
@@ -137,3 +127,325 @@ func TestAggregateVariablesCompatRoot(t *testing.T) {
 
 	testAggregateStagesCompatWithProviders(t, providers, testCases)
 }
+
+func TestAggregateVariablesCompatCurrent(t *testing.T) {
+	t.Parallel()
+
+	providers := shareddata.AllProviders().Remove(shareddata.Composites)
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Project": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"field", "$$CURRENT"}}}},
+			},
+		},
+		"ProjectField": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"field", "$$CURRENT.v"}}}},
+			},
+		},
+		"AddFields": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{{"field", "$$CURRENT.v"}}}},
+			},
+		},
+		"Match": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$$CURRENT._id", "$_id"}}}}}}},
+			},
+		},
+	}
+
+	testAggregateStagesCompatWithProviders(t, providers, testCases)
+}
+
+func TestAggregateVariablesCompatRemove(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Project": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$REMOVE"}}}},
+			},
+		},
+		"AddFields": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{{"v", "$$REMOVE"}}}},
+			},
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateVariablesCompatNow(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Type": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"now", bson.D{{"$type", "$$NOW"}}}}}},
+			},
+		},
+		"SameValue": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"same", bson.D{{"$eq", bson.A{"$$NOW", "$$NOW"}}}}}}},
+			},
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateVariablesCompatUndefined(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Project": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$foo"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"ProjectField": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$foo.bar"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"ProjectOperator": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", bson.D{{"$type", "$$foo"}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"AddFields": {
+			pipeline: bson.A{
+				bson.D{{"$addFields", bson.D{{"v", "$$foo"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"Match": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", "$$foo"}}}},
+			},
+			resultType: emptyResult,
+		},
+		"Group": {
+			pipeline: bson.A{
+				bson.D{{"$group", bson.D{{"_id", "$$foo"}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateVariablesCompatLetOperator(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Simple": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"x", "$v"}}},
+					{"in", bson.D{{"$type", "$$x"}}},
+				}}}}}}},
+			},
+		},
+		"Multiple": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"x", "$v"}, {"y", "$_id"}}},
+					{"in", bson.A{"$$y", "$$x"}},
+				}}}}}}},
+			},
+		},
+		"Nested": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"x", "foo"}}},
+					{"in", bson.D{{"$let", bson.D{
+						{"vars", bson.D{{"x", "$v"}, {"y", "$$x"}}},
+						{"in", bson.A{"$$x", "$$y"}},
+					}}}},
+				}}}}}}},
+			},
+		},
+		"EmptyVars": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{}},
+					{"in", "$v"},
+				}}}}}}},
+			},
+		},
+		"Current": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"CURRENT", bson.D{{"foo", "$v"}}}}},
+					{"in", "$foo"},
+				}}}}}}},
+			},
+		},
+		"OuterVariable": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"x", "$v"}, {"y", "$$x"}}},
+					{"in", "$$y"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"UndefinedOutside": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{
+					{"let", bson.D{{"$let", bson.D{
+						{"vars", bson.D{{"x", "$v"}}},
+						{"in", "$$x"},
+					}}}},
+					{"outside", "$$x"},
+				}}},
+			},
+			resultType: emptyResult,
+		},
+		"NotDocument": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", "foo"}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"UnknownParameter": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{}},
+					{"in", "$v"},
+					{"foo", "bar"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingVars": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"in", "$v"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"MissingIn": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{}},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"VarsNotDocument": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", "foo"},
+					{"in", "$v"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidVariableName": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"Foo", "$v"}}},
+					{"in", "$$Foo"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+		"SystemVariableName": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"let", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"ROOT", "$v"}}},
+					{"in", "$$ROOT"},
+				}}}}}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
+
+func TestAggregateVariablesCompatCommandLet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]aggregateStagesCompatTestCase{
+		"Match": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}}}},
+			},
+			let: bson.D{{"x", int32(42)}},
+		},
+		"MatchExpression": {
+			pipeline: bson.A{
+				bson.D{{"$match", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}}}},
+			},
+			let: bson.D{{"x", bson.D{{"$add", bson.A{int32(40), int32(2)}}}}},
+		},
+		"Project": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$x"}, {"w", "$$y"}}}},
+			},
+			let: bson.D{{"x", "foo"}, {"y", bson.A{"bar", int32(42)}}},
+		},
+		"Shadowed": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", bson.D{{"$let", bson.D{
+					{"vars", bson.D{{"x", "$v"}}},
+					{"in", "$$x"},
+				}}}}}}},
+			},
+			let: bson.D{{"x", "foo"}},
+		},
+		"Now": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", bson.D{{"$eq", bson.A{"$$x", "$$NOW"}}}}}}},
+			},
+			let: bson.D{{"x", "$$NOW"}},
+		},
+		"Literal": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$x"}}}},
+			},
+			let: bson.D{{"x", bson.D{{"$literal", "$v"}}}},
+		},
+		"FieldPath": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$x"}}}},
+			},
+			let:        bson.D{{"x", "$v"}},
+			resultType: emptyResult,
+		},
+		"Root": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$x"}}}},
+			},
+			let:        bson.D{{"x", "$$ROOT"}},
+			resultType: emptyResult,
+		},
+		"InvalidVariableName": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$X"}}}},
+			},
+			let:        bson.D{{"X", int32(42)}},
+			resultType: emptyResult,
+		},
+		"Undefined": {
+			pipeline: bson.A{
+				bson.D{{"$project", bson.D{{"v", "$$y"}}}},
+			},
+			let:        bson.D{{"x", int32(42)}},
+			resultType: emptyResult,
+		},
+	}
+
+	testAggregateStagesCompat(t, testCases)
+}
@@ -31,6 +31,7 @@ import (
 type deleteCommandCompatTestCase struct {
 	skip    string // optional, skip test with a specified reason
 	deletes bson.A // required
+	let     bson.D // optional, leave nil for unset let
 }
 
 func testDeleteCommandCompat(t *testing.T, testCases map[string]deleteCommandCompatTestCase) {
@@ -56,15 +57,23 @@ func testDeleteCommandCompat(t *testing.T, testCases map[string]deleteCommandCom
 				t.Run(targetCollection.Name(), func(t *testing.T) {
 					t.Helper()
 
-					var targetRes, compatRes bson.D
-					targetErr := targetCollection.Database().RunCommand(ctx, bson.D{
+					targetCommand := bson.D{
 						{"delete", targetCollection.Name()},
 						{"deletes", tc.deletes},
-					}).Decode(&targetRes)
-					compatErr := compatCollection.Database().RunCommand(ctx, bson.D{
+					}
+					compatCommand := bson.D{
 						{"delete", compatCollection.Name()},
 						{"deletes", tc.deletes},
-					}).Decode(&compatRes)
+					}
+
+					if tc.let != nil {
+						targetCommand = append(targetCommand, bson.E{Key: "let", Value: tc.let})
+						compatCommand = append(compatCommand, bson.E{Key: "let", Value: tc.let})
+					}
+
+					var targetRes, compatRes bson.D
+					targetErr := targetCollection.Database().RunCommand(ctx, targetCommand).Decode(&targetRes)
+					compatErr := compatCollection.Database().RunCommand(ctx, compatCommand).Decode(&compatRes)
 
 					if targetErr != nil {
 						t.Logf("Target error: %v", targetErr)
@@ -122,6 +131,37 @@ func TestDeleteCommandCompat(t *testing.T) {
 	testDeleteCommandCompat(t, testCases)
 }
 
+func TestDeleteCommandCompatLet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]deleteCommandCompatTestCase{
+		"Int": {
+			deletes: bson.A{
+				bson.D{{"q", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}}}, {"limit", 0}},
+			},
+			let: bson.D{{"x", int32(42)}},
+		},
+		"Expression": {
+			deletes: bson.A{
+				bson.D{{"q", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}}}, {"limit", 0}},
+				bson.D{{"q", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$y"}}}}}}, {"limit", 0}},
+			},
+			let: bson.D{
+				{"x", bson.D{{"$add", bson.A{int32(40), int32(2)}}}},
+				{"y", bson.D{{"$concat", bson.A{"f", "oo"}}}},
+			},
+		},
+		"Undefined": {
+			deletes: bson.A{
+				bson.D{{"q", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$y"}}}}}}, {"limit", 0}},
+			},
+			let: bson.D{{"x", int32(42)}},
+		},
+	}
+
+	testDeleteCommandCompat(t, testCases)
+}
+
 func TestDeleteCommandCompatNotExistingDatabase(t *testing.T) {
 	t.Parallel()
 
@@ -893,6 +893,45 @@ func TestFindAndModifyCompatRemove(t *testing.T) {
 	testFindAndModifyCompat(t, testCases)
 }
 
+func TestFindAndModifyCompatLet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]findAndModifyCompatTestCase{
+		"Update": {
+			command: bson.D{
+				{"query", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$id"}}}}}},
+				{"update", bson.D{{"$set", bson.D{{"v", "foo"}}}}},
+				{"let", bson.D{{"id", "double"}}},
+			},
+		},
+		"Remove": {
+			command: bson.D{
+				{"query", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$id"}}}}}},
+				{"remove", true},
+				{"let", bson.D{{"id", bson.D{{"$concat", bson.A{"int", "32"}}}}}},
+			},
+		},
+		"FieldPath": {
+			command: bson.D{
+				{"query", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$id"}}}}}},
+				{"remove", true},
+				{"let", bson.D{{"id", "$_id"}}},
+			},
+			resultType: emptyResult,
+		},
+		"InvalidVariableName": {
+			command: bson.D{
+				{"query", bson.D{{"$expr", bson.D{{"$eq", bson.A{"$_id", "$$Id"}}}}}},
+				{"remove", true},
+				{"let", bson.D{{"Id", "double"}}},
+			},
+			resultType: emptyResult,
+		},
+	}
+
+	testFindAndModifyCompat(t, testCases)
+}
+
 func TestFindAndModifyCompatReplacementDoc(t *testing.T) {
 	t.Parallel()
 
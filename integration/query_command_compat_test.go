@@ -35,6 +35,7 @@ type queryCommandCompatTestCase struct {
 	filter     bson.D // required
 	sort       bson.D // defaults to `bson.D{{"_id", 1}}`
 	projection bson.D // nil for leaving projection unset
+	let        bson.D // nil for leaving let unset
 
 	optSkip        any                      // defaults to nil to leave unset
 	limit          *int64                   // defaults to nil to leave unset
@@ -82,6 +83,10 @@ func testQueryCommandCompat(t *testing.T, testCases map[string]queryCommandCompa
 				rest = append(rest, bson.E{Key: "projection", Value: tc.projection})
 			}
 
+			if tc.let != nil {
+				rest = append(rest, bson.E{Key: "let", Value: tc.let})
+			}
+
 			t.Parallel()
 
 			filter := tc.filter
@@ -95,10 +100,17 @@ func testQueryCommandCompat(t *testing.T, testCases map[string]queryCommandCompa
 					t.Helper()
 
 					// don't add sort, limit, skip, and projection because we don't pushdown them yet
-					explainQuery := bson.D{{"explain", bson.D{
+					explainFind := bson.D{
 						{"find", targetCollection.Name()},
 						{"filter", filter},
-					}}}
+					}
+
+					// let is required to explain the filter using variables
+					if tc.let != nil {
+						explainFind = append(explainFind, bson.E{Key: "let", Value: tc.let})
+					}
+
+					explainQuery := bson.D{{"explain", explainFind}}
 
 					var explainRes bson.D
 					require.NoError(t, targetCollection.Database().RunCommand(ctx, explainQuery).Decode(&explainRes))
@@ -213,3 +225,36 @@ func TestQueryCommandCompatSkip(t *testing.T) {
 
 	testQueryCommandCompat(t, testCases)
 }
+
+func TestQueryCommandCompatLet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]queryCommandCompatTestCase{
+		"Int": {
+			filter: bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			let:    bson.D{{"x", int32(42)}},
+		},
+		"Expression": {
+			filter: bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			let:    bson.D{{"x", bson.D{{"$add", bson.A{int32(40), int32(2)}}}}},
+		},
+		"Multiple": {
+			filter: bson.D{{"$expr", bson.D{{"$or", bson.A{
+				bson.D{{"$eq", bson.A{"$v", "$$x"}}},
+				bson.D{{"$eq", bson.A{"$v", "$$y"}}},
+			}}}}},
+			let: bson.D{{"x", int32(42)}, {"y", "foo"}},
+		},
+		"Now": {
+			filter: bson.D{{"$expr", bson.D{{"$eq", bson.A{"$$x", "$$NOW"}}}}},
+			let:    bson.D{{"x", "$$NOW"}},
+		},
+		"NoMatch": {
+			filter:     bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			let:        bson.D{{"x", "no-such-value"}},
+			resultType: emptyResult,
+		},
+	}
+
+	testQueryCommandCompat(t, testCases)
+}
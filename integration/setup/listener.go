@@ -185,12 +185,13 @@ func setupListener(tb testtb.TB, ctx context.Context, logger *slog.Logger, opts
 		HANAURL:       *hanaURLF,
 
 		TestOpts: registry.TestOpts{
-			DisablePushdown:         *disablePushdownF,
-			CappedCleanupPercentage: opts.CappedCleanupPercentage,
-			CappedCleanupInterval:   opts.CappedCleanupInterval,
-			EnableNewAuth:           !opts.DisableNewAuth,
-			BatchSize:               *batchSizeF,
-			MaxBsonObjectSizeBytes:  opts.MaxBsonObjectSizeBytes,
+			DisablePushdown:             *disablePushdownF,
+			CappedCleanupPercentage:     opts.CappedCleanupPercentage,
+			CappedCleanupInterval:       opts.CappedCleanupInterval,
+			EnableNewAuth:               !opts.DisableNewAuth,
+			BatchSize:                   *batchSizeF,
+			MaxBsonObjectSizeBytes:      opts.MaxBsonObjectSizeBytes,
+			BlockingStageMaxMemoryBytes: opts.BlockingStageMaxMemoryBytes,
 		},
 	}
 
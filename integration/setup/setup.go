@@ -104,6 +104,9 @@ type BackendOpts struct {
 	// MaxBsonObjectSizeBytes is the maximum allowed size of a document, if not set FerretDB sets the default.
 	MaxBsonObjectSizeBytes int
 
+	// BlockingStageMaxMemoryBytes is the maximum memory used by $sort and $group stages, if not set FerretDB sets the default.
+	BlockingStageMaxMemoryBytes int
+
 	// DisableNewAuth true uses the old backend authentication.
 	DisableNewAuth bool
 }
@@ -277,6 +277,7 @@ type updateCommandCompatTestCase struct {
 	upsert     bool                     // defaults to false
 	update     bson.D                   // required
 	filter     bson.D                   // defaults to bson.D{{"_id", id}}
+	let        bson.D                   // optional, leave nil for unset let
 	resultType compatTestCaseResultType // defaults to nonEmptyResult
 
 	skip string // skips test if non-empty
@@ -350,6 +351,11 @@ func testUpdateCommandCompat(t *testing.T, testCases map[string]updateCommandCom
 								}}},
 							}
 
+							if tc.let != nil {
+								targetCommand = append(targetCommand, bson.E{Key: "let", Value: tc.let})
+								compatCommand = append(compatCommand, bson.E{Key: "let", Value: tc.let})
+							}
+
 							var targetUpdateRes, compatUpdateRes bson.D
 							var targetErr, compatErr error
 
@@ -383,6 +389,10 @@ func testUpdateCommandCompat(t *testing.T, testCases map[string]updateCommandCom
 								// if multi == false, an item updated by compat and target are different.
 
 								opts := options.Find().SetSort(bson.D{{"_id", 1}})
+								if tc.let != nil {
+									opts.SetLet(tc.let)
+								}
+
 								targetCursor, targetErr := targetCollection.Find(ctx, filter, opts)
 								compatCursor, compatErr := compatCollection.Find(ctx, filter, opts)
 
@@ -686,6 +696,33 @@ func TestUpdateCompatMultiFlagCommand(t *testing.T) {
 	testUpdateCommandCompat(t, testCases)
 }
 
+func TestUpdateCommandCompatLet(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]updateCommandCompatTestCase{
+		"Int": {
+			filter: bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			update: bson.D{{"$set", bson.D{{"v", int32(43)}}}},
+			let:    bson.D{{"x", int32(42)}},
+			multi:  true,
+		},
+		"Expression": {
+			filter: bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			update: bson.D{{"$set", bson.D{{"v", int32(43)}}}},
+			let:    bson.D{{"x", bson.D{{"$add", bson.A{int32(40), int32(2)}}}}},
+			multi:  true,
+		},
+		"FieldPath": {
+			filter:     bson.D{{"$expr", bson.D{{"$eq", bson.A{"$v", "$$x"}}}}},
+			update:     bson.D{{"$set", bson.D{{"v", int32(43)}}}},
+			let:        bson.D{{"x", "$v"}},
+			resultType: emptyResult,
+		},
+	}
+
+	testUpdateCommandCompat(t, testCases)
+}
+
 func TestReplaceKeepOrderCompat(t *testing.T) {
 	t.Parallel()
 
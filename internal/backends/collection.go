@@ -78,6 +78,7 @@ type QueryParams struct {
 	Filter *types.Document
 	Sort   *types.Document
 	Limit  int64
+	Sample int64
 
 	OnlyRecordIDs bool
 	Comment       string
@@ -104,6 +105,9 @@ type QueryResult struct {
 // If non-empty, it should be applied.
 //
 // Limit, if non-zero, should be applied.
+//
+// Sample, if non-zero, should be applied by returning at most that number of randomly selected documents.
+// It can't be combined with Filter, Sort, or Limit.
 func (cc *collectionContract) Query(ctx context.Context, params *QueryParams) (*QueryResult, error) {
 	ctx, span := otel.Tracer("").Start(ctx, "Query")
 	defer span.End()
@@ -121,6 +125,10 @@ func (cc *collectionContract) Query(ctx context.Context, params *QueryParams) (*
 		}
 	}
 
+	if params.Sample != 0 {
+		must.BeTrue(params.Filter == nil && params.Sort == nil && params.Limit == 0)
+	}
+
 	res, err := cc.c.Query(ctx, params)
 	if err != nil {
 		span.SetStatus(otelcodes.Error, "")
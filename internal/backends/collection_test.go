@@ -242,6 +242,27 @@ func TestCollectionInsertAllQueryExplain(t *testing.T) {
 				require.NoError(t, err)
 				assert.False(t, explainRes.SortPushdown)
 			})
+
+			t.Run("Sample", func(t *testing.T) {
+				if name == "hana" {
+					t.Skip("hana backend does not apply sample")
+				}
+
+				t.Parallel()
+
+				queryRes, err := coll.Query(ctx, &backends.QueryParams{Sample: 2})
+				require.NoError(t, err)
+
+				docs, err := iterator.ConsumeValues[struct{}, *types.Document](queryRes.Iter)
+				require.NoError(t, err)
+				require.Len(t, docs, 2)
+
+				for _, doc := range docs {
+					assert.True(t, slices.ContainsFunc(insertDocs, func(d *types.Document) bool {
+						return d.Map()["_id"] == doc.Map()["_id"]
+					}))
+				}
+			})
 		})
 	}
 }
@@ -101,6 +101,11 @@ func (c *collection) Query(ctx context.Context, params *backends.QueryParams) (*
 		args = append(args, params.Limit)
 	}
 
+	if params.Sample != 0 {
+		q += ` ORDER BY RAND() LIMIT ?`
+		args = append(args, params.Sample)
+	}
+
 	rows, err := p.QueryContext(ctx, q, args...)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
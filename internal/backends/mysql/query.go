@@ -170,6 +170,12 @@ func prepareWhereClause(sqlFilters *types.Document) (string, []any, error) {
 						args = append(args, a...)
 					}
 
+				case "$in":
+					if f, a := filterIn(rootKey, v); f != "" {
+						filters = append(filters, f)
+						args = append(args, a...)
+					}
+
 				case "$ne":
 					sql := `NOT ( ` +
 						// check if the value under the key is equal to filter value
@@ -297,3 +303,35 @@ func filterEqual(k string, v any) (filter string, args []any) {
 
 	return
 }
+
+// filterIn returns the proper SQL filter with arguments that filters documents
+// where the value under k is equal to any element of v array.
+//
+// Empty filter is returned if v is not a non-empty array of values supported by filterEqual.
+func filterIn(k string, v any) (filter string, args []any) {
+	arr, ok := v.(*types.Array)
+	if !ok || arr.Len() == 0 {
+		return
+	}
+
+	// check all values first, so nothing is pushed down if any of them is not supported
+	for i := 0; i < arr.Len(); i++ {
+		switch must.NotFail(arr.Get(i)).(type) {
+		case float64, string, types.ObjectID, bool, time.Time, int32, int64:
+		default:
+			return
+		}
+	}
+
+	filters := make([]string, arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		var a []any
+		filters[i], a = filterEqual(k, must.NotFail(arr.Get(i)))
+		args = append(args, a...)
+	}
+
+	filter = `(` + strings.Join(filters, " OR ") + `)`
+
+	return
+}
@@ -291,6 +291,37 @@ func TestPrepareWhereClause(t *testing.T) {
 			expected: whereNotEq + `'"objectId"' )`,
 		},
 
+		"InString": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray("foo", int32(42))))),
+			)),
+			args: []any{`v`, `"foo"`, `v`, int32(42)},
+			expected: " WHERE (JSON_CONTAINS(_ferretdb_sjson->$.?, ?, '$') OR " +
+				"JSON_CONTAINS(_ferretdb_sjson->$.?, ?, '$'))",
+		},
+		"InIDObjectID": {
+			filter: must.NotFail(types.NewDocument(
+				"_id", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray(objectID)))),
+			)),
+			args:     []any{`_id`, `"6256c5ba0badc0ffeeffffff"`},
+			expected: " WHERE (JSON_CONTAINS(_ferretdb_sjson->$.?, ?, '$'))",
+		},
+		"InEmpty": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", types.MakeArray(0))),
+			)),
+		},
+		"InNull": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray("foo", types.Null)))),
+			)),
+		},
+		"InNotArray": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", "foo")),
+			)),
+		},
+
 		"Comment": {
 			filter: must.NotFail(types.NewDocument("$comment", "I'm comment")),
 		},
@@ -104,6 +104,12 @@ func (c *collection) Query(ctx context.Context, params *backends.QueryParams) (*
 		args = append(args, params.Limit)
 	}
 
+	if params.Sample != 0 {
+		// TABLESAMPLE selects an approximate percentage of table pages, not the exact number of rows
+		q += fmt.Sprintf(` ORDER BY random() LIMIT %s`, placeholder.Next())
+		args = append(args, params.Sample)
+	}
+
 	rows, err := p.Query(ctx, q, args...)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
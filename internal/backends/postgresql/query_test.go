@@ -296,6 +296,36 @@ func TestPrepareWhereClause(t *testing.T) {
 			expected: whereNotEq + `'"objectId"' )`,
 		},
 
+		"InString": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray("foo", int32(42))))),
+			)),
+			args:     []any{`v`, `"foo"`, `v`, int32(42)},
+			expected: " WHERE (_jsonb->$1 @> $2 OR _jsonb->$3 @> $4)",
+		},
+		"InIDObjectID": {
+			filter: must.NotFail(types.NewDocument(
+				"_id", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray(objectID)))),
+			)),
+			args:     []any{`_id`, `"6256c5ba0badc0ffeeffffff"`},
+			expected: " WHERE (_jsonb->$1 @> $2)",
+		},
+		"InEmpty": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", types.MakeArray(0))),
+			)),
+		},
+		"InNull": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", must.NotFail(types.NewArray("foo", types.Null)))),
+			)),
+		},
+		"InNotArray": {
+			filter: must.NotFail(types.NewDocument(
+				"v", must.NotFail(types.NewDocument("$in", "foo")),
+			)),
+		},
+
 		"Comment": {
 			filter: must.NotFail(types.NewDocument("$comment", "I'm comment")),
 		},
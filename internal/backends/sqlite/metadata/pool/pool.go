@@ -84,7 +84,7 @@ func New(u string, l *slog.Logger, sp *state.Provider) (*Pool, map[string]*fsql.
 
 	resource.Track(p, p.token)
 
-	if p.memory() {
+	if p.Memory() {
 		return p, p.dbs, nil
 	}
 
@@ -100,7 +100,7 @@ func New(u string, l *slog.Logger, sp *state.Provider) (*Pool, map[string]*fsql.
 
 		p.l.Debug("Opening existing database", slog.String("name", name), slog.String("uri", uri))
 
-		db, err := openDB(name, uri, p.memory(), l, p.sp)
+		db, err := openDB(name, uri, p.Memory(), l, p.sp)
 		if err != nil {
 			p.Close()
 			return nil, nil, lazyerrors.Error(err)
@@ -112,14 +112,16 @@ func New(u string, l *slog.Logger, sp *state.Provider) (*Pool, map[string]*fsql.
 	return p, p.dbs, nil
 }
 
-// memory returns true if the pool is for the in-memory database.
-func (p *Pool) memory() bool {
+// Memory returns true if the pool is for the in-memory database.
+//
+// Each in-memory database uses a single connection.
+func (p *Pool) Memory() bool {
 	return p.uri.Query().Get("mode") == "memory"
 }
 
 // databaseName returns database name for given database file path.
 func (p *Pool) databaseName(databaseFile string) string {
-	if p.memory() {
+	if p.Memory() {
 		panic("should not be called for in-memory database")
 	}
 
@@ -139,7 +141,7 @@ func (p *Pool) databaseURI(databaseName string) string {
 // databaseFile returns database file path for the given database name,
 // or empty string for in-memory database.
 func (p *Pool) databaseFile(databaseName string) string {
-	if p.memory() {
+	if p.Memory() {
 		return ""
 	}
 
@@ -197,7 +199,7 @@ func (p *Pool) GetOrCreate(ctx context.Context, name string) (*fsql.DB, bool, er
 	}
 
 	uri := p.databaseURI(name)
-	db, err := openDB(name, uri, p.memory(), p.l, p.sp)
+	db, err := openDB(name, uri, p.Memory(), p.l, p.sp)
 	if err != nil {
 		return nil, false, lazyerrors.Errorf("%s: %w", uri, err)
 	}
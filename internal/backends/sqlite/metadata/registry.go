@@ -125,6 +125,13 @@ func (r *Registry) initCollections(ctx context.Context, dbName string, db *fsql.
 	return nil
 }
 
+// Memory returns true if databases are in-memory.
+//
+// Each in-memory database uses a single connection.
+func (r *Registry) Memory() bool {
+	return r.p.Memory()
+}
+
 // DatabaseList returns a sorted list of existing databases.
 func (r *Registry) DatabaseList(ctx context.Context) []string {
 	return r.p.List(ctx)
@@ -19,6 +19,7 @@ import (
 	"strings"
 
 	"github.com/FerretDB/FerretDB/internal/backends/sqlite/metadata"
+	"github.com/FerretDB/FerretDB/internal/handler/sjson"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
@@ -46,6 +47,68 @@ func prepareSelectClause(table, comment string, capped, onlyRecordIDs bool) stri
 	return fmt.Sprintf(`SELECT %s %s FROM %q`, comment, metadata.DefaultColumn, table)
 }
 
+// prepareWhereClause returns WHERE clause and arguments for the given filter.
+//
+// Only filters by _id with a string or ObjectID value, or with $in of such values, are pushed down:
+//
+//	{_id: <value>}
+//	{_id: {$in: [<value>, ...]}}
+//
+// Empty string is returned for other filters.
+func prepareWhereClause(filter *types.Document) (string, []any) {
+	if filter.Len() != 1 {
+		return "", nil
+	}
+
+	v, _ := filter.Get("_id")
+
+	if idValuePushdown(v) {
+		return fmt.Sprintf(` WHERE %s = ?`, metadata.IDColumn), []any{marshalIDValue(v)}
+	}
+
+	doc, ok := v.(*types.Document)
+	if !ok || doc.Len() != 1 {
+		return "", nil
+	}
+
+	in, _ := doc.Get("$in")
+
+	arr, ok := in.(*types.Array)
+	if !ok || arr.Len() == 0 {
+		return "", nil
+	}
+
+	placeholders := make([]string, arr.Len())
+	args := make([]any, arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		v := must.NotFail(arr.Get(i))
+		if !idValuePushdown(v) {
+			return "", nil
+		}
+
+		placeholders[i] = "?"
+		args[i] = marshalIDValue(v)
+	}
+
+	return fmt.Sprintf(` WHERE %s IN (%s)`, metadata.IDColumn, strings.Join(placeholders, ", ")), args
+}
+
+// idValuePushdown returns true if the given _id value can be compared in SQLite.
+func idValuePushdown(v any) bool {
+	switch v.(type) {
+	case string, types.ObjectID:
+		return true
+	default:
+		return false
+	}
+}
+
+// marshalIDValue returns the argument for comparing the given _id value.
+func marshalIDValue(v any) string {
+	return string(must.NotFail(sjson.MarshalSingleValue(v)))
+}
+
 // prepareOrderByClause returns ORDER BY clause for given sort document.
 //
 // The provided sort document should be already validated.
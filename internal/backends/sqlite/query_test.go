@@ -119,3 +119,68 @@ func TestPrepareOrderByClause(t *testing.T) {
 		})
 	}
 }
+
+func TestPrepareWhereClause(t *testing.T) {
+	t.Parallel()
+
+	objectID := types.ObjectID{0x62, 0x56, 0xc5, 0xba, 0x0b, 0xad, 0xc0, 0xff, 0xee, 0xff, 0xff, 0xff}
+
+	for name, tc := range map[string]struct { //nolint:vet // used for test only
+		filter   *types.Document
+		expected string
+		args     []any
+	}{
+		"IDString": {
+			filter:   must.NotFail(types.NewDocument("_id", "foo")),
+			expected: ` WHERE _ferretdb_sjson->'$._id' = ?`,
+			args:     []any{`"foo"`},
+		},
+		"IDObjectID": {
+			filter:   must.NotFail(types.NewDocument("_id", objectID)),
+			expected: ` WHERE _ferretdb_sjson->'$._id' = ?`,
+			args:     []any{`"6256c5ba0badc0ffeeffffff"`},
+		},
+		"IDInt32": {
+			filter: must.NotFail(types.NewDocument("_id", int32(42))),
+		},
+		"IDIn": {
+			filter: must.NotFail(types.NewDocument("_id", must.NotFail(types.NewDocument(
+				"$in", must.NotFail(types.NewArray("foo", objectID)),
+			)))),
+			expected: ` WHERE _ferretdb_sjson->'$._id' IN (?, ?)`,
+			args:     []any{`"foo"`, `"6256c5ba0badc0ffeeffffff"`},
+		},
+		"IDInEmpty": {
+			filter: must.NotFail(types.NewDocument("_id", must.NotFail(types.NewDocument(
+				"$in", types.MakeArray(0),
+			)))),
+		},
+		"IDInInt32": {
+			filter: must.NotFail(types.NewDocument("_id", must.NotFail(types.NewDocument(
+				"$in", must.NotFail(types.NewArray("foo", int32(42))),
+			)))),
+		},
+		"IDInAndNin": {
+			filter: must.NotFail(types.NewDocument("_id", must.NotFail(types.NewDocument(
+				"$in", must.NotFail(types.NewArray("foo")),
+				"$nin", must.NotFail(types.NewArray("bar")),
+			)))),
+		},
+		"NotID": {
+			filter: must.NotFail(types.NewDocument("v", "foo")),
+		},
+		"IDAndOther": {
+			filter: must.NotFail(types.NewDocument("_id", "foo", "v", "bar")),
+		},
+		"Nil": {},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, args := prepareWhereClause(tc.filter)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.args, args)
+		})
+	}
+}
@@ -20,6 +20,13 @@ import (
 	"github.com/cristalhq/bson/bsonproto"
 )
 
+// Size returns a size of the encoding of value v in bytes.
+//
+// It panics for invalid types.
+func Size(v any) int {
+	return sizeAny(v)
+}
+
 // sizeAny returns a size of the encoding of value v in bytes.
 //
 // It panics for invalid types.
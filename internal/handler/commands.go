@@ -19,7 +19,9 @@ import (
 	"log/slog"
 
 	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/wire"
 )
 
@@ -290,6 +292,85 @@ func (h *Handler) initCommands() {
 				return cmdHandler(ctx, msg)
 			}
 		}
+
+		cmdHandler := h.commands[name].Handler
+
+		h.commands[name].Handler = func(ctx context.Context, msg *wire.OpMsg) (*wire.OpMsg, error) {
+			defer h.trackOperation(ctx, msg)()
+
+			return cmdHandler(ctx, msg)
+		}
+	}
+}
+
+// trackOperation registers the command as an in-flight operation
+// and records the usage of the logical session, if any.
+//
+// It returns a function that should be called when the command is done.
+func (h *Handler) trackOperation(ctx context.Context, msg *wire.OpMsg) func() {
+	document, err := msg.Document()
+	if err != nil {
+		return func() {}
+	}
+
+	v, _ := document.Get("$db")
+	dbName, _ := v.(string)
+
+	ns := dbName + ".$cmd"
+
+	v, _ = document.Get(document.Command())
+
+	switch cName, ok := v.(string); {
+	case ok && cName != "":
+		ns = dbName + "." + cName
+	case document.Command() == "aggregate":
+		// collection-agnostic pipeline ({aggregate: 1})
+		ns = dbName + ".$cmd.aggregate"
+	}
+
+	connInfo := conninfo.Get(ctx)
+	username := connInfo.Username()
+	_, _, _, userDB := connInfo.Auth()
+
+	v, _ = document.Get("lsid")
+	lsid, _ := v.(*types.Document)
+
+	if lsid != nil {
+		v, _ = lsid.Get("id")
+		if id, ok := v.(types.Binary); ok {
+			h.sessions.Use(id.B, username, userDB)
+		}
+	}
+
+	return h.operations.Start(&diagnostics.Operation{
+		Command:  document,
+		DB:       dbName,
+		NS:       ns,
+		Client:   connInfo.Peer,
+		Username: username,
+		UserDB:   userDB,
+		LSID:     lsid,
+	})
+}
+
+// allUsersAllowed returns true if the current user may see operations and sessions of other users.
+//
+// Roles are not implemented, so only the user created on setup and users of the admin database may see them.
+// With backend authentication, users are not known to the handler, so all of them may see them.
+func (h *Handler) allUsersAllowed(ctx context.Context) bool {
+	if !h.EnableNewAuth {
+		return true
+	}
+
+	username, _, _, db := conninfo.Get(ctx).Auth()
+
+	switch {
+	case username == "":
+		return true
+	case db == "admin":
+		return true
+	default:
+		return username == h.SetupUsername && db == h.SetupDatabase
 	}
 }
 
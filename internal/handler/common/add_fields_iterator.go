@@ -16,7 +16,9 @@ package common
 
 import (
 	"errors"
+	"fmt"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
@@ -31,10 +33,11 @@ import (
 // Next method returns the next document after adding the new field to the document.
 //
 // Close method closes the underlying iterator.
-func AddFieldsIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, newField *types.Document) types.DocumentsIterator { //nolint:lll // for readability
+func AddFieldsIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, newField *types.Document, vars aggregations.Variables) types.DocumentsIterator { //nolint:lll // for readability
 	res := &addFieldsIterator{
 		iter:     iter,
 		newField: newField,
+		vars:     vars,
 	}
 	closer.Add(res)
 
@@ -45,6 +48,7 @@ func AddFieldsIterator(iter types.DocumentsIterator, closer *iterator.MultiClose
 type addFieldsIterator struct {
 	iter     types.DocumentsIterator
 	newField *types.Document
+	vars     aggregations.Variables
 }
 
 // Next implements iterator.Interface. See addFieldsIterator for details.
@@ -70,10 +74,38 @@ func (iter *addFieldsIterator) Next() (struct{}, *types.Document, error) {
 				return unused, nil, err
 			}
 
-			val, err = op.Process(doc)
+			val, err = op.Process(doc, iter.vars)
 			if err = processAddFieldsError(err); err != nil {
 				return unused, nil, err
 			}
+
+		case string:
+			expression, err := aggregations.NewExpression(v, nil)
+
+			var exprErr *aggregations.ExpressionError
+			if errors.As(err, &exprErr) && exprErr.Code() == aggregations.ErrNotExpression {
+				break
+			}
+
+			if err = processAddFieldsError(err); err != nil {
+				return unused, nil, err
+			}
+
+			if val, err = expression.Evaluate(doc, iter.vars); err != nil {
+				if errors.As(err, &exprErr) {
+					return unused, nil, processAddFieldsError(err)
+				}
+
+				// fields evaluated to missing values, such as `$$REMOVE`, are removed
+				doc.Remove(key)
+
+				continue
+			}
+
+			// `$$ROOT` and `$$CURRENT` evaluate to the document itself
+			if d, ok := val.(*types.Document); ok && d == doc {
+				val = doc.DeepCopy()
+			}
 		}
 
 		doc.Set(key, val)
@@ -95,6 +127,15 @@ func processAddFieldsError(err error) error {
 	}
 
 	var opErr operators.OperatorError
+	var exErr *aggregations.ExpressionError
+
+	if errors.As(err, &exErr) && exErr.Code() == aggregations.ErrUndefinedVariable {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrUndefinedVariable,
+			fmt.Sprintf("Use of undefined variable: %s", exErr.Name()),
+			"$addFields (stage)",
+		)
+	}
 
 	if !errors.As(err, &opErr) {
 		return err
@@ -132,6 +173,12 @@ func processAddFieldsError(err error) error {
 			"Invalid $addFields :: caused by :: "+opErr.Error(),
 			"$addFields (stage)",
 		)
+	case operators.ErrInvalidArgument:
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			opErr.CommandCode(),
+			opErr.Error(),
+			"$addFields (stage)",
+		)
 	default:
 		return lazyerrors.Error(err)
 	}
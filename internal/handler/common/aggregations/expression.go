@@ -15,6 +15,7 @@
 package aggregations
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -79,10 +80,13 @@ func (e *ExpressionError) Name() string {
 //
 // Expression for access field in document should be prefixed with a dollar sign $ followed by field key.
 // For accessing embedded document or array, a dollar sign $ should be followed by dot notation.
+// Expression for access variable should be prefixed with double dollar sign $$ followed by variable name,
+// it may be followed by dot notation to access fields of the variable value.
 // Options can be provided to specify how to access fields in embedded array.
 type Expression struct {
-	opts commonpath.FindValuesOpts
-	path types.Path
+	opts     commonpath.FindValuesOpts
+	path     types.Path
+	variable string
 }
 
 // NewExpression returns Expression from dollar sign $ prefixed string.
@@ -112,8 +116,27 @@ func NewExpression(expression string, opts *commonpath.FindValuesOpts) (*Express
 			return nil, newExpressionError(ErrInvalidExpression, v)
 		}
 
-		// TODO https://github.com/FerretDB/FerretDB/issues/2275
-		return nil, newExpressionError(ErrUndefinedVariable, v)
+		name, rest, _ := strings.Cut(v, ".")
+
+		e := &Expression{
+			opts:     *opts,
+			variable: name,
+		}
+
+		if rest == "" {
+			return e, nil
+		}
+
+		// fields of the variable value are accessed by the path prefixed with variable name,
+		// see Evaluate
+		path, err := types.NewPathFromString(v)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		e.path = path
+
+		return e, nil
 	case strings.HasPrefix(expression, "$"):
 		// dollar sign $ prefixed string indicates Expression accesses field or embedded fields
 		val = strings.TrimPrefix(expression, "$")
@@ -144,7 +167,35 @@ func NewExpression(expression string, opts *commonpath.FindValuesOpts) (*Express
 //
 // It returns error if field value was not found. With embedded array field being exception,
 // that case it returns empty array instead of error.
-func (e *Expression) Evaluate(doc *types.Document) (any, error) {
+//
+// For variable Expression, the value is taken from vars, see variableValue.
+// It returns *ExpressionError with ErrUndefinedVariable code if variable is not defined.
+//
+// Field paths are evaluated on `$$CURRENT` document if it is set in vars,
+// otherwise on the given document.
+func (e *Expression) Evaluate(doc *types.Document, vars Variables) (any, error) {
+	if e.variable == "" {
+		if current, ok := vars["CURRENT"]; ok {
+			// non-document value has no fields
+			doc, _ = current.(*types.Document)
+		}
+	}
+
+	if e.variable != "" {
+		v, err := e.variableValue(doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if e.path.Len() == 0 {
+			return v, nil
+		}
+
+		// evaluate the path on a document containing only the variable value,
+		// so accessing fields of embedded arrays works the same way as for documents
+		doc = must.NotFail(types.NewDocument(e.variable, v))
+	}
+
 	path := e.path
 
 	if path.Len() == 1 {
@@ -193,7 +244,45 @@ func (e *Expression) Evaluate(doc *types.Document) (any, error) {
 	return arr, nil
 }
 
+// variableValue returns the value of the variable accessed by Expression.
+//
+// System variables `$$ROOT` and `$$CURRENT` are evaluated to the given document,
+// unless `$$CURRENT` is set in vars.
+// System variable `$$REMOVE` is evaluated to a missing value, so the error is returned.
+// Other variables, including `$$NOW`, are taken from vars.
+func (e *Expression) variableValue(doc *types.Document, vars Variables) (any, error) {
+	if v, ok := vars[e.variable]; ok {
+		return v, nil
+	}
+
+	switch e.variable {
+	case "ROOT", "CURRENT":
+		// document is nil during validation
+		if doc == nil {
+			return nil, fmt.Errorf("no document for $$%s", e.variable)
+		}
+
+		return doc, nil
+
+	case "REMOVE":
+		return nil, errors.New("$$REMOVE is evaluated to missing value")
+
+	default:
+		return nil, newExpressionError(ErrUndefinedVariable, e.variable)
+	}
+}
+
 // GetExpressionSuffix returns field key of Expression, or for dot notation it returns suffix.
+// For variable Expression without dot notation, it returns variable name.
 func (e *Expression) GetExpressionSuffix() string {
+	if e.path.Len() == 0 {
+		return e.variable
+	}
+
 	return e.path.Suffix()
 }
+
+// IsVariable returns true if Expression accesses a variable.
+func (e *Expression) IsVariable() bool {
+	return e.variable != ""
+}
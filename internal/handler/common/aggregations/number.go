@@ -17,6 +17,10 @@ package aggregations
 import (
 	"math"
 	"math/big"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
 )
 
 // SumNumbers accumulate numbers and returns the result of summation.
@@ -27,45 +31,132 @@ import (
 // This should only be used for aggregation, aggregation does not return
 // error on overflow.
 func SumNumbers(vs ...any) any {
+	var s Sum
+
+	for _, v := range vs {
+		s.Add(v)
+	}
+
+	return s.Result()
+}
+
+// Sum accumulates numbers one by one, its result is the same as the result of SumNumbers.
+// The zero value is an empty sum.
+type Sum struct {
 	// use big.Int to accumulate values larger than math.MaxInt64.
-	intSum := big.NewInt(0)
+	intSum *big.Int
 
 	// handle accumulation of doubles close to max precision.
 	// TODO https://github.com/FerretDB/FerretDB/issues/2300
-	var floatSum float64
+	floatSum float64
 
-	var hasFloat64, hasInt64 bool
+	hasFloat64 bool
+	hasInt64   bool
+}
 
-	for _, v := range vs {
-		switch v := v.(type) {
-		case float64:
-			hasFloat64 = true
-
-			floatSum = floatSum + v
-		case int32:
-			intSum.Add(intSum, big.NewInt(int64(v)))
-		case int64:
-			hasInt64 = true
-
-			intSum.Add(intSum, big.NewInt(v))
-		default:
-			// ignore non-number
+// Add adds the number to the sum, non-number values are ignored.
+func (s *Sum) Add(v any) {
+	switch v := v.(type) {
+	case float64:
+		s.hasFloat64 = true
+
+		s.floatSum = s.floatSum + v
+	case int32:
+		s.addInt(int64(v))
+	case int64:
+		s.hasInt64 = true
+
+		s.addInt(v)
+	default:
+		// ignore non-number
+	}
+}
+
+// addInt adds the integer to the integer part of the sum.
+func (s *Sum) addInt(v int64) {
+	if s.intSum == nil {
+		s.intSum = big.NewInt(0)
+	}
+
+	s.intSum.Add(s.intSum, big.NewInt(v))
+}
+
+// Merge adds the other sum to the sum.
+func (s *Sum) Merge(other *Sum) {
+	s.hasFloat64 = s.hasFloat64 || other.hasFloat64
+	s.hasInt64 = s.hasInt64 || other.hasInt64
+	s.floatSum += other.floatSum
+
+	if other.intSum != nil {
+		if s.intSum == nil {
+			s.intSum = big.NewInt(0)
 		}
+
+		s.intSum.Add(s.intSum, other.intSum)
 	}
+}
 
-	if hasFloat64 || !intSum.IsInt64() {
+// Result returns the result of summation, see SumNumbers.
+func (s *Sum) Result() any {
+	intSum := s.intSum
+	if intSum == nil {
+		intSum = big.NewInt(0)
+	}
+
+	if s.hasFloat64 || !intSum.IsInt64() {
 		// ignore accuracy because there is no rounding from int64.
 		intAsFloat, _ := new(big.Float).SetInt(intSum).Float64()
 
-		return intAsFloat + floatSum
+		return intAsFloat + s.floatSum
 	}
 
 	integer := intSum.Int64()
 
-	if !hasInt64 && integer <= math.MaxInt32 && integer >= math.MinInt32 {
+	if !s.hasInt64 && integer <= math.MaxInt32 && integer >= math.MinInt32 {
 		// convert to int32 if input has no int64 and can be represented in int32.
 		return int32(integer)
 	}
 
 	return integer
 }
+
+// Encode returns the state of the sum as a document, so it could be restored by DecodeSum.
+func (s *Sum) Encode() *types.Document {
+	intSum := "0"
+	if s.intSum != nil {
+		intSum = s.intSum.String()
+	}
+
+	return must.NotFail(types.NewDocument(
+		"i", intSum,
+		"f", s.floatSum,
+		"hf", s.hasFloat64,
+		"hi", s.hasInt64,
+	))
+}
+
+// DecodeSum restores the sum from the document returned by Sum.Encode.
+func DecodeSum(doc *types.Document) (*Sum, error) {
+	intSum, _ := doc.Get("i")
+	floatSum, _ := doc.Get("f")
+	hasFloat64, _ := doc.Get("hf")
+	hasInt64, _ := doc.Get("hi")
+
+	s := new(Sum)
+
+	var ok bool
+
+	str, _ := intSum.(string)
+	if s.intSum, ok = new(big.Int).SetString(str, 10); !ok {
+		return nil, lazyerrors.Errorf("invalid encoded sum %s", types.FormatAnyValue(doc))
+	}
+
+	if s.floatSum, ok = floatSum.(float64); !ok {
+		return nil, lazyerrors.Errorf("invalid encoded sum %s", types.FormatAnyValue(doc))
+	}
+
+	s.hasFloat64, _ = hasFloat64.(bool)
+	s.hasInt64, _ = hasInt64.(bool)
+
+	return s, nil
+}
@@ -25,9 +25,11 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 	"github.com/FerretDB/FerretDB/internal/util/must"
 )
 
@@ -37,9 +39,59 @@ type newAccumulatorFunc func(args ...any) (Accumulator, error)
 
 // Accumulator is a common interface for aggregation accumulation operators.
 type Accumulator interface {
-	// Accumulate documents and returns the result of applying operator.
-	// It should always close iterator.
-	Accumulate(iter types.DocumentsIterator) (any, error)
+	// NewState returns a new empty state of accumulation.
+	// Variables are used for evaluating expressions accessing variables.
+	NewState(vars aggregations.Variables) (State, error)
+}
+
+// State is a partial result of accumulation.
+//
+// Documents are added one by one, so the whole group of documents is never held in memory.
+// States of the same accumulator could be encoded, stored and merged later;
+// they are merged in the same order as their documents were added.
+type State interface {
+	// Add accumulates the document.
+	Add(doc *types.Document) error
+
+	// Merge accumulates the state encoded by Encode of the same accumulator.
+	Merge(encoded any) error
+
+	// Encode returns the state as a value that can be stored in a document.
+	Encode() any
+
+	// Size returns the approximate size of the state in bytes.
+	Size() int
+
+	// Result returns the result of accumulation.
+	Result() (any, error)
+}
+
+// Accumulate adds all documents to a new state of the accumulator and returns the result of accumulation.
+// It closes the iterator.
+func Accumulate(acc Accumulator, iter types.DocumentsIterator, vars aggregations.Variables) (any, error) {
+	defer iter.Close()
+
+	state, err := acc.NewState(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		_, doc, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if err = state.Add(doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return state.Result()
 }
 
 // NewAccumulator returns accumulator for provided value.
@@ -103,7 +155,25 @@ func NewAccumulator(stage, key string, value any) (Accumulator, error) {
 // Accumulators maps all aggregation accumulators.
 var Accumulators = map[string]newAccumulatorFunc{
 	// sorted alphabetically
-	"$count": newCount,
-	"$sum":   newSum,
+	"$addToSet":     newAddToSet,
+	"$avg":          newAvg,
+	"$bottom":       newBottom,
+	"$bottomN":      newBottomN,
+	"$count":        newCount,
+	"$first":        newFirst,
+	"$firstN":       newFirstN,
+	"$last":         newLast,
+	"$lastN":        newLastN,
+	"$max":          newMax,
+	"$maxN":         newMaxN,
+	"$mergeObjects": newMergeObjects,
+	"$min":          newMin,
+	"$minN":         newMinN,
+	"$push":         newPush,
+	"$stdDevPop":    newStdDevPop,
+	"$stdDevSamp":   newStdDevSamp,
+	"$sum":          newSum,
+	"$top":          newTop,
+	"$topN":         newTopN,
 	// please keep sorted alphabetically
 }
@@ -0,0 +1,159 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// avg represents $avg aggregation operator.
+type avg struct {
+	expr any
+}
+
+// newAvg creates a new $avg aggregation operator.
+func newAvg(args ...any) (Accumulator, error) {
+	expr, err := unaryArg("$avg", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &avg{
+		expr: expr,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (a *avg) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression("$avg", a.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &avgState{
+		expr: e,
+		vars: vars,
+	}, nil
+}
+
+// avgState represents the state of $avg accumulation.
+//
+// Non-numeric values are ignored, null is returned if there are no numeric values.
+type avgState struct {
+	expr  *expression
+	vars  aggregations.Variables
+	sum   aggregations.Sum
+	count int64
+}
+
+// Add implements State interface.
+func (a *avgState) Add(doc *types.Document) error {
+	v, _, err := a.expr.evaluate(doc, a.vars)
+	if err != nil {
+		return err
+	}
+
+	if isNumber(v) {
+		a.sum.Add(v)
+		a.count++
+	}
+
+	return nil
+}
+
+// Merge implements State interface.
+func (a *avgState) Merge(encoded any) error {
+	doc, ok := encoded.(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	sumDoc, _ := doc.Get("s")
+	count, _ := doc.Get("c")
+
+	d, ok := sumDoc.(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %s", types.FormatAnyValue(doc))
+	}
+
+	sum, err := aggregations.DecodeSum(d)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	c, ok := count.(int64)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %s", types.FormatAnyValue(doc))
+	}
+
+	a.sum.Merge(sum)
+	a.count += c
+
+	return nil
+}
+
+// Encode implements State interface.
+func (a *avgState) Encode() any {
+	return must.NotFail(types.NewDocument("s", a.sum.Encode(), "c", a.count))
+}
+
+// Size implements State interface.
+func (a *avgState) Size() int {
+	return sumSize + 8
+}
+
+// Result implements State interface.
+func (a *avgState) Result() (any, error) {
+	if a.count == 0 {
+		return types.Null, nil
+	}
+
+	return toFloat64(a.sum.Result()) / float64(a.count), nil
+}
+
+// isNumber returns true if the value is a number.
+func isNumber(v any) bool {
+	switch v.(type) {
+	case float64, int32, int64:
+		return true
+	default:
+		return false
+	}
+}
+
+// toFloat64 converts the number to float64.
+func toFloat64(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		panic(fmt.Sprintf("unexpected type %T", v))
+	}
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*avg)(nil)
+	_ State       = (*avgState)(nil)
+)
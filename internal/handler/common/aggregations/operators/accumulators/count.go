@@ -15,11 +15,10 @@
 package accumulators
 
 import (
-	"errors"
-
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
-	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 )
 
 // count represents $count operator.
@@ -48,27 +47,51 @@ func newCount(args ...any) (Accumulator, error) {
 	return new(count), nil
 }
 
-// Accumulate implements Accumulator interface.
-func (c *count) Accumulate(iter types.DocumentsIterator) (any, error) {
-	defer iter.Close()
-	var count int32
-
-	for {
-		_, _, err := iter.Next()
-		if err != nil {
-			if errors.Is(err, iterator.ErrIteratorDone) {
-				break
-			}
-
-			return nil, err
-		}
-		count++
+// NewState implements Accumulator interface.
+func (c *count) NewState(aggregations.Variables) (State, error) {
+	return new(countState), nil
+}
+
+// countState represents the state of $count accumulation.
+type countState struct {
+	count int32
+}
+
+// Add implements State interface.
+func (c *countState) Add(*types.Document) error {
+	c.count++
+	return nil
+}
+
+// Merge implements State interface.
+func (c *countState) Merge(encoded any) error {
+	count, ok := encoded.(int32)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
 	}
 
-	return count, nil
+	c.count += count
+
+	return nil
+}
+
+// Encode implements State interface.
+func (c *countState) Encode() any {
+	return c.count
+}
+
+// Size implements State interface.
+func (c *countState) Size() int {
+	return 4
+}
+
+// Result implements State interface.
+func (c *countState) Result() (any, error) {
+	return c.count, nil
 }
 
 // check interfaces
 var (
 	_ Accumulator = (*count)(nil)
+	_ State       = (*countState)(nil)
 )
@@ -0,0 +1,81 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// expression represents an expression used as an argument of accumulator.
+type expression struct {
+	op operators.Operator
+}
+
+// newExpression validates and creates a new expression of the accumulator with the given name.
+//
+// Expressions are validated when accumulation starts, because variables are not known before that.
+func newExpression(name string, expr any, vars aggregations.Variables) (*expression, error) {
+	exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("v", expr))))
+
+	op, err := operators.NewExpr(exprValue, name+" (accumulator)", vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expression{
+		op: op,
+	}, nil
+}
+
+// evaluate returns the value of expression for the given document.
+// It returns false if the expression evaluates to missing value.
+func (e *expression) evaluate(doc *types.Document, vars aggregations.Variables) (any, bool, error) {
+	res, err := e.op.Process(doc, vars)
+	if err != nil {
+		return nil, false, err
+	}
+
+	evaluated, ok := res.(*types.Document)
+	if !ok {
+		return nil, false, lazyerrors.Errorf("unexpected type %T", res)
+	}
+
+	v, err := evaluated.Get("v")
+	if err != nil {
+		return nil, false, nil
+	}
+
+	return v, true, nil
+}
+
+// unaryArg returns the argument of the unary accumulator with the given name.
+func unaryArg(name string, args []any) (any, error) {
+	if len(args) != 1 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageGroupUnaryOperator,
+			fmt.Sprintf("The %s accumulator is a unary operator", name),
+			name+" (accumulator)",
+		)
+	}
+
+	return args[0], nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// firstLast represents $first and $last aggregation operators.
+type firstLast struct {
+	name string
+	expr any
+	last bool
+}
+
+// newFirst creates a new $first aggregation operator.
+func newFirst(args ...any) (Accumulator, error) {
+	return newFirstLast("$first", false, args)
+}
+
+// newLast creates a new $last aggregation operator.
+func newLast(args ...any) (Accumulator, error) {
+	return newFirstLast("$last", true, args)
+}
+
+// newFirstLast creates a new $first or $last aggregation operator with the given name.
+func newFirstLast(name string, last bool, args []any) (Accumulator, error) {
+	expr, err := unaryArg(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &firstLast{
+		name: name,
+		expr: expr,
+		last: last,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (f *firstLast) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression(f.name, f.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &firstLastState{
+		expr: e,
+		vars: vars,
+		last: f.last,
+	}, nil
+}
+
+// firstLastState represents the state of $first or $last accumulation.
+//
+// Missing value is returned as null.
+type firstLastState struct {
+	expr  *expression
+	vars  aggregations.Variables
+	value any // nil if there were no documents
+	last  bool
+}
+
+// Add implements State interface.
+func (f *firstLastState) Add(doc *types.Document) error {
+	// the expression is evaluated for all documents to return the same errors
+	v, found, err := f.expr.evaluate(doc, f.vars)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		v = types.Null
+	}
+
+	f.set(v)
+
+	return nil
+}
+
+// set sets the value if it is the first value for $first, or for every value for $last.
+func (f *firstLastState) set(v any) {
+	if f.value == nil || f.last {
+		f.value = v
+	}
+}
+
+// Merge implements State interface.
+func (f *firstLastState) Merge(encoded any) error {
+	arr, ok := encoded.(*types.Array)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	if arr.Len() > 0 {
+		f.set(must.NotFail(arr.Get(0)))
+	}
+
+	return nil
+}
+
+// Encode implements State interface.
+//
+// The value is encoded as an array, which is empty if there were no documents.
+func (f *firstLastState) Encode() any {
+	if f.value == nil {
+		return types.MakeArray(0)
+	}
+
+	return must.NotFail(types.NewArray(f.value))
+}
+
+// Size implements State interface.
+func (f *firstLastState) Size() int {
+	return common.EstimateValueSize(f.value)
+}
+
+// Result implements State interface.
+func (f *firstLastState) Result() (any, error) {
+	if f.value == nil {
+		return types.Null, nil
+	}
+
+	return f.value, nil
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*firstLast)(nil)
+	_ State       = (*firstLastState)(nil)
+)
@@ -0,0 +1,135 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// mergeObjects represents $mergeObjects aggregation operator.
+type mergeObjects struct {
+	expr any
+}
+
+// newMergeObjects creates a new $mergeObjects aggregation operator.
+func newMergeObjects(args ...any) (Accumulator, error) {
+	expr, err := unaryArg("$mergeObjects", args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergeObjects{
+		expr: expr,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (m *mergeObjects) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression("$mergeObjects", m.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergeObjectsState{
+		expr: e,
+		vars: vars,
+		res:  new(types.Document),
+	}, nil
+}
+
+// mergeObjectsState represents the state of $mergeObjects accumulation.
+//
+// Null and missing values are ignored, fields of later documents overwrite fields of earlier ones.
+type mergeObjectsState struct {
+	expr *expression
+	vars aggregations.Variables
+	res  *types.Document
+}
+
+// Add implements State interface.
+func (m *mergeObjectsState) Add(doc *types.Document) error {
+	v, found, err := m.expr.evaluate(doc, m.vars)
+	if err != nil {
+		return err
+	}
+
+	if !found || v == types.Null {
+		return nil
+	}
+
+	d, ok := v.(*types.Document)
+	if !ok {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMergeObjectsNotDocument,
+			fmt.Sprintf(
+				"$mergeObjects requires object inputs, but input %s is of type %s",
+				types.FormatAnyValue(v), handlerparams.AliasFromType(v),
+			),
+			"$mergeObjects (accumulator)",
+		)
+	}
+
+	m.merge(d)
+
+	return nil
+}
+
+// merge sets all fields of the document.
+func (m *mergeObjectsState) merge(d *types.Document) {
+	for _, k := range d.Keys() {
+		m.res.Set(k, must.NotFail(d.Get(k)))
+	}
+}
+
+// Merge implements State interface.
+func (m *mergeObjectsState) Merge(encoded any) error {
+	d, ok := encoded.(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	m.merge(d)
+
+	return nil
+}
+
+// Encode implements State interface.
+func (m *mergeObjectsState) Encode() any {
+	return m.res
+}
+
+// Size implements State interface.
+func (m *mergeObjectsState) Size() int {
+	return common.EstimateDocumentSize(m.res)
+}
+
+// Result implements State interface.
+func (m *mergeObjectsState) Result() (any, error) {
+	return m.res, nil
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*mergeObjects)(nil)
+	_ State       = (*mergeObjectsState)(nil)
+)
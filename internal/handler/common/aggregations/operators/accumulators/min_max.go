@@ -0,0 +1,164 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// minMax represents $min and $max aggregation operators.
+type minMax struct {
+	name string
+	expr any
+	max  bool
+}
+
+// newMin creates a new $min aggregation operator.
+func newMin(args ...any) (Accumulator, error) {
+	return newMinMax("$min", false, args)
+}
+
+// newMax creates a new $max aggregation operator.
+func newMax(args ...any) (Accumulator, error) {
+	return newMinMax("$max", true, args)
+}
+
+// newMinMax creates a new $min or $max aggregation operator with the given name.
+func newMinMax(name string, isMax bool, args []any) (Accumulator, error) {
+	expr, err := unaryArg(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &minMax{
+		name: name,
+		expr: expr,
+		max:  isMax,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (m *minMax) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression(m.name, m.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &minMaxState{
+		expr: e,
+		vars: vars,
+		max:  m.max,
+	}, nil
+}
+
+// minMaxState represents the state of $min or $max accumulation.
+//
+// Null and missing values are ignored, null is returned if there are no other values.
+type minMaxState struct {
+	expr  *expression
+	vars  aggregations.Variables
+	value any // nil if there were no values
+	max   bool
+}
+
+// Add implements State interface.
+func (m *minMaxState) Add(doc *types.Document) error {
+	v, _, err := m.expr.evaluate(doc, m.vars)
+	if err != nil {
+		return err
+	}
+
+	m.add(v)
+
+	return nil
+}
+
+// add replaces the current value with v if v is less (or greater for $max).
+// Of equal values, the first one is kept.
+func (m *minMaxState) add(v any) {
+	if isNullOrMissing(v) {
+		return
+	}
+
+	if m.value == nil {
+		m.value = v
+		return
+	}
+
+	switch types.CompareForAggregation(v, m.value) {
+	case types.Less:
+		if !m.max {
+			m.value = v
+		}
+	case types.Greater:
+		if m.max {
+			m.value = v
+		}
+	}
+}
+
+// Merge implements State interface.
+func (m *minMaxState) Merge(encoded any) error {
+	arr, ok := encoded.(*types.Array)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	if arr.Len() > 0 {
+		m.add(must.NotFail(arr.Get(0)))
+	}
+
+	return nil
+}
+
+// Encode implements State interface.
+//
+// The value is encoded as an array, which is empty if there were no values.
+func (m *minMaxState) Encode() any {
+	if m.value == nil {
+		return types.MakeArray(0)
+	}
+
+	return must.NotFail(types.NewArray(m.value))
+}
+
+// Size implements State interface.
+func (m *minMaxState) Size() int {
+	return common.EstimateValueSize(m.value)
+}
+
+// Result implements State interface.
+func (m *minMaxState) Result() (any, error) {
+	if m.value == nil {
+		return types.Null, nil
+	}
+
+	return m.value, nil
+}
+
+// isNullOrMissing returns true if the value is null or missing (nil).
+func isNullOrMissing(v any) bool {
+	return v == nil || v == types.Null
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*minMax)(nil)
+	_ State       = (*minMaxState)(nil)
+)
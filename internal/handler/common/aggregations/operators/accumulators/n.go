@@ -0,0 +1,301 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// accumulatorN represents $firstN, $lastN, $minN and $maxN aggregation operators.
+//
+//	{ <operator>: { input: <expression>, n: <expression> } }
+type accumulatorN struct {
+	name  string
+	input any
+	n     any
+
+	// insert adds the evaluated input value to at most n values and returns them with the dropped value;
+	// missing values are nil, nil is returned if no value was dropped.
+	insert func(values []any, v any, n int) ([]any, any)
+}
+
+// newFirstN creates a new $firstN aggregation operator.
+func newFirstN(args ...any) (Accumulator, error) {
+	return newAccumulatorN("$firstN", args, func(values []any, v any, n int) ([]any, any) {
+		if len(values) == n {
+			return values, v
+		}
+
+		return append(values, nullIfMissing(v)), nil
+	})
+}
+
+// newLastN creates a new $lastN aggregation operator.
+func newLastN(args ...any) (Accumulator, error) {
+	return newAccumulatorN("$lastN", args, func(values []any, v any, n int) ([]any, any) {
+		values = append(values, nullIfMissing(v))
+		if len(values) <= n {
+			return values, nil
+		}
+
+		dropped := values[0]
+		values[0] = nil
+
+		return values[1:], dropped
+	})
+}
+
+// newMinN creates a new $minN aggregation operator.
+func newMinN(args ...any) (Accumulator, error) {
+	return newAccumulatorN("$minN", args, func(values []any, v any, n int) ([]any, any) {
+		return insertSorted(values, v, n, types.Less)
+	})
+}
+
+// newMaxN creates a new $maxN aggregation operator.
+func newMaxN(args ...any) (Accumulator, error) {
+	return newAccumulatorN("$maxN", args, func(values []any, v any, n int) ([]any, any) {
+		return insertSorted(values, v, n, types.Greater)
+	})
+}
+
+// newAccumulatorN creates a new n-accumulator with the given name and pick function.
+func newAccumulatorN(name string, args []any, insert func(values []any, v any, n int) ([]any, any)) (Accumulator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.MakeArray(0)
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNNotDocument,
+			fmt.Sprintf("specification must be an object; found %s", types.FormatAnyValue(v)),
+			name+" (accumulator)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "n":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrAccumulatorNUnknownArgument,
+				fmt.Sprintf("Unknown argument for 'n' operator: %s", k),
+				name+" (accumulator)",
+			)
+		}
+	}
+
+	if !spec.Has("n") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNMissingN,
+			"Missing value for 'n'",
+			name+" (accumulator)",
+		)
+	}
+
+	if !spec.Has("input") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNMissingInput,
+			"Missing value for 'input'",
+			name+" (accumulator)",
+		)
+	}
+
+	return &accumulatorN{
+		name:   name,
+		input:  must.NotFail(spec.Get("input")),
+		n:      must.NotFail(spec.Get("n")),
+		insert: insert,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (a *accumulatorN) NewState(vars aggregations.Variables) (State, error) {
+	n, err := evaluateN(a.name, a.n, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := newExpression(a.name, a.input, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accumulatorNState{
+		accumulator: a,
+		expr:        e,
+		vars:        vars,
+		n:           n,
+	}, nil
+}
+
+// accumulatorNState represents the state of $firstN, $lastN, $minN or $maxN accumulation.
+type accumulatorNState struct {
+	accumulator *accumulatorN
+	expr        *expression
+	vars        aggregations.Variables
+	values      []any
+	n           int
+	size        int
+}
+
+// Add implements State interface.
+func (a *accumulatorNState) Add(doc *types.Document) error {
+	v, _, err := a.expr.evaluate(doc, a.vars)
+	if err != nil {
+		return err
+	}
+
+	a.add(v)
+
+	return nil
+}
+
+// add inserts the value and updates the size of the state.
+func (a *accumulatorNState) add(v any) {
+	var dropped any
+	a.values, dropped = a.accumulator.insert(a.values, v, a.n)
+	a.size += common.EstimateValueSize(v) - common.EstimateValueSize(dropped)
+}
+
+// Merge implements State interface.
+func (a *accumulatorNState) Merge(encoded any) error {
+	arr, ok := encoded.(*types.Array)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		a.add(must.NotFail(arr.Get(i)))
+	}
+
+	return nil
+}
+
+// Encode implements State interface.
+func (a *accumulatorNState) Encode() any {
+	return must.NotFail(types.NewArray(a.values...))
+}
+
+// Size implements State interface.
+func (a *accumulatorNState) Size() int {
+	return a.size
+}
+
+// Result implements State interface.
+func (a *accumulatorNState) Result() (any, error) {
+	return must.NotFail(types.NewArray(a.values...)), nil
+}
+
+// evaluateN evaluates and validates n argument of the accumulator with the given name.
+//
+// Unlike MongoDB, n cannot refer to the group key.
+func evaluateN(name string, expr any, vars aggregations.Variables) (int, error) {
+	e, err := newExpression(name, expr, vars)
+	if err != nil {
+		return 0, err
+	}
+
+	v, found, err := e.evaluate(new(types.Document), vars)
+	if err != nil {
+		return 0, err
+	}
+
+	if !found {
+		v = types.Null
+	}
+
+	n, err := handlerparams.GetWholeNumberParam(v)
+
+	switch {
+	case errors.Is(err, handlerparams.ErrUnexpectedType):
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNNotNumeric,
+			fmt.Sprintf("Value for 'n' must be of integral type, but found %s", types.FormatAnyValue(v)),
+			name+" (accumulator)",
+		)
+	case err != nil:
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNNotIntegral,
+			fmt.Sprintf("Value for 'n' must be of integral type, but found %s", types.FormatAnyValue(v)),
+			name+" (accumulator)",
+		)
+	case n <= 0:
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrAccumulatorNNotPositive,
+			fmt.Sprintf("'n' must be greater than 0, found %d", n),
+			name+" (accumulator)",
+		)
+	}
+
+	return int(min(n, math.MaxInt)), nil
+}
+
+// insertSorted inserts the value that is not null or missing into values
+// sorted in ascending order for types.Less and in descending order for types.Greater,
+// and drops the last value if there are more than n values.
+// Equal values keep the order of insertion.
+func insertSorted(values []any, v any, n int, order types.CompareResult) ([]any, any) {
+	if isNullOrMissing(v) {
+		return values, v
+	}
+
+	i := sort.Search(len(values), func(i int) bool {
+		return types.CompareForAggregation(v, values[i]) == order
+	})
+
+	values = slices.Insert(values, i, v)
+	if len(values) <= n {
+		return values, nil
+	}
+
+	dropped := values[n]
+	values[n] = nil
+
+	return values[:n], dropped
+}
+
+// nullIfMissing returns null for missing (nil) value.
+func nullIfMissing(v any) any {
+	if v == nil {
+		return types.Null
+	}
+
+	return v
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*accumulatorN)(nil)
+	_ State       = (*accumulatorNState)(nil)
+)
@@ -0,0 +1,152 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// push represents $push and $addToSet aggregation operators.
+type push struct {
+	name   string
+	expr   any
+	unique bool
+}
+
+// newPush creates a new $push aggregation operator.
+func newPush(args ...any) (Accumulator, error) {
+	return newPushOp("$push", false, args)
+}
+
+// newAddToSet creates a new $addToSet aggregation operator.
+func newAddToSet(args ...any) (Accumulator, error) {
+	return newPushOp("$addToSet", true, args)
+}
+
+// newPushOp creates a new $push or $addToSet aggregation operator with the given name.
+func newPushOp(name string, unique bool, args []any) (Accumulator, error) {
+	expr, err := unaryArg(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &push{
+		name:   name,
+		expr:   expr,
+		unique: unique,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (p *push) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression(p.name, p.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pushState{
+		expr:   e,
+		vars:   vars,
+		res:    types.MakeArray(0),
+		unique: p.unique,
+	}, nil
+}
+
+// pushState represents the state of $push or $addToSet accumulation.
+//
+// Missing values are not added.
+// For $addToSet, values are returned in order of their first occurrence,
+// while the order is unspecified for MongoDB.
+type pushState struct {
+	expr   *expression
+	vars   aggregations.Variables
+	res    *types.Array
+	size   int
+	unique bool
+}
+
+// Add implements State interface.
+func (p *pushState) Add(doc *types.Document) error {
+	v, found, err := p.expr.evaluate(doc, p.vars)
+	if err != nil {
+		return err
+	}
+
+	if found {
+		p.add(v)
+	}
+
+	return nil
+}
+
+// add appends the value to the result.
+func (p *pushState) add(v any) {
+	if p.unique && arrayContains(p.res, v) {
+		return
+	}
+
+	p.res.Append(v)
+	p.size += common.EstimateValueSize(v)
+}
+
+// Merge implements State interface.
+func (p *pushState) Merge(encoded any) error {
+	arr, ok := encoded.(*types.Array)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		p.add(must.NotFail(arr.Get(i)))
+	}
+
+	return nil
+}
+
+// Encode implements State interface.
+func (p *pushState) Encode() any {
+	return p.res
+}
+
+// Size implements State interface.
+func (p *pushState) Size() int {
+	return p.size
+}
+
+// Result implements State interface.
+func (p *pushState) Result() (any, error) {
+	return p.res, nil
+}
+
+// arrayContains returns true if the array contains a value equal to v.
+func arrayContains(arr *types.Array, v any) bool {
+	for i := 0; i < arr.Len(); i++ {
+		if types.CompareForAggregation(v, must.NotFail(arr.Get(i))) == types.Equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*push)(nil)
+	_ State       = (*pushState)(nil)
+)
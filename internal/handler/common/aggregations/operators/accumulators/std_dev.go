@@ -0,0 +1,169 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"math"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// stdDev represents $stdDevPop and $stdDevSamp aggregation operators.
+type stdDev struct {
+	name   string
+	expr   any
+	sample bool
+}
+
+// newStdDevPop creates a new $stdDevPop aggregation operator.
+func newStdDevPop(args ...any) (Accumulator, error) {
+	return newStdDev("$stdDevPop", false, args)
+}
+
+// newStdDevSamp creates a new $stdDevSamp aggregation operator.
+func newStdDevSamp(args ...any) (Accumulator, error) {
+	return newStdDev("$stdDevSamp", true, args)
+}
+
+// newStdDev creates a new $stdDevPop or $stdDevSamp aggregation operator with the given name.
+func newStdDev(name string, sample bool, args []any) (Accumulator, error) {
+	expr, err := unaryArg(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stdDev{
+		name:   name,
+		expr:   expr,
+		sample: sample,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (s *stdDev) NewState(vars aggregations.Variables) (State, error) {
+	e, err := newExpression(s.name, s.expr, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stdDevState{
+		expr:   e,
+		vars:   vars,
+		sample: s.sample,
+	}, nil
+}
+
+// stdDevState represents the state of $stdDevPop or $stdDevSamp accumulation.
+//
+// Non-numeric values are ignored, null is returned if there are not enough numeric values.
+// Mean and variance are computed with Welford's online algorithm in the same way as MongoDB does;
+// partial states are combined with Chan's parallel algorithm.
+type stdDevState struct {
+	expr   *expression
+	vars   aggregations.Variables
+	count  int64
+	mean   float64
+	m2     float64
+	sample bool
+}
+
+// Add implements State interface.
+func (s *stdDevState) Add(doc *types.Document) error {
+	v, _, err := s.expr.evaluate(doc, s.vars)
+	if err != nil {
+		return err
+	}
+
+	if !isNumber(v) {
+		return nil
+	}
+
+	f := toFloat64(v)
+	s.count++
+
+	delta := f - s.mean
+	if delta != 0 {
+		s.mean += delta / float64(s.count)
+		s.m2 += delta * (f - s.mean)
+	}
+
+	return nil
+}
+
+// Merge implements State interface.
+func (s *stdDevState) Merge(encoded any) error {
+	doc, ok := encoded.(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	countV, _ := doc.Get("c")
+	meanV, _ := doc.Get("m")
+	m2V, _ := doc.Get("m2")
+
+	count, ok1 := countV.(int64)
+	mean, ok2 := meanV.(float64)
+	m2, ok3 := m2V.(float64)
+
+	if !ok1 || !ok2 || !ok3 {
+		return lazyerrors.Errorf("unexpected encoded state %s", types.FormatAnyValue(doc))
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	total := s.count + count
+	delta := mean - s.mean
+
+	s.mean += delta * float64(count) / float64(total)
+	s.m2 += m2 + delta*delta*float64(s.count)*float64(count)/float64(total)
+	s.count = total
+
+	return nil
+}
+
+// Encode implements State interface.
+func (s *stdDevState) Encode() any {
+	return must.NotFail(types.NewDocument("c", s.count, "m", s.mean, "m2", s.m2))
+}
+
+// Size implements State interface.
+func (s *stdDevState) Size() int {
+	return 24
+}
+
+// Result implements State interface.
+func (s *stdDevState) Result() (any, error) {
+	count := s.count
+	if s.sample {
+		count--
+	}
+
+	if count <= 0 {
+		return types.Null, nil
+	}
+
+	return math.Sqrt(s.m2 / float64(count)), nil
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*stdDev)(nil)
+	_ State       = (*stdDevState)(nil)
+)
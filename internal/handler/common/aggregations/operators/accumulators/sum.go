@@ -21,7 +21,6 @@ import (
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
-	"github.com/FerretDB/FerretDB/internal/util/iterator"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
 )
 
@@ -82,59 +81,96 @@ func newSum(args ...any) (Accumulator, error) {
 	return accumulator, nil
 }
 
-// Accumulate implements Accumulator interface.
-func (s *sum) Accumulate(iter types.DocumentsIterator) (any, error) {
-	var numbers []any
+// NewState implements Accumulator interface.
+func (s *sum) NewState(vars aggregations.Variables) (State, error) {
+	return &sumState{
+		sum:  s,
+		vars: vars,
+	}, nil
+}
 
-	for {
-		_, doc, err := iter.Next()
+// sumSize is the approximate size of the summation state: integer and float sums and flags.
+const sumSize = 32
 
-		if errors.Is(err, iterator.ErrIteratorDone) {
-			break
-		}
+// sumState represents the state of $sum accumulation.
+type sumState struct {
+	sum  *sum
+	vars aggregations.Variables
+	res  aggregations.Sum
+}
 
+// Add implements State interface.
+func (s *sumState) Add(doc *types.Document) error {
+	switch {
+	case s.sum.operator != nil:
+		v, err := s.sum.operator.Process(doc, s.vars)
 		if err != nil {
-			return nil, lazyerrors.Error(err)
+			return err
 		}
 
-		switch {
-		case s.operator != nil:
-			v, err := s.operator.Process(doc)
-			if err != nil {
-				return nil, err
-			}
-
-			numbers = append(numbers, v)
+		s.res.Add(v)
 
-			continue
+		return nil
 
-		case s.expression != nil:
-			value, err := s.expression.Evaluate(doc)
+	case s.sum.expression != nil:
+		value, err := s.sum.expression.Evaluate(doc, s.vars)
 
-			// sum fields that exist
-			if err == nil {
-				numbers = append(numbers, value)
-			}
-
-			continue
+		var exprErr *aggregations.ExpressionError
+		if errors.As(err, &exprErr) {
+			return err
 		}
 
-		switch number := s.number.(type) {
-		case float64, int32, int64:
-			// For number types, the result is equivalent of iterator len*number,
-			// with conversion handled upon overflow of int32 and int64.
-			// For example, { $sum: 1 } is equivalent of { $count: { } }.
-			numbers = append(numbers, number)
-		default:
-			// $sum returns 0 on non-existent and non-numeric field.
-			return int32(0), nil
+		// sum fields that exist
+		if err == nil {
+			s.res.Add(value)
 		}
+
+		return nil
+	}
+
+	// For number types, the result is equivalent of documents count*number,
+	// with conversion handled upon overflow of int32 and int64.
+	// For example, { $sum: 1 } is equivalent of { $count: { } }.
+	// $sum returns 0 on non-existent and non-numeric field, such values are ignored by Add.
+	s.res.Add(s.sum.number)
+
+	return nil
+}
+
+// Merge implements State interface.
+func (s *sumState) Merge(encoded any) error {
+	doc, ok := encoded.(*types.Document)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
 	}
 
-	return aggregations.SumNumbers(numbers...), nil
+	other, err := aggregations.DecodeSum(doc)
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	s.res.Merge(other)
+
+	return nil
+}
+
+// Encode implements State interface.
+func (s *sumState) Encode() any {
+	return s.res.Encode()
+}
+
+// Size implements State interface.
+func (s *sumState) Size() int {
+	return sumSize
+}
+
+// Result implements State interface.
+func (s *sumState) Result() (any, error) {
+	return s.res.Result(), nil
 }
 
 // check interfaces
 var (
 	_ Accumulator = (*sum)(nil)
+	_ State       = (*sumState)(nil)
 )
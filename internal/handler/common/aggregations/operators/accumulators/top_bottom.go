@@ -0,0 +1,299 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package accumulators
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// topBottom represents $top, $bottom, $topN and $bottomN aggregation operators.
+//
+//	{ <operator>: { output: <expression>, sortBy: { <field>: <sort order>, ... }, n: <expression> } }
+//
+// The n field is used only by $topN and $bottomN.
+type topBottom struct {
+	name   string
+	output any
+	sortBy *types.Document
+	n      any // nil for $top and $bottom
+	bottom bool
+}
+
+// newTop creates a new $top aggregation operator.
+func newTop(args ...any) (Accumulator, error) {
+	return newTopBottom("$top", false, false, args)
+}
+
+// newTopN creates a new $topN aggregation operator.
+func newTopN(args ...any) (Accumulator, error) {
+	return newTopBottom("$topN", false, true, args)
+}
+
+// newBottom creates a new $bottom aggregation operator.
+func newBottom(args ...any) (Accumulator, error) {
+	return newTopBottom("$bottom", true, false, args)
+}
+
+// newBottomN creates a new $bottomN aggregation operator.
+func newBottomN(args ...any) (Accumulator, error) {
+	return newTopBottom("$bottomN", true, true, args)
+}
+
+// newTopBottom creates a new $top, $bottom, $topN or $bottomN aggregation operator with the given name.
+func newTopBottom(name string, bottom, withN bool, args []any) (Accumulator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.MakeArray(0)
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTopBottomNotDocument,
+			fmt.Sprintf("specification must be an object; found %s", types.FormatAnyValue(v)),
+			name+" (accumulator)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "output", "sortBy":
+		case "n":
+			if withN {
+				break
+			}
+
+			fallthrough
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTopBottomUnknownArgument,
+				fmt.Sprintf("Unknown argument to %s '%s'", name, k),
+				name+" (accumulator)",
+			)
+		}
+	}
+
+	if withN && !spec.Has("n") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTopBottomMissingN,
+			fmt.Sprintf("Missing value for 'n' in %s", name),
+			name+" (accumulator)",
+		)
+	}
+
+	if !spec.Has("output") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTopBottomMissingOutput,
+			fmt.Sprintf("Missing value for 'output' in %s", name),
+			name+" (accumulator)",
+		)
+	}
+
+	if !spec.Has("sortBy") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTopBottomMissingSortBy,
+			fmt.Sprintf("Missing value for 'sortBy' in %s", name),
+			name+" (accumulator)",
+		)
+	}
+
+	sortBy, ok := must.NotFail(spec.Get("sortBy")).(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf("expected 'sortBy' to be an object in %s", name),
+			name+" (accumulator)",
+		)
+	}
+
+	if _, err := common.ValidateSortDocument(sortBy); err != nil {
+		return nil, err
+	}
+
+	n, _ := spec.Get("n")
+
+	return &topBottom{
+		name:   name,
+		output: must.NotFail(spec.Get("output")),
+		sortBy: sortBy,
+		n:      n,
+		bottom: bottom,
+	}, nil
+}
+
+// NewState implements Accumulator interface.
+func (t *topBottom) NewState(vars aggregations.Variables) (State, error) {
+	n := 1
+
+	if t.n != nil {
+		var err error
+		if n, err = evaluateN(t.name, t.n, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := newExpression(t.name, t.output, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &topBottomState{
+		accumulator: t,
+		output:      output,
+		vars:        vars,
+		n:           n,
+	}, nil
+}
+
+// topBottomState represents the state of $top, $bottom, $topN or $bottomN accumulation.
+//
+// Documents are sorted by sortBy, output of the first (or last for $bottom) documents is returned.
+// Missing output values are returned as null.
+type topBottomState struct {
+	accumulator *topBottom
+	output      *expression
+	vars        aggregations.Variables
+	docs        []*types.Document
+	n           int
+	size        int
+}
+
+// Add implements State interface.
+func (t *topBottomState) Add(doc *types.Document) error {
+	t.docs = append(t.docs, doc)
+	t.size += common.EstimateDocumentSize(doc)
+
+	// keep at most 2n documents, so sorting is amortized
+	if len(t.docs)-t.n < t.n {
+		return nil
+	}
+
+	return t.compact()
+}
+
+// compact sorts documents and keeps only n of them.
+func (t *topBottomState) compact() error {
+	if err := common.SortDocuments(t.docs, t.accumulator.sortBy); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if len(t.docs) <= t.n {
+		return nil
+	}
+
+	if t.accumulator.bottom {
+		t.docs = slices.Clone(t.docs[len(t.docs)-t.n:])
+	} else {
+		t.docs = slices.Clone(t.docs[:t.n])
+	}
+
+	t.size = 0
+	for _, doc := range t.docs {
+		t.size += common.EstimateDocumentSize(doc)
+	}
+
+	return nil
+}
+
+// Merge implements State interface.
+func (t *topBottomState) Merge(encoded any) error {
+	arr, ok := encoded.(*types.Array)
+	if !ok {
+		return lazyerrors.Errorf("unexpected encoded state %T", encoded)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		doc, ok := must.NotFail(arr.Get(i)).(*types.Document)
+		if !ok {
+			return lazyerrors.Errorf("unexpected encoded state %s", types.FormatAnyValue(arr))
+		}
+
+		if err := t.Add(doc); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Encode implements State interface.
+//
+// Documents are encoded as an array.
+func (t *topBottomState) Encode() any {
+	// sort path was validated by newTopBottom
+	must.NoError(t.compact())
+
+	res := types.MakeArray(len(t.docs))
+	for _, doc := range t.docs {
+		res.Append(doc)
+	}
+
+	return res
+}
+
+// Size implements State interface.
+func (t *topBottomState) Size() int {
+	return t.size
+}
+
+// Result implements State interface.
+func (t *topBottomState) Result() (any, error) {
+	if err := t.compact(); err != nil {
+		return nil, err
+	}
+
+	res := types.MakeArray(len(t.docs))
+
+	for _, doc := range t.docs {
+		v, found, err := t.output.evaluate(doc, t.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			v = types.Null
+		}
+
+		res.Append(v)
+	}
+
+	if t.accumulator.n != nil {
+		return res, nil
+	}
+
+	if res.Len() == 0 {
+		return types.Null, nil
+	}
+
+	return must.NotFail(res.Get(0)), nil
+}
+
+// check interfaces
+var (
+	_ Accumulator = (*topBottom)(nil)
+	_ State       = (*topBottomState)(nil)
+)
@@ -0,0 +1,446 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// add represents `$add` operator.
+//
+//	{ $add: [ <expression1>, <expression2>, ... ] }
+//
+// Arguments are numbers and at most one date,
+// numbers are added to the date as milliseconds.
+type add struct {
+	args []any
+}
+
+// newAdd returns `$add` operator.
+func newAdd(args ...any) (Operator, error) {
+	return &add{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (a *add) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	numbers := make([]any, 0, len(a.args))
+
+	var date *time.Time
+
+	for _, arg := range a.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case types.NullType:
+			return types.Null, nil
+		case int32, int64, float64:
+			numbers = append(numbers, v)
+		case time.Time:
+			if date != nil {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrAddMultipleDates,
+					"$add",
+					"only one date allowed in an $add expression",
+				)
+			}
+
+			date = &v
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrTypeMismatch,
+				"$add",
+				fmt.Sprintf("$add only supports numeric or date types, not %s", handlerparams.AliasFromType(v)),
+			)
+		}
+	}
+
+	sum := aggregations.SumNumbers(numbers...)
+
+	if date != nil {
+		return addMilliseconds(*date, sum), nil
+	}
+
+	return sum, nil
+}
+
+// subtract represents `$subtract` operator.
+//
+//	{ $subtract: [ <expression1>, <expression2> ] }
+//
+// The second number or date is subtracted from the first number or date,
+// the number is subtracted from the date as milliseconds.
+type subtract struct {
+	args [2]any
+}
+
+// newSubtract returns `$subtract` operator.
+func newSubtract(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$subtract",
+			fmt.Sprintf("Expression $subtract takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &subtract{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *subtract) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	a, b, err := processTwoArgs(s.args, doc, vars)
+	if err != nil || a == types.Null || b == types.Null {
+		return a, err
+	}
+
+	date, isDate := a.(time.Time)
+
+	switch {
+	case isNumber(a) && isNumber(b):
+		return subtractNumbers(a, b), nil
+	case isDate && isNumber(b):
+		return addMilliseconds(date, negateNumber(b)), nil
+	case isDate:
+		if bDate, ok := b.(time.Time); ok {
+			return date.Sub(bDate).Milliseconds(), nil
+		}
+	}
+
+	return nil, newOperatorArgumentError(
+		handlererrors.ErrSubtractInvalidType,
+		"$subtract",
+		fmt.Sprintf(
+			"cant $subtract a %s from a %s",
+			handlerparams.AliasFromType(b), handlerparams.AliasFromType(a),
+		),
+	)
+}
+
+// multiply represents `$multiply` operator.
+//
+//	{ $multiply: [ <expression1>, <expression2>, ... ] }
+type multiply struct {
+	args []any
+}
+
+// newMultiply returns `$multiply` operator.
+func newMultiply(args ...any) (Operator, error) {
+	return &multiply{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (m *multiply) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	numbers := make([]any, 0, len(m.args))
+
+	for _, arg := range m.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case types.NullType:
+			return types.Null, nil
+		case int32, int64, float64:
+			numbers = append(numbers, v)
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrMultiplyInvalidType,
+				"$multiply",
+				fmt.Sprintf("$multiply only supports numeric types, not %s", handlerparams.AliasFromType(v)),
+			)
+		}
+	}
+
+	return multiplyNumbers(numbers...), nil
+}
+
+// divide represents `$divide` operator.
+//
+//	{ $divide: [ <expression1>, <expression2> ] }
+//
+// The result is always a double.
+type divide struct {
+	args [2]any
+}
+
+// newDivide returns `$divide` operator.
+func newDivide(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$divide",
+			fmt.Sprintf("Expression $divide takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &divide{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (d *divide) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	a, b, err := processTwoArgs(d.args, doc, vars)
+	if err != nil || a == types.Null || b == types.Null {
+		return a, err
+	}
+
+	if !isNumber(a) || !isNumber(b) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDivideInvalidType,
+			"$divide",
+			fmt.Sprintf(
+				"$divide only supports numeric types, not %s and %s",
+				handlerparams.AliasFromType(a), handlerparams.AliasFromType(b),
+			),
+		)
+	}
+
+	if toFloat64(b) == 0 {
+		return nil, newOperatorArgumentError(handlererrors.ErrDivideByZero, "$divide", "can't $divide by zero")
+	}
+
+	return toFloat64(a) / toFloat64(b), nil
+}
+
+// mod represents `$mod` operator.
+//
+//	{ $mod: [ <expression1>, <expression2> ] }
+type mod struct {
+	args [2]any
+}
+
+// newMod returns `$mod` operator.
+func newMod(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$mod",
+			fmt.Sprintf("Expression $mod takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &mod{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (m *mod) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	a, b, err := processTwoArgs(m.args, doc, vars)
+	if err != nil || a == types.Null || b == types.Null {
+		return a, err
+	}
+
+	if !isNumber(a) || !isNumber(b) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrModInvalidType,
+			"$mod",
+			fmt.Sprintf(
+				"$mod only supports numeric types, not %s and %s",
+				handlerparams.AliasFromType(a), handlerparams.AliasFromType(b),
+			),
+		)
+	}
+
+	if toFloat64(b) == 0 {
+		return nil, newOperatorArgumentError(handlererrors.ErrModByZero, "$mod", "can't $mod by zero")
+	}
+
+	switch {
+	case isFloat64(a) || isFloat64(b):
+		return math.Mod(toFloat64(a), toFloat64(b)), nil
+	case isInt64(a) || isInt64(b):
+		return toInt64(a) % toInt64(b), nil
+	default:
+		return a.(int32) % b.(int32), nil
+	}
+}
+
+// processTwoArgs evaluates both arguments of the binary operator.
+//
+// If any of them is null or missing, the first returned value is null.
+func processTwoArgs(args [2]any, doc *types.Document, vars aggregations.Variables) (any, any, error) {
+	a, err := processExpr(args[0], doc, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := processExpr(args[1], doc, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if a == types.Null || b == types.Null {
+		return types.Null, types.Null, nil
+	}
+
+	return a, b, nil
+}
+
+// subtractNumbers returns a - b.
+//
+// Integer results that do not fit the type of arguments are promoted from int32 to int64,
+// and from int64 to double.
+func subtractNumbers(a, b any) any {
+	if isFloat64(a) || isFloat64(b) {
+		return toFloat64(a) - toFloat64(b)
+	}
+
+	res := new(big.Int).Sub(big.NewInt(toInt64(a)), big.NewInt(toInt64(b)))
+
+	return intResult(res, isInt64(a) || isInt64(b))
+}
+
+// multiplyNumbers returns the product of the given numbers, or int32(1) if there are none.
+//
+// Integer results that do not fit the type of arguments are promoted from int32 to int64,
+// and from int64 to double.
+func multiplyNumbers(numbers ...any) any {
+	var hasFloat64, hasInt64 bool
+
+	for _, n := range numbers {
+		hasFloat64 = hasFloat64 || isFloat64(n)
+		hasInt64 = hasInt64 || isInt64(n)
+	}
+
+	if hasFloat64 {
+		res := float64(1)
+		for _, n := range numbers {
+			res *= toFloat64(n)
+		}
+
+		return res
+	}
+
+	res := big.NewInt(1)
+	for _, n := range numbers {
+		res.Mul(res, big.NewInt(toInt64(n)))
+	}
+
+	return intResult(res, hasInt64)
+}
+
+// negateNumber returns -n.
+func negateNumber(n any) any {
+	return subtractNumbers(int32(0), n)
+}
+
+// addMilliseconds returns the date with the given number of milliseconds added,
+// fractional milliseconds are rounded.
+func addMilliseconds(date time.Time, ms any) time.Time {
+	var n int64
+
+	switch ms := ms.(type) {
+	case float64:
+		n = int64(math.Round(ms))
+	default:
+		n = toInt64(ms)
+	}
+
+	return date.Add(time.Duration(n) * time.Millisecond)
+}
+
+// intResult returns n as int32 if it fits and hasInt64 is false, as int64 if it fits,
+// and as float64 otherwise.
+func intResult(n *big.Int, hasInt64 bool) any {
+	if !n.IsInt64() {
+		f, _ := new(big.Float).SetInt(n).Float64()
+		return f
+	}
+
+	i := n.Int64()
+
+	if !hasInt64 && i >= math.MinInt32 && i <= math.MaxInt32 {
+		return int32(i)
+	}
+
+	return i
+}
+
+// isNumber returns true if v is int32, int64 or float64.
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int32, int64, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isFloat64 returns true if v is float64.
+func isFloat64(v any) bool {
+	_, ok := v.(float64)
+	return ok
+}
+
+// isInt64 returns true if v is int64.
+func isInt64(v any) bool {
+	_, ok := v.(int64)
+	return ok
+}
+
+// toFloat64 converts the number to float64.
+func toFloat64(v any) float64 {
+	switch v := v.(type) {
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		panic(fmt.Sprintf("unexpected type %T", v))
+	}
+}
+
+// toInt64 converts the integer number to int64.
+func toInt64(v any) int64 {
+	switch v := v.(type) {
+	case int32:
+		return int64(v)
+	case int64:
+		return v
+	default:
+		panic(fmt.Sprintf("unexpected type %T", v))
+	}
+}
+
+// check interfaces
+var (
+	_ Operator = (*add)(nil)
+	_ Operator = (*subtract)(nil)
+	_ Operator = (*multiply)(nil)
+	_ Operator = (*divide)(nil)
+	_ Operator = (*mod)(nil)
+)
@@ -0,0 +1,639 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// arrayOp represents single argument array operators such as `$size` and `$reverseArray`.
+//
+//	{ <operator>: <expression> }
+type arrayOp struct {
+	arg any
+	f   func(v any) (any, error)
+}
+
+// newArrayFunc returns a function creating the single argument array operator with the given name,
+// f computes the result for the evaluated argument, that is nil for a missing field.
+func newArrayFunc(name string, f func(v any) (any, error)) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 1 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 1 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &arrayOp{
+			arg: args[0],
+			f:   f,
+		}, nil
+	}
+}
+
+// Process implements Operator interface.
+func (a *arrayOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExprOrMissing(a.arg, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil && (v == nil || v == types.Null) {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	return a.f(v)
+}
+
+// newIsArray returns `$isArray` operator.
+var newIsArray = newArrayFunc("$isArray", func(v any) (any, error) {
+	_, ok := v.(*types.Array)
+	return ok, nil
+})
+
+// newSize returns `$size` operator.
+var newSize = newArrayFunc("$size", func(v any) (any, error) {
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSizeNotArray,
+			"$size",
+			fmt.Sprintf("The argument to $size must be an array, but was of type: %s", aliasFromType(v)),
+		)
+	}
+
+	return int32(arr.Len()), nil
+})
+
+// newReverseArray returns `$reverseArray` operator.
+var newReverseArray = newArrayFunc("$reverseArray", func(v any) (any, error) {
+	if v == nil || v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrReverseArrayNotArray,
+			"$reverseArray",
+			fmt.Sprintf(
+				"The argument to $reverseArray must be an array, but was of type: %s",
+				handlerparams.AliasFromType(v),
+			),
+		)
+	}
+
+	res := types.MakeArray(arr.Len())
+	for i := arr.Len() - 1; i >= 0; i-- {
+		res.Append(must.NotFail(arr.Get(i)))
+	}
+
+	return res, nil
+})
+
+// newFirst returns `$first` array operator.
+var newFirst = newArrayFunc("$first", func(v any) (any, error) {
+	return arrayElemAtIndex("$first", v, 0)
+})
+
+// newLast returns `$last` array operator.
+var newLast = newArrayFunc("$last", func(v any) (any, error) {
+	return arrayElemAtIndex("$last", v, -1)
+})
+
+// arrayElemAt represents `$arrayElemAt` operator.
+//
+//	{ $arrayElemAt: [ <array>, <idx> ] }
+type arrayElemAt struct {
+	args [2]any
+}
+
+// newArrayElemAt returns `$arrayElemAt` operator.
+func newArrayElemAt(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$arrayElemAt",
+			fmt.Sprintf("Expression $arrayElemAt takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &arrayElemAt{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (a *arrayElemAt) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, idx, err := processTwoArgs(a.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == types.Null || doc == nil {
+		return types.Null, nil
+	}
+
+	if _, ok := v.(*types.Array); !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayElemAtArrayNotArray,
+			"$arrayElemAt",
+			fmt.Sprintf("$arrayElemAt's first argument must be an array, but is %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	if !isNumber(idx) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayElemAtIndexNotNumber,
+			"$arrayElemAt",
+			fmt.Sprintf(
+				"$arrayElemAt's second argument must be a numeric value, but is %s",
+				handlerparams.AliasFromType(idx),
+			),
+		)
+	}
+
+	i, ok := toInt32(idx)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayElemAtIndexNotInt32,
+			"$arrayElemAt",
+			fmt.Sprintf(
+				"$arrayElemAt's second argument must be representable as a 32-bit integer: %s",
+				types.FormatAnyValue(idx),
+			),
+		)
+	}
+
+	return arrayElemAtIndex("$arrayElemAt", v, int(i))
+}
+
+// arrayElemAtIndex returns the element of the array at the given index for the operator with the given name,
+// negative index counts from the end of the array.
+//
+// MongoDB returns missing value for the index out of bounds, null is returned instead.
+func arrayElemAtIndex(name string, v any, i int) (any, error) {
+	if v == nil || v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayElemAtArrayNotArray,
+			name,
+			fmt.Sprintf("%s's argument must be an array, but is %s", name, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	if i < 0 {
+		i += arr.Len()
+	}
+
+	if i < 0 || i >= arr.Len() {
+		return types.Null, nil
+	}
+
+	return must.NotFail(arr.Get(i)), nil
+}
+
+// concatArrays represents `$concatArrays` operator.
+//
+//	{ $concatArrays: [ <array1>, <array2>, ... ] }
+type concatArrays struct {
+	args []any
+}
+
+// newConcatArrays returns `$concatArrays` operator.
+func newConcatArrays(args ...any) (Operator, error) {
+	return &concatArrays{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (c *concatArrays) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	res := types.MakeArray(0)
+
+	for _, arg := range c.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null || doc == nil {
+			return types.Null, nil
+		}
+
+		arr, ok := v.(*types.Array)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrConcatArraysNotArray,
+				"$concatArrays",
+				fmt.Sprintf("$concatArrays only supports arrays, not %s", handlerparams.AliasFromType(v)),
+			)
+		}
+
+		for i := 0; i < arr.Len(); i++ {
+			res.Append(must.NotFail(arr.Get(i)))
+		}
+	}
+
+	return res, nil
+}
+
+// in represents `$in` aggregation operator.
+//
+//	{ $in: [ <expression>, <array expression> ] }
+type in struct {
+	args [2]any
+}
+
+// newIn returns `$in` operator.
+func newIn(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$in",
+			fmt.Sprintf("Expression $in takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &in{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (i *in) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(i.args[0], doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := processExprOrMissing(i.args[1], doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return false, nil
+	}
+
+	arr, ok := a.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrInArrayNotArray,
+			"$in",
+			fmt.Sprintf("$in requires an array as a second argument, found: %s", aliasFromType(a)),
+		)
+	}
+
+	for n := 0; n < arr.Len(); n++ {
+		if compare(v, must.NotFail(arr.Get(n))) == types.Equal {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// indexOfArray represents `$indexOfArray` operator.
+//
+//	{ $indexOfArray: [ <array expression>, <search expression>, <start>, <end> ] }
+type indexOfArray struct {
+	args []any
+}
+
+// newIndexOfArray returns `$indexOfArray` operator.
+func newIndexOfArray(args ...any) (Operator, error) {
+	if len(args) < 2 || len(args) > 4 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$indexOfArray",
+			fmt.Sprintf("Expression $indexOfArray takes at least 2 arguments, and at most 4, but %d were passed in.", len(args)),
+		)
+	}
+
+	return &indexOfArray{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (i *indexOfArray) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	values := make([]any, len(i.args))
+
+	for n, arg := range i.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		values[n] = v
+	}
+
+	if values[0] == types.Null || doc == nil {
+		return types.Null, nil
+	}
+
+	arr, ok := values[0].(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrIndexOfArrayNotArray,
+			"$indexOfArray",
+			fmt.Sprintf(
+				"$indexOfArray requires an array as a first argument, found: %s",
+				handlerparams.AliasFromType(values[0]),
+			),
+		)
+	}
+
+	start, end := 0, arr.Len()
+
+	if len(values) > 2 {
+		v, err := indexArgument("$indexOfArray", values[2], "starting index")
+		if err != nil {
+			return nil, err
+		}
+
+		start = v
+	}
+
+	if len(values) > 3 {
+		v, err := indexArgument("$indexOfArray", values[3], "ending index")
+		if err != nil {
+			return nil, err
+		}
+
+		end = min(v, arr.Len())
+	}
+
+	for n := start; n < end; n++ {
+		if compare(values[1], must.NotFail(arr.Get(n))) == types.Equal {
+			return int32(n), nil
+		}
+	}
+
+	return int32(-1), nil
+}
+
+// rangeOp represents `$range` operator.
+//
+//	{ $range: [ <start>, <end>, <non-zero step> ] }
+type rangeOp struct {
+	args []any
+}
+
+// newRange returns `$range` operator.
+func newRange(args ...any) (Operator, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$range",
+			fmt.Sprintf("Expression $range takes at least 2 arguments, and at most 3, but %d were passed in.", len(args)),
+		)
+	}
+
+	return &rangeOp{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (r *rangeOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	values := []int32{0, 0, 1}
+
+	params := []struct {
+		name          string
+		notNumberCode handlererrors.ErrorCode
+		notInt32Code  handlererrors.ErrorCode
+	}{
+		{"starting value", handlererrors.ErrRangeStartNotNumber, handlererrors.ErrRangeStartNotInt32},
+		{"ending value", handlererrors.ErrRangeEndNotNumber, handlererrors.ErrRangeEndNotInt32},
+		{"step value", handlererrors.ErrRangeStepNotNumber, handlererrors.ErrRangeStepNotInt32},
+	}
+
+	for n, arg := range r.args {
+		v, err := processExprOrMissing(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc == nil {
+			// field paths are evaluated to null during validation, see expr.validateExpr
+			continue
+		}
+
+		p := params[n]
+
+		if !isNumber(v) {
+			return nil, newOperatorArgumentError(
+				p.notNumberCode,
+				"$range",
+				fmt.Sprintf("$range requires a numeric %s, found value of type: %s", p.name, aliasFromType(v)),
+			)
+		}
+
+		i, ok := toInt32(v)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				p.notInt32Code,
+				"$range",
+				fmt.Sprintf(
+					"$range requires a %s that can be represented as a 32-bit integer, found value: %s",
+					p.name, types.FormatAnyValue(v),
+				),
+			)
+		}
+
+		values[n] = i
+	}
+
+	if doc == nil {
+		return types.Null, nil
+	}
+
+	start, end, step := int64(values[0]), int64(values[1]), int64(values[2])
+
+	if step == 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRangeStepZero,
+			"$range",
+			"$range requires a non-zero step value",
+		)
+	}
+
+	res := types.MakeArray(0)
+
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		res.Append(int32(i))
+	}
+
+	return res, nil
+}
+
+// slice represents `$slice` aggregation operator.
+//
+//	{ $slice: [ <array>, <n> ] }
+//	{ $slice: [ <array>, <position>, <n> ] }
+type slice struct {
+	args []any
+}
+
+// newSlice returns `$slice` operator.
+func newSlice(args ...any) (Operator, error) {
+	if len(args) < 2 || len(args) > 3 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$slice",
+			fmt.Sprintf("Expression $slice takes at least 2 arguments, and at most 3, but %d were passed in.", len(args)),
+		)
+	}
+
+	return &slice{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *slice) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	values := make([]any, len(s.args))
+	var hasNull bool
+
+	for n, arg := range s.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		hasNull = hasNull || v == types.Null
+		values[n] = v
+	}
+
+	if hasNull || doc == nil {
+		return types.Null, nil
+	}
+
+	arr, ok := values[0].(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSliceFirstArg,
+			"$slice",
+			fmt.Sprintf("First argument to $slice must be an array, but is of type: %s", handlerparams.AliasFromType(values[0])),
+		)
+	}
+
+	if !isNumber(values[1]) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSlicePositionNotNumber,
+			"$slice",
+			fmt.Sprintf(
+				"Second argument to $slice must be a numeric value, but is of type: %s",
+				handlerparams.AliasFromType(values[1]),
+			),
+		)
+	}
+
+	second, ok := toInt32(values[1])
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSlicePositionNotInt32,
+			"$slice",
+			fmt.Sprintf(
+				"Second argument to $slice can't be represented as a 32-bit integer: %s",
+				types.FormatAnyValue(values[1]),
+			),
+		)
+	}
+
+	length := arr.Len()
+	var start, end int
+
+	if len(values) == 2 {
+		if second >= 0 {
+			start, end = 0, min(int(second), length)
+		} else {
+			start, end = max(length+int(second), 0), length
+		}
+	} else {
+		if !isNumber(values[2]) {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSliceLengthNotNumber,
+				"$slice",
+				fmt.Sprintf(
+					"Third argument to $slice must be numeric, but is of type: %s",
+					handlerparams.AliasFromType(values[2]),
+				),
+			)
+		}
+
+		n, ok := toInt32(values[2])
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSliceLengthNotInt32,
+				"$slice",
+				fmt.Sprintf(
+					"Third argument to $slice can't be represented as a 32-bit integer: %s",
+					types.FormatAnyValue(values[2]),
+				),
+			)
+		}
+
+		if n <= 0 {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSliceLengthNotPositive,
+				"$slice",
+				fmt.Sprintf("Third argument to $slice must be positive: %s", types.FormatAnyValue(values[2])),
+			)
+		}
+
+		start = int(second)
+		if start < 0 {
+			start = max(length+start, 0)
+		}
+
+		start = min(start, length)
+		end = min(start+int(n), length)
+	}
+
+	res := types.MakeArray(end - start)
+	for i := start; i < end; i++ {
+		res.Append(must.NotFail(arr.Get(i)))
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*arrayOp)(nil)
+	_ Operator = (*arrayElemAt)(nil)
+	_ Operator = (*concatArrays)(nil)
+	_ Operator = (*in)(nil)
+	_ Operator = (*indexOfArray)(nil)
+	_ Operator = (*rangeOp)(nil)
+	_ Operator = (*slice)(nil)
+)
@@ -0,0 +1,435 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// mapOp represents `$map` operator.
+//
+//	{ $map: { input: <expression>, as: <string>, in: <expression> } }
+//
+// Each element of the input array is accessible in `in` expression
+// by the variable named by `as`, `$$this` by default.
+type mapOp struct {
+	input any
+	as    string
+	in    any
+}
+
+// newMap returns `$map` operator.
+func newMap(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrMapInvalidSpec,
+			"$map",
+			"$map only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "as", "in":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrMapUnknownParameter,
+				"$map",
+				fmt.Sprintf("Unrecognized parameter to $map: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("input") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrMapMissingInput,
+			"$map",
+			"Missing 'input' parameter to $map",
+		)
+	}
+
+	if !spec.Has("in") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrMapMissingIn,
+			"$map",
+			"Missing 'in' parameter to $map",
+		)
+	}
+
+	as, err := variableName("$map", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mapOp{
+		input: must.NotFail(spec.Get("input")),
+		as:    as,
+		in:    must.NotFail(spec.Get("in")),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (m *mapOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(m.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := vars.Clone()
+
+	if doc == nil {
+		// validate the expression with the variable defined, see expr.validateExpr
+		scope[m.as] = types.Null
+
+		if _, err = processExpr(m.in, doc, scope); err != nil {
+			return nil, err
+		}
+
+		return types.Null, nil
+	}
+
+	if v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrMapInputNotArray,
+			"$map",
+			fmt.Sprintf("input to $map must be an array not %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	res := types.MakeArray(arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		scope[m.as] = must.NotFail(arr.Get(i))
+
+		v, err := processExpr(m.in, doc, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Append(v)
+	}
+
+	return res, nil
+}
+
+// filter represents `$filter` operator.
+//
+//	{ $filter: { input: <array>, as: <string>, cond: <expression>, limit: <number expression> } }
+//
+// Each element of the input array is accessible in `cond` expression
+// by the variable named by `as`, `$$this` by default.
+type filter struct {
+	input any
+	as    string
+	cond  any
+	limit any // nil if not set
+}
+
+// newFilter returns `$filter` operator.
+func newFilter(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrFilterInvalidSpec,
+			"$filter",
+			"$filter only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "as", "cond", "limit":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrFilterUnknownParameter,
+				"$filter",
+				fmt.Sprintf("Unrecognized parameter to $filter: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("input") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrFilterMissingInput,
+			"$filter",
+			"Missing 'input' parameter to $filter",
+		)
+	}
+
+	if !spec.Has("cond") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrFilterMissingCond,
+			"$filter",
+			"Missing 'cond' parameter to $filter",
+		)
+	}
+
+	as, err := variableName("$filter", spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &filter{
+		input: must.NotFail(spec.Get("input")),
+		as:    as,
+		cond:  must.NotFail(spec.Get("cond")),
+		limit: getOptional(spec, "limit"),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (f *filter) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(f.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := -1
+
+	if f.limit != nil {
+		l, err := processExpr(f.limit, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		// null limit means no limit
+		if l != types.Null {
+			n, ok := toInt32(l)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrFilterLimitNotInt32,
+					"$filter",
+					fmt.Sprintf("$filter: limit must be represented as a 32-bit integral value: %s", types.FormatAnyValue(l)),
+				)
+			}
+
+			if n < 1 {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrFilterLimitNotPositive,
+					"$filter",
+					fmt.Sprintf("$filter: limit must be greater than 0: %d", n),
+				)
+			}
+
+			limit = int(n)
+		}
+	}
+
+	scope := vars.Clone()
+
+	if doc == nil {
+		// validate the expression with the variable defined, see expr.validateExpr
+		scope[f.as] = types.Null
+
+		if _, err = processExpr(f.cond, doc, scope); err != nil {
+			return nil, err
+		}
+
+		return types.Null, nil
+	}
+
+	if v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrFilterInputNotArray,
+			"$filter",
+			fmt.Sprintf("input to $filter must be an array not %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	res := types.MakeArray(0)
+
+	for i := 0; i < arr.Len() && res.Len() != limit; i++ {
+		elem := must.NotFail(arr.Get(i))
+		scope[f.as] = elem
+
+		cond, err := processExpr(f.cond, doc, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		if isTrue(cond) {
+			res.Append(elem)
+		}
+	}
+
+	return res, nil
+}
+
+// reduce represents `$reduce` operator.
+//
+//	{ $reduce: { input: <array>, initialValue: <expression>, in: <expression> } }
+//
+// The accumulated value is accessible in `in` expression by `$$value` variable,
+// and the current element of the input array by `$$this` variable.
+type reduce struct {
+	input        any
+	initialValue any
+	in           any
+}
+
+// newReduce returns `$reduce` operator.
+func newReduce(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.Null
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrReduceInvalidSpec,
+			"$reduce",
+			fmt.Sprintf("$reduce requires an object as an argument, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "initialValue", "in":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrReduceUnknownArgument,
+				"$reduce",
+				fmt.Sprintf("$reduce found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	for _, p := range []struct {
+		key  string
+		code handlererrors.ErrorCode
+	}{
+		{"input", handlererrors.ErrReduceMissingInput},
+		{"initialValue", handlererrors.ErrReduceMissingInitialValue},
+		{"in", handlererrors.ErrReduceMissingIn},
+	} {
+		if !spec.Has(p.key) {
+			return nil, newOperatorArgumentError(
+				p.code,
+				"$reduce",
+				fmt.Sprintf("$reduce requires '%s' to be specified", p.key),
+			)
+		}
+	}
+
+	return &reduce{
+		input:        must.NotFail(spec.Get("input")),
+		initialValue: must.NotFail(spec.Get("initialValue")),
+		in:           must.NotFail(spec.Get("in")),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (r *reduce) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(r.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := processExpr(r.initialValue, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := vars.Clone()
+
+	if doc == nil {
+		// validate the expression with the variables defined, see expr.validateExpr
+		scope["this"], scope["value"] = types.Null, value
+
+		if _, err = processExpr(r.in, doc, scope); err != nil {
+			return nil, err
+		}
+
+		return types.Null, nil
+	}
+
+	if v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrReduceInputNotArray,
+			"$reduce",
+			fmt.Sprintf("$reduce requires that 'input' be an array, found: %s", types.FormatAnyValue(v)),
+		)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		scope["this"], scope["value"] = must.NotFail(arr.Get(i)), value
+
+		if value, err = processExpr(r.in, doc, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+// variableName returns the variable name set by `as` argument of the operator with the given name,
+// or `this` if it is not set.
+func variableName(name string, spec *types.Document) (string, error) {
+	v, err := spec.Get("as")
+	if err != nil {
+		return "this", nil
+	}
+
+	// non-string names are treated as empty, the same way as MongoDB does
+	as, _ := v.(string)
+
+	if err := aggregations.ValidateUserVariableName(as); err != nil {
+		return "", newOperatorArgumentError(handlererrors.ErrFailedToParse, name, err.Error())
+	}
+
+	return as, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*mapOp)(nil)
+	_ Operator = (*filter)(nil)
+	_ Operator = (*reduce)(nil)
+)
@@ -0,0 +1,194 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// newObjectToArray returns `$objectToArray` operator.
+//
+//	{ $objectToArray: <object> }
+//
+// Each field of the document is converted to `{ k: <field name>, v: <field value> }` document.
+var newObjectToArray = newArrayFunc("$objectToArray", func(v any) (any, error) {
+	if v == nil || v == types.Null {
+		return types.Null, nil
+	}
+
+	doc, ok := v.(*types.Document)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrObjectToArrayNotDocument,
+			"$objectToArray",
+			fmt.Sprintf("$objectToArray requires a document input, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	res := types.MakeArray(doc.Len())
+
+	for _, k := range doc.Keys() {
+		res.Append(must.NotFail(types.NewDocument("k", k, "v", must.NotFail(doc.Get(k)))))
+	}
+
+	return res, nil
+})
+
+// newArrayToObject returns `$arrayToObject` operator.
+//
+//	{ $arrayToObject: [ [ <key>, <value> ], ... ] }
+//	{ $arrayToObject: [ { k: <key>, v: <value> }, ... ] }
+//
+// All elements must have the same format, the format is defined by the first element.
+// If the same key is used more than once, the last value is used.
+var newArrayToObject = newArrayFunc("$arrayToObject", func(v any) (any, error) {
+	if v == nil || v == types.Null {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayToObjectNotArray,
+			"$arrayToObject",
+			fmt.Sprintf("$arrayToObject requires an array input, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	res := new(types.Document)
+
+	if arr.Len() == 0 {
+		return res, nil
+	}
+
+	var pairs bool
+
+	switch first := must.NotFail(arr.Get(0)).(type) {
+	case *types.Array:
+		pairs = true
+	case *types.Document:
+	default:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrArrayToObjectInvalidElement,
+			"$arrayToObject",
+			fmt.Sprintf("Unrecognised input type format for $arrayToObject: %s", handlerparams.AliasFromType(first)),
+		)
+	}
+
+	for i := 0; i < arr.Len(); i++ {
+		var key, value any
+
+		switch elem := must.NotFail(arr.Get(i)).(type) {
+		case *types.Array:
+			if !pairs {
+				return nil, arrayToObjectFormatError(pairs, elem)
+			}
+
+			if elem.Len() != 2 {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrArrayToObjectInvalidPair,
+					"$arrayToObject",
+					fmt.Sprintf("$arrayToObject requires an array of size 2 arrays,found array of size: %d", elem.Len()),
+				)
+			}
+
+			key, value = must.NotFail(elem.Get(0)), must.NotFail(elem.Get(1))
+
+			if _, ok := key.(string); !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrArrayToObjectPairKeyNotString,
+					"$arrayToObject",
+					fmt.Sprintf(
+						"$arrayToObject requires an array of key-value pairs, where the key must be of type string. "+
+							"Found key type: %s",
+						handlerparams.AliasFromType(key),
+					),
+				)
+			}
+
+		case *types.Document:
+			if pairs {
+				return nil, arrayToObjectFormatError(pairs, elem)
+			}
+
+			if elem.Len() != 2 {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrArrayToObjectInvalidKeys,
+					"$arrayToObject",
+					fmt.Sprintf(
+						"$arrayToObject requires an object keys of 'k' and 'v'. Found incorrect number of keys:%d",
+						elem.Len(),
+					),
+				)
+			}
+
+			if !elem.Has("k") || !elem.Has("v") {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrArrayToObjectMissingKeys,
+					"$arrayToObject",
+					fmt.Sprintf(
+						"$arrayToObject requires an object with keys 'k' and 'v'. Missing either or both keys from: %s",
+						types.FormatAnyValue(elem),
+					),
+				)
+			}
+
+			key, value = must.NotFail(elem.Get("k")), must.NotFail(elem.Get("v"))
+
+			if _, ok := key.(string); !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrArrayToObjectKeyNotString,
+					"$arrayToObject",
+					fmt.Sprintf(
+						"$arrayToObject requires an object with keys 'k' and 'v', "+
+							"where the value of 'k' must be of type string. Found type: %s",
+						handlerparams.AliasFromType(key),
+					),
+				)
+			}
+
+		default:
+			return nil, arrayToObjectFormatError(pairs, elem)
+		}
+
+		res.Set(key.(string), value)
+	}
+
+	return res, nil
+})
+
+// arrayToObjectFormatError returns an error for `$arrayToObject` element v
+// that does not match the format of the first element.
+func arrayToObjectFormatError(pairs bool, v any) error {
+	code, detected := handlererrors.ErrArrayToObjectExpectedDocument, "Object"
+	if pairs {
+		code, detected = handlererrors.ErrArrayToObjectExpectedArray, "Array"
+	}
+
+	return newOperatorArgumentError(
+		code,
+		"$arrayToObject",
+		fmt.Sprintf(
+			"$arrayToObject requires a consistent input format. "+
+				"Elements must all be arrays or all be objects. %s was detected, now found: %s",
+			detected, handlerparams.AliasFromType(v),
+		),
+	)
+}
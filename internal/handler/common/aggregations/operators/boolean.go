@@ -0,0 +1,107 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// logical represents `$and` and `$or` operators.
+//
+//	{ <operator>: [ <expression1>, <expression2>, ... ] }
+type logical struct {
+	args []any
+	or   bool
+}
+
+// newAnd returns `$and` operator, it is true if all arguments are true.
+func newAnd(args ...any) (Operator, error) {
+	return &logical{
+		args: args,
+	}, nil
+}
+
+// newOr returns `$or` operator, it is true if any argument is true.
+func newOr(args ...any) (Operator, error) {
+	return &logical{
+		args: args,
+		or:   true,
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Arguments are evaluated in order until the result is known,
+// except for the validation when the document is nil.
+func (l *logical) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	for _, arg := range l.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc == nil {
+			// validate all arguments, see expr.validateExpr
+			continue
+		}
+
+		if isTrue(v) == l.or {
+			return l.or, nil
+		}
+	}
+
+	return !l.or, nil
+}
+
+// not represents `$not` operator.
+//
+//	{ $not: [ <expression> ] }
+type not struct {
+	arg any
+}
+
+// newNot returns `$not` operator.
+func newNot(args ...any) (Operator, error) {
+	if len(args) != 1 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$not",
+			fmt.Sprintf("Expression $not takes exactly 1 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &not{
+		arg: args[0],
+	}, nil
+}
+
+// Process implements Operator interface.
+func (n *not) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(n.arg, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return !isTrue(v), nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*logical)(nil)
+	_ Operator = (*not)(nil)
+)
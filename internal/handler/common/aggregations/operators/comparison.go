@@ -0,0 +1,121 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// comparison represents comparison operators `$cmp`, `$eq`, `$gt`, `$gte`, `$lt`, `$lte` and `$ne`.
+//
+//	{ <operator>: [ <expression1>, <expression2> ] }
+//
+// Unlike query comparison operators, values of different types are compared
+// by BSON comparison order, and arrays are compared as a whole.
+type comparison struct {
+	name   string
+	args   [2]any
+	result func(types.CompareResult) any
+}
+
+// newComparisonFunc returns a function creating the comparison operator with the given name,
+// result converts the comparison result to the operator result.
+func newComparisonFunc(name string, result func(types.CompareResult) any) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 2 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 2 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &comparison{
+			name:   name,
+			args:   [2]any{args[0], args[1]},
+			result: result,
+		}, nil
+	}
+}
+
+// Process implements Operator interface.
+func (c *comparison) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	a, err := processExpr(c.args[0], doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := processExpr(c.args[1], doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.result(compare(a, b)), nil
+}
+
+// compare compares two values by BSON comparison order used by aggregation expressions.
+func compare(a, b any) types.CompareResult {
+	return types.CompareForAggregation(a, b)
+}
+
+// newCmp returns `$cmp` operator, it returns -1, 0 or 1.
+var newCmp = newComparisonFunc("$cmp", func(res types.CompareResult) any {
+	switch res {
+	case types.Less:
+		return int32(-1)
+	case types.Greater:
+		return int32(1)
+	default:
+		return int32(0)
+	}
+})
+
+// newEq returns `$eq` operator.
+var newEq = newComparisonFunc("$eq", func(res types.CompareResult) any {
+	return res == types.Equal
+})
+
+// newNe returns `$ne` operator.
+var newNe = newComparisonFunc("$ne", func(res types.CompareResult) any {
+	return res != types.Equal
+})
+
+// newGt returns `$gt` operator.
+var newGt = newComparisonFunc("$gt", func(res types.CompareResult) any {
+	return res == types.Greater
+})
+
+// newGte returns `$gte` operator.
+var newGte = newComparisonFunc("$gte", func(res types.CompareResult) any {
+	return res == types.Greater || res == types.Equal
+})
+
+// newLt returns `$lt` operator.
+var newLt = newComparisonFunc("$lt", func(res types.CompareResult) any {
+	return res == types.Less
+})
+
+// newLte returns `$lte` operator.
+var newLte = newComparisonFunc("$lte", func(res types.CompareResult) any {
+	return res == types.Less || res == types.Equal
+})
+
+// check interfaces
+var (
+	_ Operator = (*comparison)(nil)
+)
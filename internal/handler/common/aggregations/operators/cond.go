@@ -0,0 +1,373 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// cond represents `$cond` operator.
+//
+//	{ $cond: { if: <boolean-expression>, then: <true-case>, else: <false-case> } }
+//	{ $cond: [ <boolean-expression>, <true-case>, <false-case> ] }
+type cond struct {
+	ifExpr   any
+	thenExpr any
+	elseExpr any
+}
+
+// newCond returns `$cond` operator.
+func newCond(args ...any) (Operator, error) {
+	if len(args) == 1 {
+		if spec, ok := args[0].(*types.Document); ok {
+			return newCondFromDocument(spec)
+		}
+	}
+
+	if len(args) != 3 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$cond",
+			fmt.Sprintf("Expression $cond takes exactly 3 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &cond{
+		ifExpr:   args[0],
+		thenExpr: args[1],
+		elseExpr: args[2],
+	}, nil
+}
+
+// newCondFromDocument returns `$cond` operator for the document form of arguments.
+func newCondFromDocument(spec *types.Document) (Operator, error) {
+	for _, k := range spec.Keys() {
+		switch k {
+		case "if", "then", "else":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrCondUnknownParameter,
+				"$cond",
+				fmt.Sprintf("Unrecognized parameter to $cond: %s", k),
+			)
+		}
+	}
+
+	for _, p := range []struct {
+		key  string
+		code handlererrors.ErrorCode
+	}{
+		{"if", handlererrors.ErrCondMissingIf},
+		{"then", handlererrors.ErrCondMissingThen},
+		{"else", handlererrors.ErrCondMissingElse},
+	} {
+		if !spec.Has(p.key) {
+			return nil, newOperatorArgumentError(
+				p.code,
+				"$cond",
+				fmt.Sprintf("Missing '%s' parameter to $cond", p.key),
+			)
+		}
+	}
+
+	return &cond{
+		ifExpr:   must.NotFail(spec.Get("if")),
+		thenExpr: must.NotFail(spec.Get("then")),
+		elseExpr: must.NotFail(spec.Get("else")),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Both branches are evaluated only if the condition selects them,
+// except for the validation when the document is nil.
+func (c *cond) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(c.ifExpr, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		// validate both branches, see expr.validateExpr
+		if _, err = processExpr(c.thenExpr, doc, vars); err != nil {
+			return nil, err
+		}
+
+		if _, err = processExpr(c.elseExpr, doc, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	if isTrue(v) {
+		return processExpr(c.thenExpr, doc, vars)
+	}
+
+	return processExpr(c.elseExpr, doc, vars)
+}
+
+// ifNull represents `$ifNull` operator.
+//
+//	{ $ifNull: [ <input-expression-1>, ... <input-expression-n>, <replacement-expression-if-null> ] }
+type ifNull struct {
+	args []any
+}
+
+// newIfNull returns `$ifNull` operator.
+func newIfNull(args ...any) (Operator, error) {
+	if len(args) < 2 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrIfNullArgsInvalidLen,
+			"$ifNull",
+			fmt.Sprintf("$ifNull needs at least two arguments, had: %d", len(args)),
+		)
+	}
+
+	return &ifNull{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// It returns the first argument that is not evaluated to null or missing,
+// or the last argument otherwise.
+func (i *ifNull) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var res any
+
+	for n, arg := range i.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if res == nil && (v != types.Null || n == len(i.args)-1) {
+			res = v
+
+			if doc != nil {
+				break
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// switchBranch represents a branch of `$switch` operator.
+type switchBranch struct {
+	caseExpr any
+	thenExpr any
+}
+
+// switchOp represents `$switch` operator.
+//
+//	{ $switch: {
+//	    branches: [ { case: <expression>, then: <expression> }, ... ],
+//	    default: <expression>
+//	} }
+type switchOp struct {
+	branches    []switchBranch
+	defaultExpr any // nil if not set
+}
+
+// newSwitch returns `$switch` operator.
+func newSwitch(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.Null
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSwitchInvalidSpec,
+			"$switch",
+			fmt.Sprintf("$switch requires an object as an argument, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	s := new(switchOp)
+
+	for _, k := range spec.Keys() {
+		v := must.NotFail(spec.Get(k))
+
+		switch k {
+		case "branches":
+			branches, ok := v.(*types.Array)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrSwitchBranchesNotArray,
+					"$switch",
+					fmt.Sprintf("$switch expected an array for 'branches', found: %s", handlerparams.AliasFromType(v)),
+				)
+			}
+
+			for _, b := range arrayValues(branches) {
+				branch, err := newSwitchBranch(b)
+				if err != nil {
+					return nil, err
+				}
+
+				s.branches = append(s.branches, branch)
+			}
+
+		case "default":
+			s.defaultExpr = v
+
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSwitchUnknownArgument,
+				"$switch",
+				fmt.Sprintf("$switch found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	if len(s.branches) == 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSwitchMissingBranches,
+			"$switch",
+			"$switch requires at least one branch.",
+		)
+	}
+
+	return s, nil
+}
+
+// newSwitchBranch validates and returns the branch of `$switch` operator.
+func newSwitchBranch(v any) (switchBranch, error) {
+	doc, ok := v.(*types.Document)
+	if !ok {
+		return switchBranch{}, newOperatorArgumentError(
+			handlererrors.ErrSwitchBranchNotDocument,
+			"$switch",
+			fmt.Sprintf("$switch expected each branch to be an object, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, k := range doc.Keys() {
+		switch k {
+		case "case", "then":
+		default:
+			return switchBranch{}, newOperatorArgumentError(
+				handlererrors.ErrSwitchBranchUnknownArgument,
+				"$switch",
+				fmt.Sprintf("$switch found an unknown argument to a branch: %s", k),
+			)
+		}
+	}
+
+	if !doc.Has("case") {
+		return switchBranch{}, newOperatorArgumentError(
+			handlererrors.ErrSwitchBranchMissingCase,
+			"$switch",
+			"$switch requires each branch have a 'case' expression",
+		)
+	}
+
+	if !doc.Has("then") {
+		return switchBranch{}, newOperatorArgumentError(
+			handlererrors.ErrSwitchBranchMissingThen,
+			"$switch",
+			"$switch requires each branch have a 'then' expression.",
+		)
+	}
+
+	return switchBranch{
+		caseExpr: must.NotFail(doc.Get("case")),
+		thenExpr: must.NotFail(doc.Get("then")),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Only the first branch with the true case is evaluated,
+// except for the validation when the document is nil.
+func (s *switchOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	if doc == nil {
+		// validate all branches, see expr.validateExpr
+		for _, b := range s.branches {
+			for _, expr := range []any{b.caseExpr, b.thenExpr} {
+				if _, err := processExpr(expr, doc, vars); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if s.defaultExpr != nil {
+			if _, err := processExpr(s.defaultExpr, doc, vars); err != nil {
+				return nil, err
+			}
+		}
+
+		return types.Null, nil
+	}
+
+	for _, b := range s.branches {
+		v, err := processExpr(b.caseExpr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if isTrue(v) {
+			return processExpr(b.thenExpr, doc, vars)
+		}
+	}
+
+	if s.defaultExpr == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSwitchNoMatchingBranch,
+			"$switch",
+			"$switch could not find a matching branch for an input, and no default was specified.",
+		)
+	}
+
+	return processExpr(s.defaultExpr, doc, vars)
+}
+
+// isTrue returns true if the given value is considered true by aggregation expressions.
+// Null, missing (evaluated to null), false and numeric zero values are false, all other values are true.
+func isTrue(v any) bool {
+	switch v := v.(type) {
+	case types.NullType:
+		return false
+	case bool:
+		return v
+	case int32:
+		return v != 0
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	default:
+		return true
+	}
+}
+
+// check interfaces
+var (
+	_ Operator = (*cond)(nil)
+	_ Operator = (*ifNull)(nil)
+	_ Operator = (*switchOp)(nil)
+)
@@ -0,0 +1,521 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// convertTypes contains BSON types that could be used as `$convert` target type.
+var convertTypes = []handlerparams.TypeCode{
+	handlerparams.TypeCodeDouble,
+	handlerparams.TypeCodeString,
+	handlerparams.TypeCodeObject,
+	handlerparams.TypeCodeArray,
+	handlerparams.TypeCodeBinData,
+	handlerparams.TypeCodeObjectID,
+	handlerparams.TypeCodeBool,
+	handlerparams.TypeCodeDate,
+	handlerparams.TypeCodeNull,
+	handlerparams.TypeCodeRegex,
+	handlerparams.TypeCodeInt,
+	handlerparams.TypeCodeTimestamp,
+	handlerparams.TypeCodeLong,
+	handlerparams.TypeCodeDecimal,
+	handlerparams.TypeCodeMinKey,
+	handlerparams.TypeCodeMaxKey,
+}
+
+// convert represents `$convert` operator.
+//
+//	{ $convert: {
+//	    input: <expression>,
+//	    to: <type expression>,
+//	    onError: <expression>,
+//	    onNull: <expression>
+//	} }
+//
+// It is also used for `$toBool`, `$toDate`, `$toDouble`, `$toInt`, `$toLong`,
+// `$toObjectId` and `$toString` operators.
+type convert struct {
+	input   any
+	to      any
+	onError any // nil if not set
+	onNull  any // nil if not set
+}
+
+// newConvert returns `$convert` operator.
+func newConvert(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.MakeArray(0)
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrFailedToParse,
+			"$convert",
+			fmt.Sprintf("$convert expects an object of named arguments but found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "to", "onError", "onNull":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrFailedToParse,
+				"$convert",
+				fmt.Sprintf("$convert found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	for _, k := range []string{"input", "to"} {
+		if !spec.Has(k) {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrFailedToParse,
+				"$convert",
+				fmt.Sprintf("Missing '%s' parameter to $convert", k),
+			)
+		}
+	}
+
+	return &convert{
+		input:   must.NotFail(spec.Get("input")),
+		to:      must.NotFail(spec.Get("to")),
+		onError: getOptional(spec, "onError"),
+		onNull:  getOptional(spec, "onNull"),
+	}, nil
+}
+
+// newConvertFunc returns a function that creates `$toX` operator with the given name,
+// it converts its single argument to the given type the same way as `$convert` does.
+func newConvertFunc(name string, to handlerparams.TypeCode) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 1 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 1 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &convert{
+			input: args[0],
+			to:    to.String(),
+		}, nil
+	}
+}
+
+var (
+	// newToBool returns `$toBool` operator.
+	newToBool = newConvertFunc("$toBool", handlerparams.TypeCodeBool)
+
+	// newToDate returns `$toDate` operator.
+	newToDate = newConvertFunc("$toDate", handlerparams.TypeCodeDate)
+
+	// newToDouble returns `$toDouble` operator.
+	newToDouble = newConvertFunc("$toDouble", handlerparams.TypeCodeDouble)
+
+	// newToInt returns `$toInt` operator.
+	newToInt = newConvertFunc("$toInt", handlerparams.TypeCodeInt)
+
+	// newToLong returns `$toLong` operator.
+	newToLong = newConvertFunc("$toLong", handlerparams.TypeCodeLong)
+
+	// newToObjectID returns `$toObjectId` operator.
+	newToObjectID = newConvertFunc("$toObjectId", handlerparams.TypeCodeObjectID)
+
+	// newToString returns `$toString` operator.
+	newToString = newConvertFunc("$toString", handlerparams.TypeCodeString)
+)
+
+// Process implements Operator interface.
+//
+// The target type is validated before null input is handled,
+// conversion failures return onError value if it is set.
+func (c *convert) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	to, err := processExpr(c.to, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := processExpr(c.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		// validate all expressions, see expr.validateExpr
+		for _, expr := range []any{c.onError, c.onNull} {
+			if expr == nil {
+				continue
+			}
+
+			if _, err = processExpr(expr, doc, vars); err != nil {
+				return nil, err
+			}
+		}
+
+		return types.Null, nil
+	}
+
+	var target handlerparams.TypeCode
+
+	if to != types.Null {
+		if target, err = convertTargetType(to); err != nil {
+			return nil, err
+		}
+	}
+
+	if input == types.Null {
+		if c.onNull == nil {
+			return types.Null, nil
+		}
+
+		return processExpr(c.onNull, doc, vars)
+	}
+
+	// null target type results in null
+	if to == types.Null {
+		return types.Null, nil
+	}
+
+	res, err := convertValue(input, target)
+
+	var opErr OperatorError
+	if c.onError != nil && errors.As(err, &opErr) && opErr.CommandCode() == handlererrors.ErrConversionFailure {
+		return processExpr(c.onError, doc, vars)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// convertTargetType returns the type code for `$convert` target type
+// specified by type name or numeric type code.
+func convertTargetType(to any) (handlerparams.TypeCode, error) {
+	switch to := to.(type) {
+	case string:
+		for _, code := range convertTypes {
+			if code.String() == to {
+				return code, nil
+			}
+		}
+
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrBadValue,
+			"$convert",
+			fmt.Sprintf("Unknown type name: %s", to),
+		)
+
+	case int32, int64, float64:
+		n, ok := toIntegral(to)
+		if !ok {
+			return 0, newOperatorArgumentError(
+				handlererrors.ErrFailedToParse,
+				"$convert",
+				"In $convert, numeric 'to' argument is not an integer",
+			)
+		}
+
+		for _, code := range convertTypes {
+			if int64(code) == n {
+				return code, nil
+			}
+		}
+
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrFailedToParse,
+			"$convert",
+			fmt.Sprintf("In $convert, numeric value for 'to' does not correspond to a BSON type: %d", n),
+		)
+
+	default:
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrFailedToParse,
+			"$convert",
+			fmt.Sprintf("$convert's 'to' argument must be a string or number, but is %s", handlerparams.AliasFromType(to)),
+		)
+	}
+}
+
+// convertValue converts non-null value v to the given type
+// according to MongoDB conversion rules.
+//
+// Any value could be converted to its own type.
+func convertValue(v any, to handlerparams.TypeCode) (any, error) {
+	if handlerparams.AliasFromType(v) == to.String() {
+		return v, nil
+	}
+
+	switch to {
+	case handlerparams.TypeCodeBool:
+		return isTrue(v), nil
+
+	case handlerparams.TypeCodeDouble:
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return float64(1), nil
+			}
+
+			return float64(0), nil
+		case int32:
+			return float64(v), nil
+		case int64:
+			return float64(v), nil
+		case string:
+			return parseConvertDouble(v)
+		case time.Time:
+			return float64(v.UnixMilli()), nil
+		}
+
+	case handlerparams.TypeCodeInt:
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return int32(1), nil
+			}
+
+			return int32(0), nil
+		case float64:
+			n, err := convertDoubleToLong(v)
+			if err != nil {
+				return nil, err
+			}
+
+			if n < math.MinInt32 || n > math.MaxInt32 {
+				return nil, convertOverflowError(v)
+			}
+
+			return int32(n), nil
+		case int64:
+			if v < math.MinInt32 || v > math.MaxInt32 {
+				return nil, convertOverflowError(v)
+			}
+
+			return int32(v), nil
+		case string:
+			n, err := parseConvertInt(v, 32)
+			if err != nil {
+				return nil, err
+			}
+
+			return int32(n), nil
+		}
+
+	case handlerparams.TypeCodeLong:
+		switch v := v.(type) {
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+
+			return int64(0), nil
+		case float64:
+			return convertDoubleToLong(v)
+		case int32:
+			return int64(v), nil
+		case string:
+			return parseConvertInt(v, 64)
+		case time.Time:
+			return v.UnixMilli(), nil
+		}
+
+	case handlerparams.TypeCodeString:
+		switch v := v.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil
+		case float64, int32, int64, time.Time:
+			return coerceToString("$convert", v)
+		case types.ObjectID:
+			return hex.EncodeToString(v[:]), nil
+		}
+
+	case handlerparams.TypeCodeDate:
+		switch v := v.(type) {
+		case float64:
+			n, err := convertDoubleToLong(v)
+			if err != nil {
+				return nil, err
+			}
+
+			return time.UnixMilli(n).UTC(), nil
+		case int64:
+			return time.UnixMilli(v).UTC(), nil
+		case string:
+			return parseDate(v, "", time.UTC, false)
+		case types.ObjectID, types.Timestamp:
+			t, _ := toDate(v)
+			return t, nil
+		}
+
+	case handlerparams.TypeCodeObjectID:
+		if s, ok := v.(string); ok {
+			return parseConvertObjectID(s)
+		}
+
+	case handlerparams.TypeCodeDecimal:
+		return nil, newOperatorError(
+			ErrNotImplemented,
+			"$convert",
+			"Conversion to decimal is not implemented yet",
+		)
+	}
+
+	return nil, newOperatorArgumentError(
+		handlererrors.ErrConversionFailure,
+		"$convert",
+		fmt.Sprintf(
+			"Unsupported conversion from %s to %s in $convert with no onError value",
+			handlerparams.AliasFromType(v), to,
+		),
+	)
+}
+
+// convertDoubleToLong converts float64 to int64 truncating the fractional part,
+// NaN, infinity and values out of int64 range cannot be converted.
+func convertDoubleToLong(f float64) (int64, error) {
+	switch {
+	case math.IsNaN(f):
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrConversionFailure,
+			"$convert",
+			"Attempt to convert NaN value to integer type in $convert with no onError value",
+		)
+	case math.IsInf(f, 0):
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrConversionFailure,
+			"$convert",
+			"Attempt to convert infinity value to integer type in $convert with no onError value",
+		)
+	case f >= math.MaxInt64 || f < math.MinInt64:
+		return 0, convertOverflowError(f)
+	}
+
+	return int64(f), nil
+}
+
+// convertOverflowError returns an error for value v that does not fit the target type.
+func convertOverflowError(v any) error {
+	return newOperatorArgumentError(
+		handlererrors.ErrConversionFailure,
+		"$convert",
+		fmt.Sprintf(
+			"Conversion would overflow target type in $convert with no onError value: %s",
+			types.FormatAnyValue(v),
+		),
+	)
+}
+
+// parseConvertInt parses decimal integer string s of the given bit size.
+func parseConvertInt(s string, bitSize int) (int64, error) {
+	n, err := strconv.ParseInt(s, 10, bitSize)
+	if err != nil {
+		return 0, convertParseNumberError(s, err)
+	}
+
+	return n, nil
+}
+
+// parseConvertDouble parses decimal floating point number string s,
+// hexadecimal numbers are not accepted.
+func parseConvertDouble(s string) (float64, error) {
+	if digits := strings.TrimLeft(s, "+-"); strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		return 0, convertParseNumberError(s, strconv.ErrSyntax)
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, convertParseNumberError(s, err)
+	}
+
+	return f, nil
+}
+
+// convertParseNumberError returns an error for the number string s that cannot be parsed.
+func convertParseNumberError(s string, err error) error {
+	reason := "Did not consume whole string."
+
+	switch {
+	case s == "":
+		reason = "No digits"
+	case errors.Is(err, strconv.ErrRange):
+		reason = "Out of range"
+	}
+
+	return newOperatorArgumentError(
+		handlererrors.ErrConversionFailure,
+		"$convert",
+		fmt.Sprintf("Failed to parse number '%s' in $convert with no onError value: %s", s, reason),
+	)
+}
+
+// parseConvertObjectID parses ObjectID from 24 hexadecimal characters string s.
+func parseConvertObjectID(s string) (types.ObjectID, error) {
+	var id types.ObjectID
+
+	if len(s) != len(id)*2 {
+		return id, newOperatorArgumentError(
+			handlererrors.ErrConversionFailure,
+			"$convert",
+			fmt.Sprintf(
+				"Failed to parse objectId '%s' in $convert with no onError value: "+
+					"Invalid string length for parsing to OID, expected 24 but found %d",
+				s, len(s),
+			),
+		)
+	}
+
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return id, newOperatorArgumentError(
+			handlererrors.ErrConversionFailure,
+			"$convert",
+			fmt.Sprintf(
+				"Failed to parse objectId '%s' in $convert with no onError value: Invalid character found in hex string",
+				s,
+			),
+		)
+	}
+
+	return id, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*convert)(nil)
+)
@@ -0,0 +1,321 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+	_ "time/tzdata" // embedded timezone database for Olson timezone identifiers
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// dateOp represents date part operators such as `$year`, `$hour` and `$isoWeek`.
+//
+//	{ <operator>: <dateExpression> }
+//	{ <operator>: [ <dateExpression> ] }
+//	{ <operator>: { date: <dateExpression>, timezone: <tzExpression> } }
+type dateOp struct {
+	name     string
+	date     any
+	timezone any // nil if not set
+	f        func(t time.Time) any
+}
+
+// newDateFunc returns a function creating the date part operator with the given name,
+// f returns the part of the date in the requested timezone.
+func newDateFunc(name string, f func(t time.Time) any) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 1 {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateOperatorInvalidArrayLen,
+				name,
+				fmt.Sprintf("%s accepts exactly one argument if given an array, but was given %d", name, len(args)),
+			)
+		}
+
+		op := &dateOp{
+			name: name,
+			date: args[0],
+			f:    f,
+		}
+
+		spec, ok := args[0].(*types.Document)
+		if !ok || IsOperator(spec) {
+			return op, nil
+		}
+
+		for _, k := range spec.Keys() {
+			switch k {
+			case "date", "timezone":
+			default:
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrDateOperatorUnknownArgument,
+					name,
+					fmt.Sprintf("unrecognized option to %s: %q", name, k),
+				)
+			}
+		}
+
+		if !spec.Has("date") {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateOperatorMissingDate,
+				name,
+				fmt.Sprintf("missing 'date' argument to %s, provided: %s", name, types.FormatAnyValue(spec)),
+			)
+		}
+
+		op.date = must.NotFail(spec.Get("date"))
+
+		if spec.Has("timezone") {
+			op.timezone = must.NotFail(spec.Get("timezone"))
+		}
+
+		return op, nil
+	}
+}
+
+// Process implements Operator interface.
+func (d *dateOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(d.date, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == types.Null {
+		return types.Null, nil
+	}
+
+	loc, err := processTimezone(d.name, d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if loc == nil {
+		return types.Null, nil
+	}
+
+	t, ok := toDate(v)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateConversion,
+			d.name,
+			fmt.Sprintf("can't convert from BSON type %s to Date", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	return d.f(t.In(loc)), nil
+}
+
+// newYear returns `$year` operator.
+var newYear = newDateFunc("$year", func(t time.Time) any {
+	return int32(t.Year())
+})
+
+// newMonth returns `$month` operator.
+var newMonth = newDateFunc("$month", func(t time.Time) any {
+	return int32(t.Month())
+})
+
+// newDayOfMonth returns `$dayOfMonth` operator.
+var newDayOfMonth = newDateFunc("$dayOfMonth", func(t time.Time) any {
+	return int32(t.Day())
+})
+
+// newDayOfYear returns `$dayOfYear` operator.
+var newDayOfYear = newDateFunc("$dayOfYear", func(t time.Time) any {
+	return int32(t.YearDay())
+})
+
+// newDayOfWeek returns `$dayOfWeek` operator, Sunday is 1 and Saturday is 7.
+var newDayOfWeek = newDateFunc("$dayOfWeek", func(t time.Time) any {
+	return int32(t.Weekday()) + 1
+})
+
+// newHour returns `$hour` operator.
+var newHour = newDateFunc("$hour", func(t time.Time) any {
+	return int32(t.Hour())
+})
+
+// newMinute returns `$minute` operator.
+var newMinute = newDateFunc("$minute", func(t time.Time) any {
+	return int32(t.Minute())
+})
+
+// newSecond returns `$second` operator.
+var newSecond = newDateFunc("$second", func(t time.Time) any {
+	return int32(t.Second())
+})
+
+// newMillisecond returns `$millisecond` operator.
+var newMillisecond = newDateFunc("$millisecond", func(t time.Time) any {
+	return int32(t.Nanosecond() / int(time.Millisecond))
+})
+
+// newWeek returns `$week` operator.
+var newWeek = newDateFunc("$week", func(t time.Time) any {
+	return int32(week(t))
+})
+
+// newIsoWeek returns `$isoWeek` operator.
+var newIsoWeek = newDateFunc("$isoWeek", func(t time.Time) any {
+	_, w := t.ISOWeek()
+	return int32(w)
+})
+
+// newIsoWeekYear returns `$isoWeekYear` operator.
+var newIsoWeekYear = newDateFunc("$isoWeekYear", func(t time.Time) any {
+	y, _ := t.ISOWeek()
+	return int64(y)
+})
+
+// newIsoDayOfWeek returns `$isoDayOfWeek` operator.
+var newIsoDayOfWeek = newDateFunc("$isoDayOfWeek", func(t time.Time) any {
+	return int32(isoDayOfWeek(t))
+})
+
+// week returns the week of the year, weeks begin on Sundays,
+// and days preceding the first Sunday of the year are in week 0.
+func week(t time.Time) int {
+	return (t.YearDay() + 6 - int(t.Weekday())) / 7
+}
+
+// isoDayOfWeek returns the day of the week in ISO 8601 format, Monday is 1 and Sunday is 7.
+func isoDayOfWeek(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return 7
+	}
+
+	return int(t.Weekday())
+}
+
+// isoWeekDate returns the UTC date for the given ISO week date.
+func isoWeekDate(year, week, day int) time.Time {
+	// January 4th is always in the first ISO week
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	return jan4.AddDate(0, 0, (week-1)*7+day-isoDayOfWeek(jan4))
+}
+
+// toDate converts date, timestamp and ObjectID values to time.
+//
+// It returns false if the value cannot be converted.
+func toDate(v any) (time.Time, bool) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case types.Timestamp:
+		return v.Time(), true
+	case types.ObjectID:
+		return time.Unix(int64(binary.BigEndian.Uint32(v[:4])), 0).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// processTimezone evaluates the timezone expression of the operator with the given name
+// and returns its location.
+//
+// UTC is returned if the expression is nil, nil location is returned if it is evaluated to null.
+func processTimezone(name string, expr any, doc *types.Document, vars aggregations.Variables) (*time.Location, error) {
+	if expr == nil {
+		return time.UTC, nil
+	}
+
+	v, err := processExpr(expr, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == types.Null {
+		return nil, nil
+	}
+
+	tz, ok := v.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrTimezoneNotString,
+			name,
+			fmt.Sprintf("timezone must evaluate to a string, found %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	return parseTimezone(name, tz)
+}
+
+// utcOffsetRe matches UTC offset timezones such as +03, -0530 and +05:30.
+var utcOffsetRe = regexp.MustCompile(`^([+-])(\d{2})(?::?(\d{2}))?$`)
+
+// locations caches loaded Olson timezones.
+var locations sync.Map
+
+// parseTimezone returns location for the Olson timezone identifier or UTC offset
+// used by the operator with the given name.
+func parseTimezone(name, tz string) (*time.Location, error) {
+	if m := utcOffsetRe.FindStringSubmatch(tz); m != nil {
+		hours := must.NotFail(strconv.Atoi(m[2]))
+
+		var minutes int
+		if m[3] != "" {
+			minutes = must.NotFail(strconv.Atoi(m[3]))
+		}
+
+		offset := (hours*60 + minutes) * 60
+		if m[1] == "-" {
+			offset = -offset
+		}
+
+		return time.FixedZone(tz, offset), nil
+	}
+
+	if loc, ok := locations.Load(tz); ok {
+		return loc.(*time.Location), nil
+	}
+
+	// empty string and Local are valid for LoadLocation but not for MongoDB
+	if tz == "" || tz == "Local" {
+		return nil, unrecognizedTimezoneError(name, tz)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, unrecognizedTimezoneError(name, tz)
+	}
+
+	locations.Store(tz, loc)
+
+	return loc, nil
+}
+
+// unrecognizedTimezoneError returns an error for the unknown timezone identifier.
+func unrecognizedTimezoneError(name, tz string) error {
+	return newOperatorArgumentError(
+		handlererrors.ErrTimezoneUnrecognized,
+		name,
+		fmt.Sprintf("unrecognized time zone identifier: %q", tz),
+	)
+}
+
+// check interfaces
+var (
+	_ Operator = (*dateOp)(nil)
+)
@@ -0,0 +1,666 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// timeUnit represents the unit argument of date arithmetic operators.
+type timeUnit string
+
+// Supported time units.
+const (
+	unitYear        = timeUnit("year")
+	unitQuarter     = timeUnit("quarter")
+	unitMonth       = timeUnit("month")
+	unitWeek        = timeUnit("week")
+	unitDay         = timeUnit("day")
+	unitHour        = timeUnit("hour")
+	unitMinute      = timeUnit("minute")
+	unitSecond      = timeUnit("second")
+	unitMillisecond = timeUnit("millisecond")
+)
+
+// unitMilliseconds contains the duration of time units in milliseconds,
+// the duration of calendar units is approximate.
+var unitMilliseconds = map[timeUnit]int64{
+	unitYear:        365 * 24 * 60 * 60 * 1000,
+	unitQuarter:     92 * 24 * 60 * 60 * 1000,
+	unitMonth:       31 * 24 * 60 * 60 * 1000,
+	unitWeek:        7 * 24 * 60 * 60 * 1000,
+	unitDay:         24 * 60 * 60 * 1000,
+	unitHour:        60 * 60 * 1000,
+	unitMinute:      60 * 1000,
+	unitSecond:      1000,
+	unitMillisecond: 1,
+}
+
+// referenceDate is the date `$dateTrunc` bins are aligned to.
+var referenceDate = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// dateAdd represents `$dateAdd` and `$dateSubtract` operators.
+//
+//	{ <operator>: { startDate: <expression>, unit: <expression>, amount: <expression>, timezone: <tzExpression> } }
+type dateAdd struct {
+	name      string
+	startDate any
+	unit      any
+	amount    any
+	timezone  any // nil if not set
+	negate    bool
+}
+
+// newDateAddFunc returns a function creating the date arithmetic operator with the given name,
+// negate defines whether the amount is subtracted.
+func newDateAddFunc(name string, negate bool) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		var spec *types.Document
+		if len(args) == 1 {
+			spec, _ = args[0].(*types.Document)
+		}
+
+		if spec == nil {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateAddInvalidSpec,
+				name,
+				fmt.Sprintf("%s expects an object as its argument", name),
+			)
+		}
+
+		for _, k := range spec.Keys() {
+			switch k {
+			case "startDate", "unit", "amount", "timezone":
+			default:
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrDateAddUnknownArgument,
+					name,
+					fmt.Sprintf(
+						"Unrecognized argument to %s: %s. Expected arguments are startDate, unit, amount, and optionally timezone.",
+						name, k,
+					),
+				)
+			}
+		}
+
+		if !spec.Has("startDate") || !spec.Has("unit") || !spec.Has("amount") {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateAddMissingArgument,
+				name,
+				fmt.Sprintf("%s requires startDate, unit, and amount to be present", name),
+			)
+		}
+
+		return &dateAdd{
+			name:      name,
+			startDate: must.NotFail(spec.Get("startDate")),
+			unit:      must.NotFail(spec.Get("unit")),
+			amount:    must.NotFail(spec.Get("amount")),
+			timezone:  getOptional(spec, "timezone"),
+			negate:    negate,
+		}, nil
+	}
+}
+
+// newDateAdd returns `$dateAdd` operator.
+var newDateAdd = newDateAddFunc("$dateAdd", false)
+
+// newDateSubtract returns `$dateSubtract` operator.
+var newDateSubtract = newDateAddFunc("$dateSubtract", true)
+
+// Process implements Operator interface.
+func (d *dateAdd) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var values [3]any
+
+	for i, expr := range []any{d.startDate, d.unit, d.amount} {
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null {
+			return types.Null, nil
+		}
+
+		values[i] = v
+	}
+
+	loc, err := processTimezone(d.name, d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if loc == nil {
+		return types.Null, nil
+	}
+
+	t, ok := toDate(values[0])
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateAddStartDateInvalid,
+			d.name,
+			fmt.Sprintf("%s requires startDate to be convertible to a date", d.name),
+		)
+	}
+
+	amount, ok := toIntegral(values[2])
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateAddAmountNotInteger,
+			d.name,
+			fmt.Sprintf("%s expects integer amount of time units", d.name),
+		)
+	}
+
+	unit, err := parseTimeUnit(d.name, values[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if d.negate {
+		amount = -amount
+	}
+
+	// the result must fit int64 milliseconds
+	approx := float64(t.UnixMilli()) + float64(amount)*float64(unitMilliseconds[unit])
+	if amount == math.MinInt64 || math.Abs(approx) >= math.MaxInt64 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateAddOverflow,
+			d.name,
+			fmt.Sprintf("%s overflowed", d.name),
+		)
+	}
+
+	t = t.In(loc)
+
+	switch unit {
+	case unitYear:
+		t = addMonths(t, amount*12)
+	case unitQuarter:
+		t = addMonths(t, amount*3)
+	case unitMonth:
+		t = addMonths(t, amount)
+	case unitWeek:
+		t = t.AddDate(0, 0, int(amount)*7)
+	case unitDay:
+		t = t.AddDate(0, 0, int(amount))
+	default:
+		t = time.UnixMilli(t.UnixMilli() + amount*unitMilliseconds[unit])
+	}
+
+	return t.UTC(), nil
+}
+
+// addMonths adds months to the date in its location,
+// the day is adjusted to the last day of the month if the result month is shorter.
+func addMonths(t time.Time, months int64) time.Time {
+	y, m, d := t.Date()
+
+	total := int64(y)*12 + int64(m-1) + months
+	year, month := int(total/12), time.Month(total%12+1)
+
+	if total < 0 && total%12 != 0 {
+		year, month = year-1, month+12
+	}
+
+	lastDay := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	return time.Date(year, month, min(d, lastDay), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// dateDiff represents `$dateDiff` operator.
+//
+//	{ $dateDiff: {
+//	    startDate: <expression>, endDate: <expression>, unit: <expression>,
+//	    timezone: <tzExpression>, startOfWeek: <string>
+//	} }
+type dateDiff struct {
+	startDate   any
+	endDate     any
+	unit        any
+	timezone    any // nil if not set
+	startOfWeek any // nil if not set
+}
+
+// newDateDiff returns `$dateDiff` operator.
+func newDateDiff(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateDiffInvalidSpec,
+			"$dateDiff",
+			"$dateDiff only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "startDate", "endDate", "unit", "timezone", "startOfWeek":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateDiffUnknownArgument,
+				"$dateDiff",
+				fmt.Sprintf("Unrecognized argument to $dateDiff: %s", k),
+			)
+		}
+	}
+
+	for _, p := range []struct {
+		key  string
+		code handlererrors.ErrorCode
+	}{
+		{"startDate", handlererrors.ErrDateDiffMissingStartDate},
+		{"endDate", handlererrors.ErrDateDiffMissingEndDate},
+		{"unit", handlererrors.ErrDateDiffMissingUnit},
+	} {
+		if !spec.Has(p.key) {
+			return nil, newOperatorArgumentError(
+				p.code,
+				"$dateDiff",
+				fmt.Sprintf("Missing '%s' parameter to $dateDiff", p.key),
+			)
+		}
+	}
+
+	return &dateDiff{
+		startDate:   must.NotFail(spec.Get("startDate")),
+		endDate:     must.NotFail(spec.Get("endDate")),
+		unit:        must.NotFail(spec.Get("unit")),
+		timezone:    getOptional(spec, "timezone"),
+		startOfWeek: getOptional(spec, "startOfWeek"),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// It returns the number of unit boundaries crossed between dates in the given timezone.
+func (d *dateDiff) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var values [3]any
+	var hasNull bool
+
+	for i, expr := range []any{d.startDate, d.endDate, d.unit} {
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		hasNull = hasNull || v == types.Null
+		values[i] = v
+	}
+
+	loc, err := processTimezone("$dateDiff", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasNull || loc == nil {
+		return types.Null, nil
+	}
+
+	start, err := toDateArgument("$dateDiff", "startDate", values[0])
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := toDateArgument("$dateDiff", "endDate", values[1])
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := parseTimeUnit("$dateDiff", values[2])
+	if err != nil {
+		return nil, err
+	}
+
+	startOfWeek := time.Sunday
+
+	if unit == unitWeek && d.startOfWeek != nil {
+		v, err := processExpr(d.startOfWeek, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null {
+			return types.Null, nil
+		}
+
+		if startOfWeek, err = parseStartOfWeek("$dateDiff", v); err != nil {
+			return nil, err
+		}
+	}
+
+	start, end = start.In(loc), end.In(loc)
+
+	switch unit {
+	case unitYear:
+		return int64(end.Year() - start.Year()), nil
+	case unitQuarter:
+		q := func(t time.Time) int64 { return int64(t.Year())*4 + int64(t.Month()-1)/3 }
+		return q(end) - q(start), nil
+	case unitMonth:
+		m := func(t time.Time) int64 { return int64(t.Year())*12 + int64(t.Month()) }
+		return m(end) - m(start), nil
+	case unitWeek:
+		w := func(t time.Time) int64 {
+			days := localDays(t) - int64((int(t.Weekday())-int(startOfWeek)+7)%7)
+			return floorDiv(days, 7)
+		}
+
+		return w(end) - w(start), nil
+	case unitDay:
+		return localDays(end) - localDays(start), nil
+	default:
+		ms := unitMilliseconds[unit]
+		return floorDiv(localMilliseconds(end), ms) - floorDiv(localMilliseconds(start), ms), nil
+	}
+}
+
+// dateTrunc represents `$dateTrunc` operator.
+//
+//	{ $dateTrunc: {
+//	    date: <expression>, unit: <expression>, binSize: <expression>,
+//	    timezone: <tzExpression>, startOfWeek: <expression>
+//	} }
+type dateTrunc struct {
+	date        any
+	unit        any
+	binSize     any // nil if not set
+	timezone    any // nil if not set
+	startOfWeek any // nil if not set
+}
+
+// newDateTrunc returns `$dateTrunc` operator.
+func newDateTrunc(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateTruncInvalidSpec,
+			"$dateTrunc",
+			"$dateTrunc only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "date", "unit", "binSize", "timezone", "startOfWeek":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateTruncUnknownArgument,
+				"$dateTrunc",
+				fmt.Sprintf(
+					"Unrecognized argument to $dateTrunc: %s. "+
+						"Expected arguments are date, unit, and optionally, binSize, timezone, startOfWeek",
+					k,
+				),
+			)
+		}
+	}
+
+	if !spec.Has("date") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateTruncMissingDate,
+			"$dateTrunc",
+			"Missing 'date' parameter to $dateTrunc",
+		)
+	}
+
+	if !spec.Has("unit") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateTruncMissingUnit,
+			"$dateTrunc",
+			"Missing 'unit' parameter to $dateTrunc",
+		)
+	}
+
+	return &dateTrunc{
+		date:        must.NotFail(spec.Get("date")),
+		unit:        must.NotFail(spec.Get("unit")),
+		binSize:     getOptional(spec, "binSize"),
+		timezone:    getOptional(spec, "timezone"),
+		startOfWeek: getOptional(spec, "startOfWeek"),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Bins are aligned to 2000-01-01 in the given timezone,
+// week bins are aligned to the first startOfWeek day on or after it.
+func (d *dateTrunc) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var values [3]any
+	var hasNull bool
+
+	for i, expr := range []any{d.date, d.unit, d.binSize} {
+		if expr == nil {
+			values[i] = int64(1)
+			continue
+		}
+
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		hasNull = hasNull || v == types.Null
+		values[i] = v
+	}
+
+	loc, err := processTimezone("$dateTrunc", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasNull || loc == nil {
+		return types.Null, nil
+	}
+
+	t, err := toDateArgument("$dateTrunc", "date", values[0])
+	if err != nil {
+		return nil, err
+	}
+
+	unit, err := parseTimeUnit("$dateTrunc", values[1])
+	if err != nil {
+		return nil, err
+	}
+
+	binSize, ok := toIntegral(values[2])
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateTruncBinSizeNotInteger,
+			"$dateTrunc",
+			fmt.Sprintf(
+				"$dateTrunc requires 'binSize' to be a 64-bit integer, but got value '%s' of type %s",
+				types.FormatAnyValue(values[2]), handlerparams.AliasFromType(values[2]),
+			),
+		)
+	}
+
+	if binSize <= 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateTruncBinSizeNotPositive,
+			"$dateTrunc",
+			fmt.Sprintf("$dateTrunc requires 'binSize' to be greater than 0, but got value %d", binSize),
+		)
+	}
+
+	startOfWeek := time.Sunday
+
+	if unit == unitWeek && d.startOfWeek != nil {
+		v, err := processExpr(d.startOfWeek, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null {
+			return types.Null, nil
+		}
+
+		if startOfWeek, err = parseStartOfWeek("$dateTrunc", v); err != nil {
+			return nil, err
+		}
+	}
+
+	t = t.In(loc)
+
+	var res time.Time
+
+	switch unit {
+	case unitYear, unitQuarter, unitMonth:
+		months := binSize
+		switch unit {
+		case unitYear:
+			months *= 12
+		case unitQuarter:
+			months *= 3
+		}
+
+		elapsed := int64(t.Year()-referenceDate.Year())*12 + int64(t.Month()-1)
+		bin := floorDiv(elapsed, months) * months
+		res = addMonths(time.Date(referenceDate.Year(), time.January, 1, 0, 0, 0, 0, loc), bin)
+
+	case unitWeek, unitDay:
+		days := binSize
+		ref := localDays(referenceDate)
+
+		if unit == unitWeek {
+			days *= 7
+			ref += int64((int(startOfWeek) - int(referenceDate.Weekday()) + 7) % 7)
+		}
+
+		bin := ref + floorDiv(localDays(t)-ref, days)*days
+		res = time.Date(1970, time.January, 1+int(bin), 0, 0, 0, 0, loc)
+
+	default:
+		ms := binSize * unitMilliseconds[unit]
+		ref := referenceDate.UnixMilli()
+		bin := ref + floorDiv(localMilliseconds(t)-ref, ms)*ms
+
+		local := time.UnixMilli(bin).UTC()
+		res = time.Date(
+			local.Year(), local.Month(), local.Day(),
+			local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), loc,
+		)
+	}
+
+	return res.UTC(), nil
+}
+
+// parseTimeUnit returns the time unit argument of the operator with the given name.
+func parseTimeUnit(name string, v any) (timeUnit, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", newOperatorArgumentError(
+			handlererrors.ErrTimeUnitNotString,
+			name,
+			fmt.Sprintf("%s requires 'unit' to be a string, but got %s", name, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	unit := timeUnit(s)
+	if _, ok := unitMilliseconds[unit]; !ok {
+		return "", newOperatorArgumentError(
+			handlererrors.ErrFailedToParse,
+			name,
+			fmt.Sprintf("%s parameter 'unit' value cannot be recognized as a time unit: %s", name, s),
+		)
+	}
+
+	return unit, nil
+}
+
+// parseStartOfWeek returns the startOfWeek argument of the operator with the given name,
+// full and three-letter day names are accepted in any case.
+func parseStartOfWeek(name string, v any) (time.Weekday, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrStartOfWeekNotString,
+			name,
+			fmt.Sprintf("%s requires 'startOfWeek' to be a string, but got %s", name, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(s, d.String()) || strings.EqualFold(s, d.String()[:3]) {
+			return d, nil
+		}
+	}
+
+	return 0, newOperatorArgumentError(
+		handlererrors.ErrStartOfWeekInvalid,
+		name,
+		fmt.Sprintf("%s parameter 'startOfWeek' value cannot be recognized as a day of a week: %s", name, s),
+	)
+}
+
+// toDateArgument converts the date argument with the given key of the operator with the given name.
+func toDateArgument(name, key string, v any) (time.Time, error) {
+	t, ok := toDate(v)
+	if !ok {
+		return time.Time{}, newOperatorArgumentError(
+			handlererrors.ErrDateArgumentNotDate,
+			name,
+			fmt.Sprintf("%s requires '%s' to be a date, but got %s", name, key, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	return t, nil
+}
+
+// localDays returns the number of days since the Unix epoch to the date in its location.
+func localDays(t time.Time) int64 {
+	y, m, d := t.Date()
+	return floorDiv(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix(), 24*60*60)
+}
+
+// localMilliseconds returns the number of milliseconds since the Unix epoch to the wall clock time in its location.
+func localMilliseconds(t time.Time) int64 {
+	_, offset := t.Zone()
+	return t.UnixMilli() + int64(offset)*1000
+}
+
+// floorDiv returns a divided by b rounded towards negative infinity.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+
+	return q
+}
+
+// check interfaces
+var (
+	_ Operator = (*dateAdd)(nil)
+	_ Operator = (*dateDiff)(nil)
+	_ Operator = (*dateTrunc)(nil)
+)
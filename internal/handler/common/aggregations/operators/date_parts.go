@@ -0,0 +1,366 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// dateToParts represents `$dateToParts` operator.
+//
+//	{ $dateToParts: { date: <dateExpression>, timezone: <tzExpression>, iso8601: <boolean> } }
+type dateToParts struct {
+	date     any
+	timezone any // nil if not set
+	iso8601  any // nil if not set
+}
+
+// newDateToParts returns `$dateToParts` operator.
+func newDateToParts(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateToPartsInvalidSpec,
+			"$dateToParts",
+			"$dateToParts only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "date", "timezone", "iso8601":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateToPartsUnknownArgument,
+				"$dateToParts",
+				fmt.Sprintf("Unrecognized argument to $dateToParts: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("date") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateToPartsMissingDate,
+			"$dateToParts",
+			"Missing 'date' parameter to $dateToParts",
+		)
+	}
+
+	return &dateToParts{
+		date:     must.NotFail(spec.Get("date")),
+		timezone: getOptional(spec, "timezone"),
+		iso8601:  getOptional(spec, "iso8601"),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (d *dateToParts) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	date, err := processExpr(d.date, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := processTimezone("$dateToParts", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var iso bool
+
+	if d.iso8601 != nil {
+		v, err := processExpr(d.iso8601, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case types.NullType:
+			return types.Null, nil
+		case bool:
+			iso = v
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateToPartsIso8601NotBool,
+				"$dateToParts",
+				fmt.Sprintf("iso8601 must evaluate to a bool, found %s", handlerparams.AliasFromType(v)),
+			)
+		}
+	}
+
+	if date == types.Null || loc == nil {
+		return types.Null, nil
+	}
+
+	t, ok := toDate(date)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateConversion,
+			"$dateToParts",
+			fmt.Sprintf("can't convert from BSON type %s to Date", handlerparams.AliasFromType(date)),
+		)
+	}
+
+	t = t.In(loc)
+
+	var res *types.Document
+
+	if iso {
+		y, w := t.ISOWeek()
+		res = must.NotFail(types.NewDocument(
+			"isoWeekYear", int32(y),
+			"isoWeek", int32(w),
+			"isoDayOfWeek", int32(isoDayOfWeek(t)),
+		))
+	} else {
+		res = must.NotFail(types.NewDocument(
+			"year", int32(t.Year()),
+			"month", int32(t.Month()),
+			"day", int32(t.Day()),
+		))
+	}
+
+	res.Set("hour", int32(t.Hour()))
+	res.Set("minute", int32(t.Minute()))
+	res.Set("second", int32(t.Second()))
+	res.Set("millisecond", int32(t.Nanosecond()/int(time.Millisecond)))
+
+	return res, nil
+}
+
+// datePart describes the argument of `$dateFromParts` operator.
+type datePart struct {
+	name string
+	def  int64 // default value
+}
+
+var (
+	// naturalDateParts are `$dateFromParts` arguments for the calendar date.
+	naturalDateParts = []datePart{
+		{"year", 1970}, {"month", 1}, {"day", 1},
+		{"hour", 0}, {"minute", 0}, {"second", 0}, {"millisecond", 0},
+	}
+
+	// isoDateParts are `$dateFromParts` arguments for the ISO week date.
+	isoDateParts = []datePart{
+		{"isoWeekYear", 1970}, {"isoWeek", 1}, {"isoDayOfWeek", 1},
+		{"hour", 0}, {"minute", 0}, {"second", 0}, {"millisecond", 0},
+	}
+)
+
+// dateFromParts represents `$dateFromParts` operator.
+//
+//	{ $dateFromParts: {
+//	    year: <year>, month: <month>, day: <day>,
+//	    hour: <hour>, minute: <minute>, second: <second>, millisecond: <ms>,
+//	    timezone: <tzExpression>
+//	} }
+//	{ $dateFromParts: {
+//	    isoWeekYear: <year>, isoWeek: <week>, isoDayOfWeek: <day>,
+//	    hour: <hour>, minute: <minute>, second: <second>, millisecond: <ms>,
+//	    timezone: <tzExpression>
+//	} }
+type dateFromParts struct {
+	parts    []datePart
+	args     map[string]any
+	timezone any // nil if not set
+}
+
+// newDateFromParts returns `$dateFromParts` operator.
+func newDateFromParts(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateFromPartsInvalidSpec,
+			"$dateFromParts",
+			"$dateFromParts only supports an object as its argument",
+		)
+	}
+
+	d := &dateFromParts{
+		args: map[string]any{},
+	}
+
+	var natural, iso bool
+
+	for _, k := range spec.Keys() {
+		v := must.NotFail(spec.Get(k))
+
+		switch k {
+		case "year", "month", "day":
+			natural = true
+		case "isoWeekYear", "isoWeek", "isoDayOfWeek":
+			iso = true
+		case "hour", "minute", "second", "millisecond":
+		case "timezone":
+			d.timezone = v
+			continue
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateFromPartsUnknownArgument,
+				"$dateFromParts",
+				fmt.Sprintf("Unrecognized argument to $dateFromParts: %s", k),
+			)
+		}
+
+		d.args[k] = v
+	}
+
+	switch {
+	case natural && iso:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateFromPartsMixedDates,
+			"$dateFromParts",
+			"$dateFromParts does not allow mixing natural dates with ISO dates",
+		)
+	case !spec.Has("year") && !spec.Has("isoWeekYear"):
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateFromPartsMissingYear,
+			"$dateFromParts",
+			"$dateFromParts requires either 'year' or 'isoWeekYear' to be present",
+		)
+	case iso:
+		d.parts = isoDateParts
+	default:
+		d.parts = naturalDateParts
+	}
+
+	return d, nil
+}
+
+// Process implements Operator interface.
+//
+// Values outside of the usual ranges are carried over to the larger units,
+// for example, month 14 is February of the next year.
+func (d *dateFromParts) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	values := make([]int64, len(d.parts))
+
+	var hasNull bool
+
+	for i, p := range d.parts {
+		values[i] = p.def
+
+		expr, ok := d.args[p.name]
+		if !ok {
+			continue
+		}
+
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null {
+			hasNull = true
+			continue
+		}
+
+		n, ok := toIntegral(v)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateFromPartsNotInteger,
+				"$dateFromParts",
+				fmt.Sprintf(
+					"'%s' must evaluate to an integer, found %s with value %s",
+					p.name, handlerparams.AliasFromType(v), types.FormatAnyValue(v),
+				),
+			)
+		}
+
+		switch {
+		case i == 0 && (n < 1 || n > 9999):
+			code := handlererrors.ErrDateFromPartsYearOutOfRange
+			if p.name == "isoWeekYear" {
+				code = handlererrors.ErrDateFromPartsIsoWeekYearOutOfRange
+			}
+
+			return nil, newOperatorArgumentError(
+				code,
+				"$dateFromParts",
+				fmt.Sprintf("'%s' must evaluate to an integer in the range 1 to 9999, found %d", p.name, n),
+			)
+		case i > 0 && (n < math.MinInt16 || n > math.MaxInt16):
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateFromPartsOutOfRange,
+				"$dateFromParts",
+				fmt.Sprintf(
+					"'%s' must evaluate to a value in the range [-32768, 32767]; value %d is not in range",
+					p.name, n,
+				),
+			)
+		}
+
+		values[i] = n
+	}
+
+	loc, err := processTimezone("$dateFromParts", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if hasNull || loc == nil {
+		return types.Null, nil
+	}
+
+	year, month, day := int(values[0]), int(values[1]), int(values[2])
+
+	if d.parts[0].name == "isoWeekYear" {
+		d := isoWeekDate(year, month, day)
+		year, month, day = d.Year(), int(d.Month()), d.Day()
+	}
+
+	t := time.Date(year, time.Month(month), day, int(values[3]), int(values[4]), int(values[5]), 0, loc)
+
+	return t.Add(time.Duration(values[6]) * time.Millisecond).UTC(), nil
+}
+
+// toIntegral returns the number as int64 if it is an integral value representable as int64.
+func toIntegral(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// check interfaces
+var (
+	_ Operator = (*dateToParts)(nil)
+	_ Operator = (*dateFromParts)(nil)
+)
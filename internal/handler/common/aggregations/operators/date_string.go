@@ -0,0 +1,676 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+const (
+	// isoFormatUTC is the default `$dateToString` format for UTC timezone.
+	isoFormatUTC = "%Y-%m-%dT%H:%M:%S.%LZ"
+
+	// isoFormat is the default `$dateToString` format for other timezones.
+	isoFormat = "%Y-%m-%dT%H:%M:%S.%L"
+
+	// toStringSpecifiers contains format specifiers supported by `$dateToString`.
+	toStringSpecifiers = "bBdGHjLmMSuUVwYzZ%"
+
+	// fromStringSpecifiers contains format specifiers supported by `$dateFromString`.
+	fromStringSpecifiers = "dGHjLmMSuVYzZ%"
+)
+
+// dateToString represents `$dateToString` operator.
+//
+//	{ $dateToString: { date: <dateExpression>, format: <formatString>, timezone: <tzExpression>, onNull: <expression> } }
+type dateToString struct {
+	date     any
+	format   any // nil if not set
+	timezone any // nil if not set
+	onNull   any // nil if not set
+}
+
+// newDateToString returns `$dateToString` operator.
+func newDateToString(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateToStringInvalidSpec,
+			"$dateToString",
+			"$dateToString only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "date", "format", "timezone", "onNull":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateToStringUnknownArgument,
+				"$dateToString",
+				fmt.Sprintf("Unrecognized argument to $dateToString: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("date") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateToStringMissingDate,
+			"$dateToString",
+			"Missing 'date' parameter to $dateToString",
+		)
+	}
+
+	return &dateToString{
+		date:     must.NotFail(spec.Get("date")),
+		format:   getOptional(spec, "format"),
+		timezone: getOptional(spec, "timezone"),
+		onNull:   getOptional(spec, "onNull"),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Format and timezone are validated before null date is handled.
+func (d *dateToString) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	date, err := processExpr(d.date, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var format any
+
+	if d.format != nil {
+		if format, err = processExpr(d.format, doc, vars); err != nil {
+			return nil, err
+		}
+
+		if format != types.Null {
+			f, ok := format.(string)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrDateToStringFormatNotString,
+					"$dateToString",
+					fmt.Sprintf(
+						"$dateToString requires that 'format' be a string, found: %s with value %s",
+						handlerparams.AliasFromType(format), types.FormatAnyValue(format),
+					),
+				)
+			}
+
+			if err = validateDateFormat("$dateToString", f, toStringSpecifiers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	loc, err := processTimezone("$dateToString", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if date == types.Null {
+		if d.onNull == nil {
+			return types.Null, nil
+		}
+
+		return processExpr(d.onNull, doc, vars)
+	}
+
+	if loc == nil || format == types.Null {
+		return types.Null, nil
+	}
+
+	t, ok := toDate(date)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateConversion,
+			"$dateToString",
+			fmt.Sprintf("can't convert from BSON type %s to Date", handlerparams.AliasFromType(date)),
+		)
+	}
+
+	f, _ := format.(string)
+	if d.format == nil {
+		f = isoFormat
+		if loc == time.UTC {
+			f = isoFormatUTC
+		}
+	}
+
+	return formatDate(t.In(loc), f)
+}
+
+// formatDate formats the time using validated `$dateToString` format specifiers.
+func formatDate(t time.Time, format string) (string, error) {
+	if y := t.Year(); y < 0 || y > 9999 {
+		return "", newOperatorArgumentError(
+			handlererrors.ErrDateToStringYearOutOfRange,
+			"$dateToString",
+			fmt.Sprintf("Could not convert date to string: date component was outside the supported range of 0-9999: %d", y),
+		)
+	}
+
+	var res strings.Builder
+
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			res.WriteByte(format[i])
+			continue
+		}
+
+		i++
+
+		switch format[i] {
+		case 'b':
+			res.WriteString(t.Format("Jan"))
+		case 'B':
+			res.WriteString(t.Format("January"))
+		case 'd':
+			fmt.Fprintf(&res, "%02d", t.Day())
+		case 'G':
+			y, _ := t.ISOWeek()
+			fmt.Fprintf(&res, "%04d", y)
+		case 'H':
+			fmt.Fprintf(&res, "%02d", t.Hour())
+		case 'j':
+			fmt.Fprintf(&res, "%03d", t.YearDay())
+		case 'L':
+			fmt.Fprintf(&res, "%03d", t.Nanosecond()/int(time.Millisecond))
+		case 'm':
+			fmt.Fprintf(&res, "%02d", t.Month())
+		case 'M':
+			fmt.Fprintf(&res, "%02d", t.Minute())
+		case 'S':
+			fmt.Fprintf(&res, "%02d", t.Second())
+		case 'u':
+			fmt.Fprintf(&res, "%d", isoDayOfWeek(t))
+		case 'U':
+			fmt.Fprintf(&res, "%02d", week(t))
+		case 'V':
+			_, w := t.ISOWeek()
+			fmt.Fprintf(&res, "%02d", w)
+		case 'w':
+			fmt.Fprintf(&res, "%d", t.Weekday()+1)
+		case 'Y':
+			fmt.Fprintf(&res, "%04d", t.Year())
+		case 'z':
+			res.WriteString(t.Format("-0700"))
+		case 'Z':
+			_, offset := t.Zone()
+			fmt.Fprintf(&res, "%+d", offset/60)
+		case '%':
+			res.WriteByte('%')
+		}
+	}
+
+	return res.String(), nil
+}
+
+// validateDateFormat checks that the format of the operator with the given name
+// contains only supported specifiers.
+func validateDateFormat(name, format, specifiers string) error {
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+
+		i++
+
+		if i == len(format) {
+			return newOperatorArgumentError(
+				handlererrors.ErrDateFormatUnmatchedPercent,
+				name,
+				"Unmatched '%' at end of format string",
+			)
+		}
+
+		if !strings.ContainsRune(specifiers, rune(format[i])) {
+			return newOperatorArgumentError(
+				handlererrors.ErrDateFormatInvalidCharacter,
+				name,
+				fmt.Sprintf("Invalid format character '%%%c' in format string", format[i]),
+			)
+		}
+	}
+
+	return nil
+}
+
+// dateFromString represents `$dateFromString` operator.
+//
+//	{ $dateFromString: {
+//	    dateString: <dateStringExpression>,
+//	    format: <formatStringExpression>,
+//	    timezone: <tzExpression>,
+//	    onError: <onErrorExpression>,
+//	    onNull: <onNullExpression>
+//	} }
+type dateFromString struct {
+	dateString any
+	format     any // nil if not set
+	timezone   any // nil if not set
+	onError    any // nil if not set
+	onNull     any // nil if not set
+}
+
+// newDateFromString returns `$dateFromString` operator.
+func newDateFromString(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.MakeArray(0)
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateFromStringInvalidSpec,
+			"$dateFromString",
+			fmt.Sprintf("$dateFromString only supports an object as an argument, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "dateString", "format", "timezone", "onError", "onNull":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrDateFromStringUnknownArgument,
+				"$dateFromString",
+				fmt.Sprintf("Unrecognized argument to $dateFromString: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("dateString") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrDateFromStringMissingDateString,
+			"$dateFromString",
+			"Missing 'dateString' parameter to $dateFromString",
+		)
+	}
+
+	return &dateFromString{
+		dateString: must.NotFail(spec.Get("dateString")),
+		format:     getOptional(spec, "format"),
+		timezone:   getOptional(spec, "timezone"),
+		onError:    getOptional(spec, "onError"),
+		onNull:     getOptional(spec, "onNull"),
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Format and timezone are validated before null date string is handled,
+// conversion failures return onError value if it is set.
+func (d *dateFromString) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	dateString, err := processExpr(d.dateString, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var format any
+
+	if d.format != nil {
+		if format, err = processExpr(d.format, doc, vars); err != nil {
+			return nil, err
+		}
+
+		if format != types.Null {
+			f, ok := format.(string)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrDateFromStringFormatNotString,
+					"$dateFromString",
+					fmt.Sprintf(
+						"$dateFromString requires that 'format' be a string, found: %s with value %s",
+						handlerparams.AliasFromType(format), types.FormatAnyValue(format),
+					),
+				)
+			}
+
+			if err = validateDateFormat("$dateFromString", f, fromStringSpecifiers); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	loc, err := processTimezone("$dateFromString", d.timezone, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if dateString == types.Null {
+		if d.onNull == nil {
+			return types.Null, nil
+		}
+
+		return processExpr(d.onNull, doc, vars)
+	}
+
+	if loc == nil || format == types.Null {
+		return types.Null, nil
+	}
+
+	var t time.Time
+
+	s, ok := dateString.(string)
+	if ok {
+		f, _ := format.(string)
+		t, err = parseDate(s, f, loc, d.timezone != nil)
+	} else {
+		err = newOperatorArgumentError(
+			handlererrors.ErrConversionFailure,
+			"$dateFromString",
+			fmt.Sprintf(
+				"$dateFromString requires that 'dateString' be a string, found: %s with value %s",
+				handlerparams.AliasFromType(dateString), types.FormatAnyValue(dateString),
+			),
+		)
+	}
+
+	var opErr OperatorError
+	if d.onError != nil && errors.As(err, &opErr) && opErr.CommandCode() == handlererrors.ErrConversionFailure {
+		return processExpr(d.onError, doc, vars)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// dateStringRe matches date strings without format such as
+// 2017-02-08, 2017-02-08T12:10:40.787Z and 2017/02/08 12:10 Europe/London.
+var dateStringRe = regexp.MustCompile(
+	`^(\d{4})[-/](\d{1,2})[-/](\d{1,2})` +
+		`(?:[T ](\d{1,2}):(\d{2})(?::(\d{2})(?:\.(\d{1,9}))?)?)?` +
+		`\s*(Z|[+-]\d{2}(?::?\d{2})?|[A-Za-z_]+(?:/[A-Za-z0-9_+-]+)*)?$`,
+)
+
+// dateParts contains the date components parsed by `$dateFromString`.
+type dateParts struct {
+	year, month, day             int
+	hour, minute, second, millis int
+	yearDay                      int // set if %j is used
+	isoYear, isoWeek, isoDay     int // set if ISO week date is used
+	loc                          *time.Location
+}
+
+// parseDate parses the date string in the given location using `$dateFromString` format.
+// If the format is empty, ISO 8601 like formats are accepted.
+//
+// If the date string contains the timezone, hasTimezone must be false.
+func parseDate(s, format string, loc *time.Location, hasTimezone bool) (time.Time, error) {
+	var p *dateParts
+	var err error
+
+	if format == "" {
+		p, err = parseISODate(s)
+	} else {
+		p, err = parseFormattedDate(s, format)
+	}
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if p.loc != nil {
+		if hasTimezone {
+			return time.Time{}, newOperatorArgumentError(
+				handlererrors.ErrDateFromStringTimezoneConflict,
+				"$dateFromString",
+				fmt.Sprintf(
+					"you cannot pass in a date/time string with time zone information ('%s') together with a timezone argument",
+					s,
+				),
+			)
+		}
+
+		loc = p.loc
+	}
+
+	if p.month < 1 || p.month > 12 || p.hour > 23 || p.minute > 59 || p.second > 59 {
+		return time.Time{}, dateParseError(s, "The parsed date was invalid")
+	}
+
+	var t time.Time
+
+	switch {
+	case p.isoYear != 0:
+		d := isoWeekDate(p.isoYear, p.isoWeek, p.isoDay)
+		t = time.Date(d.Year(), d.Month(), d.Day(), p.hour, p.minute, p.second, 0, loc)
+
+	case p.yearDay != 0:
+		t = time.Date(p.year, time.January, p.yearDay, p.hour, p.minute, p.second, 0, loc)
+
+	default:
+		t = time.Date(p.year, time.Month(p.month), p.day, p.hour, p.minute, p.second, 0, loc)
+
+		if t.Day() != p.day {
+			return time.Time{}, dateParseError(s, "The parsed date was invalid")
+		}
+	}
+
+	return t.Add(time.Duration(p.millis) * time.Millisecond).UTC(), nil
+}
+
+// parseISODate parses date string without format.
+func parseISODate(s string) (*dateParts, error) {
+	m := dateStringRe.FindStringSubmatch(s)
+	if m == nil {
+		return nil, dateParseError(s, "Unexpected data found")
+	}
+
+	atoi := func(s string) int {
+		if s == "" {
+			return 0
+		}
+
+		return must.NotFail(strconv.Atoi(s))
+	}
+
+	p := &dateParts{
+		year:   atoi(m[1]),
+		month:  atoi(m[2]),
+		day:    atoi(m[3]),
+		hour:   atoi(m[4]),
+		minute: atoi(m[5]),
+		second: atoi(m[6]),
+	}
+
+	if frac := m[7]; frac != "" {
+		frac = (frac + "00")[:3]
+		p.millis = atoi(frac)
+	}
+
+	switch tz := m[8]; tz {
+	case "":
+	case "Z":
+		p.loc = time.UTC
+	default:
+		loc, err := parseTimezone("$dateFromString", tz)
+		if err != nil {
+			return nil, dateParseError(s, "The timezone could not be found in the database")
+		}
+
+		p.loc = loc
+	}
+
+	return p, nil
+}
+
+// parseFormattedDate parses date string using validated `$dateFromString` format specifiers.
+func parseFormattedDate(s, format string) (*dateParts, error) {
+	p := &dateParts{
+		year:  1970,
+		month: 1,
+		day:   1,
+	}
+
+	// number reads up to n digits from the date string
+	number := func(n int) (int, bool) {
+		var l int
+		for l < n && l < len(s) && s[l] >= '0' && s[l] <= '9' {
+			l++
+		}
+
+		if l == 0 {
+			return 0, false
+		}
+
+		v := must.NotFail(strconv.Atoi(s[:l]))
+		s = s[l:]
+
+		return v, true
+	}
+
+	original := s
+
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+
+		specifier := c == '%'
+		if specifier {
+			i++
+			c = format[i]
+			specifier = c != '%'
+		}
+
+		if !specifier {
+			if len(s) == 0 || s[0] != c {
+				return nil, dateParseError(original, "Unexpected data found")
+			}
+
+			s = s[1:]
+
+			continue
+		}
+
+		var ok bool
+
+		switch c {
+		case 'd':
+			p.day, ok = number(2)
+		case 'G':
+			p.isoYear, ok = number(4)
+		case 'H':
+			p.hour, ok = number(2)
+		case 'j':
+			p.yearDay, ok = number(3)
+		case 'L':
+			p.millis, ok = number(3)
+		case 'm':
+			p.month, ok = number(2)
+		case 'M':
+			p.minute, ok = number(2)
+		case 'S':
+			p.second, ok = number(2)
+		case 'u':
+			p.isoDay, ok = number(1)
+		case 'V':
+			p.isoWeek, ok = number(2)
+		case 'Y':
+			p.year, ok = number(4)
+		case 'z', 'Z':
+			if len(s) == 0 || (s[0] != '+' && s[0] != '-') {
+				break
+			}
+
+			sign := 1
+			if s[0] == '-' {
+				sign = -1
+			}
+
+			s = s[1:]
+
+			var offset int
+
+			if c == 'Z' {
+				offset, ok = number(4)
+				offset *= 60
+			} else {
+				var hours, minutes int
+				if hours, ok = number(2); ok {
+					s = strings.TrimPrefix(s, ":")
+					minutes, ok = number(2)
+				}
+
+				offset = (hours*60 + minutes) * 60
+			}
+
+			p.loc = time.FixedZone("", sign*offset)
+		}
+
+		if !ok {
+			return nil, dateParseError(original, "Unexpected data found")
+		}
+	}
+
+	if s != "" {
+		return nil, dateParseError(original, "Trailing data")
+	}
+
+	if p.isoYear != 0 {
+		p.isoWeek = max(p.isoWeek, 1)
+		p.isoDay = max(p.isoDay, 1)
+	}
+
+	return p, nil
+}
+
+// dateParseError returns conversion failure error for the date string.
+func dateParseError(s, reason string) error {
+	return newOperatorArgumentError(
+		handlererrors.ErrConversionFailure,
+		"$dateFromString",
+		fmt.Sprintf("Error parsing date string '%s'; %s", s, reason),
+	)
+}
+
+// getOptional returns the value of the optional operator argument or nil if it is not set.
+func getOptional(spec *types.Document, key string) any {
+	if !spec.Has(key) {
+		return nil
+	}
+
+	return must.NotFail(spec.Get(key))
+}
+
+// check interfaces
+var (
+	_ Operator = (*dateToString)(nil)
+	_ Operator = (*dateFromString)(nil)
+)
@@ -20,6 +20,7 @@ import (
 
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
@@ -36,15 +37,17 @@ type expr struct {
 // from query and $match aggregation stage. $expr operator is a top level operator and
 // cannot be used from nested expression.
 //
+// Variables are used for validating expressions accessing variables.
+//
 // It returns CommandError for invalid value of $expr operator.
-func NewExpr(exprValue *types.Document, errArgument string) (Operator, error) {
+func NewExpr(exprValue *types.Document, errArgument string, vars aggregations.Variables) (Operator, error) {
 	v := must.NotFail(exprValue.Get("$expr"))
 	e := &expr{
 		exprValue:   v,
 		errArgument: errArgument,
 	}
 
-	if err := e.validateExpr(v); err != nil {
+	if err := e.validateExpr(v, vars); err != nil {
 		return nil, err
 	}
 
@@ -52,15 +55,20 @@ func NewExpr(exprValue *types.Document, errArgument string) (Operator, error) {
 }
 
 // Process implements Operator interface.
-func (e *expr) Process(doc *types.Document) (any, error) {
-	return e.processExpr(e.exprValue, doc)
+func (e *expr) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(e.exprValue, doc, vars)
+	if err != nil {
+		return nil, processExprOperatorErrors(err, e.errArgument)
+	}
+
+	return v, nil
 }
 
 // processExpr recursively validates operators and expressions.
 // Each array values and document fields are validated recursively.
 //
 // It returns CommandError if any validation fails.
-func (e *expr) validateExpr(exprValue any) error {
+func (e *expr) validateExpr(exprValue any, vars aggregations.Variables) error {
 	switch exprValue := exprValue.(type) {
 	case *types.Document:
 		if IsOperator(exprValue) {
@@ -69,7 +77,7 @@ func (e *expr) validateExpr(exprValue any) error {
 				return processExprOperatorErrors(err, e.errArgument)
 			}
 
-			_, err = op.Process(nil)
+			_, err = op.Process(nil, vars)
 			if err != nil {
 				// TODO https://github.com/FerretDB/FerretDB/issues/3129
 				return processExprOperatorErrors(err, e.errArgument)
@@ -91,7 +99,7 @@ func (e *expr) validateExpr(exprValue any) error {
 				return lazyerrors.Error(err)
 			}
 
-			if err = e.validateExpr(v); err != nil {
+			if err = e.validateExpr(v, vars); err != nil {
 				return err
 			}
 		}
@@ -109,16 +117,23 @@ func (e *expr) validateExpr(exprValue any) error {
 				return lazyerrors.Error(err)
 			}
 
-			if err = e.validateExpr(v); err != nil {
+			if err = e.validateExpr(v, vars); err != nil {
 				return err
 			}
 		}
 	case string:
-		_, err := aggregations.NewExpression(exprValue, nil)
+		expression, err := aggregations.NewExpression(exprValue, nil)
 		var exprErr *aggregations.ExpressionError
 
 		if errors.As(err, &exprErr) && exprErr.Code() == aggregations.ErrNotExpression {
-			err = nil
+			return nil
+		}
+
+		if err == nil {
+			// only undefined variables are reported, field paths are evaluated to missing values
+			if _, err = expression.Evaluate(nil, vars); !errors.As(err, &exprErr) {
+				err = nil
+			}
 		}
 
 		if err != nil {
@@ -130,27 +145,71 @@ func (e *expr) validateExpr(exprValue any) error {
 }
 
 // processExpr recursively processes operators and expressions and returns processed `exprValue`.
+// It is also used by operators to evaluate their arguments.
+//
+// Null is returned if `exprValue` is evaluated to a missing field, see evaluate.
+func processExpr(exprValue any, doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, found, err := evaluate(exprValue, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return types.Null, nil
+	}
+
+	return v, nil
+}
+
+// processExprOrMissing is like processExpr, but it returns nil if `exprValue` is evaluated to a missing field,
+// so operators could report missing and null values differently the same way as MongoDB does.
+func processExprOrMissing(exprValue any, doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, found, err := evaluate(exprValue, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+// aliasFromType returns the alias of the value type for error messages,
+// "missing" is returned for nil value of a missing field, see processExprOrMissing.
+func aliasFromType(v any) string {
+	if v == nil {
+		return "missing"
+	}
+
+	return handlerparams.AliasFromType(v)
+}
+
+// evaluate recursively processes operators and expressions and returns processed `exprValue`.
 //
 // Each array values and document fields are processed recursively.
-// String expression is evaluated if any, and Null is returned if field is missing.
+// String expression is evaluated if any, and false is returned if field is missing.
+// Document fields evaluated to missing fields are omitted, array values evaluated to missing fields are set to null.
 // Any value that does not require processing, it returns the original value.
-func (e *expr) processExpr(exprValue any, doc *types.Document) (any, error) {
+// It is also used by operators to evaluate their arguments, see processExpr.
+func evaluate(exprValue any, doc *types.Document, vars aggregations.Variables) (any, bool, error) {
 	switch exprValue := exprValue.(type) {
 	case *types.Document:
 		if IsOperator(exprValue) {
 			op, err := NewOperator(exprValue)
 			if err != nil {
-				// $expr was validated in NewExpr
-				return nil, lazyerrors.Error(err)
+				// nested operators of other operators are validated when the top level operator
+				// is processed by validateExpr, so the error is returned as is
+				return nil, false, err
 			}
 
-			v, err := op.Process(doc)
+			v, err := op.Process(doc, vars)
 			if err != nil {
-				// Process does not return error for existing operators
-				return nil, lazyerrors.Error(err)
+				return nil, false, err
 			}
 
-			return v, nil
+			return v, true, nil
 		}
 
 		iter := exprValue.Iterator()
@@ -165,18 +224,20 @@ func (e *expr) processExpr(exprValue any, doc *types.Document) (any, error) {
 			}
 
 			if err != nil {
-				return nil, lazyerrors.Error(err)
+				return nil, false, lazyerrors.Error(err)
 			}
 
-			processed, err := e.processExpr(v, doc)
+			processed, found, err := evaluate(v, doc, vars)
 			if err != nil {
-				return nil, lazyerrors.Error(err)
+				return nil, false, err
 			}
 
-			res.Set(k, processed)
+			if found {
+				res.Set(k, processed)
+			}
 		}
 
-		return res, nil
+		return res, true, nil
 	case *types.Array:
 		iter := exprValue.Iterator()
 		defer iter.Close()
@@ -190,42 +251,47 @@ func (e *expr) processExpr(exprValue any, doc *types.Document) (any, error) {
 			}
 
 			if err != nil {
-				return nil, lazyerrors.Error(err)
+				return nil, false, lazyerrors.Error(err)
 			}
 
-			processed, err := e.processExpr(v, doc)
+			processed, err := processExpr(v, doc, vars)
 			if err != nil {
-				return nil, lazyerrors.Error(err)
+				return nil, false, err
 			}
 
 			res.Append(processed)
 		}
 
-		return res, nil
+		return res, true, nil
 	case string:
 		expression, err := aggregations.NewExpression(exprValue, nil)
 
 		var exprErr *aggregations.ExpressionError
 		if errors.As(err, &exprErr) && exprErr.Code() == aggregations.ErrNotExpression {
 			// not an expression, return the original value
-			return exprValue, nil
+			return exprValue, true, nil
 		}
 
 		if err != nil {
 			// expression error was validated in NewExpr
-			return nil, lazyerrors.Error(err)
+			return nil, false, lazyerrors.Error(err)
 		}
 
-		v, err := expression.Evaluate(doc)
+		v, err := expression.Evaluate(doc, vars)
 		if err != nil {
-			// missing field is set to null
-			return types.Null, nil
+			var exprErr *aggregations.ExpressionError
+			if errors.As(err, &exprErr) {
+				return nil, false, err
+			}
+
+			// missing field
+			return nil, false, nil
 		}
 
-		return v, nil
+		return v, true, nil
 	default:
 		// nothing to process, return the original value
-		return exprValue, nil
+		return exprValue, true, nil
 	}
 }
 
@@ -268,6 +334,12 @@ func processExprOperatorErrors(err error, argument string) error {
 				opErr.Error(),
 				argument,
 			)
+		case ErrInvalidArgument:
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				opErr.commandCode,
+				opErr.Error(),
+				argument,
+			)
 		}
 
 	case errors.As(err, &exErr):
@@ -285,10 +357,9 @@ func processExprOperatorErrors(err error, argument string) error {
 				argument,
 			)
 		case aggregations.ErrUndefinedVariable:
-			// TODO https://github.com/FerretDB/FerretDB/issues/2275
 			return handlererrors.NewCommandErrorMsgWithArgument(
-				handlererrors.ErrNotImplemented,
-				"Aggregation expression variables are not implemented yet",
+				handlererrors.ErrUndefinedVariable,
+				fmt.Sprintf("Use of undefined variable: %s", exErr.Name()),
 				argument,
 			)
 		case aggregations.ErrEmptyVariable:
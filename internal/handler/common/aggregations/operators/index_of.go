@@ -0,0 +1,191 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// indexOf represents `$indexOfBytes` and `$indexOfCP` operators.
+//
+//	{ <operator>: [ <string expression>, <substring expression>, <start>, <end> ] }
+type indexOf struct {
+	name string
+	args []any
+	cp   bool // indexes are in code points instead of bytes
+}
+
+// newIndexOfFunc returns a function creating the index operator with the given name,
+// cp defines whether indexes are in code points or in bytes.
+func newIndexOfFunc(name string, cp bool) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) < 2 || len(args) > 4 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes at least 2 arguments, and at most 4, but %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &indexOf{
+			name: name,
+			args: args,
+			cp:   cp,
+		}, nil
+	}
+}
+
+// newIndexOfBytes returns `$indexOfBytes` operator.
+var newIndexOfBytes = newIndexOfFunc("$indexOfBytes", false)
+
+// newIndexOfCP returns `$indexOfCP` operator.
+var newIndexOfCP = newIndexOfFunc("$indexOfCP", true)
+
+// Process implements Operator interface.
+func (i *indexOf) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	values := make([]any, len(i.args))
+
+	for n, arg := range i.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		values[n] = v
+	}
+
+	if values[0] == types.Null {
+		return types.Null, nil
+	}
+
+	if doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	inputCode, substringCode := handlererrors.ErrIndexOfBytesInputNotString, handlererrors.ErrIndexOfBytesSubstringNotString
+	if i.cp {
+		inputCode, substringCode = handlererrors.ErrIndexOfCPInputNotString, handlererrors.ErrIndexOfCPSubstringNotString
+	}
+
+	s, ok := values[0].(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			inputCode,
+			i.name,
+			fmt.Sprintf(
+				"%s requires a string as the first argument, found: %s",
+				i.name, handlerparams.AliasFromType(values[0]),
+			),
+		)
+	}
+
+	substring, ok := values[1].(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			substringCode,
+			i.name,
+			fmt.Sprintf(
+				"%s requires a string as the second argument, found: %s",
+				i.name, handlerparams.AliasFromType(values[1]),
+			),
+		)
+	}
+
+	length := len(s)
+	if i.cp {
+		length = utf8.RuneCountInString(s)
+	}
+
+	start, end := 0, length
+
+	if len(values) > 2 {
+		v, err := indexArgument(i.name, values[2], "starting index")
+		if err != nil {
+			return nil, err
+		}
+
+		start = v
+	}
+
+	if len(values) > 3 {
+		v, err := indexArgument(i.name, values[3], "ending index")
+		if err != nil {
+			return nil, err
+		}
+
+		end = min(v, length)
+	}
+
+	if start > length || end < start {
+		return int32(-1), nil
+	}
+
+	if i.cp {
+		window := string([]rune(s)[start:end])
+
+		pos := strings.Index(window, substring)
+		if pos < 0 {
+			return int32(-1), nil
+		}
+
+		return int32(start + utf8.RuneCountInString(window[:pos])), nil
+	}
+
+	pos := strings.Index(s[start:end], substring)
+	if pos < 0 {
+		return int32(-1), nil
+	}
+
+	return int32(start + pos), nil
+}
+
+// indexArgument validates and returns the starting or ending index argument with the given name
+// of the operator with the given name.
+func indexArgument(op string, v any, name string) (int, error) {
+	n, ok := toInt32(v)
+	if !ok {
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrIndexOfIndexNotIntegral,
+			op,
+			fmt.Sprintf(
+				"%s requires an integral %s, found a value of type: %s, with value: %s",
+				op, name, handlerparams.AliasFromType(v), types.FormatAnyValue(v),
+			),
+		)
+	}
+
+	if n < 0 {
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrIndexOfIndexNegative,
+			op,
+			fmt.Sprintf("%s requires a nonnegative %s, found: %d", op, name, n),
+		)
+	}
+
+	return int(n), nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*indexOf)(nil)
+)
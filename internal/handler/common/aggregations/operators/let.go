@@ -0,0 +1,128 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// let represents `$let` operator.
+//
+//	{ $let: { vars: { <var1>: <expression>, ... }, in: <expression> } }
+//
+// Variables are evaluated in the outer scope, so they cannot access each other.
+// The `in` expression accesses them by `$$<var1>`.
+// System variable `$$CURRENT` could also be set.
+type let struct {
+	vars *types.Document
+	in   any
+}
+
+// newLet returns `$let` operator.
+func newLet(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLetInvalidSpec,
+			"$let",
+			"$let only supports an object as its argument",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "vars", "in":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrLetUnknownParameter,
+				"$let",
+				fmt.Sprintf("Unrecognized parameter to $let: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("vars") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLetMissingVars,
+			"$let",
+			"Missing 'vars' parameter to $let",
+		)
+	}
+
+	if !spec.Has("in") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLetMissingIn,
+			"$let",
+			"Missing 'in' parameter to $let",
+		)
+	}
+
+	v := must.NotFail(spec.Get("vars"))
+
+	vars, ok := v.(*types.Document)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrIndexesWrongType,
+			"$let",
+			fmt.Sprintf("invalid parameter: expected an object (vars), found %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, name := range vars.Keys() {
+		if name == "CURRENT" {
+			continue
+		}
+
+		if err := aggregations.ValidateUserVariableName(name); err != nil {
+			return nil, newOperatorArgumentError(handlererrors.ErrFailedToParse, "$let", err.Error())
+		}
+	}
+
+	return &let{
+		vars: vars,
+		in:   must.NotFail(spec.Get("in")),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (l *let) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	scope := vars.Clone()
+
+	for _, name := range l.vars.Keys() {
+		v, err := processExpr(must.NotFail(l.vars.Get(name)), doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		scope[name] = v
+	}
+
+	return processExpr(l.in, doc, scope)
+}
+
+// check interfaces
+var (
+	_ Operator = (*let)(nil)
+)
@@ -0,0 +1,327 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// mathOp represents single argument numeric operators such as `$abs`, `$ceil` and `$sqrt`.
+//
+//	{ <operator>: <expression> }
+type mathOp struct {
+	name string
+	arg  any
+	f    func(v any) (any, error)
+}
+
+// newMathFunc returns a function creating the single argument numeric operator with the given name,
+// f computes the result for the number argument.
+func newMathFunc(name string, f func(v any) (any, error)) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 1 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 1 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &mathOp{
+			name: name,
+			arg:  args[0],
+			f:    f,
+		}, nil
+	}
+}
+
+// Process implements Operator interface.
+func (m *mathOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(m.arg, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == types.Null {
+		return types.Null, nil
+	}
+
+	if !isNumber(v) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrExpressionNotNumeric,
+			m.name,
+			fmt.Sprintf("%s only supports numeric types, not %s", m.name, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	return m.f(v)
+}
+
+// newAbs returns `$abs` operator.
+var newAbs = newMathFunc("$abs", func(v any) (any, error) {
+	switch v := v.(type) {
+	case int32:
+		if v == math.MinInt32 {
+			return -int64(v), nil
+		}
+
+		if v < 0 {
+			return -v, nil
+		}
+
+		return v, nil
+	case int64:
+		if v == math.MinInt64 {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrAbsLongMin,
+				"$abs",
+				"can't take $abs of long long min",
+			)
+		}
+
+		if v < 0 {
+			return -v, nil
+		}
+
+		return v, nil
+	default:
+		return math.Abs(toFloat64(v)), nil
+	}
+})
+
+// newCeil returns `$ceil` operator.
+var newCeil = newMathFunc("$ceil", func(v any) (any, error) {
+	if f, ok := v.(float64); ok {
+		return math.Ceil(f), nil
+	}
+
+	return v, nil
+})
+
+// newFloor returns `$floor` operator.
+var newFloor = newMathFunc("$floor", func(v any) (any, error) {
+	if f, ok := v.(float64); ok {
+		return math.Floor(f), nil
+	}
+
+	return v, nil
+})
+
+// newSqrt returns `$sqrt` operator.
+var newSqrt = newMathFunc("$sqrt", func(v any) (any, error) {
+	f := toFloat64(v)
+
+	if f < 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSqrtNegative,
+			"$sqrt",
+			"$sqrt's argument must be greater than or equal to 0",
+		)
+	}
+
+	return math.Sqrt(f), nil
+})
+
+// newExp returns `$exp` operator.
+var newExp = newMathFunc("$exp", func(v any) (any, error) {
+	return math.Exp(toFloat64(v)), nil
+})
+
+// newLn returns `$ln` operator.
+var newLn = newMathFunc("$ln", func(v any) (any, error) {
+	f := toFloat64(v)
+
+	if f <= 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLnNotPositive,
+			"$ln",
+			fmt.Sprintf("$ln's argument must be a positive number, but %s", types.FormatAnyValue(v)),
+		)
+	}
+
+	return math.Log(f), nil
+})
+
+// newLog10 returns `$log10` operator.
+var newLog10 = newMathFunc("$log10", func(v any) (any, error) {
+	f := toFloat64(v)
+
+	if f <= 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLog10NotPositive,
+			"$log10",
+			fmt.Sprintf("$log10's argument must be a positive number, but %s", types.FormatAnyValue(v)),
+		)
+	}
+
+	return math.Log10(f), nil
+})
+
+// log represents `$log` operator.
+//
+//	{ $log: [ <number>, <base> ] }
+type log struct {
+	args [2]any
+}
+
+// newLog returns `$log` operator.
+func newLog(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$log",
+			fmt.Sprintf("Expression $log takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &log{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (l *log) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, base, err := processTwoArgs(l.args, doc, vars)
+	if err != nil || v == types.Null {
+		return v, err
+	}
+
+	if !isNumber(v) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLogArgNotNumeric,
+			"$log",
+			fmt.Sprintf("$log's argument must be numeric, not %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	if !isNumber(base) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLogBaseNotNumeric,
+			"$log",
+			fmt.Sprintf("$log's base must be numeric, not %s", handlerparams.AliasFromType(base)),
+		)
+	}
+
+	f, b := toFloat64(v), toFloat64(base)
+
+	if f <= 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLogArgNotPositive,
+			"$log",
+			fmt.Sprintf("$log's argument must be a positive number, but %s", types.FormatAnyValue(v)),
+		)
+	}
+
+	if b <= 0 || b == 1 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrLogBaseInvalid,
+			"$log",
+			fmt.Sprintf("$log's base must be a positive number not equal to 1, but %s", types.FormatAnyValue(base)),
+		)
+	}
+
+	return math.Log(f) / math.Log(b), nil
+}
+
+// pow represents `$pow` operator.
+//
+//	{ $pow: [ <number>, <exponent> ] }
+type pow struct {
+	args [2]any
+}
+
+// newPow returns `$pow` operator.
+func newPow(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$pow",
+			fmt.Sprintf("Expression $pow takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &pow{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Integer powers with non-negative integer exponent are integers if they fit into long, see intResult.
+func (p *pow) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	base, exp, err := processTwoArgs(p.args, doc, vars)
+	if err != nil || base == types.Null {
+		return base, err
+	}
+
+	if !isNumber(base) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrPowBaseNotNumeric,
+			"$pow",
+			fmt.Sprintf("$pow's base must be numeric, not %s", handlerparams.AliasFromType(base)),
+		)
+	}
+
+	if !isNumber(exp) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrPowExponentNotNumeric,
+			"$pow",
+			fmt.Sprintf("$pow's exponent must be numeric, not %s", handlerparams.AliasFromType(exp)),
+		)
+	}
+
+	if toFloat64(base) == 0 && toFloat64(exp) < 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrPowZeroNegativeExponent,
+			"$pow",
+			"$pow cannot take a base of 0 and a negative exponent",
+		)
+	}
+
+	if isFloat64(base) || isFloat64(exp) {
+		return math.Pow(toFloat64(base), toFloat64(exp)), nil
+	}
+
+	b, e := toInt64(base), toInt64(exp)
+	hasInt64 := isInt64(base) || isInt64(exp)
+
+	switch {
+	case b == 1:
+		return intResult(big.NewInt(1), hasInt64), nil
+	case b == -1:
+		return intResult(big.NewInt(1-2*(e&1)), hasInt64), nil
+	case e < 0:
+		return math.Pow(float64(b), float64(e)), nil
+	case e > 64 && b != 0:
+		// the result does not fit into long
+		return math.Pow(float64(b), float64(e)), nil
+	}
+
+	return intResult(new(big.Int).Exp(big.NewInt(b), big.NewInt(e), nil), hasInt64), nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*mathOp)(nil)
+	_ Operator = (*log)(nil)
+	_ Operator = (*pow)(nil)
+)
@@ -0,0 +1,128 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+)
+
+// literal represents `$literal` operator.
+//
+//	{ $literal: <value> }
+type literal struct {
+	value any
+}
+
+// newLiteral returns `$literal` operator.
+//
+// Array argument is not splatted by NewOperator, see there.
+func newLiteral(args ...any) (Operator, error) {
+	if len(args) != 1 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$literal",
+			fmt.Sprintf("Expression $literal takes exactly 1 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &literal{
+		value: args[0],
+	}, nil
+}
+
+// Process implements Operator interface.
+func (l *literal) Process(*types.Document, aggregations.Variables) (any, error) {
+	return l.value, nil
+}
+
+// randOp represents `$rand` operator.
+//
+//	{ $rand: {} }
+type randOp struct{}
+
+// newRand returns `$rand` operator.
+func newRand(args ...any) (Operator, error) {
+	if len(args) == 1 {
+		if d, ok := args[0].(*types.Document); ok && d.Len() == 0 {
+			return new(randOp), nil
+		}
+	}
+
+	return nil, newOperatorArgumentError(
+		handlererrors.ErrRandInvalidArgument,
+		"$rand",
+		"$rand not expecting arguments",
+	)
+}
+
+// Process implements Operator interface.
+func (r *randOp) Process(*types.Document, aggregations.Variables) (any, error) {
+	return rand.Float64(), nil
+}
+
+// newBSONSize returns `$bsonSize` operator.
+var newBSONSize = newArrayFunc("$bsonSize", func(v any) (any, error) {
+	if v == nil || v == types.Null {
+		return types.Null, nil
+	}
+
+	d, ok := v.(*types.Document)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrBSONSizeNotDocument,
+			"$bsonSize",
+			fmt.Sprintf("$bsonSize requires a document input, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	bd, err := bson.ConvertDocument(d)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return int32(bson.Size(bd)), nil
+})
+
+// newBinarySize returns `$binarySize` operator.
+var newBinarySize = newArrayFunc("$binarySize", func(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, types.NullType:
+		return types.Null, nil
+	case string:
+		return int32(len(v)), nil
+	case types.Binary:
+		return int32(len(v.B)), nil
+	default:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrBinarySizeInvalidType,
+			"$binarySize",
+			fmt.Sprintf("$binarySize requires a string or BinData argument, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+})
+
+// check interfaces
+var (
+	_ Operator = (*literal)(nil)
+	_ Operator = (*randOp)(nil)
+)
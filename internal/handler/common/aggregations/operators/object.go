@@ -0,0 +1,363 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// mergeObjects represents `$mergeObjects` operator.
+//
+//	{ $mergeObjects: [ <document1>, <document2>, ... ] }
+type mergeObjects struct {
+	args []any
+}
+
+// newMergeObjects returns `$mergeObjects` operator.
+func newMergeObjects(args ...any) (Operator, error) {
+	return &mergeObjects{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (m *mergeObjects) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	res := new(types.Document)
+
+	for _, arg := range m.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null || doc == nil {
+			// null and missing values are ignored, see also expr.validateExpr
+			continue
+		}
+
+		d, ok := v.(*types.Document)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrMergeObjectsNotDocument,
+				"$mergeObjects",
+				fmt.Sprintf(
+					"$mergeObjects requires object inputs, but input %s is of type %s",
+					types.FormatAnyValue(v), handlerparams.AliasFromType(v),
+				),
+			)
+		}
+
+		for _, k := range d.Keys() {
+			res.Set(k, must.NotFail(d.Get(k)))
+		}
+	}
+
+	return res, nil
+}
+
+// getField represents `$getField` operator.
+//
+//	{ $getField: { field: <string>, input: <document> } }
+//	{ $getField: <string> }
+type getField struct {
+	field string
+	input any
+}
+
+// newGetField returns `$getField` operator.
+func newGetField(args ...any) (Operator, error) {
+	if len(args) != 1 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrGetFieldFieldNotConstant,
+			"$getField",
+			"$getField requires 'field' to evaluate to a constant, but got a non-constant argument",
+		)
+	}
+
+	spec, ok := args[0].(*types.Document)
+	if !ok || IsOperator(spec) {
+		// shorthand form, the argument is the field name of `$$CURRENT`
+		spec = must.NotFail(types.NewDocument("field", args[0]))
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "field", "input":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrGetFieldUnknownArgument,
+				"$getField",
+				fmt.Sprintf("$getField found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("field") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrGetFieldMissingField,
+			"$getField",
+			"$getField requires 'field' to be specified",
+		)
+	}
+
+	field, err := constantFieldName("$getField", must.NotFail(spec.Get("field")), [3]handlererrors.ErrorCode{
+		handlererrors.ErrGetFieldFieldPath,
+		handlererrors.ErrGetFieldFieldNotConstant,
+		handlererrors.ErrGetFieldFieldNotString,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	input, _ := spec.Get("input")
+	if input == nil {
+		input = "$$CURRENT"
+	}
+
+	return &getField{
+		field: field,
+		input: input,
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// MongoDB returns missing value if the input is not a document or the field does not exist,
+// null is returned instead.
+func (g *getField) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	input, err := processExpr(g.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	d, ok := input.(*types.Document)
+	if !ok {
+		return types.Null, nil
+	}
+
+	v, err := d.Get(g.field)
+	if err != nil {
+		return types.Null, nil
+	}
+
+	return v, nil
+}
+
+// setField represents `$setField` and `$unsetField` operators.
+//
+//	{ $setField: { field: <string>, input: <document>, value: <expression> } }
+//	{ $unsetField: { field: <string>, input: <document> } }
+type setField struct {
+	name  string
+	field string
+	input any
+	value any // nil for `$unsetField`
+}
+
+// newSetField returns `$setField` operator.
+func newSetField(args ...any) (Operator, error) {
+	return newSetFieldOp("$setField", args)
+}
+
+// newUnsetField returns `$unsetField` operator.
+func newUnsetField(args ...any) (Operator, error) {
+	return newSetFieldOp("$unsetField", args)
+}
+
+// newSetFieldOp returns `$setField` or `$unsetField` operator with the given name.
+func newSetFieldOp(name string, args []any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetFieldNotDocument,
+			name,
+			fmt.Sprintf("%s only supports an object as its argument", name),
+		)
+	}
+
+	unset := name == "$unsetField"
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "field", "input":
+		case "value":
+			if !unset {
+				break
+			}
+
+			fallthrough
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSetFieldUnknownArgument,
+				name,
+				fmt.Sprintf("%s found an unknown argument: %s", name, k),
+			)
+		}
+	}
+
+	if !spec.Has("field") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetFieldMissingField,
+			name,
+			fmt.Sprintf("%s requires 'field' to be specified", name),
+		)
+	}
+
+	if !unset && !spec.Has("value") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetFieldMissingValue,
+			name,
+			fmt.Sprintf("%s requires 'value' to be specified", name),
+		)
+	}
+
+	if !spec.Has("input") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetFieldMissingInput,
+			name,
+			fmt.Sprintf("%s requires 'input' to be specified", name),
+		)
+	}
+
+	field, err := constantFieldName(name, must.NotFail(spec.Get("field")), [3]handlererrors.ErrorCode{
+		handlererrors.ErrSetFieldFieldPath,
+		handlererrors.ErrSetFieldFieldNotConstant,
+		handlererrors.ErrSetFieldFieldNotString,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &setField{
+		name:  name,
+		field: field,
+		input: must.NotFail(spec.Get("input")),
+	}
+
+	if !unset {
+		s.value = must.NotFail(spec.Get("value"))
+	}
+
+	return s, nil
+}
+
+// Process implements Operator interface.
+func (s *setField) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	input, err := processExpr(s.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	var found bool
+
+	if s.value != nil {
+		if value, found, err = evaluate(s.value, doc, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	if input == types.Null || doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	d, ok := input.(*types.Document)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetFieldInputNotDocument,
+			s.name,
+			fmt.Sprintf("%s requires 'input' to evaluate to type Object", s.name),
+		)
+	}
+
+	res := d.DeepCopy()
+
+	// values evaluated to missing, such as `$$REMOVE`, remove the field
+	if !found {
+		res.Remove(s.field)
+		return res, nil
+	}
+
+	res.Set(s.field, value)
+
+	return res, nil
+}
+
+// constantFieldName returns the field name argument of the operator with the given name.
+// The argument must be a string constant or `$literal` of a string,
+// codes are used for field path, non-constant and non-string arguments respectively.
+func constantFieldName(name string, field any, codes [3]handlererrors.ErrorCode) (string, error) {
+	if d, ok := field.(*types.Document); ok && d.Len() == 1 && d.Has("$literal") {
+		field = must.NotFail(d.Get("$literal"))
+	} else {
+		switch f := field.(type) {
+		case string:
+			if strings.HasPrefix(f, "$") {
+				return "", newOperatorArgumentError(
+					codes[0],
+					name,
+					fmt.Sprintf(
+						"A field path reference which is not allowed in this context. Did you mean {$literal: '%s'}?",
+						f,
+					),
+				)
+			}
+		case *types.Document, *types.Array:
+			return "", newOperatorArgumentError(
+				codes[1],
+				name,
+				fmt.Sprintf("%s requires 'field' to evaluate to a constant, but got a non-constant argument", name),
+			)
+		}
+	}
+
+	f, ok := field.(string)
+	if !ok {
+		return "", newOperatorArgumentError(
+			codes[2],
+			name,
+			fmt.Sprintf(
+				"%s requires 'field' to evaluate to type String, but got %s",
+				name, handlerparams.AliasFromType(field),
+			),
+		)
+	}
+
+	return f, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*mergeObjects)(nil)
+	_ Operator = (*getField)(nil)
+	_ Operator = (*setField)(nil)
+)
@@ -26,6 +26,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
@@ -45,7 +46,8 @@ type newOperatorFunc func(args ...any) (Operator, error)
 // Operator is a common interface for standard aggregation operators.
 type Operator interface {
 	// Process document and returns the result of applying operator.
-	Process(in *types.Document) (any, error)
+	// Variables are used for evaluating expressions accessing variables.
+	Process(in *types.Document, vars aggregations.Variables) (any, error)
 }
 
 // IsOperator returns true if provided document should be
@@ -101,7 +103,8 @@ func NewOperator(doc *types.Document) (Operator, error) {
 
 	var args []any
 
-	if arr, ok := expr.(*types.Array); ok {
+	// `$literal` argument is not evaluated, so its array is passed as is
+	if arr, ok := expr.(*types.Array); ok && operator != "$literal" {
 		iter := arr.Iterator()
 		defer iter.Close()
 
@@ -139,161 +142,155 @@ func NewOperator(doc *types.Document) (Operator, error) {
 // Operators maps all standard aggregation operators.
 var Operators = map[string]newOperatorFunc{
 	// sorted alphabetically
-	"$sum":  newSum,
-	"$type": newType,
+	"$abs":              newAbs,
+	"$acos":             newAcos,
+	"$acosh":            newAcosh,
+	"$add":              newAdd,
+	"$allElementsTrue":  newAllElementsTrue,
+	"$and":              newAnd,
+	"$anyElementTrue":   newAnyElementTrue,
+	"$arrayElemAt":      newArrayElemAt,
+	"$arrayToObject":    newArrayToObject,
+	"$asin":             newAsin,
+	"$asinh":            newAsinh,
+	"$atan":             newAtan,
+	"$atan2":            newAtan2,
+	"$atanh":            newAtanh,
+	"$binarySize":       newBinarySize,
+	"$bsonSize":         newBSONSize,
+	"$ceil":             newCeil,
+	"$cmp":              newCmp,
+	"$concat":           newConcat,
+	"$concatArrays":     newConcatArrays,
+	"$cond":             newCond,
+	"$convert":          newConvert,
+	"$cos":              newCos,
+	"$cosh":             newCosh,
+	"$dateAdd":          newDateAdd,
+	"$dateDiff":         newDateDiff,
+	"$dateFromParts":    newDateFromParts,
+	"$dateFromString":   newDateFromString,
+	"$dateSubtract":     newDateSubtract,
+	"$dateToParts":      newDateToParts,
+	"$dateToString":     newDateToString,
+	"$dateTrunc":        newDateTrunc,
+	"$dayOfMonth":       newDayOfMonth,
+	"$dayOfWeek":        newDayOfWeek,
+	"$dayOfYear":        newDayOfYear,
+	"$degreesToRadians": newDegreesToRadians,
+	"$divide":           newDivide,
+	"$eq":               newEq,
+	"$exp":              newExp,
+	"$filter":           newFilter,
+	"$first":            newFirst,
+	"$floor":            newFloor,
+	"$getField":         newGetField,
+	"$gt":               newGt,
+	"$gte":              newGte,
+	"$hour":             newHour,
+	"$ifNull":           newIfNull,
+	"$in":               newIn,
+	"$indexOfArray":     newIndexOfArray,
+	"$indexOfBytes":     newIndexOfBytes,
+	"$indexOfCP":        newIndexOfCP,
+	"$isArray":          newIsArray,
+	"$isNumber":         newIsNumber,
+	"$isoDayOfWeek":     newIsoDayOfWeek,
+	"$isoWeek":          newIsoWeek,
+	"$isoWeekYear":      newIsoWeekYear,
+	"$last":             newLast,
+	"$let":              newLet,
+	"$literal":          newLiteral,
+	"$ln":               newLn,
+	"$log":              newLog,
+	"$log10":            newLog10,
+	"$lt":               newLt,
+	"$lte":              newLte,
+	"$ltrim":            newLtrim,
+	"$map":              newMap,
+	"$mergeObjects":     newMergeObjects,
+	"$millisecond":      newMillisecond,
+	"$minute":           newMinute,
+	"$mod":              newMod,
+	"$month":            newMonth,
+	"$multiply":         newMultiply,
+	"$ne":               newNe,
+	"$not":              newNot,
+	"$objectToArray":    newObjectToArray,
+	"$or":               newOr,
+	"$pow":              newPow,
+	"$radiansToDegrees": newRadiansToDegrees,
+	"$rand":             newRand,
+	"$range":            newRange,
+	"$reduce":           newReduce,
+	"$regexFind":        newRegexFind,
+	"$regexFindAll":     newRegexFindAll,
+	"$regexMatch":       newRegexMatch,
+	"$replaceAll":       newReplaceAll,
+	"$replaceOne":       newReplaceOne,
+	"$reverseArray":     newReverseArray,
+	"$round":            newRound,
+	"$rtrim":            newRtrim,
+	"$second":           newSecond,
+	"$setDifference":    newSetDifference,
+	"$setEquals":        newSetEquals,
+	"$setField":         newSetField,
+	"$setIntersection":  newSetIntersection,
+	"$setIsSubset":      newSetIsSubset,
+	"$setUnion":         newSetUnion,
+	"$sin":              newSin,
+	"$sinh":             newSinh,
+	"$size":             newSize,
+	"$slice":            newSlice,
+	"$sortArray":        newSortArray,
+	"$split":            newSplit,
+	"$sqrt":             newSqrt,
+	"$strLenBytes":      newStrLenBytes,
+	"$strLenCP":         newStrLenCP,
+	"$strcasecmp":       newStrcasecmp,
+	"$substr":           newSubstr,
+	"$substrBytes":      newSubstrBytes,
+	"$substrCP":         newSubstrCP,
+	"$subtract":         newSubtract,
+	"$sum":              newSum,
+	"$switch":           newSwitch,
+	"$tan":              newTan,
+	"$tanh":             newTanh,
+	"$toBool":           newToBool,
+	"$toDate":           newToDate,
+	"$toDouble":         newToDouble,
+	"$toInt":            newToInt,
+	"$toLong":           newToLong,
+	"$toLower":          newToLower,
+	"$toObjectId":       newToObjectID,
+	"$toString":         newToString,
+	"$toUpper":          newToUpper,
+	"$trim":             newTrim,
+	"$trunc":            newTrunc,
+	"$type":             newType,
+	"$unsetField":       newUnsetField,
+	"$week":             newWeek,
+	"$year":             newYear,
+	"$zip":              newZip,
 	// please keep sorted alphabetically
 }
 
 // unsupportedOperators maps all unsupported yet operators.
 var unsupportedOperators = map[string]struct{}{
 	// sorted alphabetically
-	"$abs":              {},
-	"$acos":             {},
-	"$acosh":            {},
-	"$add":              {},
-	"$allElementsTrue":  {},
-	"$and":              {},
-	"$anyElementTrue":   {},
-	"$arrayElemAt":      {},
-	"$arrayToObject":    {},
-	"$asin":             {},
-	"$asinh":            {},
-	"$atan":             {},
-	"$atan2":            {},
-	"$atanh":            {},
-	"$avg":              {},
-	"$binarySize":       {},
-	"$bsonSize":         {},
-	"$ceil":             {},
-	"$cmp":              {},
-	"$concat":           {},
-	"$concatArrays":     {},
-	"$cond":             {},
-	"$convert":          {},
-	"$cos":              {},
-	"$cosh":             {},
-	"$covariancePop":    {},
-	"$covarianceSamp":   {},
-	"$dateAdd":          {},
-	"$dateDiff":         {},
-	"$dateFromParts":    {},
-	"$dateSubtract":     {},
-	"$dateTrunc":        {},
-	"$dateToParts":      {},
-	"$dateFromString":   {},
-	"$dateToString":     {},
-	"$dayOfMonth":       {},
-	"$dayOfWeek":        {},
-	"$dayOfYear":        {},
-	"$degreesToRadians": {},
-	"$denseRank":        {},
-	"$derivative":       {},
-	"$divide":           {},
-	"$documentNumber":   {},
-	"$eq":               {},
-	"$exp":              {},
-	"$expMovingAvg":     {},
-	"$filter":           {},
-	"$floor":            {},
-	"$function":         {},
-	"$getField":         {},
-	"$gt":               {},
-	"$gte":              {},
-	"$hour":             {},
-	"$ifNull":           {},
-	"$in":               {},
-	"$indexOfArray":     {},
-	"$indexOfBytes":     {},
-	"$indexOfCP":        {},
-	"$integral":         {},
-	"$isArray":          {},
-	"$isNumber":         {},
-	"$isoDayOfWeek":     {},
-	"$isoWeek":          {},
-	"$isoWeekYear":      {},
-	"$let":              {},
-	"$linearFill":       {},
-	"$literal":          {},
-	"$ln":               {},
-	"$locf":             {},
-	"$log":              {},
-	"$log10":            {},
-	"$lt":               {},
-	"$lte":              {},
-	"$ltrim":            {},
-	"$map":              {},
-	"$max":              {},
-	"$meta":             {},
-	"$min":              {},
-	"$minN":             {},
-	"$millisecond":      {},
-	"$minute":           {},
-	"$mod":              {},
-	"$month":            {},
-	"$multiply":         {},
-	"$ne":               {},
-	"$not":              {},
-	"$objectToArray":    {},
-	"$or":               {},
-	"$pow":              {},
-	"$radiansToDegrees": {},
-	"$rand":             {},
-	"$range":            {},
-	"$rank":             {},
-	"$reduce":           {},
-	"$regexFind":        {},
-	"$regexFindAll":     {},
-	"$regexMatch":       {},
-	"$replaceOne":       {},
-	"$replaceAll":       {},
-	"$reverseArray":     {},
-	"$round":            {},
-	"$rtrim":            {},
-	"$sampleRate":       {},
-	"$second":           {},
-	"$setDifference":    {},
-	"$setEquals":        {},
-	"$setField":         {},
-	"$setIntersection":  {},
-	"$setIsSubset":      {},
-	"$setUnion":         {},
-	"$shift":            {},
-	"$size":             {},
-	"$sin":              {},
-	"$sinh":             {},
-	"$slice":            {},
-	"$sortArray":        {},
-	"$split":            {},
-	"$sqrt":             {},
-	"$stdDevPop":        {},
-	"$stdDevSamp":       {},
-	"$strcasecmp":       {},
-	"$strLenBytes":      {},
-	"$strLenCP":         {},
-	"$substr":           {},
-	"$substrBytes":      {},
-	"$substrCP":         {},
-	"$subtract":         {},
-	"$switch":           {},
-	"$tan":              {},
-	"$tanh":             {},
-	"$toBool":           {},
-	"$toDate":           {},
-	"$toDecimal":        {},
-	"$toDouble":         {},
-	"$toInt":            {},
-	"$toLong":           {},
-	"$toObjectId":       {},
-	"$toString":         {},
-	"$toLower":          {},
-	"$toUpper":          {},
-	"$trim":             {},
-	"$trunc":            {},
-	"$tsIncrement":      {},
-	"$tsSecond":         {},
-	"$unsetField":       {},
-	"$week":             {},
-	"$year":             {},
-	"$zip":              {},
+	"$avg":            {},
+	"$covariancePop":  {},
+	"$covarianceSamp": {},
+	"$function":       {},
+	"$max":            {},
+	"$meta":           {},
+	"$min":            {},
+	"$minN":           {},
+	"$sampleRate":     {},
+	"$stdDevPop":      {},
+	"$stdDevSamp":     {},
+	"$toDecimal":      {},
+	"$tsIncrement":    {},
+	"$tsSecond":       {},
 	// please keep sorted alphabetically
 }
@@ -14,6 +14,8 @@
 
 package operators
 
+import "github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+
 // operatorErrorCode represents the type of error.
 type operatorErrorCode uint
 
@@ -34,6 +36,10 @@ const (
 
 	// ErrInvalidNestedExpression indicates that operator inside the target operator does not exist.
 	ErrInvalidNestedExpression
+
+	// ErrInvalidArgument indicates that operator argument is invalid,
+	// command error code compatible with MongoDB is set by newOperatorArgumentError.
+	ErrInvalidArgument
 )
 
 // newOperatorError returns new OperatorError.
@@ -45,11 +51,23 @@ func newOperatorError(code operatorErrorCode, name, msg string) error {
 	}
 }
 
+// newOperatorArgumentError returns new OperatorError with ErrInvalidArgument code
+// and the given command error code returned to the client.
+func newOperatorArgumentError(commandCode handlererrors.ErrorCode, name, msg string) error {
+	return OperatorError{
+		code:        ErrInvalidArgument,
+		commandCode: commandCode,
+		name:        name,
+		msg:         msg,
+	}
+}
+
 // OperatorError is used for reporting operator errors.
 type OperatorError struct {
-	msg  string
-	name string
-	code operatorErrorCode
+	msg         string
+	name        string
+	code        operatorErrorCode
+	commandCode handlererrors.ErrorCode // set only for ErrInvalidArgument
 }
 
 // Error implements error interface.
@@ -62,6 +80,11 @@ func (opErr OperatorError) Code() operatorErrorCode {
 	return opErr.code
 }
 
+// CommandCode returns the command error code of ErrInvalidArgument error.
+func (opErr OperatorError) CommandCode() handlererrors.ErrorCode {
+	return opErr.commandCode
+}
+
 // Name returns the name of the operator (e.g. $sum) that produced an error.
 func (opErr OperatorError) Name() string {
 	return opErr.name
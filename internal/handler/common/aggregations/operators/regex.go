@@ -0,0 +1,344 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// regexMode represents the result returned by the regular expression operator.
+type regexMode int
+
+const (
+	regexModeMatch   regexMode = iota // `$regexMatch` returns whether the input matches
+	regexModeFind                     // `$regexFind` returns the first match
+	regexModeFindAll                  // `$regexFindAll` returns all matches
+)
+
+// unsupportedRegexSyntax lists PCRE syntax supported by MongoDB but not by Go regular expressions.
+// It is only checked if the pattern fails to compile with the given error.
+var unsupportedRegexSyntax = []struct {
+	err     error
+	syntax  []string
+	feature string
+}{
+	{types.ErrUnsupportedPerlOp, []string{"(?=", "(?!"}, "lookaround assertions"},
+	{types.ErrMissingTerminator, []string{"(?<=", "(?<!"}, "lookaround assertions"},
+	{types.ErrUnsupportedPerlOp, []string{"(?>"}, "atomic groups"},
+	{types.ErrInvalidEscape, []string{`\1`, `\2`, `\3`, `\4`, `\5`, `\6`, `\7`, `\8`, `\9`, `\g`, `\k`}, "backreferences"},
+	{types.ErrNothingToRepeat, []string{"++", "*+", "?+", "}+"}, "possessive quantifiers"},
+}
+
+// regexOp represents `$regexMatch`, `$regexFind` and `$regexFindAll` operators.
+//
+//	{ <operator>: { input: <expression>, regex: <expression>, options: <expression> } }
+type regexOp struct {
+	name    string
+	mode    regexMode
+	input   any
+	regex   any
+	options any // nil if not set
+}
+
+// newRegexFunc returns a function creating the regular expression operator with the given name and mode.
+func newRegexFunc(name string, mode regexMode) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		var spec *types.Document
+		if len(args) == 1 {
+			spec, _ = args[0].(*types.Document)
+		}
+
+		if spec == nil {
+			var v any = types.Null
+			if len(args) == 1 {
+				v = args[0]
+			}
+
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrRegexOpNotDocument,
+				name,
+				fmt.Sprintf(
+					"%s expects an object of named arguments but found: %s",
+					name, handlerparams.AliasFromType(v),
+				),
+			)
+		}
+
+		for _, k := range spec.Keys() {
+			switch k {
+			case "input", "regex", "options":
+			default:
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrRegexOpUnknownArgument,
+					name,
+					fmt.Sprintf("%s found an unknown argument: %s", name, k),
+				)
+			}
+		}
+
+		if !spec.Has("input") {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrRegexOpMissingInput,
+				name,
+				fmt.Sprintf("%s requires 'input' parameter", name),
+			)
+		}
+
+		if !spec.Has("regex") {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrRegexOpMissingRegex,
+				name,
+				fmt.Sprintf("%s requires 'regex' parameter", name),
+			)
+		}
+
+		options, _ := spec.Get("options")
+
+		return &regexOp{
+			name:    name,
+			mode:    mode,
+			input:   must.NotFail(spec.Get("input")),
+			regex:   must.NotFail(spec.Get("regex")),
+			options: options,
+		}, nil
+	}
+}
+
+// newRegexMatch returns `$regexMatch` operator.
+var newRegexMatch = newRegexFunc("$regexMatch", regexModeMatch)
+
+// newRegexFind returns `$regexFind` operator.
+var newRegexFind = newRegexFunc("$regexFind", regexModeFind)
+
+// newRegexFindAll returns `$regexFindAll` operator.
+//
+// Unlike MongoDB, empty matches immediately after a previous match are not returned.
+var newRegexFindAll = newRegexFunc("$regexFindAll", regexModeFindAll)
+
+// Process implements Operator interface.
+func (r *regexOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	input, err := processExpr(r.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := processExpr(r.regex, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var options any = types.Null
+	if r.options != nil {
+		if options, err = processExpr(r.options, doc, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	s, isString := input.(string)
+	if !isString && input != types.Null {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRegexOpInputNotString,
+			r.name,
+			fmt.Sprintf("%s needs 'input' to be of type string", r.name),
+		)
+	}
+
+	re, err := r.compile(pattern, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if re == nil || !isString || doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		switch r.mode {
+		case regexModeMatch:
+			return false, nil
+		case regexModeFind:
+			return types.Null, nil
+		case regexModeFindAll:
+			return types.MakeArray(0), nil
+		}
+	}
+
+	switch r.mode {
+	case regexModeMatch:
+		return re.MatchString(s), nil
+
+	case regexModeFind:
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return types.Null, nil
+		}
+
+		return regexMatchDocument(s, loc), nil
+
+	case regexModeFindAll:
+		locs := re.FindAllStringSubmatchIndex(s, -1)
+
+		res := types.MakeArray(len(locs))
+		for _, loc := range locs {
+			res.Append(regexMatchDocument(s, loc))
+		}
+
+		return res, nil
+
+	default:
+		panic(fmt.Sprintf("unexpected regex mode %d", r.mode))
+	}
+}
+
+// compile returns the compiled regular expression for the given evaluated regex and options arguments.
+// Nil is returned if regex is null.
+func (r *regexOp) compile(pattern, options any) (*regexp.Regexp, error) {
+	var regex types.Regex
+
+	switch pattern := pattern.(type) {
+	case types.NullType:
+	case string:
+		regex.Pattern = pattern
+	case types.Regex:
+		regex = pattern
+	default:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRegexOpRegexInvalidType,
+			r.name,
+			fmt.Sprintf("%s needs 'regex' to be of type string or regex", r.name),
+		)
+	}
+
+	switch options := options.(type) {
+	case types.NullType:
+	case string:
+		if regex.Options != "" {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrRegexOpOptionsConflict,
+				r.name,
+				fmt.Sprintf("%s: found regex option(s) specified in both 'regex' and 'option' fields", r.name),
+			)
+		}
+
+		regex.Options = options
+	default:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRegexOpOptionsNotString,
+			r.name,
+			fmt.Sprintf("%s needs 'options' to be of type string", r.name),
+		)
+	}
+
+	if pattern == types.Null {
+		return nil, nil
+	}
+
+	if strings.ContainsRune(regex.Pattern, 0) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRegexOpNullByte,
+			r.name,
+			fmt.Sprintf("%s: regular expression cannot contain an embedded null byte", r.name),
+		)
+	}
+
+	if strings.ContainsRune(regex.Options, 0) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRegexOpOptionsNullByte,
+			r.name,
+			fmt.Sprintf("%s: regular expression options cannot contain an embedded null byte", r.name),
+		)
+	}
+
+	for _, o := range regex.Options {
+		switch o {
+		case 'i', 'm', 's', 'x', 'u':
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrBadRegexOption,
+				r.name,
+				fmt.Sprintf("%s invalid flag in regex options: %c", r.name, o),
+			)
+		}
+	}
+
+	re, err := regex.Compile()
+	if err == nil {
+		return re, nil
+	}
+
+	if errors.Is(err, types.ErrOptionNotImplemented) {
+		return nil, newOperatorError(
+			ErrNotImplemented,
+			r.name,
+			fmt.Sprintf("%s option 'x' is not implemented yet", r.name),
+		)
+	}
+
+	for _, u := range unsupportedRegexSyntax {
+		if !errors.Is(err, u.err) {
+			continue
+		}
+
+		for _, syntax := range u.syntax {
+			if strings.Contains(regex.Pattern, syntax) {
+				return nil, newOperatorError(
+					ErrNotImplemented,
+					r.name,
+					fmt.Sprintf("%s does not support %s in regular expressions", r.name, u.feature),
+				)
+			}
+		}
+	}
+
+	return nil, newOperatorArgumentError(
+		handlererrors.ErrRegexOpInvalid,
+		r.name,
+		fmt.Sprintf("Invalid Regex in %s: %s", r.name, strings.TrimPrefix(err.Error(), "Regular expression is invalid: ")),
+	)
+}
+
+// regexMatchDocument returns the match document of `$regexFind` and `$regexFindAll` operators
+// for the given input and submatch indexes.
+// Index of the match is in code points, captures not participating in the match are null.
+func regexMatchDocument(s string, loc []int) *types.Document {
+	captures := types.MakeArray(len(loc)/2 - 1)
+
+	for i := 2; i < len(loc); i += 2 {
+		if loc[i] < 0 {
+			captures.Append(types.Null)
+			continue
+		}
+
+		captures.Append(s[loc[i]:loc[i+1]])
+	}
+
+	return must.NotFail(types.NewDocument(
+		"match", s[loc[0]:loc[1]],
+		"idx", int32(utf8.RuneCountInString(s[:loc[0]])),
+		"captures", captures,
+	))
+}
+
+// check interfaces
+var (
+	_ Operator = (*regexOp)(nil)
+)
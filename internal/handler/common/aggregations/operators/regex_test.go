@@ -0,0 +1,70 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestRegexUnsupportedSyntax(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		regex string
+		code  operatorErrorCode
+	}{
+		"Lookahead": {
+			regex: "a(?=b)",
+			code:  ErrNotImplemented,
+		},
+		"NegativeLookahead": {
+			regex: "a(?!b)",
+			code:  ErrNotImplemented,
+		},
+		"Lookbehind": {
+			regex: "(?<=a)b",
+			code:  ErrNotImplemented,
+		},
+		"NegativeLookbehind": {
+			regex: "(?<!a)b",
+			code:  ErrNotImplemented,
+		},
+		"NamedCapture": {
+			regex: "(?<a)b",
+			code:  ErrInvalidArgument,
+		},
+	} {
+		name, tc := name, tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			op, err := newRegexMatch(must.NotFail(types.NewDocument("input", "ab", "regex", tc.regex)))
+			require.NoError(t, err)
+
+			_, err = op.Process(must.NotFail(types.NewDocument()), aggregations.NewVariables())
+
+			var opErr OperatorError
+			require.ErrorAs(t, err, &opErr)
+			assert.Equal(t, tc.code, opErr.Code())
+		})
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// replace represents `$replaceOne` and `$replaceAll` operators.
+//
+//	{ <operator>: { input: <expression>, find: <expression>, replacement: <expression> } }
+type replace struct {
+	name        string
+	input       any
+	find        any
+	replacement any
+	all         bool
+}
+
+// newReplaceFunc returns a function creating the replace operator with the given name,
+// all defines whether all occurrences are replaced or only the first one.
+func newReplaceFunc(name string, all bool) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		var spec *types.Document
+		if len(args) == 1 {
+			spec, _ = args[0].(*types.Document)
+		}
+
+		if spec == nil {
+			var v any = types.MakeArray(0)
+			if len(args) == 1 {
+				v = args[0]
+			}
+
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrReplaceInvalidSpec,
+				name,
+				fmt.Sprintf("%s requires an object as an argument, found: %s", name, handlerparams.AliasFromType(v)),
+			)
+		}
+
+		for _, k := range spec.Keys() {
+			switch k {
+			case "input", "find", "replacement":
+			default:
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrReplaceUnknownArgument,
+					name,
+					fmt.Sprintf("%s found an unknown argument: %s", name, k),
+				)
+			}
+		}
+
+		for _, p := range []struct {
+			key  string
+			code handlererrors.ErrorCode
+		}{
+			{"input", handlererrors.ErrReplaceMissingInput},
+			{"find", handlererrors.ErrReplaceMissingFind},
+			{"replacement", handlererrors.ErrReplaceMissingReplacement},
+		} {
+			if !spec.Has(p.key) {
+				return nil, newOperatorArgumentError(
+					p.code,
+					name,
+					fmt.Sprintf("%s requires '%s' to be specified", name, p.key),
+				)
+			}
+		}
+
+		return &replace{
+			name:        name,
+			input:       must.NotFail(spec.Get("input")),
+			find:        must.NotFail(spec.Get("find")),
+			replacement: must.NotFail(spec.Get("replacement")),
+			all:         all,
+		}, nil
+	}
+}
+
+// newReplaceOne returns `$replaceOne` operator.
+var newReplaceOne = newReplaceFunc("$replaceOne", false)
+
+// newReplaceAll returns `$replaceAll` operator.
+var newReplaceAll = newReplaceFunc("$replaceAll", true)
+
+// Process implements Operator interface.
+//
+// All arguments are validated before null is returned for any null argument.
+func (r *replace) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var strs [3]string
+	var hasNull bool
+
+	for i, p := range []struct {
+		expr any
+		key  string
+		code handlererrors.ErrorCode
+	}{
+		{r.input, "input", handlererrors.ErrReplaceInputNotString},
+		{r.find, "find", handlererrors.ErrReplaceFindNotString},
+		{r.replacement, "replacement", handlererrors.ErrReplaceReplacementNotString},
+	} {
+		v, err := processExpr(p.expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case types.NullType:
+			hasNull = true
+		case string:
+			strs[i] = v
+		default:
+			return nil, newOperatorArgumentError(
+				p.code,
+				r.name,
+				fmt.Sprintf("%s requires that '%s' be a string, found: %s", r.name, p.key, types.FormatAnyValue(v)),
+			)
+		}
+	}
+
+	if hasNull {
+		return types.Null, nil
+	}
+
+	if r.all {
+		return strings.ReplaceAll(strs[0], strs[1], strs[2]), nil
+	}
+
+	return strings.Replace(strs[0], strs[1], strs[2], 1), nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*replace)(nil)
+)
@@ -0,0 +1,175 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// roundOp represents `$round` and `$trunc` operators.
+//
+//	{ <operator>: [ <number>, <place> ] }
+//
+// Place defaults to 0, it must be an integer in range [-20, 100].
+// `$round` rounds half to even, `$trunc` rounds toward zero.
+type roundOp struct {
+	name  string
+	args  [2]any
+	trunc bool
+}
+
+// newRoundFunc returns a function creating `$round` or `$trunc` operator.
+func newRoundFunc(name string, trunc bool) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) < 1 || len(args) > 2 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes at least 1 arguments, and at most 2, but %d were passed in.", name, len(args)),
+			)
+		}
+
+		op := &roundOp{
+			name:  name,
+			args:  [2]any{args[0], int32(0)},
+			trunc: trunc,
+		}
+
+		if len(args) == 2 {
+			op.args[1] = args[1]
+		}
+
+		return op, nil
+	}
+}
+
+// newRound returns `$round` operator.
+var newRound = newRoundFunc("$round", false)
+
+// newTrunc returns `$trunc` operator.
+var newTrunc = newRoundFunc("$trunc", true)
+
+// Process implements Operator interface.
+func (r *roundOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, placeValue, err := processTwoArgs(r.args, doc, vars)
+	if err != nil || v == types.Null {
+		return v, err
+	}
+
+	if !isNumber(v) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRoundInvalidType,
+			r.name,
+			fmt.Sprintf("%s only supports numeric types, not %s", r.name, handlerparams.AliasFromType(v)),
+		)
+	}
+
+	place, err := handlerparams.GetWholeNumberParam(placeValue)
+	if err != nil {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRoundPlaceNotIntegral,
+			r.name,
+			fmt.Sprintf("precision argument to %s must be a integral value", r.name),
+		)
+	}
+
+	if place < -20 || place > 100 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrRoundPlaceOutOfRange,
+			r.name,
+			fmt.Sprintf("cannot apply %s with precision value %d value must be in [-20, 100]", r.name, place),
+		)
+	}
+
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(abs(place)), nil)
+
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return v, nil
+		}
+
+		// the same as MongoDB, use 15 significant digits of the double
+		n, _ := new(big.Rat).SetString(strconv.FormatFloat(v, 'e', 14, 64))
+
+		if place >= 0 {
+			n.Mul(n, new(big.Rat).SetInt(scale))
+			n.SetInt(roundRat(n, r.trunc))
+			n.Quo(n, new(big.Rat).SetInt(scale))
+		} else {
+			n.Quo(n, new(big.Rat).SetInt(scale))
+			n.SetInt(roundRat(n, r.trunc))
+			n.Mul(n, new(big.Rat).SetInt(scale))
+		}
+
+		res, _ := n.Float64()
+
+		return res, nil
+
+	default:
+		if place >= 0 {
+			return v, nil
+		}
+
+		n := new(big.Rat).SetFrac(big.NewInt(toInt64(v)), scale)
+		res := roundRat(n, r.trunc)
+
+		return intResult(res.Mul(res, scale), isInt64(v)), nil
+	}
+}
+
+// roundRat returns n rounded to an integer, half to even or toward zero if trunc is true.
+func roundRat(n *big.Rat, trunc bool) *big.Int {
+	q, m := new(big.Int).QuoRem(n.Num(), n.Denom(), new(big.Int))
+
+	if trunc || m.Sign() == 0 {
+		return q
+	}
+
+	twice := new(big.Int).Abs(m)
+	twice.Lsh(twice, 1)
+
+	if c := twice.Cmp(n.Denom()); c > 0 || (c == 0 && q.Bit(0) == 1) {
+		if n.Sign() > 0 {
+			q.Add(q, big.NewInt(1))
+		} else {
+			q.Sub(q, big.NewInt(1))
+		}
+	}
+
+	return q
+}
+
+// abs returns the absolute value of n.
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+// check interfaces
+var (
+	_ Operator = (*roundOp)(nil)
+)
@@ -0,0 +1,424 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// setUnion represents `$setUnion` operator.
+//
+//	{ $setUnion: [ <expression1>, <expression2>, ... ] }
+type setUnion struct {
+	args []any
+}
+
+// newSetUnion returns `$setUnion` operator.
+func newSetUnion(args ...any) (Operator, error) {
+	return &setUnion{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *setUnion) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	sets, err := processSets("$setUnion", handlererrors.ErrSetUnionNotArray, s.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if sets == nil {
+		return types.Null, nil
+	}
+
+	res := types.MakeArray(0)
+
+	for _, set := range sets {
+		for _, v := range arrayValues(set) {
+			if !setContains(res, v) {
+				res.Append(v)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// setIntersection represents `$setIntersection` operator.
+//
+//	{ $setIntersection: [ <array1>, <array2>, ... ] }
+type setIntersection struct {
+	args []any
+}
+
+// newSetIntersection returns `$setIntersection` operator.
+func newSetIntersection(args ...any) (Operator, error) {
+	return &setIntersection{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *setIntersection) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	sets, err := processSets("$setIntersection", handlererrors.ErrSetIntersectionNotArray, s.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if sets == nil {
+		return types.Null, nil
+	}
+
+	res := types.MakeArray(0)
+
+	if len(sets) == 0 {
+		return res, nil
+	}
+
+	for _, v := range arrayValues(sets[0]) {
+		if setContains(res, v) {
+			continue
+		}
+
+		inAll := true
+
+		for _, set := range sets[1:] {
+			if !setContains(set, v) {
+				inAll = false
+				break
+			}
+		}
+
+		if inAll {
+			res.Append(v)
+		}
+	}
+
+	return res, nil
+}
+
+// setDifference represents `$setDifference` operator.
+//
+//	{ $setDifference: [ <expression1>, <expression2> ] }
+type setDifference struct {
+	args [2]any
+}
+
+// newSetDifference returns `$setDifference` operator.
+func newSetDifference(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$setDifference",
+			fmt.Sprintf("Expression $setDifference takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &setDifference{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *setDifference) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	first, second, err := processTwoArgs(s.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if first == types.Null || second == types.Null || doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	firstArr, ok := first.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetDifferenceFirstNotArray,
+			"$setDifference",
+			fmt.Sprintf(
+				"both operands of $setDifference must be arrays. First argument is of type: %s",
+				handlerparams.AliasFromType(first),
+			),
+		)
+	}
+
+	secondArr, ok := second.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetDifferenceSecondNotArray,
+			"$setDifference",
+			fmt.Sprintf(
+				"both operands of $setDifference must be arrays. Second argument is of type: %s",
+				handlerparams.AliasFromType(second),
+			),
+		)
+	}
+
+	res := types.MakeArray(0)
+
+	for _, v := range arrayValues(firstArr) {
+		if !setContains(secondArr, v) && !setContains(res, v) {
+			res.Append(v)
+		}
+	}
+
+	return res, nil
+}
+
+// setEquals represents `$setEquals` operator.
+//
+//	{ $setEquals: [ <expression1>, <expression2>, ... ] }
+type setEquals struct {
+	args []any
+}
+
+// newSetEquals returns `$setEquals` operator.
+func newSetEquals(args ...any) (Operator, error) {
+	if len(args) < 2 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetEqualsArgsInvalidLen,
+			"$setEquals",
+			fmt.Sprintf("$setEquals needs at least two arguments had: %d", len(args)),
+		)
+	}
+
+	return &setEquals{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *setEquals) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	arrs := make([]*types.Array, len(s.args))
+
+	for i, arg := range s.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc == nil {
+			// validate all arguments, see expr.validateExpr
+			continue
+		}
+
+		arr, ok := v.(*types.Array)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSetEqualsNotArray,
+				"$setEquals",
+				fmt.Sprintf(
+					"All operands of $setEquals must be arrays. One argument is of type: %s",
+					handlerparams.AliasFromType(v),
+				),
+			)
+		}
+
+		arrs[i] = arr
+	}
+
+	if doc == nil {
+		return false, nil
+	}
+
+	for _, arr := range arrs[1:] {
+		if !setIsSubset(arrs[0], arr) || !setIsSubset(arr, arrs[0]) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// setIsSubsetOp represents `$setIsSubset` operator.
+//
+//	{ $setIsSubset: [ <expression1>, <expression2> ] }
+type setIsSubsetOp struct {
+	args [2]any
+}
+
+// newSetIsSubset returns `$setIsSubset` operator.
+func newSetIsSubset(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$setIsSubset",
+			fmt.Sprintf("Expression $setIsSubset takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &setIsSubsetOp{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *setIsSubsetOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	first, second, err := processTwoArgs(s.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return false, nil
+	}
+
+	firstArr, ok := first.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetIsSubsetFirstNotArray,
+			"$setIsSubset",
+			fmt.Sprintf(
+				"both operands of $setIsSubset must be arrays. First argument is of type: %s",
+				handlerparams.AliasFromType(first),
+			),
+		)
+	}
+
+	secondArr, ok := second.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSetIsSubsetSecondNotArray,
+			"$setIsSubset",
+			fmt.Sprintf(
+				"both operands of $setIsSubset must be arrays. Second argument is of type: %s",
+				handlerparams.AliasFromType(second),
+			),
+		)
+	}
+
+	return setIsSubset(firstArr, secondArr), nil
+}
+
+// newAllElementsTrue returns `$allElementsTrue` operator.
+var newAllElementsTrue = newArrayFunc("$allElementsTrue", func(v any) (any, error) {
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrAllElementsTrueNotArray,
+			"$allElementsTrue",
+			fmt.Sprintf("$allElementsTrue's argument must be an array, but is %s", aliasFromType(v)),
+		)
+	}
+
+	for _, v := range arrayValues(arr) {
+		if !isTrue(v) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+})
+
+// newAnyElementTrue returns `$anyElementTrue` operator.
+var newAnyElementTrue = newArrayFunc("$anyElementTrue", func(v any) (any, error) {
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrAnyElementTrueNotArray,
+			"$anyElementTrue",
+			fmt.Sprintf("$anyElementTrue's argument must be an array, but is %s", aliasFromType(v)),
+		)
+	}
+
+	for _, v := range arrayValues(arr) {
+		if isTrue(v) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+})
+
+// processSets evaluates arguments of the set operator with the given name and returns them as arrays.
+// Nil is returned if any argument is null or missing, code is used for arguments that are not arrays.
+func processSets(name string, code handlererrors.ErrorCode, args []any, doc *types.Document, vars aggregations.Variables) ([]*types.Array, error) { //nolint:lll // for readability
+	sets := make([]*types.Array, 0, len(args))
+
+	for _, arg := range args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc == nil {
+			// validate all arguments, see expr.validateExpr
+			continue
+		}
+
+		if v == types.Null {
+			return nil, nil
+		}
+
+		arr, ok := v.(*types.Array)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				code,
+				name,
+				fmt.Sprintf(
+					"All operands of %s must be arrays. One argument is of type: %s",
+					name, handlerparams.AliasFromType(v),
+				),
+			)
+		}
+
+		sets = append(sets, arr)
+	}
+
+	if doc == nil {
+		return nil, nil
+	}
+
+	return sets, nil
+}
+
+// setContains returns true if the array contains a value equal to v.
+func setContains(arr *types.Array, v any) bool {
+	for i := 0; i < arr.Len(); i++ {
+		if compare(v, must.NotFail(arr.Get(i))) == types.Equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setIsSubset returns true if all values of the first array are contained in the second array.
+func setIsSubset(first, second *types.Array) bool {
+	for i := 0; i < first.Len(); i++ {
+		if !setContains(second, must.NotFail(first.Get(i))) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// check interfaces
+var (
+	_ Operator = (*setUnion)(nil)
+	_ Operator = (*setIntersection)(nil)
+	_ Operator = (*setDifference)(nil)
+	_ Operator = (*setEquals)(nil)
+	_ Operator = (*setIsSubsetOp)(nil)
+)
@@ -0,0 +1,249 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// sortKey represents a field of `$sortArray` sortBy document.
+type sortKey struct {
+	path  types.Path
+	order types.SortType
+}
+
+// sortArray represents `$sortArray` operator.
+//
+//	{ $sortArray: { input: <array>, sortBy: <1 | -1 | document> } }
+type sortArray struct {
+	input any
+	order types.SortType // used if keys are not set
+	keys  []sortKey
+}
+
+// newSortArray returns `$sortArray` operator.
+func newSortArray(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.Null
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSortArrayInvalidSpec,
+			"$sortArray",
+			fmt.Sprintf("$sortArray requires an object as an argument, found: %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "input", "sortBy":
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSortArrayUnknownArgument,
+				"$sortArray",
+				fmt.Sprintf("$sortArray found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	if !spec.Has("input") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSortArrayMissingInput,
+			"$sortArray",
+			"$sortArray requires 'input' to be specified",
+		)
+	}
+
+	if !spec.Has("sortBy") {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSortArrayMissingSortBy,
+			"$sortArray",
+			"$sortArray requires 'sortBy' to be specified",
+		)
+	}
+
+	s := &sortArray{
+		input: must.NotFail(spec.Get("input")),
+	}
+
+	switch sortBy := must.NotFail(spec.Get("sortBy")).(type) {
+	case *types.Document:
+		if sortBy.Len() == 0 {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrSortArrayInvalidSortBy,
+				"$sortArray",
+				"$sortArray sortBy must be either 1, -1, or a non-empty object",
+			)
+		}
+
+		for _, k := range sortBy.Keys() {
+			order, err := sortOrder(k, must.NotFail(sortBy.Get(k)))
+			if err != nil {
+				return nil, err
+			}
+
+			path, err := types.NewPathFromString(k)
+			if err != nil {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrSortBadValue,
+					"$sortArray",
+					fmt.Sprintf("Illegal key in $sortArray specification: %s", k),
+				)
+			}
+
+			s.keys = append(s.keys, sortKey{path: path, order: order})
+		}
+
+	case int32, int64, float64:
+		order, err := sortOrder("", sortBy)
+		if err != nil {
+			return nil, err
+		}
+
+		s.order = order
+
+	default:
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSortArrayInvalidSortBy,
+			"$sortArray",
+			"$sortArray sortBy must be either 1, -1, or a non-empty object",
+		)
+	}
+
+	return s, nil
+}
+
+// Process implements Operator interface.
+//
+// The sort is stable, elements with equal sort keys keep their input order.
+func (s *sortArray) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(s.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if v == types.Null || doc == nil {
+		return types.Null, nil
+	}
+
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSortArrayInputNotArray,
+			"$sortArray",
+			fmt.Sprintf(
+				"The input argument to $sortArray must be an array, but was of type: %s",
+				handlerparams.AliasFromType(v),
+			),
+		)
+	}
+
+	values := arrayValues(arr)
+
+	slices.SortStableFunc(values, func(a, b any) int {
+		if s.keys == nil {
+			return compareResultToInt(compare(a, b), s.order)
+		}
+
+		for _, k := range s.keys {
+			res := types.CompareOrderForSort(sortKeyValue(a, k.path), sortKeyValue(b, k.path), k.order)
+			if res != types.Equal {
+				return compareResultToInt(res, types.Ascending)
+			}
+		}
+
+		return 0
+	})
+
+	res := types.MakeArray(len(values))
+	res.Append(values...)
+
+	return res, nil
+}
+
+// sortOrder returns the sort order for the given sortBy value of the given key.
+func sortOrder(key string, v any) (types.SortType, error) {
+	n, err := handlerparams.GetWholeNumberParam(v)
+	if errors.Is(err, handlerparams.ErrUnexpectedType) {
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrSortBadValue,
+			"$sortArray",
+			fmt.Sprintf("Illegal key in $sortArray specification: %s: %s", key, types.FormatAnyValue(v)),
+		)
+	}
+
+	switch {
+	case err == nil && n == 1:
+		return types.Ascending, nil
+	case err == nil && n == -1:
+		return types.Descending, nil
+	default:
+		return 0, newOperatorArgumentError(
+			handlererrors.ErrSortBadOrder,
+			"$sortArray",
+			"$sortArray key ordering must be 1 (for ascending) or -1 (for descending)",
+		)
+	}
+}
+
+// sortKeyValue returns the value at the given path of the document element,
+// null is returned for non-document elements and missing fields.
+func sortKeyValue(v any, path types.Path) any {
+	doc, ok := v.(*types.Document)
+	if !ok {
+		return types.Null
+	}
+
+	res, err := doc.GetByPath(path)
+	if err != nil {
+		return types.Null
+	}
+
+	return res
+}
+
+// compareResultToInt converts the comparison result to an integer for the given sort order.
+func compareResultToInt(res types.CompareResult, order types.SortType) int {
+	var n int
+
+	switch res {
+	case types.Less:
+		n = -1
+	case types.Greater:
+		n = 1
+	}
+
+	return n * int(order)
+}
+
+// check interfaces
+var (
+	_ Operator = (*sortArray)(nil)
+)
@@ -0,0 +1,351 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// concat represents `$concat` operator.
+//
+//	{ $concat: [ <expression1>, <expression2>, ... ] }
+type concat struct {
+	args []any
+}
+
+// newConcat returns `$concat` operator.
+func newConcat(args ...any) (Operator, error) {
+	return &concat{
+		args: args,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (c *concat) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var res strings.Builder
+
+	for _, arg := range c.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		switch v := v.(type) {
+		case types.NullType:
+			return types.Null, nil
+		case string:
+			res.WriteString(v)
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrConcatInvalidType,
+				"$concat",
+				fmt.Sprintf("$concat only supports strings, not %s", handlerparams.AliasFromType(v)),
+			)
+		}
+	}
+
+	return res.String(), nil
+}
+
+// stringOp represents single argument string operators such as `$toLower` and `$strLenCP`.
+//
+//	{ <operator>: <expression> }
+type stringOp struct {
+	arg any
+	f   func(v any) (any, error)
+}
+
+// newStringFunc returns a function creating the single argument string operator with the given name,
+// f computes the result for the evaluated argument, that is nil for a missing field.
+func newStringFunc(name string, f func(v any) (any, error)) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 1 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 1 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &stringOp{
+			arg: args[0],
+			f:   f,
+		}, nil
+	}
+}
+
+// Process implements Operator interface.
+func (s *stringOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExprOrMissing(s.arg, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc == nil && (v == nil || v == types.Null) {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	return s.f(v)
+}
+
+// newToLower returns `$toLower` operator.
+//
+// Only ASCII letters are converted, the same way as MongoDB does.
+var newToLower = newStringFunc("$toLower", func(v any) (any, error) {
+	s, err := coerceToString("$toLower", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return asciiToLower(s), nil
+})
+
+// newToUpper returns `$toUpper` operator.
+//
+// Only ASCII letters are converted, the same way as MongoDB does.
+var newToUpper = newStringFunc("$toUpper", func(v any) (any, error) {
+	s, err := coerceToString("$toUpper", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return asciiToUpper(s), nil
+})
+
+// newStrLenBytes returns `$strLenBytes` operator.
+var newStrLenBytes = newStringFunc("$strLenBytes", func(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrStrLenBytesInvalidType,
+			"$strLenBytes",
+			fmt.Sprintf("$strLenBytes requires a string argument, found: %s", aliasFromType(v)),
+		)
+	}
+
+	return int32(len(s)), nil
+})
+
+// newStrLenCP returns `$strLenCP` operator.
+var newStrLenCP = newStringFunc("$strLenCP", func(v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrStrLenCPInvalidType,
+			"$strLenCP",
+			fmt.Sprintf("$strLenCP requires a string argument, found: %s", aliasFromType(v)),
+		)
+	}
+
+	return int32(utf8.RuneCountInString(s)), nil
+})
+
+// strcasecmp represents `$strcasecmp` operator.
+//
+//	{ $strcasecmp: [ <expression1>, <expression2> ] }
+type strcasecmp struct {
+	args [2]any
+}
+
+// newStrcasecmp returns `$strcasecmp` operator.
+func newStrcasecmp(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$strcasecmp",
+			fmt.Sprintf("Expression $strcasecmp takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &strcasecmp{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+//
+// Only ASCII characters are compared case-insensitively, the same way as MongoDB does.
+func (s *strcasecmp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var strs [2]string
+
+	for i, arg := range s.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if strs[i], err = coerceToString("$strcasecmp", v); err != nil {
+			return nil, err
+		}
+	}
+
+	a, b := asciiToLower(strs[0]), asciiToLower(strs[1])
+
+	return int32(strings.Compare(a, b)), nil
+}
+
+// split represents `$split` operator.
+//
+//	{ $split: [ <string expression>, <delimiter> ] }
+type split struct {
+	args [2]any
+}
+
+// newSplit returns `$split` operator.
+func newSplit(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$split",
+			fmt.Sprintf("Expression $split takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &split{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (s *split) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	input, delimiter, err := processTwoArgs(s.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if input == types.Null {
+		return types.Null, nil
+	}
+
+	str, ok := input.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSplitInputNotString,
+			"$split",
+			fmt.Sprintf(
+				"$split requires an expression that evaluates to a string as a first argument, found: %s",
+				handlerparams.AliasFromType(input),
+			),
+		)
+	}
+
+	sep, ok := delimiter.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSplitDelimiterNotString,
+			"$split",
+			fmt.Sprintf(
+				"$split requires an expression that evaluates to a string as a second argument, found: %s",
+				handlerparams.AliasFromType(delimiter),
+			),
+		)
+	}
+
+	if sep == "" {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSplitEmptyDelimiter,
+			"$split",
+			"$split requires a non-empty separator",
+		)
+	}
+
+	parts := strings.Split(str, sep)
+	res := types.MakeArray(len(parts))
+
+	for _, p := range parts {
+		res.Append(p)
+	}
+
+	return res, nil
+}
+
+// coerceToString converts the value to string for operators accepting any scalar value as a string,
+// such as `$toLower` and `$substrCP`.
+//
+// Null and missing (nil) values are converted to an empty string,
+// numbers and dates are formatted the same way as MongoDB does.
+// Other types return an error for the operator with the given name.
+func coerceToString(name string, v any) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case nil, types.NullType:
+		return "", nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "nan", nil
+		case math.IsInf(v, 1):
+			return "inf", nil
+		case math.IsInf(v, -1):
+			return "-inf", nil
+		default:
+			return strconv.FormatFloat(v, 'g', 6, 64), nil
+		}
+	case time.Time:
+		return v.UTC().Format("2006-01-02T15:04:05.000Z"), nil
+	default:
+		return "", newOperatorArgumentError(
+			handlererrors.ErrStringConversion,
+			name,
+			fmt.Sprintf("can't convert from BSON type %s to String", handlerparams.AliasFromType(v)),
+		)
+	}
+}
+
+// asciiToLower returns s with all ASCII letters converted to lower case.
+func asciiToLower(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+
+		return r
+	}, s)
+}
+
+// asciiToUpper returns s with all ASCII letters converted to upper case.
+func asciiToUpper(s string) string {
+	return strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+
+		return r
+	}, s)
+}
+
+// check interfaces
+var (
+	_ Operator = (*concat)(nil)
+	_ Operator = (*stringOp)(nil)
+	_ Operator = (*strcasecmp)(nil)
+	_ Operator = (*split)(nil)
+)
@@ -0,0 +1,265 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// substr represents `$substrBytes`, `$substrCP` and deprecated `$substr` operators.
+//
+//	{ <operator>: [ <string expression>, <start>, <length> ] }
+type substr struct {
+	name string
+	args [3]any
+	f    func(s string, start, length any) (any, error)
+}
+
+// newSubstrFunc returns a function creating the substring operator with the given name,
+// f computes the substring of the string for the evaluated start and length arguments.
+func newSubstrFunc(name string, f func(s string, start, length any) (any, error)) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		if len(args) != 3 {
+			return nil, newOperatorError(
+				ErrArgsInvalidLen,
+				name,
+				fmt.Sprintf("Expression %s takes exactly 3 arguments. %d were passed in.", name, len(args)),
+			)
+		}
+
+		return &substr{
+			name: name,
+			args: [3]any{args[0], args[1], args[2]},
+			f:    f,
+		}, nil
+	}
+}
+
+// Process implements Operator interface.
+func (s *substr) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	var values [3]any
+
+	for i, arg := range s.args {
+		v, err := processExpr(arg, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+	}
+
+	if doc == nil && (values[1] == types.Null || values[2] == types.Null) {
+		// field paths are evaluated to null during validation, see expr.validateExpr
+		return types.Null, nil
+	}
+
+	str, err := coerceToString(s.name, values[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return s.f(str, values[1], values[2])
+}
+
+// substrBytes returns the substring of s for the given start and length in bytes
+// for `$substrBytes` and `$substr` operators with the given name.
+//
+// Negative start returns an empty string, negative length returns the rest of the string.
+func substrBytes(name string, s string, start, length any) (any, error) {
+	if !isNumber(start) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrBytesStartNotNumeric,
+			name,
+			fmt.Sprintf(
+				"%s:  starting index must be a numeric type (is BSON type %s)",
+				name, handlerparams.AliasFromType(start),
+			),
+		)
+	}
+
+	if !isNumber(length) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrBytesLengthNotNumeric,
+			name,
+			fmt.Sprintf(
+				"%s:  length must be a numeric type (is BSON type %s)",
+				name, handlerparams.AliasFromType(length),
+			),
+		)
+	}
+
+	// indexes are unsigned in MongoDB, negative values wrap around the same way
+	lower, n := uint64(coerceToInt64(start)), uint64(coerceToInt64(length))
+	size := uint64(len(s))
+
+	if lower < size && !utf8.RuneStart(s[lower]) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrBytesStartContinuation,
+			name,
+			fmt.Sprintf("%s:  Invalid range, starting index is a UTF-8 continuation byte.", name),
+		)
+	}
+
+	if upper := lower + n; upper < size && !utf8.RuneStart(s[upper]) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrBytesEndContinuation,
+			name,
+			fmt.Sprintf("%s:  Invalid range, ending index is in the middle of a UTF-8 character.", name),
+		)
+	}
+
+	if lower >= size {
+		return "", nil
+	}
+
+	return s[lower : lower+min(n, size-lower)], nil
+}
+
+// newSubstrBytes returns `$substrBytes` operator.
+var newSubstrBytes = newSubstrFunc("$substrBytes", func(s string, start, length any) (any, error) {
+	return substrBytes("$substrBytes", s, start, length)
+})
+
+// newSubstr returns deprecated `$substr` operator, an alias of `$substrBytes`.
+var newSubstr = newSubstrFunc("$substr", func(s string, start, length any) (any, error) {
+	return substrBytes("$substr", s, start, length)
+})
+
+// newSubstrCP returns `$substrCP` operator.
+var newSubstrCP = newSubstrFunc("$substrCP", func(s string, start, length any) (any, error) {
+	if !isNumber(start) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPStartNotNumeric,
+			"$substrCP",
+			fmt.Sprintf(
+				"$substrCP: starting index must be a numeric type (is BSON type %s)",
+				handlerparams.AliasFromType(start),
+			),
+		)
+	}
+
+	lower, ok := toInt32(start)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPStartNotInt32,
+			"$substrCP",
+			fmt.Sprintf(
+				"$substrCP: starting index cannot be represented as a 32-bit integral value: %s",
+				types.FormatAnyValue(start),
+			),
+		)
+	}
+
+	if !isNumber(length) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPLengthNotNumeric,
+			"$substrCP",
+			fmt.Sprintf(
+				"$substrCP: length must be a numeric type (is BSON type %s)",
+				handlerparams.AliasFromType(length),
+			),
+		)
+	}
+
+	n, ok := toInt32(length)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPLengthNotInt32,
+			"$substrCP",
+			fmt.Sprintf(
+				"$substrCP: length cannot be represented as a 32-bit integral value: %s",
+				types.FormatAnyValue(length),
+			),
+		)
+	}
+
+	if n < 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPLengthNegative,
+			"$substrCP",
+			"$substrCP: length must be a nonnegative integer.",
+		)
+	}
+
+	if lower < 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrSubstrCPStartNegative,
+			"$substrCP",
+			"$substrCP: the starting index must be nonnegative integer.",
+		)
+	}
+
+	runes := []rune(s)
+	size := int64(len(runes))
+
+	from := min(int64(lower), size)
+	to := min(from+int64(n), size)
+
+	return string(runes[from:to]), nil
+})
+
+// coerceToInt64 converts the number to int64, doubles are truncated and clamped to int64 range,
+// NaN is converted to 0.
+func coerceToInt64(v any) int64 {
+	f, ok := v.(float64)
+	if !ok {
+		return toInt64(v)
+	}
+
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	default:
+		return int64(f)
+	}
+}
+
+// toInt32 returns the number as int32 if it is an integral value representable as int32.
+func toInt32(v any) (int32, bool) {
+	var f float64
+
+	switch v := v.(type) {
+	case int32:
+		return v, true
+	case int64:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return 0, false
+	}
+
+	if f != math.Trunc(f) || f < math.MinInt32 || f > math.MaxInt32 {
+		return 0, false
+	}
+
+	return int32(f), true
+}
+
+// check interfaces
+var (
+	_ Operator = (*substr)(nil)
+)
@@ -0,0 +1,185 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// Angle conversion factors are computed at runtime in double precision like MongoDB does.
+var (
+	pi               = math.Pi
+	radiansPerDegree = pi / 180
+	degreesPerRadian = 180 / pi
+)
+
+// trigonometricBounds represents the domain of a trigonometric function.
+type trigonometricBounds struct {
+	s  string // as formatted in error messages
+	in func(f float64) bool
+}
+
+var (
+	// boundsFinite is the domain of `$sin`, `$cos` and `$tan`.
+	boundsFinite = trigonometricBounds{
+		s:  "(-inf,inf)",
+		in: func(f float64) bool { return !math.IsInf(f, 0) },
+	}
+
+	// boundsUnit is the domain of `$asin`, `$acos` and `$atanh`.
+	boundsUnit = trigonometricBounds{
+		s:  "[-1,1]",
+		in: func(f float64) bool { return f >= -1 && f <= 1 },
+	}
+
+	// boundsAcosh is the domain of `$acosh`.
+	boundsAcosh = trigonometricBounds{
+		s:  "[1,inf]",
+		in: func(f float64) bool { return f >= 1 },
+	}
+)
+
+// newTrigonometricFunc returns a function creating the single argument trigonometric operator with the given name,
+// f computes the result for the argument converted to double.
+//
+// NaN argument is returned as is, arguments outside of bounds are rejected unless bounds is nil.
+func newTrigonometricFunc(name string, bounds *trigonometricBounds, f func(float64) float64) newOperatorFunc {
+	return newMathFunc(name, func(v any) (any, error) {
+		arg := toFloat64(v)
+
+		if math.IsNaN(arg) {
+			return arg, nil
+		}
+
+		if bounds != nil && !bounds.in(arg) {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrTrigonometricOutOfBounds,
+				name,
+				fmt.Sprintf("cannot apply %s to %s, value must in %s", name, types.FormatAnyValue(v), bounds.s),
+			)
+		}
+
+		return f(arg), nil
+	})
+}
+
+// newSin returns `$sin` operator.
+var newSin = newTrigonometricFunc("$sin", &boundsFinite, math.Sin)
+
+// newCos returns `$cos` operator.
+var newCos = newTrigonometricFunc("$cos", &boundsFinite, math.Cos)
+
+// newTan returns `$tan` operator.
+var newTan = newTrigonometricFunc("$tan", &boundsFinite, math.Tan)
+
+// newAsin returns `$asin` operator.
+var newAsin = newTrigonometricFunc("$asin", &boundsUnit, math.Asin)
+
+// newAcos returns `$acos` operator.
+var newAcos = newTrigonometricFunc("$acos", &boundsUnit, math.Acos)
+
+// newAtan returns `$atan` operator.
+var newAtan = newTrigonometricFunc("$atan", nil, math.Atan)
+
+// newSinh returns `$sinh` operator.
+var newSinh = newTrigonometricFunc("$sinh", nil, math.Sinh)
+
+// newCosh returns `$cosh` operator.
+var newCosh = newTrigonometricFunc("$cosh", nil, math.Cosh)
+
+// newTanh returns `$tanh` operator.
+var newTanh = newTrigonometricFunc("$tanh", nil, math.Tanh)
+
+// newAsinh returns `$asinh` operator.
+var newAsinh = newTrigonometricFunc("$asinh", nil, math.Asinh)
+
+// newAcosh returns `$acosh` operator.
+var newAcosh = newTrigonometricFunc("$acosh", &boundsAcosh, math.Acosh)
+
+// newAtanh returns `$atanh` operator.
+var newAtanh = newTrigonometricFunc("$atanh", &boundsUnit, math.Atanh)
+
+// newDegreesToRadians returns `$degreesToRadians` operator.
+var newDegreesToRadians = newTrigonometricFunc("$degreesToRadians", nil, func(f float64) float64 {
+	return f * radiansPerDegree
+})
+
+// newRadiansToDegrees returns `$radiansToDegrees` operator.
+var newRadiansToDegrees = newTrigonometricFunc("$radiansToDegrees", nil, func(f float64) float64 {
+	return f * degreesPerRadian
+})
+
+// atan2 represents `$atan2` operator.
+//
+//	{ $atan2: [ <y>, <x> ] }
+type atan2 struct {
+	args [2]any
+}
+
+// newAtan2 returns `$atan2` operator.
+func newAtan2(args ...any) (Operator, error) {
+	if len(args) != 2 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$atan2",
+			fmt.Sprintf("Expression $atan2 takes exactly 2 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &atan2{
+		args: [2]any{args[0], args[1]},
+	}, nil
+}
+
+// Process implements Operator interface.
+func (a *atan2) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	y, x, err := processTwoArgs(a.args, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if y == types.Null || x == types.Null {
+		return types.Null, nil
+	}
+
+	if !isNumber(y) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrAtan2YNotNumeric,
+			"$atan2",
+			fmt.Sprintf("$atan2 only supports numeric types, not %s", handlerparams.AliasFromType(y)),
+		)
+	}
+
+	if !isNumber(x) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrAtan2XNotNumeric,
+			"$atan2",
+			fmt.Sprintf("$atan2 only supports numeric types, not %s", handlerparams.AliasFromType(x)),
+		)
+	}
+
+	return math.Atan2(toFloat64(y), toFloat64(x)), nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*atan2)(nil)
+)
@@ -0,0 +1,172 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// defaultTrimChars contains characters removed by `$trim`, `$ltrim` and `$rtrim`
+// if chars argument is not set: null, whitespace and Unicode space separators.
+const defaultTrimChars = "\x00 \t\n\v\f\r\u00a0\u1680" +
+	"\u2000\u2001\u2002\u2003\u2004\u2005\u2006\u2007\u2008\u2009\u200a"
+
+// trim represents `$trim`, `$ltrim` and `$rtrim` operators.
+//
+//	{ <operator>: { input: <string>, chars: <string> } }
+type trim struct {
+	name  string
+	input any
+	chars any // nil if not set
+	left  bool
+	right bool
+}
+
+// newTrimFunc returns a function creating the trim operator with the given name,
+// left and right define which ends of the input are trimmed.
+func newTrimFunc(name string, left, right bool) newOperatorFunc {
+	return func(args ...any) (Operator, error) {
+		var spec *types.Document
+		if len(args) == 1 {
+			spec, _ = args[0].(*types.Document)
+		}
+
+		if spec == nil {
+			var v any = types.MakeArray(0)
+			if len(args) == 1 {
+				v = args[0]
+			}
+
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrTrimInvalidSpec,
+				name,
+				fmt.Sprintf("%s only supports an object as an argument, found: %s", name, handlerparams.AliasFromType(v)),
+			)
+		}
+
+		for _, k := range spec.Keys() {
+			switch k {
+			case "input", "chars":
+			default:
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrTrimUnknownArgument,
+					name,
+					fmt.Sprintf("%s found an unknown argument: %s", name, k),
+				)
+			}
+		}
+
+		if !spec.Has("input") {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrTrimMissingInput,
+				name,
+				fmt.Sprintf("%s requires an 'input' field", name),
+			)
+		}
+
+		t := &trim{
+			name:  name,
+			input: must.NotFail(spec.Get("input")),
+			left:  left,
+			right: right,
+		}
+
+		if spec.Has("chars") {
+			t.chars = must.NotFail(spec.Get("chars"))
+		}
+
+		return t, nil
+	}
+}
+
+// newTrim returns `$trim` operator.
+var newTrim = newTrimFunc("$trim", true, true)
+
+// newLtrim returns `$ltrim` operator.
+var newLtrim = newTrimFunc("$ltrim", true, false)
+
+// newRtrim returns `$rtrim` operator.
+var newRtrim = newTrimFunc("$rtrim", false, true)
+
+// Process implements Operator interface.
+func (t *trim) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	input, err := processExpr(t.input, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if input == types.Null {
+		return types.Null, nil
+	}
+
+	s, ok := input.(string)
+	if !ok {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrTrimInputNotString,
+			t.name,
+			fmt.Sprintf(
+				"%s requires its input to be a string, got %s (of type %s) instead.",
+				t.name, types.FormatAnyValue(input), handlerparams.AliasFromType(input),
+			),
+		)
+	}
+
+	chars := defaultTrimChars
+
+	if t.chars != nil {
+		v, err := processExpr(t.chars, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null {
+			return types.Null, nil
+		}
+
+		if chars, ok = v.(string); !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrTrimCharsNotString,
+				t.name,
+				fmt.Sprintf(
+					"%s requires 'chars' to be a string, got %s (of type %s) instead.",
+					t.name, types.FormatAnyValue(v), handlerparams.AliasFromType(v),
+				),
+			)
+		}
+	}
+
+	// characters are matched by code points
+	if t.left {
+		s = strings.TrimLeft(s, chars)
+	}
+
+	if t.right {
+		s = strings.TrimRight(s, chars)
+	}
+
+	return s, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*trim)(nil)
+)
@@ -48,7 +48,7 @@ func newType(args ...any) (Operator, error) {
 }
 
 // Process implements Operator interface.
-func (t *typeOp) Process(doc *types.Document) (any, error) {
+func (t *typeOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
 	typeParam := t.param
 
 	var paramEvaluated bool
@@ -79,7 +79,7 @@ func (t *typeOp) Process(doc *types.Document) (any, error) {
 				return nil, opErr
 			}
 
-			if typeParam, err = operator.Process(doc); err != nil {
+			if typeParam, err = operator.Process(doc, vars); err != nil {
 				var opErr OperatorError
 				if !errors.As(err, &opErr) {
 					return nil, lazyerrors.Error(err)
@@ -102,8 +102,13 @@ func (t *typeOp) Process(doc *types.Document) (any, error) {
 					return nil, err
 				}
 
-				value, err := expression.Evaluate(doc)
+				value, err := expression.Evaluate(doc, vars)
 				if err != nil {
+					var exprErr *aggregations.ExpressionError
+					if errors.As(err, &exprErr) {
+						return nil, err
+					}
+
 					return "missing", nil
 				}
 
@@ -122,7 +127,38 @@ func (t *typeOp) Process(doc *types.Document) (any, error) {
 	return handlerparams.AliasFromType(res), nil
 }
 
+// isNumberOp represents `$isNumber` operator.
+type isNumberOp struct {
+	arg any
+}
+
+// newIsNumber returns `$isNumber` operator.
+func newIsNumber(args ...any) (Operator, error) {
+	if len(args) != 1 {
+		return nil, newOperatorError(
+			ErrArgsInvalidLen,
+			"$isNumber",
+			fmt.Sprintf("Expression $isNumber takes exactly 1 arguments. %d were passed in.", len(args)),
+		)
+	}
+
+	return &isNumberOp{
+		arg: args[0],
+	}, nil
+}
+
+// Process implements Operator interface.
+func (i *isNumberOp) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	v, err := processExpr(i.arg, doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return isNumber(v), nil
+}
+
 // check interfaces
 var (
 	_ Operator = (*typeOp)(nil)
+	_ Operator = (*isNumberOp)(nil)
 )
@@ -0,0 +1,84 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators/accumulators"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// accumulator represents an accumulator (such as $sum) used as window operator.
+//
+//	{ <$accumulator>: <arguments>, window: <window> }
+//
+// The accumulator is applied to documents in the window of each document.
+// If window is not set, all documents of the partition are used.
+type accumulator struct {
+	accumulator accumulators.Accumulator
+	bounds      *bounds
+}
+
+// newAccumulator validates the specification and creates a new accumulator window operator.
+func newAccumulator(params *newOperatorParams) (Operator, error) {
+	b, err := newBounds(params)
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := accumulators.NewAccumulator(
+		"$setWindowFields",
+		params.name,
+		must.NotFail(types.NewDocument(params.name, params.args)),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &accumulator{
+		accumulator: acc,
+		bounds:      b,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (a *accumulator) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+
+	for i := range docs {
+		lo, hi, err := a.bounds.window(docs, i)
+		if err != nil {
+			return nil, err
+		}
+
+		iter := iterator.Values(iterator.ForSlice(docs[lo:hi]))
+
+		v, err := accumulators.Accumulate(a.accumulator, iter, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = v
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*accumulator)(nil)
+)
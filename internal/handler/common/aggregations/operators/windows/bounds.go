@@ -0,0 +1,360 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// bound represents a lower or upper bound of the window.
+type bound struct {
+	unbounded bool
+	offset    any // int64 for document-based windows, float64 or int64 for range-based windows
+}
+
+// bounds represents a window of documents used to compute the value for a document.
+//
+//	{ documents: [ <lower>, <upper> ] }
+//	{ range: [ <lower>, <upper> ], unit: <time unit> }
+//
+// Each bound is "unbounded", "current" or a number relative to the current document.
+type bounds struct {
+	lower bound
+	upper bound
+
+	// fields for range-based windows
+	isRange   bool
+	unit      string     // empty if not set
+	sortPath  types.Path // path of the only sortBy field
+	ascending bool       // true if sortBy field is sorted in ascending order
+}
+
+// unboundedWindow is the window containing all documents of the partition.
+var unboundedWindow = &bounds{
+	lower: bound{unbounded: true},
+	upper: bound{unbounded: true},
+}
+
+// newBounds validates window specification and creates new bounds.
+// If window is not set, it returns unbounded window.
+func newBounds(params *newOperatorParams) (*bounds, error) {
+	if params.window == nil {
+		return unboundedWindow, nil
+	}
+
+	window := params.window
+	res := new(bounds)
+
+	for _, k := range window.Keys() {
+		switch k {
+		case "documents", "range", "unit":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				"'window' field can only contain 'documents' as the only argument or 'range' with an optional 'unit' field",
+				"$setWindowFields (stage)",
+			)
+		}
+	}
+
+	hasDocuments, hasRange := window.Has("documents"), window.Has("range")
+
+	switch {
+	case hasDocuments && hasRange:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"Window bounds can only specify one of 'documents' or 'range'",
+			"$setWindowFields (stage)",
+		)
+	case !hasDocuments && !hasRange:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"'window' field that specifies a bound must specify 'documents' or 'range'",
+			"$setWindowFields (stage)",
+		)
+	case hasDocuments && window.Has("unit"):
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"Document-based bounds can't have a unit",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	name := "documents"
+	if hasRange {
+		name = "range"
+		res.isRange = true
+	}
+
+	v := must.NotFail(window.Get(name))
+
+	arr, ok := v.(*types.Array)
+	if !ok || arr.Len() != 2 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("Window bounds must be a 2-element array: %s: %s", name, types.FormatAnyValue(v)),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	var err error
+
+	if res.lower, err = newBound(must.NotFail(arr.Get(0)), res.isRange); err != nil {
+		return nil, err
+	}
+
+	if res.upper, err = newBound(must.NotFail(arr.Get(1)), res.isRange); err != nil {
+		return nil, err
+	}
+
+	if !res.lower.unbounded && !res.upper.unbounded &&
+		types.CompareForAggregation(res.lower.offset, res.upper.offset) == types.Greater {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSetWindowFieldsInvalidBounds,
+			"Lower bound must not exceed upper bound",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	if !res.isRange {
+		return res, nil
+	}
+
+	if params.sortBy == nil || params.sortBy.Len() != 1 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSetWindowFieldsSortBy,
+			"Range-based bounds require sortBy a single field",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	res.sortPath = sortPath(params.sortBy)
+
+	sortType := must.NotFail(params.sortBy.Get(params.sortBy.Keys()[0]))
+	res.ascending = types.CompareForAggregation(sortType, int32(0)) == types.Greater
+
+	if v, err := window.Get("unit"); err == nil {
+		unit, ok := v.(string)
+		if !ok || !aggregations.IsTimeUnit(unit) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("unknown time unit value: %s", types.FormatAnyValue(v)),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		res.unit = unit
+	}
+
+	return res, nil
+}
+
+// newBound validates and creates a new bound.
+func newBound(v any, isRange bool) (bound, error) {
+	switch v := v.(type) {
+	case string:
+		switch v {
+		case "unbounded":
+			return bound{unbounded: true}, nil
+		case "current":
+			return bound{offset: int64(0)}, nil
+		}
+	case int32:
+		return bound{offset: int64(v)}, nil
+	case int64:
+		return bound{offset: v}, nil
+	case float64:
+		if !isRange && (v != math.Trunc(v) || math.IsInf(v, 0)) {
+			return bound{}, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("Numeric document-based bounds must be an integer: %s", types.FormatAnyValue(v)),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		if !isRange {
+			return bound{offset: int64(v)}, nil
+		}
+
+		if math.IsNaN(v) {
+			break
+		}
+
+		return bound{offset: v}, nil
+	}
+
+	return bound{}, handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrFailedToParse,
+		fmt.Sprintf(
+			"Window bounds must be 'unbounded', 'current', or a number, found %s",
+			handlerparams.AliasFromType(v),
+		),
+		"$setWindowFields (stage)",
+	)
+}
+
+// window returns the range [lo, hi) of documents in the window of the document at the given position.
+func (b *bounds) window(docs []*types.Document, pos int) (int, int, error) {
+	if !b.isRange {
+		lo, hi := 0, len(docs)
+
+		if !b.lower.unbounded {
+			lo = max(0, min(len(docs), pos+int(b.lower.offset.(int64))))
+		}
+
+		if !b.upper.unbounded {
+			hi = max(0, min(len(docs), pos+int(b.upper.offset.(int64))+1))
+		}
+
+		return lo, max(lo, hi), nil
+	}
+
+	current := sortValue(docs[pos], b.sortPath)
+
+	lower, upper, err := b.rangeValues(current)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// documents are sorted by the sortBy field, so the window is contiguous
+	lo, hi := len(docs), 0
+
+	for i, doc := range docs {
+		v := sortValue(doc, b.sortPath)
+
+		if b.unit == "" {
+			if _, ok := v.(time.Time); ok {
+				return 0, 0, b.invalidRangeValueError(v)
+			}
+		}
+
+		if lower != nil && types.CompareForAggregation(v, lower) == types.Less {
+			continue
+		}
+
+		if upper != nil && types.CompareForAggregation(v, upper) == types.Greater {
+			continue
+		}
+
+		lo, hi = min(lo, i), max(hi, i+1)
+	}
+
+	return lo, max(lo, hi), nil
+}
+
+// rangeValues returns the lowest and the highest values of the sortBy field for documents in the window
+// of the document with the given sortBy value. Nil is returned for unbounded values.
+func (b *bounds) rangeValues(current any) (any, any, error) {
+	lower, upper := b.lower, b.upper
+	if !b.ascending {
+		lower, upper = negateBound(upper), negateBound(lower)
+	}
+
+	var lo, hi any
+
+	for _, p := range []struct {
+		b   bound
+		res *any
+	}{{lower, &lo}, {upper, &hi}} {
+		if p.b.unbounded {
+			continue
+		}
+
+		v, err := b.addOffset(current, p.b.offset)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		*p.res = v
+	}
+
+	return lo, hi, nil
+}
+
+// addOffset returns the given sortBy value shifted by the offset.
+func (b *bounds) addOffset(v, offset any) (any, error) {
+	if b.unit != "" {
+		t, ok := v.(time.Time)
+		if !ok {
+			return nil, b.invalidRangeValueError(v)
+		}
+
+		return addTime(t, offset, b.unit), nil
+	}
+
+	switch v := v.(type) {
+	case float64, int32, int64:
+		return aggregations.SumNumbers(v, offset), nil
+	default:
+		return nil, b.invalidRangeValueError(v)
+	}
+}
+
+// invalidRangeValueError returns an error for sortBy value that can't be used with range-based window.
+func (b *bounds) invalidRangeValueError(v any) error {
+	if b.unit != "" {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSetWindowFieldsInvalidRangeValue,
+			fmt.Sprintf(
+				"Invalid range: Expected the sortBy field to be a Date, but it was %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrStageSetWindowFieldsInvalidRangeValue,
+		fmt.Sprintf(
+			"Invalid range: Expected the sortBy field to be a number, but it was %s",
+			handlerparams.AliasFromType(v),
+		),
+		"$setWindowFields (stage)",
+	)
+}
+
+// negateBound returns the bound with the negated offset.
+func negateBound(b bound) bound {
+	switch offset := b.offset.(type) {
+	case int64:
+		b.offset = -offset
+	case float64:
+		b.offset = -offset
+	}
+
+	return b
+}
+
+// addTime returns the time shifted by the given number of time units.
+func addTime(t time.Time, amount any, unit string) time.Time {
+	var n float64
+
+	switch amount := amount.(type) {
+	case int64:
+		n = float64(amount)
+	case float64:
+		n = amount
+	}
+
+	return aggregations.AddTime(t, n, unit)
+}
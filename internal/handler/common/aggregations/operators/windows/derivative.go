@@ -0,0 +1,225 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// derivative represents $derivative window operator.
+//
+//	{ $derivative: { input: <expression>, unit: <time unit> }, window: <window> }
+//
+// It returns the average rate of change of input within the window,
+// computed using the first and the last documents of the window.
+type derivative struct {
+	*series
+}
+
+// newDerivative validates the specification and creates a new $derivative window operator.
+func newDerivative(params *newOperatorParams) (Operator, error) {
+	if params.window == nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"$derivative requires explicit window bounds",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	s, err := newSeries(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &derivative{
+		series: s,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (d *derivative) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	points, err := d.points(docs, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(docs))
+
+	for i := range docs {
+		lo, hi, err := d.bounds.window(docs, i)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = types.Null
+
+		if hi-lo < 2 {
+			continue
+		}
+
+		first, last := points[lo], points[hi-1]
+		if !first.ok || !last.ok || first.x == last.x {
+			continue
+		}
+
+		res[i] = (last.y - first.y) / (last.x - first.x)
+	}
+
+	return res, nil
+}
+
+// series contains common fields of window operators treating documents of the partition
+// as a series of points with sortBy values as x coordinates and input values as y coordinates.
+type series struct {
+	input    *expression
+	unit     string // empty if not set
+	bounds   *bounds
+	sortPath types.Path
+}
+
+// point represents a point of the series.
+type point struct {
+	x, y float64
+	ok   bool // false if x or y is not a number
+}
+
+// newSeries validates the specification and creates a new series.
+func newSeries(params *newOperatorParams) (*series, error) {
+	if err := requireSortBy(params, true); err != nil {
+		return nil, err
+	}
+
+	args, err := parseArgs(params, []string{"input"}, "unit")
+	if err != nil {
+		return nil, err
+	}
+
+	input, err := newExpression(must.NotFail(args.Get("input")), params.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := newBounds(params)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &series{
+		input:    input,
+		bounds:   b,
+		sortPath: sortPath(params.sortBy),
+	}
+
+	if v, err := args.Get("unit"); err == nil {
+		unit, ok := v.(string)
+		if _, isUnit := aggregations.TimeUnits[unit]; !ok || !isUnit {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("%s 'unit' must be one of week, day, hour, minute, second or millisecond: %s",
+					params.name, types.FormatAnyValue(v),
+				),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		s.unit = unit
+	}
+
+	return s, nil
+}
+
+// points returns points of the series for the given documents.
+func (s *series) points(docs []*types.Document, vars aggregations.Variables) ([]point, error) {
+	res := make([]point, len(docs))
+
+	for i, doc := range docs {
+		x, ok, err := s.x(sortValue(doc, s.sortPath))
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		v, _, err := s.input.evaluate(doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		y, ok := toFloat(v)
+		if !ok {
+			continue
+		}
+
+		res[i] = point{x: x, y: y, ok: true}
+	}
+
+	return res, nil
+}
+
+// x returns x coordinate for the given sortBy value.
+// If unit is set, sortBy values must be dates, and the result is expressed in units.
+func (s *series) x(v any) (float64, bool, error) {
+	t, isTime := v.(time.Time)
+
+	switch {
+	case s.unit != "" && !isTime:
+		return 0, false, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSetWindowFieldsInvalidRangeValue,
+			fmt.Sprintf("Expected the sortBy field to be a Date, but it was %s", handlerparams.AliasFromType(v)),
+			"$setWindowFields (stage)",
+		)
+	case s.unit == "" && isTime:
+		return 0, false, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSetWindowFieldsInvalidRangeValue,
+			"For windows that involve date or time ranges, a unit must be provided",
+			"$setWindowFields (stage)",
+		)
+	case isTime:
+		return float64(t.UnixMilli()) / float64(aggregations.TimeUnits[s.unit].Milliseconds()), true, nil
+	}
+
+	x, ok := toFloat(v)
+
+	return x, ok, nil
+}
+
+// toFloat returns the given number as float64.
+// It returns false if the value is not a number.
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// check interfaces
+var (
+	_ Operator = (*derivative)(nil)
+)
@@ -0,0 +1,138 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// expMovingAvg represents $expMovingAvg window operator.
+//
+//	{ $expMovingAvg: { input: <expression>, N: <positive integer> } }
+//	{ $expMovingAvg: { input: <expression>, alpha: <double between 0 and 1> } }
+//
+// The first numeric input value is used as the average,
+// each next value is weighted with alpha, and the previous average with 1 - alpha.
+// If N is specified, alpha is 2 / (N + 1).
+type expMovingAvg struct {
+	input *expression
+	alpha float64
+}
+
+// newExpMovingAvg validates the specification and creates a new $expMovingAvg window operator.
+func newExpMovingAvg(params *newOperatorParams) (Operator, error) {
+	if err := noWindow(params); err != nil {
+		return nil, err
+	}
+
+	if err := requireSortBy(params, false); err != nil {
+		return nil, err
+	}
+
+	args, err := parseArgs(params, []string{"input"}, "N", "alpha")
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Len() != 2 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"$expMovingAvg sub object must have exactly two fields: "+
+				"An 'input' field, and either an 'N' field or an 'alpha' field",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	input, err := newExpression(must.NotFail(args.Get("input")), params.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &expMovingAvg{
+		input: input,
+	}
+
+	if v, err := args.Get("N"); err == nil {
+		n, ok := toFloat(v)
+		if !ok || n != math.Trunc(n) || n <= 0 {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("'N' field must be a positive integer, but found %s", types.FormatAnyValue(v)),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		e.alpha = 2 / (n + 1)
+
+		return e, nil
+	}
+
+	v := must.NotFail(args.Get("alpha"))
+
+	alpha, ok := toFloat(v)
+	if !ok || alpha <= 0 || alpha >= 1 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("'alpha' must be between 0 and 1 (exclusive), found alpha: %s", types.FormatAnyValue(v)),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	e.alpha = alpha
+
+	return e, nil
+}
+
+// Process implements Operator interface.
+func (e *expMovingAvg) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+
+	var avg float64
+	var started bool
+
+	for i, doc := range docs {
+		v, _, err := e.input.evaluate(doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		x, ok := toFloat(v)
+		if !ok {
+			res[i] = types.Null
+			continue
+		}
+
+		if started {
+			avg = x*e.alpha + avg*(1-e.alpha)
+		} else {
+			avg, started = x, true
+		}
+
+		res[i] = avg
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*expMovingAvg)(nil)
+)
@@ -0,0 +1,89 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// integral represents $integral window operator.
+//
+//	{ $integral: { input: <expression>, unit: <time unit> }, window: <window> }
+//
+// It returns the approximation of the area under the curve of input values within the window
+// computed using the trapezoidal rule. Points with non-numeric values are skipped.
+type integral struct {
+	*series
+}
+
+// newIntegral validates the specification and creates a new $integral window operator.
+func newIntegral(params *newOperatorParams) (Operator, error) {
+	s, err := newSeries(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &integral{
+		series: s,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (in *integral) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	points, err := in.points(docs, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]any, len(docs))
+
+	for i := range docs {
+		lo, hi, err := in.bounds.window(docs, i)
+		if err != nil {
+			return nil, err
+		}
+
+		var sum float64
+		var prev *point
+
+		for j := lo; j < hi; j++ {
+			p := points[j]
+			if !p.ok {
+				continue
+			}
+
+			if prev != nil {
+				sum += (p.x - prev.x) * (p.y + prev.y) / 2
+			}
+
+			prev = &p
+		}
+
+		if prev == nil {
+			res[i] = types.Null
+			continue
+		}
+
+		res[i] = sum
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*integral)(nil)
+)
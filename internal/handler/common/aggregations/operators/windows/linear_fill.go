@@ -0,0 +1,144 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// linearFill represents $linearFill window operator.
+//
+//	{ $linearFill: <expression> }
+//
+// It returns the value of expression, or the value linearly interpolated
+// between the closest previous and next non-null values if the expression evaluates to null or missing value.
+// Values before the first and after the last non-null value are null.
+type linearFill struct {
+	input    *expression
+	sortPath types.Path
+}
+
+// newLinearFill validates the specification and creates a new $linearFill window operator.
+func newLinearFill(params *newOperatorParams) (Operator, error) {
+	if err := noWindow(params); err != nil {
+		return nil, err
+	}
+
+	if err := requireSortBy(params, true); err != nil {
+		return nil, err
+	}
+
+	input, err := newExpression(params.args, params.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &linearFill{
+		input:    input,
+		sortPath: sortPath(params.sortBy),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (l *linearFill) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+	xs := make([]float64, len(docs))
+
+	// index of the previous document with non-null value, -1 if none
+	prev := -1
+
+	for i, doc := range docs {
+		x, err := linearFillX(sortValue(doc, l.sortPath))
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 && x == xs[i-1] {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrBadValue,
+				"There can be no repeated values in the sort field",
+				"$setWindowFields (stage)",
+			)
+		}
+
+		xs[i] = x
+
+		v, ok, err := l.input.evaluate(doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		res[i] = types.Null
+
+		if !ok || v == types.Null {
+			continue
+		}
+
+		y, ok := toFloat(v)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				fmt.Sprintf("Invalid input for $linearFill: Expected a numeric type but found %s", handlerparams.AliasFromType(v)),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		res[i] = v
+
+		if prev >= 0 {
+			prevY, _ := toFloat(res[prev])
+
+			for j := prev + 1; j < i; j++ {
+				res[j] = prevY + (y-prevY)*(xs[j]-xs[prev])/(x-xs[prev])
+			}
+		}
+
+		prev = i
+	}
+
+	return res, nil
+}
+
+// linearFillX returns x coordinate for the given sortBy value of $linearFill.
+func linearFillX(v any) (float64, error) {
+	if t, ok := v.(time.Time); ok {
+		return float64(t.UnixMilli()), nil
+	}
+
+	x, ok := toFloat(v)
+	if !ok {
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"Invalid sortBy for $linearFill: Expected a numeric or date type but found %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	return x, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*linearFill)(nil)
+)
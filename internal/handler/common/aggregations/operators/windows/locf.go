@@ -0,0 +1,77 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// locf represents $locf window operator.
+//
+//	{ $locf: <expression> }
+//
+// It returns the value of expression, or the last non-null value
+// of the previous documents if the expression evaluates to null or missing value.
+type locf struct {
+	input *expression
+}
+
+// newLocf validates the specification and creates a new $locf window operator.
+func newLocf(params *newOperatorParams) (Operator, error) {
+	if err := noWindow(params); err != nil {
+		return nil, err
+	}
+
+	if err := requireSortBy(params, false); err != nil {
+		return nil, err
+	}
+
+	input, err := newExpression(params.args, params.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &locf{
+		input: input,
+	}, nil
+}
+
+// Process implements Operator interface.
+func (l *locf) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+
+	last := any(types.Null)
+
+	for i, doc := range docs {
+		v, ok, err := l.input.evaluate(doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok && v != types.Null {
+			last = v
+		}
+
+		res[i] = last
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*locf)(nil)
+)
@@ -0,0 +1,117 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"math"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// rankType represents a type of rank computed by rank operator.
+type rankType int
+
+const (
+	rankTypeRank rankType = iota
+	rankTypeDense
+	rankTypeDocumentNumber
+)
+
+// rank represents $rank, $denseRank and $documentNumber window operators.
+//
+//	{ $rank: {} }
+//	{ $denseRank: {} }
+//	{ $documentNumber: {} }
+type rank struct {
+	typ      rankType
+	sortPath types.Path
+}
+
+// newRank creates a new $rank window operator.
+func newRank(params *newOperatorParams) (Operator, error) {
+	return newRankOperator(params, rankTypeRank)
+}
+
+// newDenseRank creates a new $denseRank window operator.
+func newDenseRank(params *newOperatorParams) (Operator, error) {
+	return newRankOperator(params, rankTypeDense)
+}
+
+// newDocumentNumber creates a new $documentNumber window operator.
+func newDocumentNumber(params *newOperatorParams) (Operator, error) {
+	return newRankOperator(params, rankTypeDocumentNumber)
+}
+
+// newRankOperator validates the specification and creates a new rank window operator of the given type.
+func newRankOperator(params *newOperatorParams, typ rankType) (Operator, error) {
+	if args, ok := params.args.(*types.Document); !ok || args.Len() != 0 || params.window != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"Rank style window functions take no other arguments",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	if err := requireSortBy(params, true); err != nil {
+		return nil, err
+	}
+
+	return &rank{
+		typ:      typ,
+		sortPath: sortPath(params.sortBy),
+	}, nil
+}
+
+// Process implements Operator interface.
+func (r *rank) Process(docs []*types.Document, _ aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+
+	var current int64
+	var prev any
+
+	for i, doc := range docs {
+		v := sortValue(doc, r.sortPath)
+
+		switch r.typ {
+		case rankTypeRank:
+			if i == 0 || types.CompareForAggregation(v, prev) != types.Equal {
+				current = int64(i) + 1
+			}
+		case rankTypeDense:
+			if i == 0 || types.CompareForAggregation(v, prev) != types.Equal {
+				current++
+			}
+		case rankTypeDocumentNumber:
+			current = int64(i) + 1
+		}
+
+		prev = v
+
+		if current <= math.MaxInt32 {
+			res[i] = int32(current)
+		} else {
+			res[i] = current
+		}
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*rank)(nil)
+)
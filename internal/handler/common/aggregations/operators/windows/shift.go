@@ -0,0 +1,146 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package windows
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// shift represents $shift window operator.
+//
+//	{ $shift: { output: <expression>, by: <integer>, default: <constant expression> } }
+//
+// It returns the value of output expression evaluated for the document
+// at the given offset from the current document of the partition.
+type shift struct {
+	output *expression
+	by     int
+	def    any
+}
+
+// newShift validates the specification and creates a new $shift window operator.
+func newShift(params *newOperatorParams) (Operator, error) {
+	if err := noWindow(params); err != nil {
+		return nil, err
+	}
+
+	if err := requireSortBy(params, false); err != nil {
+		return nil, err
+	}
+
+	args, err := parseArgs(params, []string{"output", "by"}, "default")
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := newExpression(must.NotFail(args.Get("output")), params.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	var by int
+
+	byValue := must.NotFail(args.Get("by"))
+
+	switch v := byValue.(type) {
+	case int32:
+		by = int(v)
+	case int64:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, shiftByError(byValue)
+		}
+
+		by = int(v)
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, shiftByError(byValue)
+		}
+
+		by = int(v)
+	default:
+		return nil, shiftByError(byValue)
+	}
+
+	def := any(types.Null)
+
+	if v, err := args.Get("default"); err == nil {
+		// default must be a constant expression, so it is evaluated against an empty document
+		e, err := newExpression(v, params.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		res, ok, err := e.evaluate(types.MakeDocument(0), params.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			def = res
+		}
+	}
+
+	return &shift{
+		output: output,
+		by:     by,
+		def:    def,
+	}, nil
+}
+
+// shiftByError returns an error for invalid 'by' argument of $shift.
+func shiftByError(v any) error {
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrFailedToParse,
+		fmt.Sprintf("'$shift:by' field must be an integer, but found %s", types.FormatAnyValue(v)),
+		"$setWindowFields (stage)",
+	)
+}
+
+// Process implements Operator interface.
+func (s *shift) Process(docs []*types.Document, vars aggregations.Variables) ([]any, error) {
+	res := make([]any, len(docs))
+
+	for i := range docs {
+		j := i + s.by
+		if j < 0 || j >= len(docs) {
+			res[i] = s.def
+			continue
+		}
+
+		v, ok, err := s.output.evaluate(docs[j], vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			v = types.Null
+		}
+
+		res[i] = v
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ Operator = (*shift)(nil)
+)
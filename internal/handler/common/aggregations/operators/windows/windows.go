@@ -0,0 +1,268 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package windows provides aggregation window operators.
+// Window operators are used only in `$setWindowFields` stage.
+// They compute a value for each document of a sorted partition
+// using other documents of the same partition.
+//
+// Accumulators (like `$sum`) can be used as window operators too,
+// they are applied to documents in the window of each document.
+package windows
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators/accumulators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// newOperatorFunc is a type for a function that creates a window operator.
+type newOperatorFunc func(params *newOperatorParams) (Operator, error)
+
+// newOperatorParams contains a specification of the window operator.
+type newOperatorParams struct {
+	name   string                 // operator name, such as $rank
+	args   any                    // operator arguments
+	window *types.Document        // nil if window is not set
+	sortBy *types.Document        // nil if sortBy is not set
+	vars   aggregations.Variables // variables available for expressions
+}
+
+// Operator is a common interface for window operators.
+type Operator interface {
+	// Process returns the results of applying operator to each document of the partition.
+	// Documents of the partition are sorted by sortBy fields.
+	// Variables are used for evaluating expressions accessing variables.
+	Process(docs []*types.Document, vars aggregations.Variables) ([]any, error)
+}
+
+// NewOperator returns window operator for the given output field specification.
+//
+// The specification looks like `{<$operator>: <arguments>, window: <window>}`.
+func NewOperator(key string, value any, sortBy *types.Document, vars aggregations.Variables) (Operator, error) {
+	spec, ok := value.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("The field '%s' must be an object", key),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	params := &newOperatorParams{
+		sortBy: sortBy,
+		vars:   vars,
+	}
+
+	for _, k := range spec.Keys() {
+		v := must.NotFail(spec.Get(k))
+
+		switch {
+		case k == "window":
+			if params.window, ok = v.(*types.Document); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrFailedToParse,
+					"'window' field must be an object",
+					"$setWindowFields (stage)",
+				)
+			}
+		case !strings.HasPrefix(k, "$"):
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("Window function found an unknown argument: %s", k),
+				"$setWindowFields (stage)",
+			)
+		case params.name != "":
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("Cannot specify multiple functions in window function spec: %s", types.FormatAnyValue(spec)),
+				"$setWindowFields (stage)",
+			)
+		default:
+			params.name = k
+			params.args = v
+		}
+	}
+
+	if params.name == "" {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("Expected a $-prefixed window function, %s", key),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	if newOperator, ok := Operators[params.name]; ok {
+		return newOperator(params)
+	}
+
+	if _, ok := accumulators.Accumulators[params.name]; ok {
+		return newAccumulator(params)
+	}
+
+	return nil, handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrFailedToParse,
+		fmt.Sprintf("Unrecognized window function, %s", params.name),
+		"$setWindowFields (stage)",
+	)
+}
+
+// Operators maps all window operators that are not accumulators.
+var Operators = map[string]newOperatorFunc{
+	// sorted alphabetically
+	"$denseRank":      newDenseRank,
+	"$derivative":     newDerivative,
+	"$documentNumber": newDocumentNumber,
+	"$expMovingAvg":   newExpMovingAvg,
+	"$integral":       newIntegral,
+	"$linearFill":     newLinearFill,
+	"$locf":           newLocf,
+	"$rank":           newRank,
+	"$shift":          newShift,
+	// please keep sorted alphabetically
+}
+
+// expression represents an expression used as an argument of window operator.
+type expression struct {
+	op operators.Operator
+}
+
+// newExpression validates and creates a new expression.
+func newExpression(expr any, vars aggregations.Variables) (*expression, error) {
+	exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("v", expr))))
+
+	op, err := operators.NewExpr(exprValue, "$setWindowFields (stage)", vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expression{
+		op: op,
+	}, nil
+}
+
+// evaluate returns the value of expression for the given document.
+// It returns false if the expression evaluates to missing value.
+func (e *expression) evaluate(doc *types.Document, vars aggregations.Variables) (any, bool, error) {
+	res, err := e.op.Process(doc, vars)
+	if err != nil {
+		return nil, false, err
+	}
+
+	evaluated, ok := res.(*types.Document)
+	if !ok {
+		return nil, false, lazyerrors.Errorf("unexpected type %T", res)
+	}
+
+	v, err := evaluated.Get("v")
+	if err != nil {
+		return nil, false, nil
+	}
+
+	return v, true, nil
+}
+
+// noWindow returns an error if window is set for the operator that does not accept it.
+func noWindow(params *newOperatorParams) error {
+	if params.window == nil {
+		return nil
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrFailedToParse,
+		fmt.Sprintf("'window' field is not allowed in %s", params.name),
+		"$setWindowFields (stage)",
+	)
+}
+
+// requireSortBy returns an error if sortBy is not set or, if single is true,
+// it does not contain exactly one field.
+func requireSortBy(params *newOperatorParams, single bool) error {
+	if params.sortBy != nil && (!single || params.sortBy.Len() == 1) {
+		return nil
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrStageSetWindowFieldsSortBy,
+		fmt.Sprintf("%s must be specified with a top level sortBy expression with exactly one element", params.name),
+		"$setWindowFields (stage)",
+	)
+}
+
+// parseArgs returns arguments of the operator that accepts an object with the given fields.
+// Required fields must be present, other fields are optional.
+func parseArgs(params *newOperatorParams, required []string, optional ...string) (*types.Document, error) {
+	args, ok := params.args.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("Argument to %s must be an object", params.name),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	for _, k := range args.Keys() {
+		var known bool
+
+		for _, f := range append(required, optional...) {
+			if k == f {
+				known = true
+				break
+			}
+		}
+
+		if !known {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("%s got unexpected argument: %s", params.name, k),
+				"$setWindowFields (stage)",
+			)
+		}
+	}
+
+	for _, f := range required {
+		if !args.Has(f) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("%s requires an '%s' expression", params.name, f),
+				"$setWindowFields (stage)",
+			)
+		}
+	}
+
+	return args, nil
+}
+
+// sortValue returns the value of the only sortBy field of the document.
+// It returns null if the field is missing.
+func sortValue(doc *types.Document, path types.Path) any {
+	v, err := doc.GetByPath(path)
+	if err != nil {
+		return types.Null
+	}
+
+	return v
+}
+
+// sortPath returns the path of the only sortBy field.
+func sortPath(sortBy *types.Document) types.Path {
+	return must.NotFail(types.NewPathFromString(sortBy.Keys()[0]))
+}
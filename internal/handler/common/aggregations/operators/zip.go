@@ -0,0 +1,226 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package operators
+
+import (
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// zip represents `$zip` operator.
+//
+//	{ $zip: { inputs: [ <array expression1>,  ... ], useLongestLength: <boolean>, defaults: <array expression> } }
+type zip struct {
+	inputs           []any
+	defaults         []any
+	useLongestLength bool
+}
+
+// newZip returns `$zip` operator.
+func newZip(args ...any) (Operator, error) {
+	var spec *types.Document
+	if len(args) == 1 {
+		spec, _ = args[0].(*types.Document)
+	}
+
+	if spec == nil {
+		var v any = types.Null
+		if len(args) == 1 {
+			v = args[0]
+		}
+
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrZipInvalidSpec,
+			"$zip",
+			fmt.Sprintf("$zip only supports an object as an argument, found %s", handlerparams.AliasFromType(v)),
+		)
+	}
+
+	z := new(zip)
+
+	for _, k := range spec.Keys() {
+		v := must.NotFail(spec.Get(k))
+
+		switch k {
+		case "inputs":
+			arr, ok := v.(*types.Array)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrZipInputsNotArray,
+					"$zip",
+					fmt.Sprintf("inputs must be an array of expressions, found %s", handlerparams.AliasFromType(v)),
+				)
+			}
+
+			z.inputs = arrayValues(arr)
+
+		case "defaults":
+			arr, ok := v.(*types.Array)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrZipDefaultsNotArray,
+					"$zip",
+					fmt.Sprintf("defaults must be an array of expressions, found %s", handlerparams.AliasFromType(v)),
+				)
+			}
+
+			z.defaults = arrayValues(arr)
+
+		case "useLongestLength":
+			b, ok := v.(bool)
+			if !ok {
+				return nil, newOperatorArgumentError(
+					handlererrors.ErrZipUseLongestLengthNotBool,
+					"$zip",
+					fmt.Sprintf("useLongestLength must be a bool, found %s", handlerparams.AliasFromType(v)),
+				)
+			}
+
+			z.useLongestLength = b
+
+		default:
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrZipUnknownArgument,
+				"$zip",
+				fmt.Sprintf("$zip found an unknown argument: %s", k),
+			)
+		}
+	}
+
+	if len(z.inputs) == 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrZipMissingInputs,
+			"$zip",
+			"$zip requires at least one input array",
+		)
+	}
+
+	if !z.useLongestLength && len(z.defaults) > 0 {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrZipDefaultsWithoutLongestLength,
+			"$zip",
+			"cannot specify defaults unless useLongestLength is true",
+		)
+	}
+
+	if len(z.defaults) > 0 && len(z.defaults) != len(z.inputs) {
+		return nil, newOperatorArgumentError(
+			handlererrors.ErrZipDefaultsLengthMismatch,
+			"$zip",
+			"defaults and inputs must have the same length",
+		)
+	}
+
+	return z, nil
+}
+
+// Process implements Operator interface.
+//
+// The result is as long as the shortest input array,
+// or the longest one if useLongestLength is set, with missing elements taken from defaults.
+func (z *zip) Process(doc *types.Document, vars aggregations.Variables) (any, error) {
+	inputs := make([]*types.Array, len(z.inputs))
+	var hasNull bool
+
+	for i, expr := range z.inputs {
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if v == types.Null || doc == nil {
+			hasNull = true
+			continue
+		}
+
+		arr, ok := v.(*types.Array)
+		if !ok {
+			return nil, newOperatorArgumentError(
+				handlererrors.ErrZipInputNotArray,
+				"$zip",
+				fmt.Sprintf("$zip found a non-array expression in input: %s", types.FormatAnyValue(v)),
+			)
+		}
+
+		inputs[i] = arr
+	}
+
+	defaults := make([]any, len(z.inputs))
+	for i := range defaults {
+		defaults[i] = types.Null
+	}
+
+	for i, expr := range z.defaults {
+		v, err := processExpr(expr, doc, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		defaults[i] = v
+	}
+
+	if hasNull {
+		return types.Null, nil
+	}
+
+	length := inputs[0].Len()
+
+	for _, arr := range inputs[1:] {
+		if z.useLongestLength {
+			length = max(length, arr.Len())
+		} else {
+			length = min(length, arr.Len())
+		}
+	}
+
+	res := types.MakeArray(length)
+
+	for i := 0; i < length; i++ {
+		tuple := types.MakeArray(len(inputs))
+
+		for j, arr := range inputs {
+			if i < arr.Len() {
+				tuple.Append(must.NotFail(arr.Get(i)))
+				continue
+			}
+
+			tuple.Append(defaults[j])
+		}
+
+		res.Append(tuple)
+	}
+
+	return res, nil
+}
+
+// arrayValues returns values of the array as a slice.
+func arrayValues(arr *types.Array) []any {
+	res := make([]any, arr.Len())
+	for i := range res {
+		res[i] = must.NotFail(arr.Get(i))
+	}
+
+	return res
+}
+
+// check interfaces
+var (
+	_ Operator = (*zip)(nil)
+)
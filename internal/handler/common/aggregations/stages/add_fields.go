@@ -31,10 +31,11 @@ import (
 //	{ $addFields: { <newField>: <expression>, ... } }
 type addFields struct {
 	newField *types.Document
+	vars     aggregations.Variables
 }
 
 // newAddFields validates stage document and creates a new $addFields stage.
-func newAddFields(stage *types.Document) (aggregations.Stage, error) {
+func newAddFields(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	fields, err := stage.Get("$addFields")
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -59,12 +60,13 @@ func newAddFields(stage *types.Document) (aggregations.Stage, error) {
 
 	return &addFields{
 		newField: fieldsDoc,
+		vars:     params.Variables,
 	}, nil
 }
 
 // Process implements Stage interface.
 func (s *addFields) Process(_ context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
-	return common.AddFieldsIterator(iter, closer, s.newField), nil
+	return common.AddFieldsIterator(iter, closer, s.newField, s.vars), nil
 }
 
 // check interfaces
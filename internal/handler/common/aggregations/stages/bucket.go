@@ -0,0 +1,414 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators/accumulators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// bucket represents $bucket stage.
+//
+//	{ $bucket: {
+//		groupBy: <expression>,
+//		boundaries: [ <lowerbound1>, <lowerbound2>, ... ],
+//		default: <literal>,
+//		output: {
+//			<output1>: { <accumulator>: <expression> },
+//			...
+//		}
+//	} }
+//
+// $bucket groups documents into buckets by the value of groupBy expression.
+// Each bucket includes values greater than or equal to its lower boundary and less than the next boundary,
+// documents outside of boundaries are placed into the default bucket.
+// For each bucket, accumulators are applied the same way as in $group.
+type bucket struct {
+	groupBy      any
+	defaultValue any // nil if not set
+	vars         aggregations.Variables
+	boundaries   []any
+	output       []groupBy
+}
+
+// newBucket validates and creates a new $bucket stage.
+func newBucket(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$bucket"))
+
+	fields, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketInvalidSpec,
+			fmt.Sprintf("Argument to $bucket stage must be an object, but found type: %s.", handlerparams.AliasFromType(v)),
+			"$bucket (stage)",
+		)
+	}
+
+	b := &bucket{
+		vars: params.Variables,
+	}
+
+	iter := fields.Iterator()
+	defer iter.Close()
+
+	for {
+		field, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch field {
+		case "groupBy":
+			b.groupBy = v
+
+		case "boundaries":
+			if b.boundaries, err = newBucketBoundaries(v); err != nil {
+				return nil, err
+			}
+
+		case "default":
+			if !isBucketConstant(v) {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageBucketDefaultNotConstant,
+					fmt.Sprintf("The $bucket 'default' field must be a constant expression, but found: %s.", types.FormatAnyValue(v)),
+					"$bucket (stage)",
+				)
+			}
+
+			b.defaultValue = v
+
+		case "output":
+			output, ok := v.(*types.Document)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageBucketInvalidOutput,
+					fmt.Sprintf("The $bucket 'output' field must be an object, but found type: %s.", handlerparams.AliasFromType(v)),
+					"$bucket (stage)",
+				)
+			}
+
+			if b.output, err = newBucketOutput("$bucket", output); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketUnknownOption,
+				fmt.Sprintf("Unrecognized option to $bucket: %s.", field),
+				"$bucket (stage)",
+			)
+		}
+	}
+
+	if b.groupBy == nil || b.boundaries == nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketMissingRequired,
+			"$bucket requires 'groupBy' and 'boundaries' to be specified.",
+			"$bucket (stage)",
+		)
+	}
+
+	var validGroupBy bool
+
+	switch groupBy := b.groupBy.(type) {
+	case string:
+		validGroupBy = strings.HasPrefix(groupBy, "$")
+	case *types.Document:
+		validGroupBy = groupBy.Len() > 0 && strings.HasPrefix(groupBy.Keys()[0], "$")
+	}
+
+	if !validGroupBy {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketInvalidGroupBy,
+			fmt.Sprintf(
+				"The $bucket 'groupBy' field must be defined as a $-prefixed path or an expression, but found: %s.",
+				types.FormatAnyValue(b.groupBy),
+			),
+			"$bucket (stage)",
+		)
+	}
+
+	if err := validateBucketGroupBy(b.groupBy, b.vars); err != nil {
+		return nil, err
+	}
+
+	if b.defaultValue != nil && sameBucketType(b.defaultValue, b.boundaries[0]) {
+		lower, upper := b.boundaries[0], b.boundaries[len(b.boundaries)-1]
+
+		if types.CompareOrder(b.defaultValue, lower, types.Ascending) != types.Less &&
+			types.CompareOrder(b.defaultValue, upper, types.Ascending) == types.Less {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketDefaultInRange,
+				"The $bucket 'default' field must be less than the lowest boundary or greater than or equal to the highest boundary.",
+				"$bucket (stage)",
+			)
+		}
+	}
+
+	if b.output == nil {
+		var err error
+		if b.output, err = newBucketOutput("$bucket", nil); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	return b, nil
+}
+
+// newBucketBoundaries validates and returns $bucket boundaries.
+func newBucketBoundaries(v any) ([]any, error) {
+	arr, ok := v.(*types.Array)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketBoundariesNotArray,
+			fmt.Sprintf("The $bucket 'boundaries' field must be an array, but found type: %s.", handlerparams.AliasFromType(v)),
+			"$bucket (stage)",
+		)
+	}
+
+	boundaries := make([]any, arr.Len())
+
+	for i := range boundaries {
+		boundary := must.NotFail(arr.Get(i))
+
+		if !isBucketConstant(boundary) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketBoundariesNotConstant,
+				fmt.Sprintf(
+					"The $bucket 'boundaries' field must be an array of constant values, but found value: %s.",
+					types.FormatAnyValue(boundary),
+				),
+				"$bucket (stage)",
+			)
+		}
+
+		boundaries[i] = boundary
+	}
+
+	if len(boundaries) < 2 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketBoundariesTooFew,
+			fmt.Sprintf(
+				"The $bucket 'boundaries' field must have at least 2 values, but found %d value(s).",
+				len(boundaries),
+			),
+			"$bucket (stage)",
+		)
+	}
+
+	for i := 1; i < len(boundaries); i++ {
+		lower, upper := boundaries[i-1], boundaries[i]
+
+		if !sameBucketType(lower, upper) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketBoundariesMixedTypes,
+				fmt.Sprintf(
+					"All values in the the 'boundaries' option to $bucket must have the same type. "+
+						"Found conflicting types %s and %s.",
+					handlerparams.AliasFromType(lower), handlerparams.AliasFromType(upper),
+				),
+				"$bucket (stage)",
+			)
+		}
+
+		if types.CompareOrder(lower, upper, types.Ascending) != types.Less {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketBoundariesNotSorted,
+				fmt.Sprintf(
+					"The 'boundaries' option to $bucket must be sorted, but elements %d and %d "+
+						"are not in ascending order (%s is not less than %s).",
+					i-1, i, types.FormatAnyValue(lower), types.FormatAnyValue(upper),
+				),
+				"$bucket (stage)",
+			)
+		}
+	}
+
+	return boundaries, nil
+}
+
+// newBucketOutput returns accumulators of $bucket and $bucketAuto output.
+// If output is nil, the default output { count: { $sum: 1 } } is used.
+func newBucketOutput(stage string, output *types.Document) ([]groupBy, error) {
+	if output == nil {
+		output = must.NotFail(types.NewDocument("count", must.NotFail(types.NewDocument("$sum", int32(1)))))
+	}
+
+	res := make([]groupBy, 0, output.Len())
+
+	iter := output.Iterator()
+	defer iter.Close()
+
+	for {
+		field, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		accumulator, err := accumulators.NewAccumulator(stage, field, v)
+		if err != nil {
+			return nil, processGroupStageError(err)
+		}
+
+		res = append(res, groupBy{
+			outputField: field,
+			accumulator: accumulator,
+		})
+	}
+
+	return res, nil
+}
+
+// validateBucketGroupBy returns an error if groupBy expression of $bucket or $bucketAuto is invalid.
+func validateBucketGroupBy(groupBy any, vars aggregations.Variables) error {
+	if s, ok := groupBy.(string); ok {
+		if _, err := aggregations.NewExpression(s, nil); err != nil {
+			return processGroupStageError(err)
+		}
+
+		return nil
+	}
+
+	return validateGroupKey(groupBy, vars)
+}
+
+// isBucketConstant returns true if the given value is a constant, not an expression or an operator.
+func isBucketConstant(v any) bool {
+	switch v := v.(type) {
+	case string:
+		return !strings.HasPrefix(v, "$")
+	case *types.Document:
+		return !operators.IsOperator(v)
+	default:
+		return true
+	}
+}
+
+// sameBucketType returns true if both values have the same type, all numbers are considered to have the same type.
+func sameBucketType(a, b any) bool {
+	isNumber := func(v any) bool {
+		switch v.(type) {
+		case float64, int32, int64:
+			return true
+		default:
+			return false
+		}
+	}
+
+	if isNumber(a) && isNumber(b) {
+		return true
+	}
+
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+// Process implements Stage interface.
+func (b *bucket) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var m groupMap
+
+	for {
+		_, doc, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		v, err := evaluateGroupKey(b.groupBy, doc, b.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := b.bucketKey(v)
+		if err != nil {
+			return nil, err
+		}
+
+		m.addOrAppend(key, doc)
+	}
+
+	// buckets are sorted by _id the same way as $sort stage does
+	slices.SortStableFunc(m.docs, func(a, b groupedDocuments) int {
+		return int(types.CompareOrderForSort(a.groupID, b.groupID, types.Ascending))
+	})
+
+	res := make([]*types.Document, 0, len(m.docs))
+
+	for _, groupedDocument := range m.docs {
+		doc, err := accumulateGroup("$bucket", groupedDocument, b.output, b.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, doc)
+	}
+
+	iter = iterator.Values(iterator.ForSlice(res))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// bucketKey returns the lower boundary of the bucket containing the given value,
+// or the default value if there is no such bucket.
+func (b *bucket) bucketKey(v any) (any, error) {
+	// index of the first boundary greater than v
+	i := slices.IndexFunc(b.boundaries, func(boundary any) bool {
+		return types.CompareOrder(boundary, v, types.Ascending) == types.Greater
+	})
+
+	if i > 0 {
+		return b.boundaries[i-1], nil
+	}
+
+	if b.defaultValue == nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrSwitchNoMatchingBranch,
+			"$switch could not find a matching branch for an input, and no default was specified.",
+			"$bucket (stage)",
+		)
+	}
+
+	return b.defaultValue, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*bucket)(nil)
+)
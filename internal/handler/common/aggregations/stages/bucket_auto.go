@@ -0,0 +1,426 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// bucketAuto represents $bucketAuto stage.
+//
+//	{ $bucketAuto: {
+//		groupBy: <expression>,
+//		buckets: <number>,
+//		output: {
+//			<output1>: { <accumulator>: <expression> },
+//			...
+//		},
+//		granularity: <string>
+//	} }
+//
+// $bucketAuto sorts documents by the value of groupBy expression and distributes them
+// evenly into the specified number of buckets, boundaries are determined automatically.
+// Documents with the same value are always placed into the same bucket.
+// For each bucket, accumulators are applied the same way as in $group.
+type bucketAuto struct {
+	groupBy     any
+	granularity *granularity // nil if not set
+	vars        aggregations.Variables
+	output      []groupBy
+	buckets     int
+}
+
+// newBucketAuto validates and creates a new $bucketAuto stage.
+func newBucketAuto(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$bucketAuto"))
+
+	fields, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoInvalidSpec,
+			fmt.Sprintf("The argument to $bucketAuto must be an object, but found type: %s", handlerparams.AliasFromType(v)),
+			"$bucketAuto (stage)",
+		)
+	}
+
+	b := &bucketAuto{
+		vars: params.Variables,
+	}
+
+	iter := fields.Iterator()
+	defer iter.Close()
+
+	for {
+		field, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch field {
+		case "groupBy":
+			if err = validateBucketAutoGroupBy(v, b.vars); err != nil {
+				return nil, err
+			}
+
+			b.groupBy = v
+
+		case "buckets":
+			if b.buckets, err = newBucketAutoBuckets(v); err != nil {
+				return nil, err
+			}
+
+		case "output":
+			output, ok := v.(*types.Document)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageBucketAutoInvalidOutput,
+					fmt.Sprintf("The $bucketAuto 'output' field must be an object, but found type: %s", handlerparams.AliasFromType(v)),
+					"$bucketAuto (stage)",
+				)
+			}
+
+			if b.output, err = newBucketOutput("$bucketAuto", output); err != nil {
+				return nil, err
+			}
+
+		case "granularity":
+			name, ok := v.(string)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageBucketAutoGranularityNotString,
+					fmt.Sprintf(
+						"The $bucketAuto 'granularity' field must be a string, but found type: %s",
+						handlerparams.AliasFromType(v),
+					),
+					"$bucketAuto (stage)",
+				)
+			}
+
+			if b.granularity, ok = newGranularity(name); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageBucketAutoUnknownGranularity,
+					fmt.Sprintf("Unknown rounding granularity '%s'", name),
+					"$bucketAuto (stage)",
+				)
+			}
+
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageBucketAutoUnknownOption,
+				fmt.Sprintf("Unrecognized option to $bucketAuto: %s.", field),
+				"$bucketAuto (stage)",
+			)
+		}
+	}
+
+	if b.groupBy == nil || b.buckets == 0 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoMissingRequired,
+			"$bucketAuto requires 'groupBy' and 'buckets' to be specified",
+			"$bucketAuto (stage)",
+		)
+	}
+
+	if b.output == nil {
+		var err error
+		if b.output, err = newBucketOutput("$bucketAuto", nil); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	return b, nil
+}
+
+// validateBucketAutoGroupBy returns an error if $bucketAuto groupBy is not a valid path or expression object.
+func validateBucketAutoGroupBy(groupBy any, vars aggregations.Variables) error {
+	var valid bool
+
+	switch groupBy := groupBy.(type) {
+	case string:
+		valid = strings.HasPrefix(groupBy, "$")
+	case *types.Document:
+		valid = true
+	}
+
+	if !valid {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoInvalidGroupBy,
+			fmt.Sprintf(
+				"The $bucketAuto 'groupBy' field must be defined as a $-prefixed path or an expression object, but found: %s",
+				types.FormatAnyValue(groupBy),
+			),
+			"$bucketAuto (stage)",
+		)
+	}
+
+	return validateBucketGroupBy(groupBy, vars)
+}
+
+// newBucketAutoBuckets validates and returns the number of $bucketAuto buckets.
+func newBucketAutoBuckets(v any) (int, error) {
+	n, err := handlerparams.GetWholeNumberParam(v)
+
+	switch {
+	case errors.Is(err, handlerparams.ErrUnexpectedType):
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoBucketsNotNumeric,
+			fmt.Sprintf("The $bucketAuto 'buckets' field must be a numeric value, but found type: %s", handlerparams.AliasFromType(v)),
+			"$bucketAuto (stage)",
+		)
+	case err != nil, n < math.MinInt32, n > math.MaxInt32:
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoBucketsNotInt32,
+			fmt.Sprintf(
+				"The $bucketAuto 'buckets' field must be representable as a 32-bit integer, but found %s",
+				types.FormatAnyValue(v),
+			),
+			"$bucketAuto (stage)",
+		)
+	case n <= 0:
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoBucketsNotPositive,
+			fmt.Sprintf("The $bucketAuto 'buckets' field must be greater than 0, but found: %d", n),
+			"$bucketAuto (stage)",
+		)
+	}
+
+	return int(n), nil
+}
+
+// bucketAutoValue contains the evaluated groupBy value of the document.
+type bucketAutoValue struct {
+	value any
+	doc   *types.Document
+}
+
+// Process implements Stage interface.
+func (b *bucketAuto) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var values []bucketAutoValue
+
+	for {
+		_, doc, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		v, err := evaluateGroupKey(b.groupBy, doc, b.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if b.granularity != nil {
+			if err = validateGranularityValue(v); err != nil {
+				return nil, err
+			}
+		}
+
+		values = append(values, bucketAutoValue{value: v, doc: doc})
+	}
+
+	slices.SortStableFunc(values, func(a, b bucketAutoValue) int {
+		return int(types.CompareOrder(a.value, b.value, types.Ascending))
+	})
+
+	buckets := b.makeBuckets(values)
+
+	res := make([]*types.Document, 0, len(buckets))
+
+	for _, bucket := range buckets {
+		doc, err := accumulateGroup("$bucketAuto", bucket, b.output, b.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, doc)
+	}
+
+	iter = iterator.Values(iterator.ForSlice(res))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// makeBuckets distributes sorted values into buckets.
+//
+// Each bucket contains approximately the same number of documents,
+// but documents with the same value are never split between buckets.
+// Group ID of each bucket is a document with min (inclusive) and max (exclusive) boundaries,
+// the last bucket's max boundary is inclusive.
+// With granularity, boundaries are rounded and each bucket's min boundary
+// is the previous bucket's max boundary, so there are no gaps between buckets.
+func (b *bucketAuto) makeBuckets(values []bucketAutoValue) []groupedDocuments {
+	if len(values) == 0 {
+		return nil
+	}
+
+	bucketSize := int(math.Round(float64(len(values)) / float64(b.buckets)))
+	if bucketSize < 1 {
+		bucketSize = 1
+	}
+
+	type autoBucket struct {
+		min, max any
+		docs     []*types.Document
+	}
+
+	var buckets []autoBucket
+
+	var next int // index of the first value of the next bucket
+
+	for i := 0; i < b.buckets && next < len(values); i++ {
+		bucket := autoBucket{
+			min: values[next].value,
+		}
+
+		isLast := i == b.buckets-1
+
+		end := next + bucketSize
+		if isLast || end > len(values) {
+			end = len(values)
+		}
+
+		for _, v := range values[next:end] {
+			bucket.docs = append(bucket.docs, v.doc)
+		}
+
+		bucket.max = values[end-1].value
+		next = end
+
+		switch {
+		case isLast:
+			// the last bucket contains all remaining values
+
+		case b.granularity == nil:
+			// values equal to the max boundary are placed into the same bucket
+			for next < len(values) && types.CompareOrder(values[next].value, bucket.max, types.Ascending) == types.Equal {
+				bucket.docs = append(bucket.docs, values[next].doc)
+				next++
+			}
+
+			if next < len(values) {
+				bucket.max = values[next].value
+			}
+
+		default:
+			boundary := b.granularity.roundUp(bucket.max)
+
+			// values less than the rounded max boundary are placed into the same bucket
+			for next < len(values) && types.CompareOrder(values[next].value, boundary, types.Ascending) == types.Less {
+				bucket.docs = append(bucket.docs, values[next].doc)
+				next++
+			}
+
+			bucket.max = boundary
+
+			// zero is not rounded up, so the max boundary is set to the rounded down next value instead
+			if granularityFloat(boundary) == 0 && next < len(values) {
+				bucket.max = b.granularity.roundDown(values[next].value)
+			}
+		}
+
+		buckets = append(buckets, bucket)
+	}
+
+	if b.granularity != nil {
+		buckets[0].min = b.granularity.roundDown(buckets[0].min)
+
+		for i := 1; i < len(buckets); i++ {
+			buckets[i].min = buckets[i-1].max
+		}
+
+		buckets[len(buckets)-1].max = b.granularity.roundUp(buckets[len(buckets)-1].max)
+	}
+
+	res := make([]groupedDocuments, len(buckets))
+
+	for i, bucket := range buckets {
+		res[i] = groupedDocuments{
+			groupID:   must.NotFail(types.NewDocument("min", bucket.min, "max", bucket.max)),
+			documents: bucket.docs,
+		}
+	}
+
+	return res
+}
+
+// validateGranularityValue returns an error if the given value could not be rounded by granularity.
+func validateGranularityValue(v any) error {
+	var f float64
+
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case int32:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	default:
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoGranularityNonNumeric,
+			fmt.Sprintf(
+				"$bucketAuto can specify a 'granularity' with numeric boundaries only, but found a value with type: %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$bucketAuto (stage)",
+		)
+	}
+
+	if math.IsNaN(f) {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoGranularityNaN,
+			"$bucketAuto can specify a 'granularity' with numeric boundaries only, but found a value with type: NaN",
+			"$bucketAuto (stage)",
+		)
+	}
+
+	if f < 0 {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageBucketAutoGranularityNegative,
+			fmt.Sprintf(
+				"$bucketAuto can specify a 'granularity' with non-negative numbers only, but found a value: %s",
+				types.FormatAnyValue(v),
+			),
+			"$bucketAuto (stage)",
+		)
+	}
+
+	return nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*bucketAuto)(nil)
+)
@@ -31,7 +31,7 @@ type count struct {
 }
 
 // newCount creates a new $count stage.
-func newCount(stage *types.Document) (aggregations.Stage, error) {
+func newCount(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	field, err := common.GetRequiredParam[string](stage, "$count")
 	if err != nil {
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
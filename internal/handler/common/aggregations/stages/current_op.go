@@ -0,0 +1,197 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// currentOpTimeFormat is the format of currentOpTime field.
+const currentOpTimeFormat = "2006-01-02T15:04:05.000-07:00"
+
+// currentOpRedactedFields contains top-level command fields with credentials,
+// such as passwords of createUser and updateUser, and SASL payloads of saslStart and saslContinue.
+var currentOpRedactedFields = []string{"pwd", "payload"}
+
+// currentOp represents $currentOp stage.
+//
+//	{ $currentOp: { allUsers: <boolean>, idleConnections: <boolean>, ... } }
+//
+// It is a producer stage: input documents are ignored, and it may only be the first stage of the pipeline.
+// Only in-flight commands are listed, idle connections, sessions and cursors are not.
+// allUsers option is rejected if the current user is not allowed to see other users, see NewStageParams.
+type currentOp struct {
+	operations *diagnostics.Operations
+	allUsers   bool
+}
+
+// newCurrentOp validates stage document and creates a new $currentOp stage.
+func newCurrentOp(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$currentOp"))
+
+	fields, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("$currentOp options must be specified in an object, but found: %s", handlerparams.AliasFromType(v)),
+			"$currentOp (stage)",
+		)
+	}
+
+	var cp currentOp
+
+	for _, key := range fields.Keys() {
+		value := must.NotFail(fields.Get(key))
+
+		switch key {
+		case "allUsers", "idleConnections", "idleCursors", "idleSessions", "localOps", "backtrace", "truncateOps":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("Unrecognized option '%s' in $currentOp stage.", key),
+				"$currentOp (stage)",
+			)
+		}
+
+		b, ok := value.(bool)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf(
+					"The '%s' parameter of the $currentOp stage must be a boolean value, but found: %s",
+					key, handlerparams.AliasFromType(value),
+				),
+				"$currentOp (stage)",
+			)
+		}
+
+		if key == "allUsers" {
+			cp.allUsers = b
+		}
+	}
+
+	if cp.allUsers && !params.AllUsersAllowed {
+		return nil, newAllUsersError("$currentOp")
+	}
+
+	cp.operations = params.Operations
+
+	return &cp, nil
+}
+
+// Process implements Stage interface.
+//
+// Without allUsers option, only commands of the current user are listed.
+// Credentials in listed commands are redacted.
+func (cp *currentOp) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var ops []*diagnostics.Operation
+	if cp.operations != nil {
+		ops = cp.operations.List()
+	}
+
+	username := conninfo.Get(ctx).Username()
+
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	now := time.Now()
+	docs := make([]*types.Document, 0, len(ops))
+
+	for _, op := range ops {
+		if !cp.allUsers && username != "" && op.Username != username {
+			continue
+		}
+
+		running := now.Sub(op.Started)
+
+		doc := must.NotFail(types.NewDocument(
+			"type", "op",
+			"host", host,
+			"active", true,
+			"currentOpTime", now.Format(currentOpTimeFormat),
+			"opid", op.ID,
+		))
+
+		if op.Client.IsValid() {
+			doc.Set("client", op.Client.String())
+		}
+
+		if op.LSID != nil {
+			doc.Set("lsid", op.LSID.DeepCopy())
+		}
+
+		doc.Set("secs_running", int64(running/time.Second))
+		doc.Set("microsecs_running", running.Microseconds())
+		doc.Set("op", "command")
+		doc.Set("ns", op.NS)
+		doc.Set("command", redactCommand(op.Command))
+
+		if op.Username != "" {
+			doc.Set("effectiveUsers", must.NotFail(types.NewArray(
+				must.NotFail(types.NewDocument("user", op.Username, "db", op.UserDB)),
+			)))
+		}
+
+		docs = append(docs, doc)
+	}
+
+	res := iterator.Values(iterator.ForSlice(docs))
+	closer.Add(res)
+
+	return res, nil
+}
+
+// redactCommand returns a copy of the given command document with credentials replaced.
+func redactCommand(command *types.Document) *types.Document {
+	res := command.DeepCopy()
+
+	for _, field := range currentOpRedactedFields {
+		if res.Has(field) {
+			res.Set(field, "xxx")
+		}
+	}
+
+	return res
+}
+
+// newAllUsersError returns an error for allUsers option of the given stage used by a user
+// that is not allowed to see operations and sessions of other users.
+func newAllUsersError(stage string) error {
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrUnauthorized,
+		fmt.Sprintf("not authorized on admin to execute %s with allUsers: true", stage),
+		stage+" (stage)",
+	)
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*currentOp)(nil)
+)
@@ -0,0 +1,543 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// densify represents $densify stage.
+//
+//	{ $densify: {
+//		field: <field>,
+//		partitionByFields: [ <field 1>, <field 2>, ... ],
+//		range: {
+//			step: <number>,
+//			unit: <time unit>,
+//			bounds: < "full" or "partition" or [ <lower bound>, <upper bound> ] >
+//		}
+//	}}
+//
+// Documents are sorted by partition fields and the densified field,
+// then documents with missing values of the field are generated.
+type densify struct {
+	field             types.Path
+	partitionByFields []types.Path
+	step              any    // int32, int64 or float64
+	unit              string // empty for numeric ranges
+	bounds            string // "full", "partition", or empty for explicit bounds
+	lower             any    // explicit lower bound (inclusive), nil if not set
+	upper             any    // explicit upper bound (exclusive), nil if not set
+	sort              aggregations.Stage
+}
+
+// newDensify validates stage document and creates a new $densify stage.
+func newDensify(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields := must.NotFail(stage.Get("$densify"))
+
+	spec, ok := fields.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("The $densify stage specification must be an object, found %s", handlerparams.AliasFromType(fields)),
+			"$densify (stage)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "field", "partitionByFields", "range":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$densify.%s' is an unknown field.", k),
+				"$densify (stage)",
+			)
+		}
+	}
+
+	var d densify
+
+	v, err := getDensifyRequired(spec, "$densify", "field")
+	if err != nil {
+		return nil, err
+	}
+
+	if d.field, err = newDensifyPath("field", v); err != nil {
+		return nil, err
+	}
+
+	sortBy := types.MakeDocument(1)
+
+	if v, err = spec.Get("partitionByFields"); err == nil {
+		partitionByFields, ok := v.(*types.Array)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				fmt.Sprintf(
+					"BSON field '$densify.partitionByFields' is the wrong type '%s', expected type 'array'",
+					handlerparams.AliasFromType(v),
+				),
+				"$densify (stage)",
+			)
+		}
+
+		for i := 0; i < partitionByFields.Len(); i++ {
+			path, err := newDensifyPath("partitionByFields", must.NotFail(partitionByFields.Get(i)))
+			if err != nil {
+				return nil, err
+			}
+
+			d.partitionByFields = append(d.partitionByFields, path)
+			sortBy.Set(path.String(), int32(1))
+		}
+	}
+
+	sortBy.Set(d.field.String(), int32(1))
+
+	if d.sort, err = newSort(must.NotFail(types.NewDocument("$sort", sortBy)), params); err != nil {
+		return nil, err
+	}
+
+	if err = d.parseRange(spec); err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
+
+// getDensifyRequired returns the value of the required field of $densify stage specification.
+func getDensifyRequired(doc *types.Document, prefix, key string) (any, error) {
+	v, err := doc.Get(key)
+	if err != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			fmt.Sprintf("BSON field '%s.%s' is missing but a required field", prefix, key),
+			"$densify (stage)",
+		)
+	}
+
+	return v, nil
+}
+
+// newDensifyPath validates and returns path of the given $densify field.
+func newDensifyPath(key string, v any) (types.Path, error) {
+	field, ok := v.(string)
+	if !ok {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$densify.%s' is the wrong type '%s', expected type 'string'",
+				key, handlerparams.AliasFromType(v),
+			),
+			"$densify (stage)",
+		)
+	}
+
+	if strings.HasPrefix(field, "$") {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathInvalidName,
+			"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+			"$densify (stage)",
+		)
+	}
+
+	path, err := types.NewPathFromString(field)
+	if err != nil {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrPathContainsEmptyElement,
+			"FieldPath field names may not be empty strings.",
+			"$densify (stage)",
+		)
+	}
+
+	return path, nil
+}
+
+// parseRange validates and sets range fields of $densify stage.
+func (d *densify) parseRange(spec *types.Document) error {
+	v, err := getDensifyRequired(spec, "$densify", "range")
+	if err != nil {
+		return err
+	}
+
+	rangeSpec, ok := v.(*types.Document)
+	if !ok {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$densify.range' is the wrong type '%s', expected type 'object'",
+				handlerparams.AliasFromType(v),
+			),
+			"$densify (stage)",
+		)
+	}
+
+	for _, k := range rangeSpec.Keys() {
+		switch k {
+		case "step", "unit", "bounds":
+		default:
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$densify.range.%s' is an unknown field.", k),
+				"$densify (stage)",
+			)
+		}
+	}
+
+	if v, err = rangeSpec.Get("unit"); err == nil {
+		unit, ok := v.(string)
+		if !ok || !aggregations.IsTimeUnit(unit) {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("unknown time unit value: %s", types.FormatAnyValue(v)),
+				"$densify (stage)",
+			)
+		}
+
+		d.unit = unit
+	}
+
+	if v, err = getDensifyRequired(rangeSpec, "$densify.range", "step"); err != nil {
+		return err
+	}
+
+	switch v.(type) {
+	case int32, int64, float64:
+	default:
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$densify.range.step' is the wrong type '%s', expected types '[long, int, decimal, double]'",
+				handlerparams.AliasFromType(v),
+			),
+			"$densify (stage)",
+		)
+	}
+
+	if types.CompareForAggregation(v, int32(0)) != types.Greater {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageDensifyInvalidStep,
+			"the step parameter in a range statement must be a strictly positive numeric value",
+			"$densify (stage)",
+		)
+	}
+
+	d.step = v
+
+	if d.unit != "" {
+		step, err := handlerparams.GetWholeNumberParam(v)
+		if err != nil {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyStepNotInteger,
+				"The step parameter in a range statement must be a whole number when densifying a date range",
+				"$densify (stage)",
+			)
+		}
+
+		d.step = step
+	}
+
+	if v, err = getDensifyRequired(rangeSpec, "$densify.range", "bounds"); err != nil {
+		return err
+	}
+
+	switch bounds := v.(type) {
+	case string:
+		if bounds != "full" && bounds != "partition" {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyInvalidBoundsString,
+				"Bounds string must either be 'full' or 'partition'",
+				"$densify (stage)",
+			)
+		}
+
+		if bounds == "partition" && len(d.partitionByFields) == 0 {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyPartitionBounds,
+				"one may not specify the bounds as 'partition' without specifying "+
+					"a non-empty array of partitionByFields. You may have meant to specify 'full' bounds.",
+				"$densify (stage)",
+			)
+		}
+
+		d.bounds = bounds
+
+	case *types.Array:
+		if bounds.Len() != 2 {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyBoundsLength,
+				"A bounding array in a range statement must have exactly two elements",
+				"$densify (stage)",
+			)
+		}
+
+		d.lower = must.NotFail(bounds.Get(0))
+		d.upper = must.NotFail(bounds.Get(1))
+
+		if !d.validValue(d.lower) || !d.validValue(d.upper) {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyInvalidBounds,
+				"A bounding array must contain either both dates or both numeric types",
+				"$densify (stage)",
+			)
+		}
+
+		if types.CompareForAggregation(d.lower, d.upper) == types.Greater {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageDensifyInvalidBounds,
+				"A bounding array in a range statement must be sorted in increasing order",
+				"$densify (stage)",
+			)
+		}
+
+	default:
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageDensifyInvalidBoundsString,
+			"Range bounds must be a string or an array",
+			"$densify (stage)",
+		)
+	}
+
+	return nil
+}
+
+// validValue returns true if the value can be densified:
+// a date for a range with unit, or a number for a range without unit.
+func (d *densify) validValue(v any) bool {
+	if d.unit != "" {
+		_, ok := v.(time.Time)
+		return ok
+	}
+
+	switch v.(type) {
+	case int32, int64, float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// Process implements Stage interface.
+//
+// It fully consumes the input iterator.
+func (d *densify) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	iter, err := d.sort.Process(ctx, iter, closer)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	// global minimal and maximal values are used for "full" bounds
+	var minValue, maxValue any
+
+	for _, doc := range docs {
+		v, ok, err := d.value(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if minValue == nil || types.CompareForAggregation(v, minValue) == types.Less {
+			minValue = v
+		}
+
+		if maxValue == nil || types.CompareForAggregation(v, maxValue) == types.Greater {
+			maxValue = v
+		}
+	}
+
+	res := make([]*types.Document, 0, len(docs))
+
+	for start := 0; start < len(docs); {
+		end := start + 1
+		for end < len(docs) && d.samePartition(docs[start], docs[end]) {
+			end++
+		}
+
+		res = append(res, d.densifyPartition(docs[start:end], minValue, maxValue)...)
+		start = end
+	}
+
+	iter = iterator.Values(iterator.ForSlice(res))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// value returns the value of the densified field of the document.
+// It returns false if the value is null or missing.
+func (d *densify) value(doc *types.Document) (any, bool, error) {
+	v, err := doc.GetByPath(d.field)
+	if err != nil || v == types.Null {
+		return nil, false, nil
+	}
+
+	if d.validValue(v) {
+		return v, true, nil
+	}
+
+	expected := "numeric"
+	if d.unit != "" {
+		expected = "date"
+	}
+
+	return nil, false, handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrStageDensifyInvalidValue,
+		fmt.Sprintf("Densify field type must be %s, found %s", expected, handlerparams.AliasFromType(v)),
+		"$densify (stage)",
+	)
+}
+
+// partitionValue returns the value of the partition field of the document, null if it is missing.
+func partitionValue(doc *types.Document, path types.Path) any {
+	v, err := doc.GetByPath(path)
+	if err != nil {
+		return types.Null
+	}
+
+	return v
+}
+
+// samePartition returns true if both documents belong to the same partition.
+func (d *densify) samePartition(a, b *types.Document) bool {
+	for _, path := range d.partitionByFields {
+		if types.CompareForAggregation(partitionValue(a, path), partitionValue(b, path)) != types.Equal {
+			return false
+		}
+	}
+
+	return true
+}
+
+// densifyPartition returns sorted documents of the partition with generated documents.
+// Documents with null or missing values of the densified field are returned first.
+func (d *densify) densifyPartition(docs []*types.Document, minValue, maxValue any) []*types.Document {
+	lower, upper, inclusive := d.lower, d.upper, false
+
+	switch d.bounds {
+	case "full":
+		lower, upper, inclusive = minValue, maxValue, true
+
+	case "partition":
+		lower, upper = nil, nil
+
+		for _, doc := range docs {
+			v, ok, _ := d.value(doc)
+			if !ok {
+				continue
+			}
+
+			if lower == nil {
+				lower = v
+			}
+
+			upper = v
+		}
+
+		inclusive = true
+	}
+
+	if lower == nil {
+		return docs
+	}
+
+	res := make([]*types.Document, 0, len(docs))
+
+	var k int64
+	next := lower
+
+	// inRange returns true if the next generated value is within the range
+	inRange := func() bool {
+		c := types.CompareForAggregation(next, upper)
+		return c == types.Less || (inclusive && c == types.Equal)
+	}
+
+	advance := func() {
+		k++
+
+		if t, ok := lower.(time.Time); ok {
+			next = aggregations.AddTime(t, float64(k*d.step.(int64)), d.unit)
+			return
+		}
+
+		next = aggregations.SumNumbers(next, d.step)
+	}
+
+	for _, doc := range docs {
+		v, ok, _ := d.value(doc)
+		if !ok {
+			res = append(res, doc)
+			continue
+		}
+
+		for inRange() && types.CompareForAggregation(next, v) == types.Less {
+			res = append(res, d.generate(docs[0], next))
+			advance()
+		}
+
+		if types.CompareForAggregation(next, v) == types.Equal {
+			advance()
+		}
+
+		res = append(res, doc)
+	}
+
+	for inRange() {
+		res = append(res, d.generate(docs[0], next))
+		advance()
+	}
+
+	return res
+}
+
+// generate returns a new document with partition fields of the given partition document
+// and the given value of the densified field.
+func (d *densify) generate(partition *types.Document, value any) *types.Document {
+	doc := types.MakeDocument(len(d.partitionByFields) + 1)
+
+	for _, path := range d.partitionByFields {
+		if v, err := partition.GetByPath(path); err == nil {
+			setByPath(doc, path, v)
+		}
+	}
+
+	setByPath(doc, d.field, value)
+
+	return doc
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*densify)(nil)
+)
@@ -0,0 +1,116 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// documents represents $documents stage.
+//
+//	{ $documents: <expression> }
+//
+// The expression must evaluate to an array of documents.
+// It is a producer stage: input documents are ignored, and it may only be the first stage of the pipeline.
+type documents struct {
+	// op evaluates { documents: <expression> } document
+	op   operators.Operator
+	vars aggregations.Variables
+}
+
+// newDocuments validates stage document and creates a new $documents stage.
+func newDocuments(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	exprValue := must.NotFail(types.NewDocument(
+		"$expr", must.NotFail(types.NewDocument("documents", must.NotFail(stage.Get("$documents")))),
+	))
+
+	op, err := operators.NewExpr(exprValue, "$documents (stage)", params.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return &documents{
+		op:   op,
+		vars: params.Variables,
+	}, nil
+}
+
+// Process implements Stage interface.
+//
+// The expression is evaluated once against an empty document.
+func (d *documents) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	v, err := d.op.Process(types.MakeDocument(0), d.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	value, _ := evaluated.Get("documents")
+
+	arr, ok := value.(*types.Array)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageDocumentsNotArray,
+			"an array is expected",
+			"$documents (stage)",
+		)
+	}
+
+	docs := make([]*types.Document, arr.Len())
+
+	for i := 0; i < arr.Len(); i++ {
+		elem := must.NotFail(arr.Get(i))
+
+		doc, ok := elem.(*types.Document)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageReplaceRootNotDocument,
+				fmt.Sprintf(
+					"'newRoot' expression must evaluate to an object, but resulting value was: %s. "+
+						"Type of resulting value: '%s'.",
+					types.FormatAnyValue(elem), handlerparams.AliasFromType(elem),
+				),
+				"$documents (stage)",
+			)
+		}
+
+		// documents are copied because next stages may modify them in place
+		docs[i] = doc.DeepCopy()
+	}
+
+	res := iterator.Values(iterator.ForSlice(docs))
+	closer.Add(res)
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*documents)(nil)
+)
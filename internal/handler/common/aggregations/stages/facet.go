@@ -0,0 +1,339 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// facetNotAllowedStages contains stages that cannot be used within $facet sub-pipelines.
+var facetNotAllowedStages = map[string]struct{}{
+	// sorted alphabetically
+	"$changeStream":      {},
+	"$collStats":         {},
+	"$currentOp":         {},
+	"$documents":         {},
+	"$facet":             {},
+	"$geoNear":           {},
+	"$indexStats":        {},
+	"$listLocalSessions": {},
+	"$merge":             {},
+	"$out":               {},
+	"$planCacheStats":    {},
+	"$search":            {},
+	"$searchMeta":        {},
+	// please keep sorted alphabetically
+}
+
+// facet represents $facet stage.
+//
+//	{ $facet: {
+//		<outputField1>: [ <stage1>, <stage2>, ... ],
+//		<outputField2>: [ <stage1>, <stage2>, ... ],
+//	} }
+type facet struct {
+	facets  []facetPipeline
+	maxSize int
+}
+
+// facetPipeline represents a single named sub-pipeline of $facet stage.
+type facetPipeline struct {
+	name   string
+	stages []aggregations.Stage
+}
+
+// newFacet validates and creates a new $facet stage.
+func newFacet(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$facet"))
+
+	spec, ok := v.(*types.Document)
+	if !ok || spec.Len() == 0 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageFacetInvalidSpec,
+			fmt.Sprintf("the $facet specification must be a non-empty object, but found: %s", types.FormatAnyValue(v)),
+			"$facet (stage)",
+		)
+	}
+
+	f := &facet{
+		facets:  make([]facetPipeline, 0, spec.Len()),
+		maxSize: params.MaxBsonObjectSizeBytes,
+	}
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		name, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if err = validateFacetName(name); err != nil {
+			return nil, err
+		}
+
+		pipeline, ok := v.(*types.Array)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageFacetInvalidArgs,
+				fmt.Sprintf("arguments to $facet must be arrays, %s is type %s", name, handlerparams.AliasFromType(v)),
+				"$facet (stage)",
+			)
+		}
+
+		if pipeline.Len() == 0 {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrBadValue,
+				"sub-pipeline in $facet stage cannot be empty",
+				"$facet (stage)",
+			)
+		}
+
+		for i := 0; i < pipeline.Len(); i++ {
+			elem := must.NotFail(pipeline.Get(i))
+
+			d, ok := elem.(*types.Document)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageFacetInvalidSubPipeline,
+					fmt.Sprintf(
+						"elements of arrays in $facet spec must be non-empty objects, %s argument contained an element of type %s: %s",
+						name, handlerparams.AliasFromType(elem), types.FormatAnyValue(elem),
+					),
+					"$facet (stage)",
+				)
+			}
+
+			if d.Len() != 1 {
+				continue
+			}
+
+			if _, notAllowed := facetNotAllowedStages[d.Command()]; notAllowed {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageFacetNotAllowedStage,
+					fmt.Sprintf("%s is not allowed to be used within a $facet stage", d.Command()),
+					"$facet (stage)",
+				)
+			}
+		}
+
+		stages, err := newPipeline(pipeline, "$facet", params)
+		if err != nil {
+			return nil, err
+		}
+
+		// sub-pipelines of nested stages are validated by those stages first
+		if nested := facetNestedNotAllowedStage(pipeline); nested != "" {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageFacetNotAllowedStage,
+				fmt.Sprintf("%s is not allowed to be used within a $facet stage", nested),
+				"$facet (stage)",
+			)
+		}
+
+		f.facets = append(f.facets, facetPipeline{
+			name:   name,
+			stages: stages,
+		})
+	}
+
+	return f, nil
+}
+
+// facetNestedNotAllowedStage returns the name of the first stage not allowed within $facet
+// found in sub-pipelines of $lookup and $unionWith stages of the given pipeline, including nested ones.
+// It returns an empty string if there is no such stage.
+func facetNestedNotAllowedStage(pipeline *types.Array) string {
+	for i := 0; i < pipeline.Len(); i++ {
+		d, ok := must.NotFail(pipeline.Get(i)).(*types.Document)
+		if !ok || d.Len() != 1 {
+			continue
+		}
+
+		switch d.Command() {
+		case "$lookup", "$unionWith":
+		default:
+			continue
+		}
+
+		spec, ok := must.NotFail(d.Get(d.Command())).(*types.Document)
+		if !ok {
+			continue
+		}
+
+		v, _ := spec.Get("pipeline")
+
+		nested, ok := v.(*types.Array)
+		if !ok {
+			continue
+		}
+
+		for j := 0; j < nested.Len(); j++ {
+			s, ok := must.NotFail(nested.Get(j)).(*types.Document)
+			if !ok || s.Len() != 1 {
+				continue
+			}
+
+			if _, notAllowed := facetNotAllowedStages[s.Command()]; notAllowed {
+				return s.Command()
+			}
+		}
+
+		if name := facetNestedNotAllowedStage(nested); name != "" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// validateFacetName returns an error if the given $facet output field name is not a valid field name.
+func validateFacetName(name string) error {
+	switch {
+	case name == "":
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrPathContainsEmptyElement,
+			"FieldPath field names may not be empty strings.",
+			"$facet (stage)",
+		)
+	case strings.HasPrefix(name, "$"):
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathInvalidName,
+			"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+			"$facet (stage)",
+		)
+	case strings.Contains(name, "."):
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathContainsDot,
+			"FieldPath field names may not contain '.'. Consider using $getField or $setField.",
+			"$facet (stage)",
+		)
+	}
+
+	return nil
+}
+
+// Process implements Stage interface.
+//
+// Input documents are consumed once and passed to each sub-pipeline,
+// the result is a single document containing an array of output documents for each sub-pipeline.
+func (f *facet) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	docs, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := types.MakeDocument(len(f.facets))
+
+	for i, fp := range f.facets {
+		out, err := fp.process(ctx, docs, i == len(f.facets)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Set(fp.name, out)
+	}
+
+	doc, err := bson.ConvertDocument(res)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if size := bson.Size(doc); size > f.maxSize {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageFacetOutputTooLarge,
+			fmt.Sprintf("document constructed by $facet is %d bytes, which exceeds the limit of %d bytes", size, f.maxSize),
+			"$facet (stage)",
+		)
+	}
+
+	iter = iterator.Values(iterator.ForSlice([]*types.Document{res}))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// process applies sub-pipeline stages to the given documents and returns an array of output documents.
+//
+// Documents are copied one by one when the sub-pipeline reads them, because stages may modify documents in place;
+// the last sub-pipeline reads documents without copying, as they are not used after it,
+// and removes them from docs.
+func (fp *facetPipeline) process(ctx context.Context, docs []*types.Document, last bool) (*types.Array, error) {
+	closer := iterator.NewMultiCloser()
+	defer closer.Close()
+
+	var i int
+
+	var iter types.DocumentsIterator = iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		if i >= len(docs) {
+			return unused, nil, iterator.ErrIteratorDone
+		}
+
+		doc := docs[i]
+
+		if last {
+			// release the document as soon as possible
+			docs[i] = nil
+		} else {
+			doc = doc.DeepCopy()
+		}
+
+		i++
+
+		return unused, doc, nil
+	})
+	closer.Add(iter)
+
+	iter, err := processPipeline(ctx, fp.stages, iter, closer)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := types.MakeArray(len(out))
+	for _, doc := range out {
+		res.Append(doc)
+	}
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*facet)(nil)
+)
@@ -0,0 +1,369 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// fill represents $fill stage.
+//
+//	{ $fill: {
+//		partitionBy: <expression>,
+//		partitionByFields: [ <field 1>, <field 2>, ... ],
+//		sortBy: { <sort field 1>: <sort order>, ... },
+//		output: {
+//			<field 1>: { value: <expression> },
+//			<field 2>: { method: <"linear" or "locf"> },
+//			...
+//		}
+//	}}
+//
+// Fields filled with a method are computed by $setWindowFields stage
+// with $linearFill and $locf window operators.
+// Fields filled with a value are set if they are null or missing.
+type fill struct {
+	sort         aggregations.Stage // nil if sortBy is not set
+	windowFields aggregations.Stage // nil if no output fields use method
+	values       []fillValue
+	vars         aggregations.Variables
+}
+
+// fillValue represents an output field of $fill stage filled with a value.
+type fillValue struct {
+	path types.Path
+	op   operators.Operator
+}
+
+// newFill validates stage document and creates a new $fill stage.
+func newFill(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields := must.NotFail(stage.Get("$fill"))
+
+	spec, ok := fields.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("the $fill stage specification must be an object, found %s", handlerparams.AliasFromType(fields)),
+			"$fill (stage)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "partitionBy", "partitionByFields", "sortBy", "output":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$fill.%s' is an unknown field.", k),
+				"$fill (stage)",
+			)
+		}
+	}
+
+	if spec.Has("partitionBy") && spec.Has("partitionByFields") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageFillPartitionByConflict,
+			"Maximum one of 'partitionBy' and 'partitionByFields can be specified in '$fill'",
+			"$fill (stage)",
+		)
+	}
+
+	v, err := spec.Get("output")
+	if err != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			"BSON field '$fill.output' is missing but a required field",
+			"$fill (stage)",
+		)
+	}
+
+	output, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$fill.output' is the wrong type '%s', expected type 'object'",
+				handlerparams.AliasFromType(v),
+			),
+			"$fill (stage)",
+		)
+	}
+
+	f := &fill{
+		vars: params.Variables,
+	}
+
+	windowOutput := types.MakeDocument(0)
+
+	for _, key := range output.Keys() {
+		path, err := newFillPath(key)
+		if err != nil {
+			return nil, err
+		}
+
+		method, value, err := getFillOutput(key, must.NotFail(output.Get(key)))
+		if err != nil {
+			return nil, err
+		}
+
+		if method != "" {
+			windowOutput.Set(key, must.NotFail(types.NewDocument(method, "$"+key)))
+			continue
+		}
+
+		exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("value", value))))
+
+		op, err := operators.NewExpr(exprValue, "$fill (stage)", params.Variables)
+		if err != nil {
+			return nil, err
+		}
+
+		f.values = append(f.values, fillValue{
+			path: path,
+			op:   op,
+		})
+	}
+
+	sortBy, _ := spec.Get("sortBy")
+
+	if sortBy != nil {
+		if f.sort, err = newSort(must.NotFail(types.NewDocument("$sort", sortBy)), params); err != nil {
+			return nil, err
+		}
+	}
+
+	if windowOutput.Len() == 0 {
+		return f, nil
+	}
+
+	windowSpec := types.MakeDocument(3)
+
+	partitionBy, err := getFillPartitionBy(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if partitionBy != nil {
+		windowSpec.Set("partitionBy", partitionBy)
+	}
+
+	if sortBy != nil {
+		windowSpec.Set("sortBy", sortBy)
+	}
+
+	windowSpec.Set("output", windowOutput)
+
+	f.windowFields, err = newSetWindowFields(must.NotFail(types.NewDocument("$setWindowFields", windowSpec)), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// newFillPath returns path for the given output field of $fill.
+func newFillPath(field string) (types.Path, error) {
+	if strings.HasPrefix(field, "$") {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathInvalidName,
+			"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+			"$fill (stage)",
+		)
+	}
+
+	path, err := types.NewPathFromString(field)
+	if err != nil {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrPathContainsEmptyElement,
+			"FieldPath field names may not be empty strings.",
+			"$fill (stage)",
+		)
+	}
+
+	return path, nil
+}
+
+// getFillOutput returns the window operator name for the output field filled with a method,
+// or the value expression for the output field filled with a value.
+func getFillOutput(key string, v any) (string, any, error) {
+	spec, ok := v.(*types.Document)
+	if !ok {
+		return "", nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$fill.output.%s' is the wrong type '%s', expected type 'object'",
+				key, handlerparams.AliasFromType(v),
+			),
+			"$fill (stage)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		if k != "value" && k != "method" {
+			return "", nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$fill.output.%s' is an unknown field.", k),
+				"$fill (stage)",
+			)
+		}
+	}
+
+	if spec.Len() != 1 {
+		return "", nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"Exactly one of 'value' and 'method' must be specified in each $fill output field",
+			"$fill (stage)",
+		)
+	}
+
+	if value, err := spec.Get("value"); err == nil {
+		return "", value, nil
+	}
+
+	switch method := must.NotFail(spec.Get("method")); method {
+	case "linear":
+		return "$linearFill", nil, nil
+	case "locf":
+		return "$locf", nil, nil
+	default:
+		return "", nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("Method must be either 'linear' or 'locf', found %s", types.FormatAnyValue(method)),
+			"$fill (stage)",
+		)
+	}
+}
+
+// getFillPartitionBy returns partitionBy expression of $fill stage.
+// If partitionByFields is set, the expression is a document of those fields.
+// It returns nil if neither is set.
+func getFillPartitionBy(spec *types.Document) (any, error) {
+	if v, err := spec.Get("partitionBy"); err == nil {
+		return v, nil
+	}
+
+	v, err := spec.Get("partitionByFields")
+	if err != nil {
+		return nil, nil
+	}
+
+	fields, ok := v.(*types.Array)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$fill.partitionByFields' is the wrong type '%s', expected type 'array'",
+				handlerparams.AliasFromType(v),
+			),
+			"$fill (stage)",
+		)
+	}
+
+	res := types.MakeDocument(fields.Len())
+
+	for i := 0; i < fields.Len(); i++ {
+		field, ok := must.NotFail(fields.Get(i)).(string)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				"BSON field '$fill.partitionByFields' must be an array of strings",
+				"$fill (stage)",
+			)
+		}
+
+		if _, err = newFillPath(field); err != nil {
+			return nil, err
+		}
+
+		res.Set(strconv.Itoa(i), "$"+field)
+	}
+
+	return res, nil
+}
+
+// Process implements Stage interface.
+func (f *fill) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var err error
+
+	if f.sort != nil {
+		if iter, err = f.sort.Process(ctx, iter, closer); err != nil {
+			return nil, err
+		}
+	}
+
+	if f.windowFields != nil {
+		if iter, err = f.windowFields.Process(ctx, iter, closer); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(f.values) == 0 {
+		return iter, nil
+	}
+
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		_, doc, err := iter.Next()
+		if err != nil {
+			return unused, nil, lazyerrors.Error(err)
+		}
+
+		for _, v := range f.values {
+			if current, err := doc.GetByPath(v.path); err == nil && current != types.Null {
+				continue
+			}
+
+			res, err := v.op.Process(doc, f.vars)
+			if err != nil {
+				return unused, nil, err
+			}
+
+			evaluated, ok := res.(*types.Document)
+			if !ok {
+				return unused, nil, lazyerrors.Errorf("unexpected type %T", res)
+			}
+
+			value, err := evaluated.Get("value")
+			if err != nil {
+				value = types.Null
+			}
+
+			setByPath(doc, v.path, value)
+		}
+
+		return unused, doc, nil
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*fill)(nil)
+)
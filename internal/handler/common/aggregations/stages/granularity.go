@@ -0,0 +1,224 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+)
+
+// granularity rounds non-negative numbers to the values of the preferred number series
+// or to powers of two, it is used by $bucketAuto to round bucket boundaries.
+type granularity struct {
+	// series contains preferred numbers of a single decade in ascending order,
+	// it is nil for powers of two.
+	series []float64
+}
+
+// granularities maps granularity names to their preferred number series.
+//
+// Renard series are R5, R10, R20, R40 and R80, E series are E6, E12, E24, E48, E96 and E192,
+// see https://en.wikipedia.org/wiki/Preferred_number.
+var granularities = map[string][]float64{
+	"R5":  {10, 16, 25, 40, 63, 100},
+	"R10": {100, 125, 160, 200, 250, 315, 400, 500, 630, 800, 1000},
+	"R20": {
+		100, 112, 125, 140, 160, 180, 200, 224, 250, 280, 315,
+		355, 400, 450, 500, 560, 630, 710, 800, 900, 1000,
+	},
+	"R40": {
+		100, 106, 112, 118, 125, 132, 140, 150, 160, 170, 180, 190, 200, 212,
+		224, 236, 250, 265, 280, 300, 315, 335, 355, 375, 400, 425, 450, 475,
+		500, 530, 560, 600, 630, 670, 710, 750, 800, 850, 900, 950, 1000,
+	},
+	"R80": {
+		100, 103, 106, 109, 112, 115, 118, 122, 125, 128, 132, 136, 140, 145, 150, 155, 160,
+		165, 170, 175, 180, 185, 190, 195, 200, 206, 212, 218, 224, 230, 236, 243, 250, 258,
+		265, 272, 280, 290, 300, 307, 315, 325, 335, 345, 355, 365, 375, 387, 400, 412, 425,
+		437, 450, 462, 475, 487, 500, 515, 530, 545, 560, 580, 600, 615, 630, 650, 670, 690,
+		710, 730, 750, 775, 800, 825, 850, 875, 900, 925, 950, 975, 1000,
+	},
+	"1-2-5": {1, 2, 5, 10},
+	"E6":    {10, 15, 22, 33, 47, 68, 100},
+	"E12":   {10, 12, 15, 18, 22, 27, 33, 39, 47, 56, 68, 82, 100},
+	"E24": {
+		10, 11, 12, 13, 15, 16, 18, 20, 22, 24, 27, 30, 33,
+		36, 39, 43, 47, 51, 56, 62, 68, 75, 82, 91, 100,
+	},
+	"E48": {
+		100, 105, 110, 115, 121, 127, 133, 140, 147, 154, 162, 169, 178, 187, 196, 205, 215,
+		226, 237, 249, 261, 274, 287, 301, 316, 332, 348, 365, 383, 402, 422, 442, 464, 487,
+		511, 536, 562, 590, 619, 649, 681, 715, 750, 787, 825, 866, 909, 953, 1000,
+	},
+	"E96": {
+		100, 102, 105, 107, 110, 113, 115, 118, 121, 124, 127, 130, 133, 137, 140, 143, 147,
+		150, 154, 158, 162, 165, 169, 174, 178, 182, 187, 191, 196, 200, 205, 210, 215, 221,
+		226, 232, 237, 243, 249, 255, 261, 267, 274, 280, 287, 294, 301, 309, 316, 324, 332,
+		340, 348, 357, 365, 374, 383, 392, 402, 412, 422, 432, 442, 453, 464, 475, 487, 499,
+		511, 523, 536, 549, 562, 576, 590, 604, 619, 634, 649, 665, 681, 698, 715, 732, 750,
+		768, 787, 806, 825, 845, 866, 887, 909, 931, 953, 976, 1000,
+	},
+	"E192": {
+		100, 101, 102, 104, 105, 106, 107, 109, 110, 111, 113, 114, 115, 117, 118, 120, 121,
+		123, 124, 126, 127, 129, 130, 132, 133, 135, 137, 138, 140, 142, 143, 145, 147, 149,
+		150, 152, 154, 156, 158, 160, 162, 164, 165, 167, 169, 172, 174, 176, 178, 180, 182,
+		184, 187, 189, 191, 193, 196, 198, 200, 203, 205, 208, 210, 213, 215, 218, 221, 223,
+		226, 229, 232, 234, 237, 240, 243, 246, 249, 252, 255, 258, 261, 264, 267, 271, 274,
+		277, 280, 284, 287, 291, 294, 298, 301, 305, 309, 312, 316, 320, 324, 328, 332, 336,
+		340, 344, 348, 352, 357, 361, 365, 370, 374, 379, 383, 388, 392, 397, 402, 407, 412,
+		417, 422, 427, 432, 437, 442, 448, 453, 459, 464, 470, 475, 481, 487, 493, 499, 505,
+		511, 517, 523, 530, 536, 542, 549, 556, 562, 569, 576, 583, 590, 597, 604, 612, 619,
+		626, 634, 642, 649, 657, 665, 673, 681, 690, 698, 706, 715, 723, 732, 741, 750, 759,
+		768, 777, 787, 796, 806, 816, 825, 835, 845, 856, 866, 876, 887, 898, 909, 920, 931,
+		942, 953, 965, 976, 988, 1000,
+	},
+	"POWERSOF2": nil,
+}
+
+// newGranularity returns granularity for the given name, or false if the name is unknown.
+func newGranularity(name string) (*granularity, bool) {
+	series, ok := granularities[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &granularity{series: series}, true
+}
+
+// roundUp returns the smallest value of the series that is strictly greater than the given number.
+// Zero and infinity are returned as is.
+//
+// The number must be non-negative int32, int64 or float64.
+func (g *granularity) roundUp(v any) any {
+	n := granularityFloat(v)
+	if n == 0 || math.IsInf(n, 1) {
+		return v
+	}
+
+	if g.series == nil {
+		var exp int
+
+		switch v := v.(type) {
+		case float64:
+			exp = int(math.Floor(math.Log2(v))) + 1
+		case int32:
+			exp = bits.Len64(uint64(v))
+		case int64:
+			exp = bits.Len64(uint64(v))
+		}
+
+		return powerOfTwo(exp)
+	}
+
+	first, last := g.series[0], g.series[len(g.series)-1]
+	multiplier := 1.0
+
+	// scale the number to be within the series range
+	for n >= last {
+		n /= 10
+		multiplier *= 10
+	}
+
+	for n < first {
+		n *= 10
+		multiplier /= 10
+	}
+
+	i := slices.IndexFunc(g.series, func(s float64) bool { return s > n })
+
+	return g.series[i] * multiplier
+}
+
+// roundDown returns the largest value of the series that is strictly less than the given number.
+// Zero and infinity are returned as is.
+//
+// The number must be non-negative int32, int64 or float64.
+func (g *granularity) roundDown(v any) any {
+	n := granularityFloat(v)
+	if n == 0 || math.IsInf(n, 1) {
+		return v
+	}
+
+	if g.series == nil {
+		var exp int
+
+		switch v := v.(type) {
+		case float64:
+			exp = int(math.Ceil(math.Log2(v))) - 1
+		case int32:
+			exp = bits.Len64(uint64(v)) - 1
+			if v&(v-1) == 0 {
+				exp--
+			}
+		case int64:
+			exp = bits.Len64(uint64(v)) - 1
+			if v&(v-1) == 0 {
+				exp--
+			}
+		}
+
+		return powerOfTwo(exp)
+	}
+
+	first, last := g.series[0], g.series[len(g.series)-1]
+	multiplier := 1.0
+
+	// scale the number to be within the series range
+	for n > last {
+		n /= 10
+		multiplier *= 10
+	}
+
+	for n <= first {
+		n *= 10
+		multiplier /= 10
+	}
+
+	i := slices.IndexFunc(g.series, func(s float64) bool { return s >= n })
+
+	return g.series[i-1] * multiplier
+}
+
+// powerOfTwo returns 2 raised to the power of exp.
+//
+// The result is int32 or int64 if it fits, and float64 otherwise.
+func powerOfTwo(exp int) any {
+	switch {
+	case exp < 0:
+		return math.Ldexp(1, exp)
+	case exp < 31:
+		return int32(1) << exp
+	case exp < 63:
+		return int64(1) << exp
+	default:
+		return math.Ldexp(1, exp)
+	}
+}
+
+// granularityFloat returns the given number as float64.
+func granularityFloat(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	default:
+		panic(fmt.Sprintf("unexpected type %T", v))
+	}
+}
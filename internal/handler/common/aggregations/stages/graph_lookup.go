@@ -0,0 +1,504 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// graphLookup represents $graphLookup stage.
+//
+//	{ $graphLookup: {
+//		from: <collection>,
+//		startWith: <expression>,
+//		connectFromField: <string>,
+//		connectToField: <string>,
+//		as: <string>,
+//		maxDepth: <number>,
+//		depthField: <string>,
+//		restrictSearchWithMatch: <document>
+//	}}
+//
+// For each input document, it performs a breadth-first search on the "from" collection
+// starting with documents whose connectToField matches the value of startWith expression,
+// and following connectFromField values of found documents on the next levels.
+type graphLookup struct {
+	params *NewStageParams
+
+	startWith operators.Operator
+
+	from                    string
+	connectFromField        types.Path
+	connectToField          string
+	as                      types.Path
+	maxDepth                *int64          // nil if maxDepth is not set
+	depthField              *types.Path     // nil if depthField is not set
+	restrictSearchWithMatch *types.Document // nil if restrictSearchWithMatch is not set
+
+	// maxMemory is the maximum size in bytes of documents found for one input document.
+	maxMemory int
+}
+
+// newGraphLookup validates stage document and creates a new $graphLookup stage.
+func newGraphLookup(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields := must.NotFail(stage.Get("$graphLookup"))
+
+	spec, ok := fields.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf(
+				"the $graphLookup stage specification must be an object, but found %s",
+				handlerparams.AliasFromType(fields),
+			),
+			"$graphLookup (stage)",
+		)
+	}
+
+	g := &graphLookup{
+		params:    params,
+		maxMemory: params.GraphLookupMaxMemoryBytes,
+	}
+
+	var startWith any
+	var hasStartWith bool
+	var connectFromField, as string
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch k {
+		case "startWith":
+			startWith = v
+			hasStartWith = true
+
+			continue
+		case "maxDepth":
+			maxDepth, err := getGraphLookupMaxDepth(v)
+			if err != nil {
+				return nil, err
+			}
+
+			g.maxDepth = &maxDepth
+
+			continue
+		case "restrictSearchWithMatch":
+			if g.restrictSearchWithMatch, ok = v.(*types.Document); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageGraphLookupInvalidMatch,
+					fmt.Sprintf("restrictSearchWithMatch must be an object, found %s", handlerparams.AliasFromType(v)),
+					"$graphLookup (stage)",
+				)
+			}
+
+			if err = validateGraphLookupMatch(g.restrictSearchWithMatch); err != nil {
+				return nil, err
+			}
+
+			continue
+		case "from", "as", "connectFromField", "connectToField", "depthField":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageGraphLookupUnknownArgument,
+				fmt.Sprintf("Unknown argument to $graphLookup: %s", k),
+				"$graphLookup (stage)",
+			)
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageGraphLookupNotString,
+				fmt.Sprintf("expected string as argument for %s, found: %s", k, types.FormatAnyValue(v)),
+				"$graphLookup (stage)",
+			)
+		}
+
+		switch k {
+		case "from":
+			g.from = s
+		case "as":
+			as = s
+		case "connectFromField":
+			connectFromField = s
+		case "connectToField":
+			g.connectToField = s
+		case "depthField":
+			path, err := newGraphLookupPath(s)
+			if err != nil {
+				return nil, err
+			}
+
+			g.depthField = &path
+		}
+	}
+
+	if g.from == "" || as == "" || !hasStartWith || connectFromField == "" || g.connectToField == "" {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageGraphLookupMissingRequired,
+			"$graphLookup requires 'from', 'as', 'startWith', 'connectFromField', and 'connectToField' to be specified.",
+			"$graphLookup (stage)",
+		)
+	}
+
+	var err error
+
+	if g.as, err = newGraphLookupPath(as); err != nil {
+		return nil, err
+	}
+
+	if g.connectFromField, err = newGraphLookupPath(connectFromField); err != nil {
+		return nil, err
+	}
+
+	if _, err = newGraphLookupPath(g.connectToField); err != nil {
+		return nil, err
+	}
+
+	exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("startWith", startWith))))
+
+	if g.startWith, err = operators.NewExpr(exprValue, "$graphLookup (stage)", params.Variables); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// getGraphLookupMaxDepth returns maxDepth value of $graphLookup stage.
+// It returns the proper error if the value is not a nonnegative integer.
+func getGraphLookupMaxDepth(v any) (int64, error) {
+	var maxDepth int64
+	var exact bool
+
+	switch v := v.(type) {
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			maxDepth = 0
+		case v >= math.MaxInt64:
+			maxDepth = math.MaxInt64
+		case v <= math.MinInt64:
+			maxDepth = math.MinInt64
+		default:
+			maxDepth = int64(v)
+			exact = float64(maxDepth) == v
+		}
+	case int32:
+		maxDepth, exact = int64(v), true
+	case int64:
+		maxDepth, exact = v, true
+	default:
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageGraphLookupMaxDepthNotNumber,
+			fmt.Sprintf("maxDepth must be numeric, found type: %s", handlerparams.AliasFromType(v)),
+			"$graphLookup (stage)",
+		)
+	}
+
+	if maxDepth < 0 {
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageGraphLookupMaxDepthNegative,
+			fmt.Sprintf("maxDepth requires a nonnegative argument, found: %s", types.FormatAnyValue(v)),
+			"$graphLookup (stage)",
+		)
+	}
+
+	if !exact {
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageGraphLookupMaxDepthNotInteger,
+			fmt.Sprintf("maxDepth could not be represented as a long long: %s", types.FormatAnyValue(v)),
+			"$graphLookup (stage)",
+		)
+	}
+
+	return maxDepth, nil
+}
+
+// validateGraphLookupMatch returns an error if restrictSearchWithMatch filter
+// contains $expr, including $expr nested in logical operators.
+func validateGraphLookupMatch(filter *types.Document) error {
+	for _, k := range filter.Keys() {
+		switch k {
+		case "$expr":
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrQueryFeatureNotAllowed,
+				"$expr is not allowed in this context",
+				"$graphLookup (stage)",
+			)
+		case "$and", "$or", "$nor":
+			arr, ok := must.NotFail(filter.Get(k)).(*types.Array)
+			if !ok {
+				continue
+			}
+
+			for i := 0; i < arr.Len(); i++ {
+				d, ok := must.NotFail(arr.Get(i)).(*types.Document)
+				if !ok {
+					continue
+				}
+
+				if err := validateGraphLookupMatch(d); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// newGraphLookupPath returns path for the given $graphLookup field.
+func newGraphLookupPath(field string) (types.Path, error) {
+	if strings.HasPrefix(field, "$") {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathInvalidName,
+			"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+			"$graphLookup (stage)",
+		)
+	}
+
+	path, err := types.NewPathFromString(field)
+	if err != nil {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrPathContainsEmptyElement,
+			"FieldPath field names may not be empty strings.",
+			"$graphLookup (stage)",
+		)
+	}
+
+	return path, nil
+}
+
+// Process implements Stage interface.
+func (g *graphLookup) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	c, err := g.params.DB.Collection(g.from)
+	if err != nil {
+		if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrInvalidNamespace,
+				fmt.Sprintf("Invalid collection name: %s", g.from),
+				"$graphLookup (stage)",
+			)
+		}
+
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		_, doc, err := iter.Next()
+		if err != nil {
+			return unused, nil, lazyerrors.Error(err)
+		}
+
+		found, err := g.search(ctx, c, doc)
+		if err != nil {
+			return unused, nil, err
+		}
+
+		setByPath(doc, g.as, found)
+
+		return unused, doc, nil
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// search returns an array of documents reachable from the given input document.
+func (g *graphLookup) search(ctx context.Context, c backends.Collection, doc *types.Document) (*types.Array, error) {
+	v, err := g.startWith.Process(doc, g.params.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	var frontier []any
+
+	startWith, _ := evaluated.Get("startWith")
+
+	switch startWith := startWith.(type) {
+	case nil:
+		// missing value matches documents with null or missing field
+		frontier = append(frontier, types.Null)
+	case *types.Array:
+		for i := 0; i < startWith.Len(); i++ {
+			frontier = appendGraphLookupValue(frontier, nil, must.NotFail(startWith.Get(i)))
+		}
+	default:
+		frontier = append(frontier, startWith)
+	}
+
+	var visited []*types.Document
+	var queried []any
+	var size int
+
+	for depth := int64(0); len(frontier) > 0; depth++ {
+		if g.maxDepth != nil && depth > *g.maxDepth {
+			break
+		}
+
+		queried = append(queried, frontier...)
+
+		docs, err := g.query(ctx, c, frontier)
+		if err != nil {
+			return nil, err
+		}
+
+		var next []any
+
+		for _, d := range docs {
+			id, _ := d.Get("_id")
+			if graphLookupContains(visited, id) {
+				continue
+			}
+
+			if g.depthField != nil {
+				setByPath(d, *g.depthField, depth)
+			}
+
+			bd, err := bson.ConvertDocument(d)
+			if err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+
+			if size += bson.Size(bd); size > g.maxMemory {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageGraphLookupMaxMemory,
+					fmt.Sprintf("$graphLookup reached maximum memory consumption of %d bytes", g.maxMemory),
+					"$graphLookup (stage)",
+				)
+			}
+
+			visited = append(visited, d)
+
+			for _, v := range lookupPathValues(d, g.connectFromField) {
+				next = appendGraphLookupValue(next, queried, v)
+			}
+		}
+
+		frontier = next
+	}
+
+	res := types.MakeArray(len(visited))
+	for _, d := range visited {
+		res.Append(d)
+	}
+
+	return res, nil
+}
+
+// query returns documents of the "from" collection with connectToField matching any of the given values
+// and matching restrictSearchWithMatch filter if it is set.
+func (g *graphLookup) query(ctx context.Context, c backends.Collection, values []any) ([]*types.Document, error) {
+	closer := iterator.NewMultiCloser()
+	defer closer.Close()
+
+	qp := new(backends.QueryParams)
+
+	// push down simple equality, it is applied to top-level fields with scalar values only
+	if len(values) == 1 && !strings.ContainsRune(g.connectToField, '.') {
+		switch v := values[0].(type) {
+		case float64, string, types.ObjectID, bool, int32, int64:
+			qp.Filter = must.NotFail(types.NewDocument(g.connectToField, v))
+		}
+	}
+
+	in := types.MakeArray(len(values))
+	for _, v := range values {
+		in.Append(v)
+	}
+
+	filter := must.NotFail(types.NewDocument(g.connectToField, must.NotFail(types.NewDocument("$in", in))))
+
+	if g.restrictSearchWithMatch != nil {
+		filter = must.NotFail(types.NewDocument("$and", must.NotFail(types.NewArray(filter, g.restrictSearchWithMatch))))
+	}
+
+	queryRes, err := c.Query(ctx, qp)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	closer.Add(queryRes.Iter)
+
+	docs, err := iterator.ConsumeValues(common.FilterIterator(queryRes.Iter, closer, filter, nil))
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	return docs, nil
+}
+
+// appendGraphLookupValue appends the given value to values to search for,
+// unless it is already present in values or in queried values.
+func appendGraphLookupValue(values, queried []any, v any) []any {
+	for _, existing := range [][]any{values, queried} {
+		for _, e := range existing {
+			if types.CompareForAggregation(v, e) == types.Equal {
+				return values
+			}
+		}
+	}
+
+	return append(values, v)
+}
+
+// graphLookupContains returns true if the given documents contain a document with the given _id.
+func graphLookupContains(docs []*types.Document, id any) bool {
+	for _, d := range docs {
+		if types.CompareForAggregation(must.NotFail(d.Get("_id")), id) == types.Equal {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*graphLookup)(nil)
+)
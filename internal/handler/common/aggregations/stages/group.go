@@ -45,7 +45,9 @@ import (
 // For each group of documents, accumulators are applied.
 type group struct {
 	groupExpression any
+	vars            aggregations.Variables
 	groupBy         []groupBy
+	params          *common.BlockingParams
 }
 
 // groupBy represents accumulation to apply on the group.
@@ -55,7 +57,7 @@ type groupBy struct {
 }
 
 // newGroup creates a new $group stage.
-func newGroup(stage *types.Document) (aggregations.Stage, error) {
+func newGroup(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	fields, err := common.GetRequiredParam[*types.Document](stage, "$group")
 	if err != nil {
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
@@ -83,7 +85,7 @@ func newGroup(stage *types.Document) (aggregations.Stage, error) {
 		}
 
 		if field == "_id" {
-			if err = validateGroupKey(v); err != nil {
+			if err = validateGroupKey(v, params.Variables); err != nil {
 				return nil, err
 			}
 
@@ -112,56 +114,77 @@ func newGroup(stage *types.Document) (aggregations.Stage, error) {
 
 	return &group{
 		groupExpression: groupKey,
+		vars:            params.Variables,
 		groupBy:         groups,
+		params:          params.blockingParams(),
 	}, nil
 }
 
 // Process implements Stage interface.
+//
+// Documents are added to the accumulation states of their groups one by one.
+// If states do not fit into the memory limit and disk use is allowed,
+// they are written to temporary files sorted by the group key, and merged group by group at the end.
 func (g *group) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
-	groupedDocuments, err := g.groupDocuments(iter)
+	res, err := g.groupDocuments(iter)
 	if err != nil {
 		return nil, err
 	}
 
-	var res []*types.Document
+	iter = iterator.Values(iterator.ForSlice(res))
+	closer.Add(iter)
 
-	for _, groupedDocument := range groupedDocuments {
-		doc := must.NotFail(types.NewDocument("_id", groupedDocument.groupID))
+	return iter, nil
+}
 
-		groupIter := iterator.Values(iterator.ForSlice(groupedDocument.documents))
-		defer groupIter.Close()
+// accumulateGroup returns a document containing group's _id
+// and the results of applying accumulators to documents of the group.
+//
+// The stage is the name of the stage, it is used in error messages.
+func accumulateGroup(stage string, g groupedDocuments, groupBy []groupBy, vars aggregations.Variables) (*types.Document, error) { //nolint:lll // for readability
+	res := make([]any, len(groupBy))
 
-		for _, accumulation := range g.groupBy {
-			out, err := accumulation.accumulator.Accumulate(groupIter)
-			if err != nil {
-				// existing accumulators do not return error
-				return nil, processGroupStageError(err)
-			}
+	for i, accumulation := range groupBy {
+		// each accumulator consumes and closes the iterator
+		groupIter := iterator.Values(iterator.ForSlice(g.documents))
 
-			if doc.Has(accumulation.outputField) {
-				// document has duplicate key
-				return nil, handlererrors.NewCommandErrorMsgWithArgument(
-					handlererrors.ErrStageIndexedStringVectorDuplicate,
-					fmt.Sprintf("duplicate field: %s", accumulation.outputField),
-					"$group (stage)",
-				)
-			}
-
-			doc.Set(accumulation.outputField, out)
+		out, err := accumulators.Accumulate(accumulation.accumulator, groupIter, vars)
+		if err != nil {
+			// existing accumulators do not return error
+			return nil, processGroupStageError(err)
 		}
 
-		res = append(res, doc)
+		res[i] = out
 	}
 
-	iter = iterator.Values(iterator.ForSlice(res))
-	closer.Add(iter)
+	return groupResult(stage, g.groupID, groupBy, res)
+}
 
-	return iter, nil
+// groupResult returns a document containing group's _id and the given results of accumulators.
+//
+// The stage is the name of the stage, it is used in error messages.
+func groupResult(stage string, groupID any, groupBy []groupBy, res []any) (*types.Document, error) {
+	doc := must.NotFail(types.NewDocument("_id", groupID))
+
+	for i, accumulation := range groupBy {
+		if doc.Has(accumulation.outputField) {
+			// document has duplicate key
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageIndexedStringVectorDuplicate,
+				fmt.Sprintf("duplicate field: %s", accumulation.outputField),
+				stage+" (stage)",
+			)
+		}
+
+		doc.Set(accumulation.outputField, res[i])
+	}
+
+	return doc, nil
 }
 
 // validateGroupKey returns error on invalid group key.
 // If group key is a document, it recursively validates operator and expression.
-func validateGroupKey(groupKey any) error {
+func validateGroupKey(groupKey any, vars aggregations.Variables) error {
 	doc, ok := groupKey.(*types.Document)
 	if !ok {
 		return nil
@@ -173,7 +196,7 @@ func validateGroupKey(groupKey any) error {
 			return processGroupStageError(err)
 		}
 
-		_, err = op.Process(nil)
+		_, err = op.Process(nil, vars)
 		if err != nil {
 			// TODO https://github.com/FerretDB/FerretDB/issues/3129
 			return processGroupStageError(err)
@@ -206,7 +229,7 @@ func validateGroupKey(groupKey any) error {
 
 		switch v := v.(type) {
 		case *types.Document:
-			return validateGroupKey(v)
+			return validateGroupKey(v, vars)
 		case string:
 			_, err := aggregations.NewExpression(v, nil)
 			var exprErr *aggregations.ExpressionError
@@ -224,10 +247,19 @@ func validateGroupKey(groupKey any) error {
 	return nil
 }
 
-// groupDocuments groups documents into groups using group key. If group key contains expressions
-// or operators, they are evaluated before using it as the group key of documents.
-func (g *group) groupDocuments(iter types.DocumentsIterator) ([]groupedDocuments, error) {
-	var m groupMap
+// groupDocuments groups documents into groups using group key and returns the results of accumulators.
+// If group key contains expressions or operators, they are evaluated before using it as the group key of documents.
+//
+// Groups are returned in order of their first appearance, or sorted by the group key
+// if accumulation states were written to temporary files.
+func (g *group) groupDocuments(iter types.DocumentsIterator) ([]*types.Document, error) {
+	groups := map[string]*groupState{}
+
+	// groups in order of their first appearance
+	var ordered []*groupState
+
+	var sorter *common.ExternalSorter
+	var size int
 
 	for {
 		_, doc, err := iter.Next()
@@ -239,51 +271,252 @@ func (g *group) groupDocuments(iter types.DocumentsIterator) ([]groupedDocuments
 			return nil, lazyerrors.Error(err)
 		}
 
-		switch groupKey := g.groupExpression.(type) {
-		case *types.Document:
-			val, err := evaluateDocument(groupKey, doc, false)
-			if err != nil {
-				// operator and expression errors are validated in newGroup
-				return nil, lazyerrors.Error(err)
+		val, err := evaluateGroupKey(g.groupExpression, doc, g.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		// groupID is a distinct key and can be any BSON type including array and Binary,
+		// numbers are grouped for the same value regardless of their number type,
+		// so the map is keyed by valuesKey
+		key := valuesKey(val)
+
+		state, ok := groups[key]
+		if !ok {
+			if state, err = g.newGroupState(val); err != nil {
+				return nil, err
 			}
 
-			m.addOrAppend(val, doc)
-		case *types.Array, float64, types.Binary, types.ObjectID, bool, time.Time, types.NullType,
-			types.Regex, int32, types.Timestamp, int64:
-			m.addOrAppend(groupKey, doc)
-		case string:
-			expression, err := aggregations.NewExpression(groupKey, nil)
+			groups[key] = state
+			ordered = append(ordered, state)
+			size += common.EstimateValueSize(val)
+		}
+
+		for _, s := range state.states {
+			before := s.Size()
+
+			if err = s.Add(doc); err != nil {
+				return nil, processGroupStageError(err)
+			}
+
+			size += s.Size() - before
+		}
+
+		if g.params.MaxMemoryBytes == 0 || size <= g.params.MaxMemoryBytes {
+			continue
+		}
+
+		if !g.params.AllowDiskUse {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrQueryExceededMemoryLimitNoDiskUseAllowed,
+				"Exceeded memory limit for $group, but didn't allow external sort. Pass allowDiskUse:true to opt in.",
+				"$group (stage)",
+			)
+		}
+
+		if sorter == nil {
+			sorter = common.NewExternalSorter(func(a, b *types.Document) bool {
+				return types.CompareForAggregation(must.NotFail(a.Get("k")), must.NotFail(b.Get("k"))) == types.Less
+			}, 0, g.params)
+			defer sorter.Close()
+		}
+
+		if err = spillGroupStates(sorter, ordered); err != nil {
+			return nil, err
+		}
+
+		groups = map[string]*groupState{}
+		ordered = nil
+		size = 0
+	}
+
+	if sorter == nil {
+		res := make([]*types.Document, 0, len(ordered))
+
+		for _, state := range ordered {
+			doc, err := g.result(state)
 			if err != nil {
-				var exprErr *aggregations.ExpressionError
-				if errors.As(err, &exprErr) {
-					if exprErr.Code() == aggregations.ErrNotExpression {
-						m.addOrAppend(groupKey, doc)
-						continue
-					}
+				return nil, err
+			}
 
-					return nil, processGroupStageError(err)
+			res = append(res, doc)
+		}
+
+		return res, nil
+	}
+
+	if err := spillGroupStates(sorter, ordered); err != nil {
+		return nil, err
+	}
+
+	return g.mergeSpilled(sorter)
+}
+
+// groupState contains group key and accumulation states for that group.
+type groupState struct {
+	groupID any
+	states  []accumulators.State
+}
+
+// newGroupState returns empty accumulation states for the given group key.
+func (g *group) newGroupState(groupID any) (*groupState, error) {
+	res := &groupState{
+		groupID: groupID,
+		states:  make([]accumulators.State, len(g.groupBy)),
+	}
+
+	for i, accumulation := range g.groupBy {
+		var err error
+		if res.states[i], err = accumulation.accumulator.NewState(g.vars); err != nil {
+			return nil, processGroupStageError(err)
+		}
+	}
+
+	return res, nil
+}
+
+// result returns a document containing group's _id and the results of accumulators.
+func (g *group) result(state *groupState) (*types.Document, error) {
+	res := make([]any, len(state.states))
+
+	for i, s := range state.states {
+		var err error
+		if res[i], err = s.Result(); err != nil {
+			return nil, processGroupStageError(err)
+		}
+	}
+
+	return groupResult("$group", state.groupID, g.groupBy, res)
+}
+
+// spillGroupStates adds encoded accumulation states of groups to the sorter.
+func spillGroupStates(sorter *common.ExternalSorter, groups []*groupState) error {
+	for _, state := range groups {
+		encoded := types.MakeArray(len(state.states))
+		for _, s := range state.states {
+			encoded.Append(s.Encode())
+		}
+
+		if err := sorter.Add(must.NotFail(types.NewDocument("k", state.groupID, "s", encoded))); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// mergeSpilled merges encoded accumulation states of consecutive records with the same group key
+// and returns the results of accumulators.
+//
+// Records of the same group keep their original order, so states are merged in the order of documents.
+func (g *group) mergeSpilled(sorter *common.ExternalSorter) ([]*types.Document, error) {
+	sorted, err := sorter.Iterator()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	defer sorted.Close()
+
+	var res []*types.Document
+	var current *groupState
+
+	for {
+		_, record, err := sorted.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		key := must.NotFail(record.Get("k"))
+		encoded := must.NotFail(record.Get("s")).(*types.Array)
+
+		if current == nil || types.CompareForAggregation(key, current.groupID) != types.Equal {
+			if current != nil {
+				doc, err := g.result(current)
+				if err != nil {
+					return nil, err
 				}
 
-				return nil, lazyerrors.Error(err)
+				res = append(res, doc)
 			}
 
-			val, err := expression.Evaluate(doc)
-			if err != nil {
-				// $group treats non-existent fields as nulls
-				val = types.Null
+			if current, err = g.newGroupState(key); err != nil {
+				return nil, err
 			}
+		}
 
-			m.addOrAppend(val, doc)
-		default:
-			panic(fmt.Sprintf("unexpected type %[1]T (%#[1]v)", groupKey))
+		for i, s := range current.states {
+			if err = s.Merge(must.NotFail(encoded.Get(i))); err != nil {
+				return nil, processGroupStageError(err)
+			}
 		}
 	}
 
-	return m.docs, nil
+	if current != nil {
+		doc, err := g.result(current)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, doc)
+	}
+
+	return res, nil
+}
+
+// evaluateGroupKey evaluates group key for the given document.
+// If group key contains expressions or operators, they are evaluated,
+// non-existent fields are evaluated to null.
+func evaluateGroupKey(groupKey any, doc *types.Document, vars aggregations.Variables) (any, error) {
+	switch groupKey := groupKey.(type) {
+	case *types.Document:
+		val, err := evaluateDocument(groupKey, doc, false, vars)
+		if err != nil {
+			// operator and expression errors are validated in newGroup
+			return nil, lazyerrors.Error(err)
+		}
+
+		return val, nil
+	case *types.Array, float64, types.Binary, types.ObjectID, bool, time.Time, types.NullType,
+		types.Regex, int32, types.Timestamp, int64:
+		return groupKey, nil
+	case string:
+		expression, err := aggregations.NewExpression(groupKey, nil)
+		if err != nil {
+			var exprErr *aggregations.ExpressionError
+			if errors.As(err, &exprErr) {
+				if exprErr.Code() == aggregations.ErrNotExpression {
+					return groupKey, nil
+				}
+
+				return nil, processGroupStageError(err)
+			}
+
+			return nil, lazyerrors.Error(err)
+		}
+
+		val, err := expression.Evaluate(doc, vars)
+		if err != nil {
+			var exprErr *aggregations.ExpressionError
+			if errors.As(err, &exprErr) {
+				return nil, processGroupStageError(err)
+			}
+
+			// $group treats non-existent fields as nulls
+			val = types.Null
+		}
+
+		return val, nil
+	default:
+		panic(fmt.Sprintf("unexpected type %[1]T (%#[1]v)", groupKey))
+	}
 }
 
 // evaluateDocument recursively evaluates document's field expressions and operators.
-func evaluateDocument(expr, doc *types.Document, nestedField bool) (any, error) {
+func evaluateDocument(expr, doc *types.Document, nestedField bool, vars aggregations.Variables) (any, error) {
 	if operators.IsOperator(expr) {
 		op, err := operators.NewOperator(expr)
 		if err != nil {
@@ -291,7 +524,7 @@ func evaluateDocument(expr, doc *types.Document, nestedField bool) (any, error)
 			return nil, processGroupStageError(err)
 		}
 
-		v, err := op.Process(doc)
+		v, err := op.Process(doc, vars)
 		if err != nil {
 			// operator and expression errors are validated in newGroup
 			return nil, processGroupStageError(err)
@@ -317,9 +550,9 @@ func evaluateDocument(expr, doc *types.Document, nestedField bool) (any, error)
 
 		switch exprVal := exprVal.(type) {
 		case *types.Document:
-			v, err := evaluateDocument(exprVal, doc, true)
+			v, err := evaluateDocument(exprVal, doc, true, vars)
 			if err != nil {
-				return nil, lazyerrors.Error(err)
+				return nil, err
 			}
 
 			evaluatedDocument.Set(k, v)
@@ -337,8 +570,13 @@ func evaluateDocument(expr, doc *types.Document, nestedField bool) (any, error)
 				return nil, lazyerrors.Error(err)
 			}
 
-			v, err := expression.Evaluate(doc)
+			v, err := expression.Evaluate(doc, vars)
 			if err != nil {
+				var exprErr *aggregations.ExpressionError
+				if errors.As(err, &exprErr) {
+					return nil, processGroupStageError(err)
+				}
+
 				if expr.Len() == 1 && !nestedField {
 					// non-existent path is set to null if expression contains single field and not a nested document
 					evaluatedDocument.Set(k, types.Null)
@@ -427,6 +665,12 @@ func processGroupStageError(err error) error {
 				opErr.Error(),
 				"$group (stage)",
 			)
+		case operators.ErrInvalidArgument:
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				opErr.CommandCode(),
+				opErr.Error(),
+				"$group (stage)",
+			)
 		}
 
 	case errors.As(err, &exErr):
@@ -447,10 +691,9 @@ func processGroupStageError(err error) error {
 				"$group (stage)",
 			)
 		case aggregations.ErrUndefinedVariable:
-			// TODO https://github.com/FerretDB/FerretDB/issues/2275
 			return handlererrors.NewCommandErrorMsgWithArgument(
-				handlererrors.ErrNotImplemented,
-				"Aggregation expression variables are not implemented yet",
+				handlererrors.ErrUndefinedVariable,
+				fmt.Sprintf("Use of undefined variable: %s", exErr.Name()),
 				"$group (stage)",
 			)
 		case aggregations.ErrEmptyVariable:
@@ -0,0 +1,147 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// indexStats represents $indexStats stage.
+//
+//	{ $indexStats: {} }
+//
+// It is a producer stage: input documents are ignored, and it may only be the first stage of the pipeline.
+//
+// Unlike MongoDB, accesses.ops is an approximation: the backend does not report which index is used,
+// so it counts queries with a top-level filter field that is the first field of the index key.
+// Indexes sharing the first field have the same counter.
+type indexStats struct {
+	db         backends.Database
+	indexUsage *diagnostics.IndexUsage
+	dbName     string
+	cName      string
+}
+
+// newIndexStats validates stage document and creates a new $indexStats stage.
+func newIndexStats(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields, ok := must.NotFail(stage.Get("$indexStats")).(*types.Document)
+	if !ok || fields.Len() != 0 {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageIndexStatsInvalidSpec,
+			"The $indexStats stage specification must be an empty object",
+			"$indexStats (stage)",
+		)
+	}
+
+	return &indexStats{
+		db:         params.DB,
+		indexUsage: params.IndexUsage,
+		dbName:     params.DBName,
+		cName:      params.CollectionName,
+	}, nil
+}
+
+// Process implements Stage interface.
+//
+// Indexes of non-existent collection are not reported.
+func (is *indexStats) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	c, err := is.db.Collection(is.cName)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	var indexes []backends.IndexInfo
+
+	list, err := c.ListIndexes(ctx, nil)
+
+	switch {
+	case err == nil:
+		indexes = list.Indexes
+	case backends.ErrorCodeIs(err, backends.ErrorCodeCollectionDoesNotExist):
+		// no indexes
+	default:
+		return nil, lazyerrors.Error(err)
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	docs := make([]*types.Document, 0, len(indexes))
+
+	for _, index := range indexes {
+		key := must.NotFail(types.NewDocument())
+
+		for _, pair := range index.Key {
+			order := int32(1)
+			if pair.Descending {
+				order = -1
+			}
+
+			key.Set(pair.Field, order)
+		}
+
+		var ops int64
+		var since time.Time
+
+		if is.indexUsage != nil {
+			ops, since = is.indexUsage.Accesses(is.dbName, is.cName, index)
+		}
+
+		spec := must.NotFail(types.NewDocument(
+			"v", int32(2),
+			"key", key.DeepCopy(),
+			"name", index.Name,
+		))
+
+		// only non-default unique indexes have unique field, the same as listIndexes
+		if index.Unique && index.Name != backends.DefaultIndexName {
+			spec.Set("unique", true)
+		}
+
+		docs = append(docs, must.NotFail(types.NewDocument(
+			"name", index.Name,
+			"key", key,
+			"host", host,
+			"accesses", must.NotFail(types.NewDocument(
+				"ops", ops,
+				"since", since,
+			)),
+			"spec", spec,
+		)))
+	}
+
+	res := iterator.Values(iterator.ForSlice(docs))
+	closer.Add(res)
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*indexStats)(nil)
+)
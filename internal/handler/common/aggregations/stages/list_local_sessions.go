@@ -0,0 +1,231 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// sessionUser represents a user in $listLocalSessions users option.
+type sessionUser struct {
+	user string
+	db   string
+}
+
+// listLocalSessions represents $listLocalSessions stage.
+//
+//	{ $listLocalSessions: { allUsers: <boolean>, users: [ { user: <user>, db: <db> }, ... ] } }
+//
+// It is a producer stage: input documents are ignored, and it may only be the first stage of the pipeline.
+// allUsers option is rejected if the current user is not allowed to see other users, see NewStageParams.
+type listLocalSessions struct {
+	sessions *diagnostics.Sessions
+	users    []sessionUser
+	allUsers bool
+}
+
+// newListLocalSessions validates stage document and creates a new $listLocalSessions stage.
+func newListLocalSessions(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$listLocalSessions"))
+
+	fields, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"$listLocalSessions options must be specified in an object, but found: %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$listLocalSessions (stage)",
+		)
+	}
+
+	ls := listLocalSessions{
+		sessions: params.Sessions,
+	}
+
+	for _, key := range fields.Keys() {
+		value := must.NotFail(fields.Get(key))
+
+		switch key {
+		case "allUsers":
+			if ls.allUsers, ok = value.(bool); !ok {
+				return nil, listLocalSessionsTypeError(key, value, "bool")
+			}
+
+		case "users":
+			var users *types.Array
+
+			if users, ok = value.(*types.Array); !ok {
+				return nil, listLocalSessionsTypeError(key, value, "array")
+			}
+
+			var err error
+			if ls.users, err = sessionUsers(users); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$listLocalSessions.%s' is an unknown field.", key),
+				"$listLocalSessions (stage)",
+			)
+		}
+	}
+
+	if ls.allUsers && !params.AllUsersAllowed {
+		return nil, newAllUsersError("$listLocalSessions")
+	}
+
+	return &ls, nil
+}
+
+// sessionUsers validates and returns users of $listLocalSessions users option.
+func sessionUsers(users *types.Array) ([]sessionUser, error) {
+	res := make([]sessionUser, 0, users.Len())
+
+	for i := 0; i < users.Len(); i++ {
+		v := must.NotFail(users.Get(i))
+
+		user, ok := v.(*types.Document)
+		if !ok {
+			return nil, listLocalSessionsTypeError(fmt.Sprintf("users.%d", i), v, "object")
+		}
+
+		var su sessionUser
+
+		for _, field := range []string{"user", "db"} {
+			fv, err := user.Get(field)
+			if err != nil {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrMissingField,
+					fmt.Sprintf("BSON field '$listLocalSessions.users.%s' is missing but a required field", field),
+					"$listLocalSessions (stage)",
+				)
+			}
+
+			s, ok := fv.(string)
+			if !ok {
+				return nil, listLocalSessionsTypeError(fmt.Sprintf("users.%s", field), fv, "string")
+			}
+
+			if field == "user" {
+				su.user = s
+			} else {
+				su.db = s
+			}
+		}
+
+		res = append(res, su)
+	}
+
+	return res, nil
+}
+
+// listLocalSessionsTypeError returns an error for the $listLocalSessions option of unexpected type.
+func listLocalSessionsTypeError(key string, value any, expected string) error {
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrTypeMismatch,
+		fmt.Sprintf(
+			"BSON field '$listLocalSessions.%s' is the wrong type '%s', expected type '%s'",
+			key, handlerparams.AliasFromType(value), expected,
+		),
+		"$listLocalSessions (stage)",
+	)
+}
+
+// Process implements Stage interface.
+//
+// Without options, only sessions of the current user are listed.
+func (ls *listLocalSessions) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var sessions []*diagnostics.Session
+	if ls.sessions != nil {
+		sessions = ls.sessions.List()
+	}
+
+	users := ls.users
+	if users == nil {
+		username, _, _, db := conninfo.Get(ctx).Auth()
+		users = []sessionUser{{user: username, db: db}}
+	}
+
+	docs := make([]*types.Document, 0, len(sessions))
+
+	for _, sess := range sessions {
+		if !ls.allUsers && !hasSessionUser(users, sess) {
+			continue
+		}
+
+		id := must.NotFail(types.NewDocument(
+			"id", types.Binary{Subtype: types.BinaryUUID, B: sess.ID},
+			"uid", sessionUID(sess),
+		))
+
+		doc := must.NotFail(types.NewDocument("_id", id))
+
+		if sess.Username != "" {
+			doc.Set("user", must.NotFail(types.NewDocument("name", sess.Username+"@"+sess.DB)))
+		}
+
+		doc.Set("lastUse", sess.LastUse)
+
+		docs = append(docs, doc)
+	}
+
+	res := iterator.Values(iterator.ForSlice(docs))
+	closer.Add(res)
+
+	return res, nil
+}
+
+// hasSessionUser returns true if the session belongs to one of the given users.
+func hasSessionUser(users []sessionUser, sess *diagnostics.Session) bool {
+	for _, u := range users {
+		if u.user == sess.Username && u.db == sess.DB {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sessionUID returns the SHA256 digest of the session user's name, or of empty string if there is no user.
+func sessionUID(sess *diagnostics.Session) types.Binary {
+	var name string
+	if sess.Username != "" {
+		name = sess.Username + "@" + sess.DB
+	}
+
+	sum := sha256.Sum256([]byte(name))
+
+	return types.Binary{Subtype: types.BinaryGeneric, B: sum[:]}
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*listLocalSessions)(nil)
+)
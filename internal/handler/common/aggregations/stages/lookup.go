@@ -0,0 +1,675 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/commonpath"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// lookupNotAllowedStages contains stages that cannot be used within $lookup sub-pipeline.
+var lookupNotAllowedStages = map[string]struct{}{
+	// sorted alphabetically
+	"$currentOp":         {},
+	"$indexStats":        {},
+	"$listLocalSessions": {},
+	"$merge":             {},
+	"$out":               {},
+	// please keep sorted alphabetically
+}
+
+// lookup represents $lookup stage.
+//
+// Equality match form:
+//
+//	{ $lookup: {
+//		from: <foreign collection>,
+//		localField: <field from the input documents>,
+//		foreignField: <field from the documents of the "from" collection>,
+//		as: <output array field>
+//	}}
+//
+// Correlated subquery form:
+//
+//	{ $lookup: {
+//		from: <foreign collection>,
+//		let: { <var_1>: <expression>, …, <var_n>: <expression> },
+//		pipeline: [ <pipeline to run on foreign collection> ],
+//		as: <output array field>
+//	}}
+//
+// Both forms could be combined, then the pipeline runs on equality matched documents.
+type lookup struct {
+	params *NewStageParams
+
+	from         string
+	localField   *types.Path // nil for correlated subquery form
+	foreignField string
+	as           types.Path
+
+	let      *types.Document // nil if let is not set
+	pipeline *types.Array    // nil if pipeline is not set
+
+	// stages are created from the pipeline once,
+	// vars they use are updated with let variables for each input document
+	stages []aggregations.Stage
+	vars   aggregations.Variables
+}
+
+// newLookup validates stage document and creates a new $lookup stage.
+func newLookup(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields, err := stage.Get("$lookup")
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	spec, ok := fields.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("the $lookup specification must be an Object, but found %s", handlerparams.AliasFromType(fields)),
+			"$lookup (stage)",
+		)
+	}
+
+	l := &lookup{
+		params: params,
+	}
+
+	var hasFrom bool
+	var localField, as string
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch k {
+		case "pipeline":
+			if l.pipeline, ok = v.(*types.Array); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrTypeMismatch,
+					"'pipeline' option must be specified as an array",
+					"$lookup (stage)",
+				)
+			}
+
+			continue
+		case "let":
+			if l.let, ok = v.(*types.Document); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrFailedToParse,
+					fmt.Sprintf("$lookup argument 'let' must be an object, is type %s", handlerparams.AliasFromType(v)),
+					"$lookup (stage)",
+				)
+			}
+
+			continue
+		case "from":
+			switch v := v.(type) {
+			case string:
+				l.from = v
+				hasFrom = true
+			case *types.Document:
+				return nil, common.Unimplemented(stage, "$lookup.from")
+			default:
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrFailedToParse,
+					fmt.Sprintf(
+						"$lookup 'from' field must be either a string or an object, but found %s",
+						handlerparams.AliasFromType(v),
+					),
+					"$lookup (stage)",
+				)
+			}
+
+			continue
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("$lookup argument '%s: %s' must be a string, is type %s",
+					k, types.FormatAnyValue(v), handlerparams.AliasFromType(v),
+				),
+				"$lookup (stage)",
+			)
+		}
+
+		switch k {
+		case "as":
+			as = s
+		case "localField":
+			localField = s
+		case "foreignField":
+			l.foreignField = s
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				fmt.Sprintf("unknown argument to $lookup: %s", k),
+				"$lookup (stage)",
+			)
+		}
+	}
+
+	if !hasFrom {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf("missing 'from' option to $lookup stage specification: %s", types.FormatAnyValue(spec)),
+			"$lookup (stage)",
+		)
+	}
+
+	if as == "" {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"must specify 'as' field for a $lookup",
+			"$lookup (stage)",
+		)
+	}
+
+	if l.as, err = newLookupPath(as, "as"); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case localField != "" && l.foreignField != "":
+		path, err := newLookupPath(localField, "localField")
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = newLookupPath(l.foreignField, "foreignField"); err != nil {
+			return nil, err
+		}
+
+		l.localField = &path
+	case localField != "" || l.foreignField != "":
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"$lookup requires both or neither of 'localField' and 'foreignField' to be specified",
+			"$lookup (stage)",
+		)
+	case l.pipeline == nil:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			"$lookup requires either 'pipeline' or both 'localField' and 'foreignField' to be specified",
+			"$lookup (stage)",
+		)
+	}
+
+	if l.let.Len() == 0 {
+		l.let = nil
+	}
+
+	if l.pipeline == nil {
+		return l, nil
+	}
+
+	for i := 0; i < l.pipeline.Len(); i++ {
+		d, ok := must.NotFail(l.pipeline.Get(i)).(*types.Document)
+		if !ok || d.Len() != 1 {
+			continue
+		}
+
+		if _, notAllowed := lookupNotAllowedStages[d.Command()]; notAllowed {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageLookupNotAllowedStage,
+				fmt.Sprintf("%s is not allowed to be used within a $lookup stage", d.Command()),
+				"$lookup (stage)",
+			)
+		}
+	}
+
+	pipelineVars := l.params.Variables
+
+	if l.let != nil {
+		letExpr := must.NotFail(types.NewDocument("$expr", l.let))
+		if _, err = operators.NewExpr(letExpr, "$lookup (stage)", l.params.Variables); err != nil {
+			return nil, err
+		}
+
+		// validate the pipeline with let variables being defined
+		pipelineVars = l.params.Variables.Clone()
+
+		for _, name := range l.let.Keys() {
+			if err = aggregations.ValidateUserVariableName(name); err != nil {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrFailedToParse,
+					err.Error(),
+					"$lookup (stage)",
+				)
+			}
+
+			pipelineVars[name] = types.Null
+		}
+	}
+
+	stages, err := newPipeline(l.pipeline, "$lookup", l.params.withVariables(pipelineVars))
+	if err != nil {
+		return nil, err
+	}
+
+	l.stages = stages
+	l.vars = pipelineVars
+
+	return l, nil
+}
+
+// newLookupPath returns path for the given $lookup field.
+func newLookupPath(field, name string) (types.Path, error) {
+	if strings.HasPrefix(field, "$") {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFieldPathInvalidName,
+			fmt.Sprintf("FieldPath field names may not start with '$'. Consider using $getField or $setField. (%s: %s)", name, field),
+			"$lookup (stage)",
+		)
+	}
+
+	path, err := types.NewPathFromString(field)
+	if err != nil {
+		return types.Path{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrPathContainsEmptyElement,
+			"FieldPath field names may not be empty strings.",
+			"$lookup (stage)",
+		)
+	}
+
+	return path, nil
+}
+
+// Process implements Stage interface.
+//
+// Input documents are joined in batches.
+// For equality match form, documents of the foreign collection are queried once per batch
+// with $in filter on foreignField values pushed down to the backend, and indexed by those values,
+// so input documents are joined without querying and scanning the foreign collection for each of them.
+// If the filter can't be pushed down, the whole foreign collection is queried and indexed once.
+func (l *lookup) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	c, err := l.params.DB.Collection(l.from)
+	if err != nil {
+		if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrInvalidNamespace,
+				fmt.Sprintf("Invalid collection name: %s", l.from),
+				"$lookup (stage)",
+			)
+		}
+
+		return nil, lazyerrors.Error(err)
+	}
+
+	// documents of the whole foreign collection, set once the filter can't be pushed down
+	var all *lookupForeign
+
+	var foreign *lookupForeign
+	var batch []*types.Document
+
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		if len(batch) == 0 {
+			docs, err := iterator.ConsumeValuesN(iter, joinBatchSize)
+			if err != nil {
+				return unused, nil, lazyerrors.Error(err)
+			}
+
+			if len(docs) == 0 {
+				return unused, nil, iterator.ErrIteratorDone
+			}
+
+			if foreign = all; foreign == nil {
+				if foreign, err = l.queryBatch(ctx, c, docs); err != nil {
+					return unused, nil, err
+				}
+			}
+
+			if foreign == nil {
+				if all, err = l.loadForeign(ctx, c, nil); err != nil {
+					return unused, nil, err
+				}
+
+				foreign = all
+			}
+
+			batch = docs
+		}
+
+		doc := batch[0]
+		batch = batch[1:]
+
+		joined, err := l.lookupDocument(ctx, foreign, doc)
+		if err != nil {
+			return unused, nil, err
+		}
+
+		setByPath(doc, l.as, joined)
+
+		return unused, doc, nil
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// lookupForeign contains documents of the foreign collection.
+type lookupForeign struct {
+	docs []*types.Document
+
+	// index contains positions of documents by keys of their foreignField values,
+	// it is nil for correlated subquery form
+	index map[string][]int
+}
+
+// queryBatch returns documents of the foreign collection matching localField values
+// of the given input documents, indexed by foreignField values.
+//
+// It returns nil for correlated subquery form, for foreignField with dots,
+// if some of localField values are not scalar, or if the backend can't push $in filter down.
+func (l *lookup) queryBatch(ctx context.Context, c backends.Collection, docs []*types.Document) (*lookupForeign, error) { //nolint:lll // for readability
+	// backends don't look into arrays on the path, so fields with dots are not pushed down
+	if l.localField == nil || strings.ContainsRune(l.foreignField, '.') {
+		return nil, nil
+	}
+
+	in := types.MakeArray(len(docs))
+	seen := make(map[string]struct{}, len(docs))
+
+	for _, doc := range docs {
+		for _, v := range lookupLocalValues(doc, *l.localField) {
+			// null matches missing fields, and documents and arrays are compared as a whole,
+			// so only scalar values are pushed down
+			switch v.(type) {
+			case float64, string, types.ObjectID, bool, int32, int64:
+			default:
+				return nil, nil
+			}
+
+			key := valuesKey(v)
+			if _, ok := seen[key]; ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			in.Append(v)
+		}
+	}
+
+	filter := must.NotFail(types.NewDocument(l.foreignField, must.NotFail(types.NewDocument("$in", in))))
+
+	explain, err := c.Explain(ctx, &backends.ExplainParams{Filter: filter})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if !explain.FilterPushdown {
+		return nil, nil
+	}
+
+	return l.loadForeign(ctx, c, filter)
+}
+
+// loadForeign returns documents of the foreign collection matching the given filter
+// (all documents if it is nil), indexed by foreignField values for equality match form.
+//
+// The backend may return more documents than matched by the filter,
+// and the index returns a superset of matching documents, they are filtered by lookupDocument.
+func (l *lookup) loadForeign(ctx context.Context, c backends.Collection, filter *types.Document) (*lookupForeign, error) { //nolint:lll // for readability
+	res, err := c.Query(ctx, &backends.QueryParams{Filter: filter})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	docs, err := iterator.ConsumeValues(res.Iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	f := &lookupForeign{
+		docs: docs,
+	}
+
+	if l.localField == nil {
+		return f, nil
+	}
+
+	// foreignField was validated by newLookup
+	path := must.NotFail(types.NewPathFromString(l.foreignField))
+
+	nullKey := valuesKey(types.Null)
+	f.index = make(map[string][]int)
+
+	for i, doc := range docs {
+		// null matches missing fields, including fields missing in some of array documents,
+		// so all documents are indexed by null
+		keys := map[string]struct{}{nullKey: {}}
+
+		values, _ := commonpath.FindValues(doc, path, &commonpath.FindValuesOpts{
+			FindArrayIndex:     true,
+			FindArrayDocuments: true,
+		})
+
+		for _, v := range values {
+			keys[valuesKey(v)] = struct{}{}
+
+			arr, ok := v.(*types.Array)
+			if !ok {
+				continue
+			}
+
+			for j := 0; j < arr.Len(); j++ {
+				keys[valuesKey(must.NotFail(arr.Get(j)))] = struct{}{}
+			}
+		}
+
+		for k := range keys {
+			f.index[k] = append(f.index[k], i)
+		}
+	}
+
+	return f, nil
+}
+
+// lookupDocument returns an array of foreign documents joined with the given input document.
+func (l *lookup) lookupDocument(ctx context.Context, foreign *lookupForeign, doc *types.Document) (*types.Array, error) {
+	closer := iterator.NewMultiCloser()
+	defer closer.Close()
+
+	candidates := foreign.docs
+
+	var filter *types.Document
+
+	if l.localField != nil {
+		values := lookupLocalValues(doc, *l.localField)
+
+		eqs := types.MakeArray(len(values))
+		for _, v := range values {
+			eqs.Append(must.NotFail(types.NewDocument(l.foreignField, must.NotFail(types.NewDocument("$eq", v)))))
+		}
+
+		filter = must.NotFail(types.NewDocument("$or", eqs))
+
+		var positions []int
+		seen := make(map[int]struct{})
+
+		for _, v := range values {
+			for _, i := range foreign.index[valuesKey(v)] {
+				if _, ok := seen[i]; !ok {
+					seen[i] = struct{}{}
+					positions = append(positions, i)
+				}
+			}
+		}
+
+		// keep the order of the foreign collection
+		slices.Sort(positions)
+
+		candidates = make([]*types.Document, len(positions))
+		for j, i := range positions {
+			candidates[j] = foreign.docs[i]
+		}
+	}
+
+	// foreign documents are shared by all input documents of the batch, so they are copied
+	// to be modified by the pipeline and the following stages
+	copied := make([]*types.Document, len(candidates))
+	for i, d := range candidates {
+		copied[i] = d.DeepCopy()
+	}
+
+	var iter types.DocumentsIterator = iterator.Values(iterator.ForSlice(copied))
+	closer.Add(iter)
+
+	if filter != nil {
+		iter = common.FilterIterator(iter, closer, filter, nil)
+	}
+
+	if l.let != nil {
+		vars, err := letVariables(l.let, doc, l.params.Variables, "$lookup (stage)")
+		if err != nil {
+			return nil, err
+		}
+
+		// stages were created with l.vars, so variables are updated in place;
+		// that's safe as the pipeline is fully processed below before the next input document
+		maps.Copy(l.vars, vars)
+	}
+
+	iter, err := processPipeline(ctx, l.stages, iter, closer)
+	if err != nil {
+		return nil, err
+	}
+
+	joined, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	res := types.MakeArray(len(joined))
+	for _, d := range joined {
+		res.Append(d)
+	}
+
+	return res, nil
+}
+
+// lookupLocalValues returns values of the input document to match foreign documents with.
+//
+// Values of arrays found on the path are returned as separate values.
+// If no value is found, it returns null to match foreign documents with null or missing field.
+func lookupLocalValues(doc *types.Document, path types.Path) []any {
+	res := lookupPathValues(doc, path)
+
+	if len(res) == 0 {
+		res = append(res, types.Null)
+	}
+
+	return res
+}
+
+// lookupPathValues returns values found on the given path of the document.
+//
+// Values of arrays found on the path are returned as separate values.
+func lookupPathValues(doc *types.Document, path types.Path) []any {
+	var found []any
+
+	if path.Len() == 1 {
+		if v, err := doc.Get(path.String()); err == nil {
+			found = append(found, v)
+		}
+	} else {
+		found, _ = commonpath.FindValues(doc, path, &commonpath.FindValuesOpts{
+			FindArrayIndex:     false,
+			FindArrayDocuments: true,
+		})
+	}
+
+	var res []any
+
+	for _, v := range found {
+		arr, ok := v.(*types.Array)
+		if !ok {
+			res = append(res, v)
+			continue
+		}
+
+		iter := arr.Iterator()
+
+		for {
+			_, elem, err := iter.Next()
+			if err != nil {
+				break
+			}
+
+			res = append(res, elem)
+		}
+
+		iter.Close()
+	}
+
+	return res
+}
+
+// setByPath sets the value by the given path the way aggregation stages do:
+// missing parts of the path are created, and non-document values
+// on the path are replaced by documents.
+func setByPath(doc *types.Document, path types.Path, value any) {
+	parts := path.Slice()
+
+	for _, key := range parts[:len(parts)-1] {
+		v, _ := doc.Get(key)
+
+		next, ok := v.(*types.Document)
+		if !ok {
+			next = types.MakeDocument(1)
+			doc.Set(key, next)
+		}
+
+		doc = next
+	}
+
+	doc.Set(parts[len(parts)-1], value)
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*lookup)(nil)
+)
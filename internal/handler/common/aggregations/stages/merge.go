@@ -0,0 +1,736 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// mergeWhenMatched contains supported string values of $merge stage whenMatched field.
+var mergeWhenMatched = []string{"replace", "keepExisting", "merge", "fail"}
+
+// mergeWhenNotMatched contains supported values of $merge stage whenNotMatched field.
+var mergeWhenNotMatched = []string{"insert", "discard", "fail"}
+
+// mergePipelineStages contains stages that can be used within $merge whenMatched pipeline.
+var mergePipelineStages = map[string]struct{}{
+	// sorted alphabetically
+	"$addFields":   {},
+	"$project":     {},
+	"$replaceRoot": {},
+	"$replaceWith": {},
+	"$set":         {},
+	"$unset":       {},
+	// please keep sorted alphabetically
+}
+
+// merge represents $merge stage.
+//
+//	{ $merge: {
+//		into: <collection> or { db: <database>, coll: <collection> },
+//		on: <identifier field> or [ <identifier field1>, ... ],
+//		let: <variables>,
+//		whenMatched: <replace|keepExisting|merge|fail|pipeline>,
+//		whenNotMatched: <insert|discard|fail>
+//	} }
+//
+// The short form { $merge: <collection> } uses default values of all other fields.
+type merge struct {
+	params *NewStageParams
+	target outputNamespace
+
+	on []types.Path // identifier fields, _id by default
+
+	whenMatched    string       // "pipeline" if the pipeline is set
+	pipeline       *types.Array // nil if whenMatched is not a pipeline
+	let            *types.Document
+	whenNotMatched string
+}
+
+// newMerge validates stage document and creates a new $merge stage.
+func newMerge(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	m := &merge{
+		params:         params,
+		on:             []types.Path{types.NewStaticPath("_id")},
+		whenMatched:    "merge",
+		whenNotMatched: "insert",
+	}
+
+	var spec *types.Document
+
+	switch v := must.NotFail(stage.Get("$merge")).(type) {
+	case string:
+		spec = must.NotFail(types.NewDocument("into", v))
+	case *types.Document:
+		spec = v
+	default:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageMergeInvalidArg,
+			fmt.Sprintf("$merge only supports a string or object argument, not %s", handlerparams.AliasFromType(v)),
+			"$merge (stage)",
+		)
+	}
+
+	var hasInto bool
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch k {
+		case "into":
+			if m.target, err = newMergeInto(v, params.DBName); err != nil {
+				return nil, err
+			}
+
+			hasInto = true
+
+		case "on":
+			if m.on, err = newMergeOn(v); err != nil {
+				return nil, err
+			}
+
+		case "let":
+			var ok bool
+			if m.let, ok = v.(*types.Document); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrTypeMismatch,
+					fmt.Sprintf(
+						"BSON field '$merge.let' is the wrong type '%s', expected type 'object'",
+						handlerparams.AliasFromType(v),
+					),
+					"$merge (stage)",
+				)
+			}
+
+		case "whenMatched":
+			switch v := v.(type) {
+			case string:
+				if !slices.Contains(mergeWhenMatched, v) {
+					return nil, handlererrors.NewCommandErrorMsgWithArgument(
+						handlererrors.ErrBadValue,
+						fmt.Sprintf("Enumeration value '%s' for field '$merge.whenMatched' is not a valid value.", v),
+						"$merge (stage)",
+					)
+				}
+
+				m.whenMatched = v
+			case *types.Array:
+				m.whenMatched = "pipeline"
+				m.pipeline = v
+			default:
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageMergeInvalidWhenMatched,
+					fmt.Sprintf(
+						"$merge 'whenMatched' field must be either a string or an array, but found %s",
+						handlerparams.AliasFromType(v),
+					),
+					"$merge (stage)",
+				)
+			}
+
+		case "whenNotMatched":
+			s, ok := v.(string)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrTypeMismatch,
+					fmt.Sprintf(
+						"BSON field '$merge.whenNotMatched' is the wrong type '%s', expected type 'string'",
+						handlerparams.AliasFromType(v),
+					),
+					"$merge (stage)",
+				)
+			}
+
+			if !slices.Contains(mergeWhenNotMatched, s) {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrBadValue,
+					fmt.Sprintf("Enumeration value '%s' for field '$merge.whenNotMatched' is not a valid value.", s),
+					"$merge (stage)",
+				)
+			}
+
+			m.whenNotMatched = s
+
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$merge.%s' is an unknown field.", k),
+				"$merge (stage)",
+			)
+		}
+	}
+
+	if !hasInto {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			"BSON field '$merge.into' is missing but a required field",
+			"$merge (stage)",
+		)
+	}
+
+	if strings.HasPrefix(m.target.coll, "system.") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageMergeSpecialCollection,
+			fmt.Sprintf("Cannot $merge to special collection: %s", m.target.coll),
+			"$merge (stage)",
+		)
+	}
+
+	if m.let != nil && m.pipeline == nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageMergeLetNotAllowed,
+			fmt.Sprintf("Cannot use 'let' variables with 'whenMatched: %s' mode", m.whenMatched),
+			"$merge (stage)",
+		)
+	}
+
+	if m.pipeline != nil {
+		if err := m.validatePipeline(); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// newMergeInto validates $merge stage into field and returns the output namespace.
+func newMergeInto(v any, db string) (outputNamespace, error) {
+	switch v := v.(type) {
+	case string:
+		return outputNamespace{db: db, coll: v}, nil
+	case *types.Document:
+		return newOutputNamespace(v, "$merge.into", db)
+	default:
+		return outputNamespace{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageMergeInvalidInto,
+			fmt.Sprintf(
+				"$merge 'into' field must be either a string or an object, but found %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$merge (stage)",
+		)
+	}
+}
+
+// newMergeOn validates $merge stage on field and returns paths of identifier fields.
+func newMergeOn(v any) ([]types.Path, error) {
+	var fields []string
+
+	switch v := v.(type) {
+	case string:
+		fields = []string{v}
+	case *types.Array:
+		if v.Len() == 0 {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageMergeOnEmpty,
+				"If explicitly specifying $merge 'on', must include at least one field",
+				"$merge (stage)",
+			)
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			elem := must.NotFail(v.Get(i))
+
+			s, ok := elem.(string)
+			if !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageMergeOnNotString,
+					fmt.Sprintf("$merge 'on' array elements must be strings, but found %s", handlerparams.AliasFromType(elem)),
+					"$merge (stage)",
+				)
+			}
+
+			fields = append(fields, s)
+		}
+	default:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageMergeInvalidOn,
+			fmt.Sprintf(
+				"$merge 'on' field must be either a string or an array of strings, but found %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$merge (stage)",
+		)
+	}
+
+	res := make([]types.Path, len(fields))
+
+	for i, field := range fields {
+		if strings.HasPrefix(field, "$") {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFieldPathInvalidName,
+				"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+				"$merge (stage)",
+			)
+		}
+
+		path, err := types.NewPathFromString(field)
+		if err != nil {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrPathContainsEmptyElement,
+				"FieldPath field names may not be empty strings.",
+				"$merge (stage)",
+			)
+		}
+
+		res[i] = path
+	}
+
+	return res, nil
+}
+
+// validatePipeline checks that whenMatched pipeline contains only allowed stages
+// and that they could be created with let variables being defined.
+func (m *merge) validatePipeline() error {
+	for i := 0; i < m.pipeline.Len(); i++ {
+		d, ok := must.NotFail(m.pipeline.Get(i)).(*types.Document)
+		if !ok || d.Len() != 1 {
+			continue
+		}
+
+		if _, allowed := mergePipelineStages[d.Command()]; !allowed {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrInvalidOptions,
+				fmt.Sprintf("%s is not allowed to be used within an update", d.Command()),
+				"$merge (stage)",
+			)
+		}
+	}
+
+	vars := m.params.Variables.Clone()
+
+	if m.let != nil {
+		letExpr := must.NotFail(types.NewDocument("$expr", m.let))
+		if _, err := operators.NewExpr(letExpr, "$merge (stage)", m.params.Variables); err != nil {
+			return err
+		}
+
+		for _, name := range m.let.Keys() {
+			if err := aggregations.ValidateUserVariableName(name); err != nil {
+				return handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrFailedToParse,
+					err.Error(),
+					"$merge (stage)",
+				)
+			}
+
+			vars[name] = types.Null
+		}
+	}
+
+	vars["new"] = types.Null
+
+	_, err := newPipeline(m.pipeline, "$merge", m.params.withVariables(vars))
+
+	return err
+}
+
+// Process implements Stage interface.
+//
+// It writes all input documents to the output collection and returns no documents.
+//
+// Input documents are read by batches. For each batch, existing documents are queried with $in filter
+// on identifier values, so the backend may look them up without scanning the collection.
+// If the backend can't push that filter down, the whole collection is indexed once instead.
+func (m *merge) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	_, c, err := m.target.open(m.params.Backend, "$merge")
+	if err != nil {
+		return nil, err
+	}
+
+	if !m.onID() {
+		if err = m.checkUniqueIndex(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+
+	// index of the whole collection, set once the filter can't be pushed down
+	var all map[string]*types.Document
+
+	for {
+		docs, err := iterator.ConsumeValuesN(iter, joinBatchSize)
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if len(docs) == 0 {
+			break
+		}
+
+		// documents before the first one with invalid identifier values are still written
+		keys := make([]string, 0, len(docs))
+		values := make([][]any, 0, len(docs))
+
+		var invalidErr error
+
+		for _, doc := range docs {
+			v, err := m.identifierValues(doc)
+			if err != nil {
+				invalidErr = err
+				break
+			}
+
+			keys = append(keys, valuesKey(v...))
+			values = append(values, v)
+		}
+
+		if len(keys) == 0 {
+			return nil, invalidErr
+		}
+
+		existing := all
+
+		if existing == nil {
+			if existing, err = m.matchBatch(ctx, c, values); err != nil {
+				return nil, err
+			}
+		}
+
+		if existing == nil {
+			if all, err = m.matchIndex(ctx, c, nil); err != nil {
+				return nil, err
+			}
+
+			existing = all
+		}
+
+		for i, key := range keys {
+			if err = m.mergeDocument(ctx, c, existing, docs[i], key); err != nil {
+				return nil, err
+			}
+		}
+
+		if invalidErr != nil {
+			return nil, invalidErr
+		}
+	}
+
+	iter = iterator.Values(iterator.ForSlice([]*types.Document{}))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// onID returns true if _id is the only identifier field.
+func (m *merge) onID() bool {
+	return len(m.on) == 1 && m.on[0].String() == "_id"
+}
+
+// checkUniqueIndex returns an error if the collection does not have a unique index
+// on exactly the identifier fields.
+func (m *merge) checkUniqueIndex(ctx context.Context, c backends.Collection) error {
+	res, err := c.ListIndexes(ctx, new(backends.ListIndexesParams))
+	if err != nil && !backends.ErrorCodeIs(err, backends.ErrorCodeCollectionDoesNotExist) {
+		return lazyerrors.Error(err)
+	}
+
+	if res != nil {
+		for _, index := range res.Indexes {
+			if !index.Unique || len(index.Key) != len(m.on) {
+				continue
+			}
+
+			covered := true
+
+			for _, pair := range index.Key {
+				if !slices.ContainsFunc(m.on, func(p types.Path) bool { return p.String() == pair.Field }) {
+					covered = false
+					break
+				}
+			}
+
+			if covered {
+				return nil
+			}
+		}
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrStageMergeOnNotUnique,
+		"Cannot find index to verify that join fields will be unique",
+		"$merge (stage)",
+	)
+}
+
+// identifierValues returns values of identifier fields of the given input document.
+//
+// If _id is the only identifier field and it is missing, a new ObjectID is set first.
+func (m *merge) identifierValues(doc *types.Document) ([]any, error) {
+	if m.onID() && !doc.Has("_id") {
+		doc.Set("_id", types.NewObjectID())
+	}
+
+	values := make([]any, len(m.on))
+
+	for i, path := range m.on {
+		v, err := doc.GetByPath(path)
+
+		switch v.(type) {
+		case types.NullType, *types.Array:
+			err = errors.New("invalid value")
+		}
+
+		if err != nil {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageMergeInvalidOnValue,
+				"$merge write error: 'on' field cannot be missing, null, undefined or an array",
+				"$merge (stage)",
+			)
+		}
+
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// mergeDocument writes the given input document with the given identifier values key
+// to the collection according to whenMatched and whenNotMatched.
+//
+// Existing documents are looked up in the given index, it is updated with written documents.
+func (m *merge) mergeDocument(ctx context.Context, c backends.Collection, existingDocs map[string]*types.Document, doc *types.Document, key string) error { //nolint:lll // for readability
+	existing := existingDocs[key]
+
+	var err error
+
+	if existing == nil {
+		switch m.whenNotMatched {
+		case "insert":
+			if err = prepareOutputDocument(doc, "$merge"); err != nil {
+				return err
+			}
+
+			if _, err = c.InsertAll(ctx, &backends.InsertAllParams{Docs: []*types.Document{doc}}); err != nil {
+				return m.target.writeError(err, "$merge")
+			}
+
+			existingDocs[key] = doc
+
+			return nil
+		case "discard":
+			return nil
+		case "fail":
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageMergeNoMatch,
+				"$merge could not find a matching document in the target collection for at least one document in the source collection",
+				"$merge (stage)",
+			)
+		default:
+			panic(fmt.Sprintf("unexpected whenNotMatched %q", m.whenNotMatched))
+		}
+	}
+
+	var updated *types.Document
+
+	switch m.whenMatched {
+	case "replace":
+		updated = doc
+	case "keepExisting":
+		return nil
+	case "merge":
+		updated = existing.DeepCopy()
+
+		for _, k := range doc.Keys() {
+			updated.Set(k, must.NotFail(doc.Get(k)))
+		}
+	case "fail":
+		return m.target.writeError(backends.NewError(backends.ErrorCodeInsertDuplicateID, nil), "$merge")
+	case "pipeline":
+		if updated, err = m.applyPipeline(ctx, existing, doc); err != nil {
+			return err
+		}
+	default:
+		panic(fmt.Sprintf("unexpected whenMatched %q", m.whenMatched))
+	}
+
+	id := must.NotFail(existing.Get("_id"))
+
+	if !updated.Has("_id") {
+		updated.Set("_id", id)
+	}
+
+	if types.CompareForAggregation(must.NotFail(updated.Get("_id")), id) != types.Equal {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrImmutableField,
+			"$merge failed to update the matching document, did you attempt to modify the _id or the shard key?",
+			"$merge (stage)",
+		)
+	}
+
+	if err = prepareOutputDocument(updated, "$merge"); err != nil {
+		return err
+	}
+
+	if _, err = c.UpdateAll(ctx, &backends.UpdateAllParams{Docs: []*types.Document{updated}}); err != nil {
+		return m.target.writeError(err, "$merge")
+	}
+
+	delete(existingDocs, key)
+
+	if k, ok := m.identifierKey(updated); ok {
+		existingDocs[k] = updated
+	}
+
+	return nil
+}
+
+// matchBatch returns documents of the collection matching the given identifier values of a batch,
+// indexed by keys of identifier fields values.
+//
+// It returns nil if there are several identifier fields,
+// or if the backend can't push $in filter on identifier values down.
+func (m *merge) matchBatch(ctx context.Context, c backends.Collection, values [][]any) (map[string]*types.Document, error) { //nolint:lll // for readability
+	if len(m.on) != 1 {
+		return nil, nil
+	}
+
+	in := types.MakeArray(len(values))
+	for _, v := range values {
+		in.Append(v[0])
+	}
+
+	filter := must.NotFail(types.NewDocument(
+		m.on[0].String(), must.NotFail(types.NewDocument("$in", in)),
+	))
+
+	explain, err := c.Explain(ctx, &backends.ExplainParams{Filter: filter})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if !explain.FilterPushdown {
+		return nil, nil
+	}
+
+	return m.matchIndex(ctx, c, filter)
+}
+
+// matchIndex returns documents of the collection matching the given filter (all documents if it is nil)
+// indexed by keys of identifier fields values.
+//
+// The backend may return more documents than matched by the filter, they are indexed too.
+func (m *merge) matchIndex(ctx context.Context, c backends.Collection, filter *types.Document) (map[string]*types.Document, error) { //nolint:lll // for readability
+	res, err := c.Query(ctx, &backends.QueryParams{Filter: filter})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	defer res.Iter.Close()
+
+	index := make(map[string]*types.Document)
+
+	for {
+		_, doc, err := res.Iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			return index, nil
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		if key, ok := m.identifierKey(doc); ok {
+			index[key] = doc
+		}
+	}
+}
+
+// identifierKey returns the key of identifier fields values of the given document.
+// It returns false if some of identifier fields are missing.
+func (m *merge) identifierKey(doc *types.Document) (string, bool) {
+	values := make([]any, len(m.on))
+
+	for i, path := range m.on {
+		v, err := doc.GetByPath(path)
+		if err != nil {
+			return "", false
+		}
+
+		values[i] = v
+	}
+
+	return valuesKey(values...), true
+}
+
+// applyPipeline returns the result of applying whenMatched pipeline to the existing document.
+//
+// The input document is available to the pipeline as $$new variable.
+func (m *merge) applyPipeline(ctx context.Context, existing, doc *types.Document) (*types.Document, error) {
+	vars := m.params.Variables.Clone()
+
+	if m.let != nil {
+		var err error
+		if vars, err = letVariables(m.let, doc, m.params.Variables, "$merge (stage)"); err != nil {
+			return nil, err
+		}
+	}
+
+	vars["new"] = doc
+
+	stages, err := newPipeline(m.pipeline, "$merge", m.params.withVariables(vars))
+	if err != nil {
+		return nil, err
+	}
+
+	closer := iterator.NewMultiCloser()
+	defer closer.Close()
+
+	var iter types.DocumentsIterator = iterator.Values(iterator.ForSlice([]*types.Document{existing.DeepCopy()}))
+	closer.Add(iter)
+
+	if iter, err = processPipeline(ctx, stages, iter, closer); err != nil {
+		return nil, err
+	}
+
+	res, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if len(res) != 1 {
+		return nil, lazyerrors.Errorf("expected 1 document, got %d", len(res))
+	}
+
+	return res[0], nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*merge)(nil)
+)
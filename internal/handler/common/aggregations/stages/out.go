@@ -0,0 +1,376 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// outTempCollectionPrefix is the name prefix of temporary collections used by $out stage.
+const outTempCollectionPrefix = "tmp.agg_out."
+
+// out represents $out stage.
+//
+//	{ $out: <output collection> }
+//	{ $out: { db: <output database>, coll: <output collection> } }
+//
+// Documents are written to a temporary collection that replaces the output collection
+// only if all documents were written successfully.
+type out struct {
+	params *NewStageParams
+	target outputNamespace
+}
+
+// newOut validates stage document and creates a new $out stage.
+func newOut(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	o := &out{
+		params: params,
+	}
+
+	switch v := must.NotFail(stage.Get("$out")).(type) {
+	case string:
+		o.target = outputNamespace{db: params.DBName, coll: v}
+	case *types.Document:
+		if !v.Has("db") || !v.Has("coll") {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageOutInvalidNamespace,
+				fmt.Sprintf(
+					"If an object is passed to $out it must have exactly 2 fields: 'db' and 'coll'. Found: %s",
+					types.FormatAnyValue(v),
+				),
+				"$out (stage)",
+			)
+		}
+
+		var err error
+		if o.target, err = newOutputNamespace(v, "$out", params.DBName); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageOutInvalidArg,
+			fmt.Sprintf("$out only supports a string or object argument, not %s", handlerparams.AliasFromType(v)),
+			"$out (stage)",
+		)
+	}
+
+	if strings.HasPrefix(o.target.coll, "system.") {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageOutSpecialCollection,
+			fmt.Sprintf("Can't $out to special collection: %s", o.target.coll),
+			"$out (stage)",
+		)
+	}
+
+	return o, nil
+}
+
+// Process implements Stage interface.
+//
+// It writes all input documents to the output collection and returns no documents.
+// Documents are written to a temporary collection that replaces the output collection;
+// the existing output collection is dropped only after that.
+func (o *out) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	docs, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	db, c, err := o.target.open(o.params.Backend, "$out")
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := db.ListCollections(ctx, &backends.ListCollectionsParams{Name: o.target.coll})
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	// indexes of the existing output collection are preserved
+	var indexes []backends.IndexInfo
+
+	if len(list.Collections) > 0 {
+		if list.Collections[0].Capped() {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageOutCappedCollection,
+				fmt.Sprintf("namespace '%s' is capped so it can't be used for $out", o.target),
+				"$out (stage)",
+			)
+		}
+
+		var res *backends.ListIndexesResult
+		if res, err = c.ListIndexes(ctx, new(backends.ListIndexesParams)); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		for _, index := range res.Indexes {
+			if index.Name != backends.DefaultIndexName {
+				indexes = append(indexes, index)
+			}
+		}
+	}
+
+	for _, doc := range docs {
+		if err = prepareOutputDocument(doc, "$out"); err != nil {
+			return nil, err
+		}
+	}
+
+	tmpName := outTempCollectionPrefix + uuid.NewString()
+
+	if err = db.CreateCollection(ctx, &backends.CreateCollectionParams{Name: tmpName}); err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	var renamed bool
+
+	defer func() {
+		if renamed {
+			return
+		}
+
+		// drop the temporary collection even if the context is canceled
+		_ = db.DropCollection(context.WithoutCancel(ctx), &backends.DropCollectionParams{Name: tmpName})
+	}()
+
+	tmp, err := db.Collection(tmpName)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	if len(indexes) > 0 {
+		if _, err = tmp.CreateIndexes(ctx, &backends.CreateIndexesParams{Indexes: indexes}); err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	if len(docs) > 0 {
+		if _, err = tmp.InsertAll(ctx, &backends.InsertAllParams{Docs: docs}); err != nil {
+			return nil, o.target.writeError(err, "$out")
+		}
+	}
+
+	// the existing target collection is renamed aside instead of being dropped,
+	// so it could be restored if the temporary collection can't take its place
+	backupName := outTempCollectionPrefix + uuid.NewString()
+
+	backup := true
+
+	err = db.RenameCollection(ctx, &backends.RenameCollectionParams{OldName: o.target.coll, NewName: backupName})
+	if err != nil {
+		if !backends.ErrorCodeIs(err, backends.ErrorCodeCollectionDoesNotExist) {
+			return nil, lazyerrors.Error(err)
+		}
+
+		backup = false
+	}
+
+	err = db.RenameCollection(ctx, &backends.RenameCollectionParams{OldName: tmpName, NewName: o.target.coll})
+	if err != nil {
+		if backup {
+			// restore the target collection even if the context is canceled
+			_ = db.RenameCollection(
+				context.WithoutCancel(ctx),
+				&backends.RenameCollectionParams{OldName: backupName, NewName: o.target.coll},
+			)
+		}
+
+		return nil, lazyerrors.Error(err)
+	}
+
+	renamed = true
+
+	if backup {
+		// new documents are already in place, drop the old ones even if the context is canceled
+		_ = db.DropCollection(context.WithoutCancel(ctx), &backends.DropCollectionParams{Name: backupName})
+	}
+
+	iter = iterator.Values(iterator.ForSlice([]*types.Document{}))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// outputNamespace represents the output collection of $out and $merge stages.
+type outputNamespace struct {
+	db   string
+	coll string
+}
+
+// newOutputNamespace validates the given { db: <database>, coll: <collection> } document
+// and returns output namespace.
+//
+// The field is the name of the stage field containing the document, it is used in error messages.
+// The db is used if the document does not contain the database.
+func newOutputNamespace(spec *types.Document, field, db string) (outputNamespace, error) {
+	ns := outputNamespace{db: db}
+
+	var hasColl bool
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return outputNamespace{}, lazyerrors.Error(err)
+		}
+
+		if k != "db" && k != "coll" {
+			return outputNamespace{}, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '%s.%s' is an unknown field.", field, k),
+				field,
+			)
+		}
+
+		s, ok := v.(string)
+		if !ok {
+			return outputNamespace{}, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				fmt.Sprintf(
+					"BSON field '%s.%s' is the wrong type '%s', expected type 'string'",
+					field, k, handlerparams.AliasFromType(v),
+				),
+				field,
+			)
+		}
+
+		if k == "db" {
+			ns.db = s
+			continue
+		}
+
+		ns.coll = s
+		hasColl = true
+	}
+
+	if !hasColl {
+		return outputNamespace{}, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			fmt.Sprintf("BSON field '%s.coll' is missing but a required field", field),
+			field,
+		)
+	}
+
+	return ns, nil
+}
+
+// String implements fmt.Stringer interface.
+func (ns outputNamespace) String() string {
+	return ns.db + "." + ns.coll
+}
+
+// open returns the database and the collection of the output namespace.
+//
+// The stage is the name of the stage writing to the namespace, it is used in error messages.
+func (ns outputNamespace) open(b backends.Backend, stage string) (backends.Database, backends.Collection, error) {
+	db, err := b.Database(ns.db)
+	if err != nil {
+		if backends.ErrorCodeIs(err, backends.ErrorCodeDatabaseNameIsInvalid) {
+			return nil, nil, ns.invalidError(stage)
+		}
+
+		return nil, nil, lazyerrors.Error(err)
+	}
+
+	c, err := db.Collection(ns.coll)
+	if err != nil {
+		if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
+			return nil, nil, ns.invalidError(stage)
+		}
+
+		return nil, nil, lazyerrors.Error(err)
+	}
+
+	return db, c, nil
+}
+
+// invalidError returns an error for the invalid output namespace.
+func (ns outputNamespace) invalidError(stage string) error {
+	return handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrInvalidNamespace,
+		fmt.Sprintf("Invalid %s target namespace, %s", stage, ns),
+		stage+" (stage)",
+	)
+}
+
+// writeError converts the backend error returned by writing to the output namespace.
+func (ns outputNamespace) writeError(err error, stage string) error {
+	if backends.ErrorCodeIs(err, backends.ErrorCodeInsertDuplicateID) {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrDuplicateKeyInsert,
+			fmt.Sprintf("E11000 duplicate key error collection: %s", ns),
+			stage+" (stage)",
+		)
+	}
+
+	return lazyerrors.Error(err)
+}
+
+// prepareOutputDocument sets _id of the document written by $out or $merge stage if it is not set,
+// and validates the document.
+func prepareOutputDocument(doc *types.Document, stage string) error {
+	if !doc.Has("_id") {
+		doc.Set("_id", types.NewObjectID())
+	}
+
+	err := doc.ValidateData()
+	if err == nil {
+		return nil
+	}
+
+	var ve *types.ValidationError
+	if !errors.As(err, &ve) {
+		return lazyerrors.Error(err)
+	}
+
+	var code handlererrors.ErrorCode
+
+	switch ve.Code() {
+	case types.ErrValidation, types.ErrIDNotFound:
+		code = handlererrors.ErrBadValue
+	case types.ErrWrongIDType:
+		code = handlererrors.ErrInvalidID
+	default:
+		panic(fmt.Sprintf("Unknown error code: %v", ve.Code()))
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(code, ve.Error(), stage+" (stage)")
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*out)(nil)
+	_ fmt.Stringer       = outputNamespace{}
+)
@@ -0,0 +1,149 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// newPipeline creates stages of the sub-pipeline used by stages such as $lookup.
+//
+// The stage is the name of the stage containing the sub-pipeline, it is used in error messages.
+func newPipeline(pipeline *types.Array, stage string, params *NewStageParams) ([]aggregations.Stage, error) {
+	res := make([]aggregations.Stage, 0, pipeline.Len())
+
+	iter := pipeline.Iterator()
+	defer iter.Close()
+
+	for {
+		i, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		d, ok := v.(*types.Document)
+		if !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				"Each element of the 'pipeline' array must be an object",
+				stage+" (stage)",
+			)
+		}
+
+		if i > 0 && d.Len() == 1 && d.Command() == "$documents" {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrCollStatsIsNotFirstStage,
+				"$documents is only valid as the first stage in a pipeline",
+				stage+" (stage)",
+			)
+		}
+
+		s, err := NewStage(d, params)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, s)
+	}
+
+	SetSortLimits(res)
+
+	return res, nil
+}
+
+// processPipeline applies stages of the sub-pipeline to documents from iterator.
+//
+// Returned iterator and all iterators created by stages are added to the given closer.
+func processPipeline(ctx context.Context, stages []aggregations.Stage, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var err error
+
+	for _, s := range stages {
+		if iter, err = s.Process(ctx, iter, closer); err != nil {
+			return nil, err
+		}
+	}
+
+	return iter, nil
+}
+
+// hasDocumentsFirst returns true if the first stage of the pipeline is $documents.
+func hasDocumentsFirst(pipeline *types.Array) bool {
+	if pipeline.Len() == 0 {
+		return false
+	}
+
+	d, ok := must.NotFail(pipeline.Get(0)).(*types.Document)
+
+	return ok && d.Len() == 1 && d.Command() == "$documents"
+}
+
+// emptyIterator returns an iterator without documents,
+// it is used as an input of pipelines starting with $documents.
+//
+// Returned iterator is added to the given closer.
+func emptyIterator(closer *iterator.MultiCloser) types.DocumentsIterator {
+	iter := iterator.Values(iterator.ForSlice([]*types.Document{}))
+	closer.Add(iter)
+
+	return iter
+}
+
+// letVariables returns a copy of the given variables with let variables evaluated for the given document,
+// it is used by stages with sub-pipelines accepting let, such as $lookup.
+//
+// The errArgument is used in error messages.
+func letVariables(let, doc *types.Document, vars aggregations.Variables, errArgument string) (aggregations.Variables, error) { //nolint:lll // for readability
+	op, err := operators.NewExpr(must.NotFail(types.NewDocument("$expr", let)), errArgument, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := op.Process(doc, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	vars = vars.Clone()
+
+	for _, name := range let.Keys() {
+		// variables evaluated to missing fields are set to null
+		v, err := evaluated.Get(name)
+		if err != nil {
+			v = types.Null
+		}
+
+		vars[name] = v
+	}
+
+	return vars, nil
+}
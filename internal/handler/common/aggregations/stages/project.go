@@ -35,11 +35,12 @@ import (
 //	  }
 type project struct {
 	projection *types.Document
+	vars       aggregations.Variables
 	inclusion  bool
 }
 
 // newProject validates projection document and creates a new $project stage.
-func newProject(stage *types.Document) (aggregations.Stage, error) {
+func newProject(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	fields, err := common.GetRequiredParam[*types.Document](stage, "$project")
 	if err != nil {
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
@@ -49,13 +50,14 @@ func newProject(stage *types.Document) (aggregations.Stage, error) {
 		)
 	}
 
-	validated, inclusion, err := projection.ValidateProjection(fields)
+	validated, inclusion, err := projection.ValidateProjection(fields, params.Variables)
 	if err != nil {
 		return nil, err
 	}
 
 	return &project{
 		projection: validated,
+		vars:       params.Variables,
 		inclusion:  inclusion,
 	}, nil
 }
@@ -64,7 +66,7 @@ func newProject(stage *types.Document) (aggregations.Stage, error) {
 //
 //nolint:lll // for readability
 func (p *project) Process(_ context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) {
-	return projection.ProjectionIterator(iter, closer, p.projection)
+	return projection.ProjectionIterator(iter, closer, p.projection, p.vars)
 }
 
 // check interfaces
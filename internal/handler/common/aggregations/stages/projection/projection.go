@@ -46,7 +46,9 @@ import (
 //   - `ErrAggregatePositionalProject` when `$` is used in the suffix key;
 //   - `ErrAggregatePositionalProject` when positional projection contains empty path;
 //   - `ErrNotImplemented` when there is unimplemented projection operators and expressions.
-func ValidateProjection(projection *types.Document) (*types.Document, bool, error) {
+//
+// Variables are used for validating operators accessing variables.
+func ValidateProjection(projection *types.Document, vars aggregations.Variables) (*types.Document, bool, error) {
 	validated := types.MakeDocument(0)
 
 	if projection.Len() == 0 {
@@ -163,7 +165,8 @@ func ValidateProjection(projection *types.Document) (*types.Document, bool, erro
 				return nil, false, err
 			}
 
-			_, err = op.Process(must.NotFail(types.NewDocument("key", "value")))
+			// operators are validated with nil document, the same way as operators.NewExpr does
+			_, err = op.Process(nil, vars)
 			if err = processOperatorError(err); err != nil {
 				return nil, false, err
 			}
@@ -173,7 +176,16 @@ func ValidateProjection(projection *types.Document) (*types.Document, bool, erro
 
 			result = true
 
-		case *types.Array, string, types.Binary, types.ObjectID,
+		case string:
+			// expressions are validated with nil document, the same way as operators.NewExpr does
+			if _, _, err = evaluateExpression(value, nil, vars); err != nil {
+				return nil, false, err
+			}
+
+			result = true
+
+			validated.Set(key, value)
+		case *types.Array, types.Binary, types.ObjectID,
 			time.Time, types.NullType, types.Regex, types.Timestamp: // all this types are treated as new fields value
 			result = true
 
@@ -237,10 +249,13 @@ func ValidateProjection(projection *types.Document) (*types.Document, bool, erro
 }
 
 // ProjectDocument applies projection to the copy of the document.
-func ProjectDocument(doc, projection *types.Document, inclusion bool) (*types.Document, error) {
-	projected, err := types.NewDocument("_id", must.NotFail(doc.Get("_id")))
-	if err != nil {
-		return nil, err
+// Variables are used for evaluating operators accessing variables.
+func ProjectDocument(doc, projection *types.Document, inclusion bool, vars aggregations.Variables) (*types.Document, error) {
+	projected := types.MakeDocument(1)
+
+	// documents produced by stages such as $densify or $replaceRoot may not have _id
+	if id, err := doc.Get("_id"); err == nil {
+		projected.Set("_id", id)
 	}
 
 	if projection.Has("_id") {
@@ -252,6 +267,7 @@ func ProjectDocument(doc, projection *types.Document, inclusion bool) (*types.Do
 		case *types.Document: // field: { $elemMatch: { field2: value }}
 			var op operators.Operator
 			var value any
+			var err error
 
 			if !operators.IsOperator(idValue) {
 				projected.Set("_id", idValue)
@@ -265,15 +281,26 @@ func ProjectDocument(doc, projection *types.Document, inclusion bool) (*types.Do
 				return nil, processOperatorError(err)
 			}
 
-			value, err = op.Process(doc)
+			value, err = op.Process(doc, vars)
 			if err != nil {
-				return nil, err
+				return nil, processOperatorError(err)
 			}
 
 			set = true
 			projected.Set("_id", value)
 
-		case *types.Array, string, types.Binary, types.ObjectID,
+		case string:
+			value, found, err := evaluateExpression(idValue, doc, vars)
+			if err != nil {
+				return nil, err
+			}
+
+			if found {
+				projected.Set("_id", value)
+				set = true
+			}
+
+		case *types.Array, types.Binary, types.ObjectID,
 			time.Time, types.NullType, types.Regex, types.Timestamp: // all this types are treated as new fields value
 			projected.Set("_id", idValue)
 
@@ -291,7 +318,7 @@ func ProjectDocument(doc, projection *types.Document, inclusion bool) (*types.Do
 		}
 	}
 
-	projectedWithoutID, err := projectDocumentWithoutID(doc, projection, inclusion)
+	projectedWithoutID, err := projectDocumentWithoutID(doc, projection, inclusion, vars)
 	if err != nil {
 		// TODO https://github.com/FerretDB/FerretDB/issues/2633
 		return nil, err
@@ -306,7 +333,7 @@ func ProjectDocument(doc, projection *types.Document, inclusion bool) (*types.Do
 
 // projectDocumentWithoutID applies projection to the copy of the document and returns projected document.
 // It ignores _id field in the projection.
-func projectDocumentWithoutID(doc *types.Document, projection *types.Document, inclusion bool) (*types.Document, error) {
+func projectDocumentWithoutID(doc *types.Document, projection *types.Document, inclusion bool, vars aggregations.Variables) (*types.Document, error) { //nolint:lll // for readability
 	projectionWithoutID := projection.DeepCopy()
 	projectionWithoutID.Remove("_id")
 
@@ -353,14 +380,27 @@ func projectDocumentWithoutID(doc *types.Document, projection *types.Document, i
 				return nil, processOperatorError(err)
 			}
 
-			v, err = op.Process(doc)
+			v, err = op.Process(doc, vars)
 			if err != nil {
-				return nil, err
+				return nil, processOperatorError(err)
 			}
 
 			projected.Set(key, v)
 
-		case *types.Array, string, types.Binary, types.ObjectID,
+		case string:
+			var v any
+			var found bool
+
+			if v, found, err = evaluateExpression(value, doc, vars); err != nil {
+				return nil, err
+			}
+
+			// fields evaluated to missing values, such as `$$REMOVE`, are not set
+			if found {
+				projected.Set(key, v)
+			}
+
+		case *types.Array, types.Binary, types.ObjectID,
 			time.Time, types.NullType, types.Regex, types.Timestamp: // all these types are treated as new fields value
 			projected.Set(key, value)
 
@@ -621,6 +661,36 @@ func setBySourceOrder(key string, val any, source, projected *types.Document) {
 	}
 }
 
+// evaluateExpression evaluates field path or variable expression on the document.
+// Strings that are not expressions are returned as is.
+// It returns false if the expression evaluates to a missing value.
+//
+// The document is nil for validation, only expression errors such as undefined variables are returned then.
+func evaluateExpression(value string, doc *types.Document, vars aggregations.Variables) (any, bool, error) {
+	expression, err := aggregations.NewExpression(value, nil)
+	if err != nil {
+		var exprErr *aggregations.ExpressionError
+		if errors.As(err, &exprErr) && exprErr.Code() == aggregations.ErrNotExpression {
+			return value, true, nil
+		}
+
+		return nil, false, processOperatorError(err)
+	}
+
+	v, err := expression.Evaluate(doc, vars)
+	if err != nil {
+		var exprErr *aggregations.ExpressionError
+		if errors.As(err, &exprErr) {
+			return nil, false, processOperatorError(err)
+		}
+
+		// missing field
+		return nil, false, nil
+	}
+
+	return v, true, nil
+}
+
 // processOperatorError takes internal error related to operator evaluation and
 // returns proper CommandError that can be returned by $project aggregation stage.
 //
@@ -674,6 +744,12 @@ func processOperatorError(err error) error {
 				"Invalid $project :: caused by :: "+opErr.Error(),
 				"$project (stage)",
 			)
+		case operators.ErrInvalidArgument:
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				opErr.CommandCode(),
+				opErr.Error(),
+				"$project (stage)",
+			)
 		}
 
 	case errors.As(err, &exErr):
@@ -694,10 +770,9 @@ func processOperatorError(err error) error {
 				"$project (stage)",
 			)
 		case aggregations.ErrUndefinedVariable:
-			// TODO https://github.com/FerretDB/FerretDB/issues/2275
 			return handlererrors.NewCommandErrorMsgWithArgument(
-				handlererrors.ErrNotImplemented,
-				"Aggregation expression variables are not implemented yet",
+				handlererrors.ErrUndefinedVariable,
+				fmt.Sprintf("Invalid $project :: caused by :: Use of undefined variable: %s", exErr.Name()),
 				"$project (stage)",
 			)
 		case aggregations.ErrEmptyVariable:
@@ -0,0 +1,226 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// Values of $$DESCEND, $$PRUNE and $$KEEP system variables available in $redact expression.
+const (
+	redactDescend = "descend"
+	redactPrune   = "prune"
+	redactKeep    = "keep"
+)
+
+// redact represents $redact stage.
+//
+//	{ $redact: <expression> }
+//
+// The expression is evaluated for each document and must return one of
+// $$DESCEND, $$PRUNE or $$KEEP system variables.
+// For $$DESCEND, the expression is evaluated recursively for embedded documents,
+// including documents in arrays.
+type redact struct {
+	// op evaluates { redact: <expression> } document
+	op operators.Operator
+
+	// vars contains pipeline variables and system variables of $redact;
+	// pipeline variables are copied from params on each Process call,
+	// as they may change between calls, for example, in $lookup sub-pipeline with let
+	vars   aggregations.Variables
+	params *NewStageParams
+}
+
+// newRedact validates stage document and creates a new $redact stage.
+func newRedact(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	vars := params.Variables.Clone()
+	vars["DESCEND"] = redactDescend
+	vars["PRUNE"] = redactPrune
+	vars["KEEP"] = redactKeep
+
+	exprValue := must.NotFail(types.NewDocument(
+		"$expr", must.NotFail(types.NewDocument("redact", must.NotFail(stage.Get("$redact")))),
+	))
+
+	op, err := operators.NewExpr(exprValue, "$redact (stage)", vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redact{
+		op:     op,
+		vars:   vars,
+		params: params,
+	}, nil
+}
+
+// Process implements Stage interface.
+func (r *redact) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	maps.Copy(r.vars, r.params.Variables)
+
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		for {
+			_, doc, err := iter.Next()
+			if err != nil {
+				return unused, nil, lazyerrors.Error(err)
+			}
+
+			redacted, err := r.redactDocument(doc)
+			if err != nil {
+				return unused, nil, err
+			}
+
+			if redacted != nil {
+				return unused, redacted, nil
+			}
+		}
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// redactDocument returns the document with redacted embedded documents,
+// or nil if the document is pruned.
+func (r *redact) redactDocument(doc *types.Document) (*types.Document, error) {
+	action, err := r.action(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case redactPrune:
+		return nil, nil
+	case redactKeep:
+		return doc, nil
+	}
+
+	res := types.MakeDocument(doc.Len())
+
+	iter := doc.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		redacted, err := r.redactValue(v)
+		if err != nil {
+			return nil, err
+		}
+
+		if redacted != nil {
+			res.Set(k, redacted)
+		}
+	}
+
+	return res, nil
+}
+
+// redactValue returns the value with redacted embedded documents,
+// or nil if the value is a pruned document.
+func (r *redact) redactValue(v any) (any, error) {
+	switch v := v.(type) {
+	case *types.Document:
+		doc, err := r.redactDocument(v)
+		if err != nil || doc == nil {
+			return nil, err
+		}
+
+		return doc, nil
+
+	case *types.Array:
+		res := types.MakeArray(v.Len())
+
+		for i := 0; i < v.Len(); i++ {
+			redacted, err := r.redactValue(must.NotFail(v.Get(i)))
+			if err != nil {
+				return nil, err
+			}
+
+			if redacted != nil {
+				res.Append(redacted)
+			}
+		}
+
+		return res, nil
+
+	default:
+		return v, nil
+	}
+}
+
+// action evaluates the expression for the given document and returns one of
+// redactDescend, redactPrune or redactKeep.
+func (r *redact) action(doc *types.Document) (string, error) {
+	v, err := r.op.Process(doc, r.vars)
+	if err != nil {
+		return "", err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return "", lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	res, _ := evaluated.Get("redact")
+
+	// values of system variables are compared, so a string literal with the same value is also accepted
+	if action, ok := res.(string); ok {
+		switch action {
+		case redactDescend, redactPrune, redactKeep:
+			return action, nil
+		}
+	}
+
+	if res == nil {
+		res = types.Null
+	}
+
+	return "", handlererrors.NewCommandErrorMsgWithArgument(
+		handlererrors.ErrStageRedactInvalidReturn,
+		fmt.Sprintf(
+			"$redact's expression should not return anything aside from the variables $$KEEP, $$DESCEND, "+
+				"and $$PRUNE, but returned %s",
+			types.FormatAnyValue(res),
+		),
+		"$redact (stage)",
+	)
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*redact)(nil)
+)
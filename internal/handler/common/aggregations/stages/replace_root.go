@@ -0,0 +1,165 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// replaceRoot represents $replaceRoot and $replaceWith stages.
+//
+//	{ $replaceRoot: { newRoot: <replacementDocument> } }
+//	{ $replaceWith: <replacementDocument> }
+type replaceRoot struct {
+	// op evaluates { root: <replacementDocument> } document,
+	// so the missing root field indicates that the expression is evaluated to a missing value
+	op          operators.Operator
+	vars        aggregations.Variables
+	errArgument string
+}
+
+// newReplaceRoot validates stage document and creates a new $replaceRoot stage.
+func newReplaceRoot(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	v := must.NotFail(stage.Get("$replaceRoot"))
+
+	spec, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrIndexesWrongType,
+			"invalid parameter: expected an object ($replaceRoot)",
+			"$replaceRoot (stage)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		if k != "newRoot" {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$replaceRoot.%s' is an unknown field.", k),
+				"$replaceRoot (stage)",
+			)
+		}
+	}
+
+	newRoot, err := spec.Get("newRoot")
+	if err != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			"BSON field '$replaceRoot.newRoot' is missing but a required field",
+			"$replaceRoot (stage)",
+		)
+	}
+
+	return newReplaceRootStage(newRoot, "$replaceRoot (stage)", params)
+}
+
+// newReplaceWith validates stage document and creates a new $replaceWith stage.
+func newReplaceWith(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	return newReplaceRootStage(must.NotFail(stage.Get("$replaceWith")), "$replaceWith (stage)", params)
+}
+
+// newReplaceRootStage creates a stage replacing input documents with the given expression.
+func newReplaceRootStage(newRoot any, errArgument string, params *NewStageParams) (aggregations.Stage, error) {
+	exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("root", newRoot))))
+
+	op, err := operators.NewExpr(exprValue, errArgument, params.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	return &replaceRoot{
+		op:          op,
+		vars:        params.Variables,
+		errArgument: errArgument,
+	}, nil
+}
+
+// Process implements Stage interface.
+func (r *replaceRoot) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		var unused struct{}
+
+		_, doc, err := iter.Next()
+		if err != nil {
+			return unused, nil, lazyerrors.Error(err)
+		}
+
+		newRoot, err := r.replace(doc)
+		if err != nil {
+			return unused, nil, err
+		}
+
+		return unused, newRoot, nil
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// replace returns the replacement document evaluated for the given input document.
+func (r *replaceRoot) replace(doc *types.Document) (*types.Document, error) {
+	v, err := r.op.Process(doc, r.vars)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	root, err := evaluated.Get("root")
+	if err != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageReplaceRootNotDocument,
+			fmt.Sprintf(
+				"'newRoot' expression must evaluate to an object, but resulting value was: MISSING. "+
+					"Type of resulting value: 'missing'. Input document: %s",
+				types.FormatAnyValue(doc),
+			),
+			r.errArgument,
+		)
+	}
+
+	newRoot, ok := root.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageReplaceRootNotDocument,
+			fmt.Sprintf(
+				"'newRoot' expression must evaluate to an object, but resulting value was: %s. "+
+					"Type of resulting value: '%s'. Input document: %s",
+				types.FormatAnyValue(root), handlerparams.AliasFromType(root), types.FormatAnyValue(doc),
+			),
+			r.errArgument,
+		)
+	}
+
+	return newRoot, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*replaceRoot)(nil)
+)
@@ -0,0 +1,200 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// sample represents $sample stage.
+//
+//	{ $sample: { size: <positive integer> } }
+type sample struct {
+	size int64
+}
+
+// newSample validates stage document and creates a new $sample stage.
+func newSample(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	size, err := getSampleSize(must.NotFail(stage.Get("$sample")))
+	if err != nil {
+		return nil, err
+	}
+
+	return &sample{
+		size: size,
+	}, nil
+}
+
+// GetPushdownSample returns the number of documents to sample
+// if the first stage of the pipeline is valid $sample stage that can be pushed down.
+// It returns 0 otherwise.
+func GetPushdownSample(stagesDocs []any) int64 {
+	if len(stagesDocs) == 0 {
+		return 0
+	}
+
+	stage, ok := stagesDocs[0].(*types.Document)
+	if !ok || stage.Len() != 1 || stage.Command() != "$sample" {
+		return 0
+	}
+
+	size, err := getSampleSize(must.NotFail(stage.Get("$sample")))
+	if err != nil {
+		return 0
+	}
+
+	return size
+}
+
+// getSampleSize returns the number of documents to sample from $sample stage specification.
+// It returns the proper error if specification doesn't meet requirements.
+func getSampleSize(spec any) (int64, error) {
+	doc, ok := spec.(*types.Document)
+	if !ok {
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSampleInvalidSpec,
+			"the $sample stage specification must be an object",
+			"$sample (stage)",
+		)
+	}
+
+	var size int64
+	var hasSize bool
+
+	iter := doc.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return 0, lazyerrors.Error(err)
+		}
+
+		if k != "size" {
+			return 0, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageSampleUnknownOption,
+				fmt.Sprintf("unrecognized option to $sample: %s", k),
+				"$sample (stage)",
+			)
+		}
+
+		switch v := v.(type) {
+		case float64:
+			switch {
+			case math.IsNaN(v):
+				size = 0
+			case v >= math.MaxInt64:
+				size = math.MaxInt64
+			case v <= math.MinInt64:
+				size = math.MinInt64
+			default:
+				size = int64(v)
+			}
+		case int32:
+			size = int64(v)
+		case int64:
+			size = v
+		default:
+			return 0, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageSampleSizeNotNumber,
+				"size argument to $sample must be a number",
+				"$sample (stage)",
+			)
+		}
+
+		if size < 0 {
+			return 0, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageSampleSizeNegative,
+				"size argument to $sample must not be negative",
+				"$sample (stage)",
+			)
+		}
+
+		hasSize = true
+	}
+
+	if !hasSize {
+		return 0, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSampleMissingSize,
+			"$sample stage must specify a size",
+			"$sample (stage)",
+		)
+	}
+
+	return size, nil
+}
+
+// Process implements Stage interface.
+//
+// It fully consumes the input iterator and selects documents using reservoir sampling.
+// Sampled documents are returned in random order.
+func (s *sample) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var docs []*types.Document
+	var seen int64
+
+	for {
+		_, doc, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		seen++
+
+		if int64(len(docs)) < s.size {
+			docs = append(docs, doc)
+			continue
+		}
+
+		// Math/rand is good enough because we don't need the randomness to be cryptographically secure.
+		if i := rand.Int63n(seen); i < s.size {
+			docs[i] = doc
+		}
+	}
+
+	iter.Close()
+
+	rand.Shuffle(len(docs), func(i, j int) {
+		docs[i], docs[j] = docs[j], docs[i]
+	})
+
+	res := iterator.Values(iterator.ForSlice(docs))
+	closer.Add(res)
+
+	return res, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*sample)(nil)
+)
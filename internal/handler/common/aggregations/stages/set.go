@@ -33,10 +33,11 @@ import (
 //	{ $set: { <newField>: <expression>, ... } }
 type set struct {
 	newField *types.Document
+	vars     aggregations.Variables
 }
 
 // newSet validates stage document and creates a new $set stage.
-func newSet(stage *types.Document) (aggregations.Stage, error) {
+func newSet(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	fields, err := stage.Get("$set")
 	if err != nil {
 		return nil, lazyerrors.Error(err)
@@ -61,12 +62,13 @@ func newSet(stage *types.Document) (aggregations.Stage, error) {
 
 	return &set{
 		newField: fieldsDoc,
+		vars:     params.Variables,
 	}, nil
 }
 
 // Process implements Stage interface.
 func (s *set) Process(_ context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
-	return common.AddFieldsIterator(iter, closer, s.newField), nil
+	return common.AddFieldsIterator(iter, closer, s.newField, s.vars), nil
 }
 
 // check interfaces
@@ -0,0 +1,307 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators/windows"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// setWindowFields represents $setWindowFields stage.
+//
+//	{ $setWindowFields: {
+//		partitionBy: <expression>,
+//		sortBy: { <sort field 1>: <sort order>, ... },
+//		output: {
+//			<output field 1>: {
+//				<window operator>: <window operator parameters>,
+//				window: {
+//					documents: [ <lower boundary>, <upper boundary> ],
+//					range: [ <lower boundary>, <upper boundary> ],
+//					unit: <time unit>
+//				}
+//			},
+//			...
+//		}
+//	}}
+type setWindowFields struct {
+	partitionBy operators.Operator // nil if partitionBy is not set
+	sortBy      *types.Document    // nil if sortBy is not set
+	output      []windowOutput
+	vars        aggregations.Variables
+}
+
+// windowOutput represents an output field of $setWindowFields stage.
+type windowOutput struct {
+	path types.Path
+	op   windows.Operator
+}
+
+// newSetWindowFields validates stage document and creates a new $setWindowFields stage.
+func newSetWindowFields(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	fields := must.NotFail(stage.Get("$setWindowFields"))
+
+	spec, ok := fields.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf(
+				"the $setWindowFields stage specification must be an object, found %s",
+				handlerparams.AliasFromType(fields),
+			),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	for _, k := range spec.Keys() {
+		switch k {
+		case "partitionBy", "sortBy", "output":
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$setWindowFields.%s' is an unknown field.", k),
+				"$setWindowFields (stage)",
+			)
+		}
+	}
+
+	s := &setWindowFields{
+		vars: params.Variables,
+	}
+
+	if v, err := spec.Get("partitionBy"); err == nil {
+		exprValue := must.NotFail(types.NewDocument("$expr", must.NotFail(types.NewDocument("key", v))))
+
+		if s.partitionBy, err = operators.NewExpr(exprValue, "$setWindowFields (stage)", params.Variables); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, err := spec.Get("sortBy"); err == nil {
+		if s.sortBy, ok = v.(*types.Document); !ok {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrTypeMismatch,
+				fmt.Sprintf(
+					"BSON field '$setWindowFields.sortBy' is the wrong type '%s', expected type 'object'",
+					handlerparams.AliasFromType(v),
+				),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		if err = validateWindowSortBy(s.sortBy); err != nil {
+			return nil, err
+		}
+
+		if s.sortBy.Len() == 0 {
+			s.sortBy = nil
+		}
+	}
+
+	v, err := spec.Get("output")
+	if err != nil {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrMissingField,
+			"BSON field '$setWindowFields.output' is missing but a required field",
+			"$setWindowFields (stage)",
+		)
+	}
+
+	output, ok := v.(*types.Document)
+	if !ok {
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrTypeMismatch,
+			fmt.Sprintf(
+				"BSON field '$setWindowFields.output' is the wrong type '%s', expected type 'object'",
+				handlerparams.AliasFromType(v),
+			),
+			"$setWindowFields (stage)",
+		)
+	}
+
+	for _, key := range output.Keys() {
+		if strings.HasPrefix(key, "$") {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFieldPathInvalidName,
+				fmt.Sprintf("FieldPath field names may not start with '$'. Consider using $getField or $setField. (%s)", key),
+				"$setWindowFields (stage)",
+			)
+		}
+
+		path, err := types.NewPathFromString(key)
+		if err != nil {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrPathContainsEmptyElement,
+				"FieldPath field names may not be empty strings.",
+				"$setWindowFields (stage)",
+			)
+		}
+
+		op, err := windows.NewOperator(key, must.NotFail(output.Get(key)), s.sortBy, params.Variables)
+		if err != nil {
+			return nil, err
+		}
+
+		s.output = append(s.output, windowOutput{
+			path: path,
+			op:   op,
+		})
+	}
+
+	return s, nil
+}
+
+// validateWindowSortBy validates sortBy field of $setWindowFields stage.
+func validateWindowSortBy(sortBy *types.Document) error {
+	for _, key := range sortBy.Keys() {
+		if _, err := common.GetSortType(key, must.NotFail(sortBy.Get(key))); err != nil {
+			return err
+		}
+
+		if strings.HasPrefix(key, "$") {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFieldPathInvalidName,
+				"FieldPath field names may not start with '$'. Consider using $getField or $setField.",
+				"$setWindowFields (stage)",
+			)
+		}
+
+		if _, err := types.NewPathFromString(key); err != nil {
+			return handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrPathContainsEmptyElement,
+				"FieldPath field names may not be empty strings.",
+				"$setWindowFields (stage)",
+			)
+		}
+	}
+
+	return nil
+}
+
+// Process implements Stage interface.
+//
+// It fully consumes the input iterator, splits documents into partitions,
+// and returns documents of partitions sorted by partition key and sortBy fields.
+func (s *setWindowFields) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	docs, err := iterator.ConsumeValues(iter)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	partitions, err := s.partition(docs)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*types.Document, 0, len(docs))
+
+	for _, p := range partitions {
+		if s.sortBy != nil {
+			if err = common.SortDocuments(p.docs, s.sortBy); err != nil {
+				return nil, lazyerrors.Error(err)
+			}
+		}
+
+		values := make([][]any, len(s.output))
+
+		for i, out := range s.output {
+			if values[i], err = out.op.Process(p.docs, s.vars); err != nil {
+				return nil, err
+			}
+		}
+
+		for i, out := range s.output {
+			for j, doc := range p.docs {
+				setByPath(doc, out.path, values[i][j])
+			}
+		}
+
+		res = append(res, p.docs...)
+	}
+
+	iter = iterator.Values(iterator.ForSlice(res))
+	closer.Add(iter)
+
+	return iter, nil
+}
+
+// windowPartition represents documents with the same partition key.
+type windowPartition struct {
+	key  any
+	docs []*types.Document
+}
+
+// partition splits documents into partitions sorted by partition key.
+// If partitionBy is not set, all documents belong to a single partition.
+func (s *setWindowFields) partition(docs []*types.Document) ([]*windowPartition, error) {
+	if s.partitionBy == nil {
+		return []*windowPartition{{docs: docs}}, nil
+	}
+
+	var partitions []*windowPartition
+
+	for _, doc := range docs {
+		v, err := s.partitionBy.Process(doc, s.vars)
+		if err != nil {
+			return nil, err
+		}
+
+		evaluated, ok := v.(*types.Document)
+		if !ok {
+			return nil, lazyerrors.Errorf("unexpected type %T", v)
+		}
+
+		// missing partition key is the same as null
+		key, err := evaluated.Get("key")
+		if err != nil {
+			key = types.Null
+		}
+
+		i := slices.IndexFunc(partitions, func(p *windowPartition) bool {
+			return types.CompareForAggregation(p.key, key) == types.Equal
+		})
+
+		if i < 0 {
+			partitions = append(partitions, &windowPartition{key: key})
+			i = len(partitions) - 1
+		}
+
+		partitions[i].docs = append(partitions[i].docs, doc)
+	}
+
+	slices.SortStableFunc(partitions, func(a, b *windowPartition) int {
+		return int(types.CompareForAggregation(a.key, b.key))
+	})
+
+	return partitions, nil
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*setWindowFields)(nil)
+)
@@ -17,6 +17,7 @@ package stages
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/FerretDB/FerretDB/internal/handler/common"
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
@@ -29,10 +30,12 @@ import (
 // sort represents $sort stage.
 type sort struct {
 	fields *types.Document
+	params *common.BlockingParams
+	limit  int64 // set by SetSortLimits, 0 means no limit
 }
 
 // newSort creates a new $sort stage.
-func newSort(stage *types.Document) (aggregations.Stage, error) {
+func newSort(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	fields, err := common.GetRequiredParam[*types.Document](stage, "$sort")
 	if err != nil {
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
@@ -54,6 +57,7 @@ func newSort(stage *types.Document) (aggregations.Stage, error) {
 
 	return &sort{
 		fields: fields,
+		params: params.blockingParams(),
 	}, nil
 }
 
@@ -61,8 +65,20 @@ func newSort(stage *types.Document) (aggregations.Stage, error) {
 //
 // If sort path is invalid, it returns a possibly wrapped types.PathError.
 func (s *sort) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
-	iter, err := common.SortIterator(iter, closer, s.fields)
+	iter, err := common.SortIterator(iter, closer, s.fields, s.limit, s.params)
 	if err != nil {
+		if errors.Is(err, common.ErrMemoryLimitExceeded) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrQueryExceededMemoryLimitNoDiskUseAllowed,
+				fmt.Sprintf(
+					"Sort exceeded memory limit of %d bytes, but did not opt in to external sorting. "+
+						"Aborting operation. Pass allowDiskUse:true to opt in.",
+					s.params.MaxMemoryBytes,
+				),
+				"$sort (stage)",
+			)
+		}
+
 		// TODO https://github.com/FerretDB/FerretDB/issues/3125
 		var pathErr *types.PathError
 		if errors.As(err, &pathErr) && pathErr.Code() == types.ErrPathElementEmpty {
@@ -79,6 +95,21 @@ func (s *sort) Process(ctx context.Context, iter types.DocumentsIterator, closer
 	return iter, nil
 }
 
+// SetSortLimits passes limits of $limit stages to $sort stages directly preceding them,
+// so only the limited number of documents is kept in memory while sorting.
+func SetSortLimits(stages []aggregations.Stage) {
+	for i := 0; i < len(stages)-1; i++ {
+		s, ok := stages[i].(*sort)
+		if !ok {
+			continue
+		}
+
+		if l, ok := stages[i+1].(*limit); ok {
+			s.limit = l.limit
+		}
+	}
+}
+
 // check interfaces
 var (
 	_ aggregations.Stage = (*sort)(nil)
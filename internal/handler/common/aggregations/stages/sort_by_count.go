@@ -0,0 +1,104 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// sortByCount represents $sortByCount stage.
+//
+//	{ $sortByCount: <expression> }
+//
+// It is the same as the following stages:
+//
+//	{ $group: { _id: <expression>, count: { $sum: 1 } } },
+//	{ $sort: { count: -1 } }
+type sortByCount struct {
+	group aggregations.Stage
+	sort  aggregations.Stage
+}
+
+// newSortByCount validates stage document and creates a new $sortByCount stage.
+func newSortByCount(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	expr := must.NotFail(stage.Get("$sortByCount"))
+
+	switch expr := expr.(type) {
+	case *types.Document:
+		if expr.Len() == 0 || !strings.HasPrefix(expr.Keys()[0], "$") {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageSortByCountInvalidObject,
+				"the sortByCount field must be defined as a $-prefixed path or an expression inside an object",
+				"$sortByCount (stage)",
+			)
+		}
+	case string:
+		if !strings.HasPrefix(expr, "$") {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageSortByCountInvalidPath,
+				"the sortByCount field must be defined as a $-prefixed path or an expression inside an object",
+				"$sortByCount (stage)",
+			)
+		}
+	default:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrStageSortByCountInvalidType,
+			"the sortByCount field must be specified as a string or as an object",
+			"$sortByCount (stage)",
+		)
+	}
+
+	group, err := newGroup(must.NotFail(types.NewDocument("$group", must.NotFail(types.NewDocument(
+		"_id", expr,
+		"count", must.NotFail(types.NewDocument("$sum", int32(1))),
+	)))), params)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := newSort(must.NotFail(types.NewDocument("$sort", must.NotFail(types.NewDocument(
+		"count", int32(-1),
+	)))), params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sortByCount{
+		group: group,
+		sort:  sort,
+	}, nil
+}
+
+// Process implements Stage interface.
+func (s *sortByCount) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	iter, err := s.group.Process(ctx, iter, closer)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sort.Process(ctx, iter, closer)
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*sortByCount)(nil)
+)
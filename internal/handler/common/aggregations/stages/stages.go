@@ -18,67 +18,142 @@ package stages
 import (
 	"fmt"
 
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common"
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
 )
 
 // newStageFunc is a type for a function that creates a new aggregation stage.
-type newStageFunc func(stage *types.Document) (aggregations.Stage, error)
+type newStageFunc func(stage *types.Document, params *NewStageParams) (aggregations.Stage, error)
+
+// NewStageParams contains parameters shared by all stages of the pipeline.
+type NewStageParams struct {
+	// Backend is used by stages writing to collections of any database, such as $out.
+	Backend backends.Backend
+
+	// DB is the database the pipeline is running against.
+	// It is used by stages reading other collections, such as $lookup.
+	DB backends.Database
+
+	// DBName is the name of the database the pipeline is running against.
+	DBName string
+
+	// CollectionName is the name of the collection the pipeline is running against.
+	// It is empty for collection-agnostic pipelines.
+	CollectionName string
+
+	// Variables contains variables available for expressions of the stage.
+	Variables aggregations.Variables
+
+	// MaxBsonObjectSizeBytes is the maximum size of a document created by a stage, such as $facet.
+	MaxBsonObjectSizeBytes int
+
+	// GraphLookupMaxMemoryBytes is the maximum size of documents $graphLookup keeps in memory for one input document.
+	GraphLookupMaxMemoryBytes int
+
+	// BlockingMaxMemoryBytes is the maximum size of documents blocking stages, such as $sort and $group, keep in memory.
+	BlockingMaxMemoryBytes int
+
+	// AllowDiskUse allows blocking stages to write documents exceeding BlockingMaxMemoryBytes to temporary files.
+	AllowDiskUse bool
+
+	// SpillDir is the directory for temporary files of blocking stages.
+	SpillDir string
+
+	// Operations contains in-flight commands listed by $currentOp.
+	Operations *diagnostics.Operations
+
+	// Sessions contains logical sessions listed by $listLocalSessions.
+	Sessions *diagnostics.Sessions
+
+	// IndexUsage contains index usage counters reported by $indexStats.
+	IndexUsage *diagnostics.IndexUsage
+
+	// AllUsersAllowed is true if the current user may use allUsers option of $currentOp and $listLocalSessions.
+	AllUsersAllowed bool
+}
+
+// withVariables returns a copy of params with the given variables,
+// it is used for creating stages of sub-pipelines.
+func (p *NewStageParams) withVariables(vars aggregations.Variables) *NewStageParams {
+	res := *p
+	res.Variables = vars
+
+	return &res
+}
+
+// blockingParams returns parameters for blocking stages.
+func (p *NewStageParams) blockingParams() *common.BlockingParams {
+	return &common.BlockingParams{
+		MaxMemoryBytes: p.BlockingMaxMemoryBytes,
+		AllowDiskUse:   p.AllowDiskUse,
+		Dir:            p.SpillDir,
+	}
+}
 
 // Stages maps all supported aggregation Stages.
-var Stages = map[string]newStageFunc{
-	// sorted alphabetically
-	"$addFields": newAddFields,
-	"$collStats": newCollStats,
-	"$count":     newCount,
-	"$group":     newGroup,
-	"$limit":     newLimit,
-	"$match":     newMatch,
-	"$project":   newProject,
-	"$set":       newSet,
-	"$skip":      newSkip,
-	"$sort":      newSort,
-	"$unset":     newUnset,
-	"$unwind":    newUnwind,
-	// please keep sorted alphabetically
+//
+// It is initialized in init function to avoid initialization cycle
+// for stages containing sub-pipelines, such as $lookup.
+var Stages map[string]newStageFunc
+
+func init() {
+	Stages = map[string]newStageFunc{
+		// sorted alphabetically
+		"$addFields":         newAddFields,
+		"$bucket":            newBucket,
+		"$bucketAuto":        newBucketAuto,
+		"$collStats":         newCollStats,
+		"$count":             newCount,
+		"$currentOp":         newCurrentOp,
+		"$densify":           newDensify,
+		"$documents":         newDocuments,
+		"$facet":             newFacet,
+		"$fill":              newFill,
+		"$graphLookup":       newGraphLookup,
+		"$group":             newGroup,
+		"$indexStats":        newIndexStats,
+		"$limit":             newLimit,
+		"$listLocalSessions": newListLocalSessions,
+		"$lookup":            newLookup,
+		"$match":             newMatch,
+		"$merge":             newMerge,
+		"$out":               newOut,
+		"$project":           newProject,
+		"$redact":            newRedact,
+		"$replaceRoot":       newReplaceRoot,
+		"$replaceWith":       newReplaceWith,
+		"$sample":            newSample,
+		"$set":               newSet,
+		"$setWindowFields":   newSetWindowFields,
+		"$skip":              newSkip,
+		"$sort":              newSort,
+		"$sortByCount":       newSortByCount,
+		"$unionWith":         newUnionWith,
+		"$unset":             newUnset,
+		"$unwind":            newUnwind,
+		// please keep sorted alphabetically
+	}
 }
 
 // unsupportedStages maps all unsupported yet stages.
 var unsupportedStages = map[string]struct{}{
 	// sorted alphabetically
-	"$bucket":                 {},
-	"$bucketAuto":             {},
 	"$changeStream":           {},
-	"$currentOp":              {},
-	"$densify":                {},
-	"$documents":              {},
-	"$facet":                  {},
-	"$fill":                   {},
 	"$geoNear":                {},
-	"$graphLookup":            {},
-	"$indexStats":             {},
-	"$listLocalSessions":      {},
 	"$listSessions":           {},
-	"$lookup":                 {},
-	"$merge":                  {},
-	"$out":                    {},
 	"$planCacheStats":         {},
-	"$redact":                 {},
-	"$replaceRoot":            {},
-	"$replaceWith":            {},
-	"$sample":                 {},
 	"$search":                 {},
 	"$searchMeta":             {},
-	"$setWindowFields":        {},
 	"$sharedDataDistribution": {},
-	"$sortByCount":            {},
-	"$unionWith":              {},
 	// please keep sorted alphabetically
 }
 
 // NewStage creates a new aggregation stage.
-func NewStage(stage *types.Document) (aggregations.Stage, error) {
+func NewStage(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	if stage.Len() != 1 {
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
 			handlererrors.ErrStageInvalid,
@@ -97,7 +172,7 @@ func NewStage(stage *types.Document) (aggregations.Stage, error) {
 		panic(fmt.Sprintf("stage %q is in both `stages` and `unsupportedStages`", name))
 
 	case supported && !unsupported:
-		return f(stage)
+		return f(stage, params)
 
 	case !supported && unsupported:
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
@@ -0,0 +1,242 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// unionWithNotAllowedStages contains stages that cannot be used within $unionWith sub-pipeline.
+var unionWithNotAllowedStages = map[string]struct{}{
+	// sorted alphabetically
+	"$currentOp":         {},
+	"$indexStats":        {},
+	"$listLocalSessions": {},
+	"$merge":             {},
+	"$out":               {},
+	// please keep sorted alphabetically
+}
+
+// unionWith represents $unionWith stage.
+//
+//	{ $unionWith: <collection> }
+//	{ $unionWith: { coll: <collection>, pipeline: [ <stage1>, ... ] } }
+type unionWith struct {
+	db     backends.Database
+	coll   string               // empty if pipeline starts with $documents
+	stages []aggregations.Stage // empty if pipeline is not set
+}
+
+// newUnionWith validates stage document and creates a new $unionWith stage.
+func newUnionWith(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
+	u := &unionWith{
+		db: params.DB,
+	}
+
+	var spec *types.Document
+
+	switch v := must.NotFail(stage.Get("$unionWith")).(type) {
+	case string:
+		u.coll = v
+		return u, nil
+	case *types.Document:
+		spec = v
+	default:
+		return nil, handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrFailedToParse,
+			fmt.Sprintf(
+				"the $unionWith stage specification must be an object or string, but found %s",
+				handlerparams.AliasFromType(v),
+			),
+			"$unionWith (stage)",
+		)
+	}
+
+	var hasColl bool
+	var pipeline *types.Array
+
+	iter := spec.Iterator()
+	defer iter.Close()
+
+	for {
+		k, v, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err != nil {
+			return nil, lazyerrors.Error(err)
+		}
+
+		switch k {
+		case "coll":
+			var ok bool
+			if u.coll, ok = v.(string); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrTypeMismatch,
+					fmt.Sprintf(
+						"BSON field '$unionWith.coll' is the wrong type '%s', expected type 'string'",
+						handlerparams.AliasFromType(v),
+					),
+					"$unionWith (stage)",
+				)
+			}
+
+			hasColl = true
+		case "pipeline":
+			var ok bool
+			if pipeline, ok = v.(*types.Array); !ok {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrTypeMismatch,
+					fmt.Sprintf(
+						"BSON field '$unionWith.pipeline' is the wrong type '%s', expected type 'array'",
+						handlerparams.AliasFromType(v),
+					),
+					"$unionWith (stage)",
+				)
+			}
+		default:
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParseInput,
+				fmt.Sprintf("BSON field '$unionWith.%s' is an unknown field.", k),
+				"$unionWith (stage)",
+			)
+		}
+	}
+
+	if !hasColl {
+		if pipeline == nil {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrMissingField,
+				"BSON field '$unionWith.coll' is missing but a required field",
+				"$unionWith (stage)",
+			)
+		}
+
+		if !hasDocumentsFirst(pipeline) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				"$unionWith stage without explicit collection must have a pipeline with $documents as first stage",
+				"$unionWith (stage)",
+			)
+		}
+	}
+
+	if pipeline == nil {
+		return u, nil
+	}
+
+	for i := 0; i < pipeline.Len(); i++ {
+		d, ok := must.NotFail(pipeline.Get(i)).(*types.Document)
+		if !ok || d.Len() != 1 {
+			continue
+		}
+
+		if _, notAllowed := unionWithNotAllowedStages[d.Command()]; notAllowed {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrStageUnionWithNotAllowedStage,
+				fmt.Sprintf("%s is not allowed within a $unionWith's sub-pipeline", d.Command()),
+				"$unionWith (stage)",
+			)
+		}
+	}
+
+	var err error
+	if u.stages, err = newPipeline(pipeline, "$unionWith", params); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// Process implements Stage interface.
+//
+// It returns input documents followed by documents of the collection processed by the sub-pipeline.
+// The collection is queried only after all input documents are returned.
+func (u *unionWith) Process(ctx context.Context, iter types.DocumentsIterator, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	var c backends.Collection
+
+	// without collection, documents are produced by $documents first stage of the sub-pipeline
+	if u.coll != "" {
+		var err error
+
+		if c, err = u.db.Collection(u.coll); err != nil {
+			if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrInvalidNamespace,
+					fmt.Sprintf("Invalid collection name: %s", u.coll),
+					"$unionWith (stage)",
+				)
+			}
+
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	var unionIter types.DocumentsIterator
+
+	res := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		if unionIter == nil {
+			_, doc, err := iter.Next()
+			if !errors.Is(err, iterator.ErrIteratorDone) {
+				return struct{}{}, doc, err
+			}
+
+			if unionIter, err = u.query(ctx, c, closer); err != nil {
+				return struct{}{}, nil, err
+			}
+		}
+
+		return unionIter.Next()
+	})
+	closer.Add(res)
+
+	return res, nil
+}
+
+// query returns an iterator of the collection documents processed by the sub-pipeline.
+// If the collection is nil, the sub-pipeline produces documents with $documents stage.
+//
+// Returned iterator and all iterators created by stages are added to the given closer.
+func (u *unionWith) query(ctx context.Context, c backends.Collection, closer *iterator.MultiCloser) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	if c == nil {
+		return processPipeline(ctx, u.stages, emptyIterator(closer), closer)
+	}
+
+	queryRes, err := c.Query(ctx, nil)
+	if err != nil {
+		return nil, lazyerrors.Error(err)
+	}
+
+	closer.Add(queryRes.Iter)
+
+	return processPipeline(ctx, u.stages, queryRes.Iter, closer)
+}
+
+// check interfaces
+var (
+	_ aggregations.Stage = (*unionWith)(nil)
+)
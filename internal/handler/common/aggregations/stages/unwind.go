@@ -35,7 +35,7 @@ type unwind struct {
 }
 
 // newUnwind creates a new $unwind stage.
-func newUnwind(stage *types.Document) (aggregations.Stage, error) {
+func newUnwind(stage *types.Document, params *NewStageParams) (aggregations.Stage, error) {
 	field, err := stage.Get("$unwind")
 	if err != nil {
 		return nil, err
@@ -91,6 +91,14 @@ func newUnwind(stage *types.Document) (aggregations.Stage, error) {
 				return nil, lazyerrors.Error(err)
 			}
 		}
+
+		if expr.IsVariable() {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFieldPathInvalidName,
+				"Expression field names may not start with '$'. Consider using $getField or $setField",
+				"$unwind (stage)",
+			)
+		}
 	default:
 		return nil, handlererrors.NewCommandErrorMsgWithArgument(
 			handlererrors.ErrStageUnwindWrongType,
@@ -124,7 +132,7 @@ func (u *unwind) Process(ctx context.Context, iter types.DocumentsIterator, clos
 	key := u.field.GetExpressionSuffix()
 
 	for _, doc := range docs {
-		d, err := u.field.Evaluate(doc)
+		d, err := u.field.Evaluate(doc, nil)
 		if err != nil {
 			// Ignore non-existent values
 			continue
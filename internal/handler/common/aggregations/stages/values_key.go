@@ -0,0 +1,133 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stages
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// joinBatchSize is the number of input documents read at once by stages that join documents,
+// so matching documents are queried once per batch instead of once per input document.
+const joinBatchSize = 100
+
+// valuesKey returns a string key for the given values, it is used by stages
+// that join documents to index them in a map instead of scanning a collection for each input document.
+//
+// Keys of two values are equal if and only if values are equal according to types.CompareForAggregation,
+// so numbers of different types with the same value have the same key.
+func valuesKey(values ...any) string {
+	var sb strings.Builder
+
+	for _, v := range values {
+		writeValueKey(&sb, v)
+		sb.WriteByte(';')
+	}
+
+	return sb.String()
+}
+
+// writeValueKey writes the key of a single value to sb.
+func writeValueKey(sb *strings.Builder, v any) {
+	switch v := v.(type) {
+	case *types.Document:
+		sb.WriteByte('{')
+
+		for _, k := range v.Keys() {
+			sb.WriteString(strconv.Quote(k))
+			sb.WriteByte(':')
+			writeValueKey(sb, must.NotFail(v.Get(k)))
+			sb.WriteByte(',')
+		}
+
+		sb.WriteByte('}')
+
+	case *types.Array:
+		sb.WriteByte('[')
+
+		for i := 0; i < v.Len(); i++ {
+			writeValueKey(sb, must.NotFail(v.Get(i)))
+			sb.WriteByte(',')
+		}
+
+		sb.WriteByte(']')
+
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			writeIntKey(sb, int64(v))
+			return
+		}
+
+		sb.WriteString("f:")
+		sb.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+
+	case int32:
+		writeIntKey(sb, int64(v))
+
+	case int64:
+		writeIntKey(sb, v)
+
+	case string:
+		sb.WriteString("s:")
+		sb.WriteString(strconv.Quote(v))
+
+	case types.Binary:
+		fmt.Fprintf(sb, "bin:%d:%s", v.Subtype, hex.EncodeToString(v.B))
+
+	case types.ObjectID:
+		sb.WriteString("oid:")
+		sb.WriteString(hex.EncodeToString(v[:]))
+
+	case bool:
+		sb.WriteString("b:")
+		sb.WriteString(strconv.FormatBool(v))
+
+	case time.Time:
+		sb.WriteString("t:")
+		sb.WriteString(strconv.FormatInt(v.UnixMilli(), 10))
+
+	case types.NullType:
+		sb.WriteString("null")
+
+	case types.Regex:
+		sb.WriteString("re:")
+
+		if re, err := v.Compile(); err == nil {
+			sb.WriteString(strconv.Quote(re.String()))
+		} else {
+			sb.WriteString(strconv.Quote("/" + v.Pattern + "/" + v.Options))
+		}
+
+	case types.Timestamp:
+		sb.WriteString("ts:")
+		sb.WriteString(strconv.FormatUint(uint64(v), 10))
+
+	default:
+		panic(fmt.Sprintf("unexpected type %[1]T (%#[1]v)", v))
+	}
+}
+
+// writeIntKey writes the key of an integer number to sb.
+func writeIntKey(sb *strings.Builder, v int64) {
+	sb.WriteString("n:")
+	sb.WriteString(strconv.FormatInt(v, 10))
+}
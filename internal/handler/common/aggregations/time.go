@@ -0,0 +1,59 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregations
+
+import "time"
+
+// TimeUnits contains durations of time units with fixed duration.
+var TimeUnits = map[string]time.Duration{
+	"week":        7 * 24 * time.Hour,
+	"day":         24 * time.Hour,
+	"hour":        time.Hour,
+	"minute":      time.Minute,
+	"second":      time.Second,
+	"millisecond": time.Millisecond,
+}
+
+// CalendarUnits contains time units without fixed duration and their length in months.
+var CalendarUnits = map[string]int{
+	"year":    12,
+	"quarter": 3,
+	"month":   1,
+}
+
+// IsTimeUnit returns true if the given string is a known time unit.
+func IsTimeUnit(unit string) bool {
+	_, isUnit := TimeUnits[unit]
+	_, isCalendarUnit := CalendarUnits[unit]
+
+	return isUnit || isCalendarUnit
+}
+
+// AddTime returns the time shifted by the given number of time units.
+// For calendar units, the day of month is clamped to the last day of the resulting month,
+// so one month after January 31 is the last day of February.
+func AddTime(t time.Time, n float64, unit string) time.Time {
+	if months, ok := CalendarUnits[unit]; ok {
+		first := time.Date(t.Year(), t.Month(), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+		first = first.AddDate(0, int(n)*months, 0)
+
+		// day 0 of the next month is the last day of this month
+		last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+
+		return first.AddDate(0, 0, min(t.Day(), last)-1)
+	}
+
+	return t.Add(time.Duration(n * float64(TimeUnits[unit])))
+}
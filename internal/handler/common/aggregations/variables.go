@@ -0,0 +1,76 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aggregations
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode"
+)
+
+// Variables maps aggregation expression variable names to their values.
+//
+// Names are stored without `$$` prefix, so expression `$$foo` accesses the value stored under `foo` key.
+// Nil Variables is valid and contains no variables.
+type Variables map[string]any
+
+// NewVariables returns Variables containing system variables
+// that have the same value for all documents processed by the command, such as `$$NOW`.
+//
+// Other system variables, such as `$$ROOT`, are evaluated by Expression.
+func NewVariables() Variables {
+	return Variables{
+		"NOW": time.Now().UTC().Truncate(time.Millisecond),
+	}
+}
+
+// Clone returns a shallow copy of Variables, so new variables could be added
+// without affecting the original scope.
+func (v Variables) Clone() Variables {
+	res := make(Variables, len(v))
+
+	for name, value := range v {
+		res[name] = value
+	}
+
+	return res
+}
+
+// ValidateUserVariableName returns an error if the given name cannot be used as a user variable name.
+//
+// User variable names must start with a lowercase ASCII letter or a non-ASCII character,
+// and contain only ASCII letters, digits, underscores, and non-ASCII characters.
+// Returned error message is compatible with MongoDB.
+func ValidateUserVariableName(name string) error {
+	if name == "" {
+		return errors.New("empty variable names are not allowed")
+	}
+
+	for i, r := range name {
+		switch {
+		case r > unicode.MaxASCII, r >= 'a' && r <= 'z':
+			continue
+		case i == 0:
+			return fmt.Errorf("'%s' starts with an invalid character for a user variable name", name)
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			continue
+		default:
+			return fmt.Errorf("'%s' contains an invalid character for a variable name: '%c'", name, r)
+		}
+	}
+
+	return nil
+}
@@ -17,6 +17,7 @@ package common
 import (
 	"log/slog"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
 	"github.com/FerretDB/FerretDB/internal/types"
 )
@@ -32,7 +33,8 @@ type DeleteParams struct {
 	Comment string   `ferretdb:"comment,opt"`
 	Ordered bool     `ferretdb:"ordered,opt"`
 
-	Let *types.Document `ferretdb:"let,unimplemented"`
+	Let       *types.Document        `ferretdb:"let,opt"`
+	Variables aggregations.Variables `ferretdb:"-"`
 
 	MaxTimeMS      int64           `ferretdb:"maxTimeMS,ignored"`
 	WriteConcern   *types.Document `ferretdb:"writeConcern,ignored"`
@@ -65,5 +67,9 @@ func GetDeleteParams(document *types.Document, l *slog.Logger) (*DeleteParams, e
 		return nil, err
 	}
 
+	if params.Variables, err = CommandVariables(params.Let, "delete"); err != nil {
+		return nil, err
+	}
+
 	return &params, nil
 }
@@ -0,0 +1,436 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// ErrMemoryLimitExceeded is returned by ExternalSorter when documents do not fit into the memory limit,
+// and writing them to disk is not allowed.
+var ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+
+// BlockingParams contains parameters of blocking operations that buffer all documents, such as sorting.
+type BlockingParams struct {
+	// MaxMemoryBytes is the maximum size of documents kept in memory, 0 means no limit.
+	MaxMemoryBytes int
+
+	// AllowDiskUse allows writing documents that do not fit into MaxMemoryBytes to temporary files.
+	AllowDiskUse bool
+
+	// Dir is the directory for temporary files.
+	// If empty, the default directory for temporary files is used.
+	Dir string
+}
+
+// ExternalSorter sorts documents that might not fit into memory.
+//
+// Added documents are kept in memory until their total size exceeds the memory limit.
+// Then they are sorted and written to a temporary file as a sorted run.
+// All runs are merged when documents are read.
+//
+// If only the first documents are needed (for example, for sorting followed by a limit),
+// at most that number of documents is kept in memory and written to each run.
+type ExternalSorter struct {
+	params *BlockingParams
+	less   func(a, b *types.Document) bool
+	limit  int
+	docs   []*types.Document
+	size   int
+	runs   []*os.File
+}
+
+// NewExternalSorter creates a new sorter that orders documents with the given less function.
+//
+// If limit is not 0, only that number of the first sorted documents is returned.
+// If params is nil, documents are always kept in memory.
+// Close must be called to remove temporary files, unless iterator returned by Iterator is closed.
+func NewExternalSorter(less func(a, b *types.Document) bool, limit int, params *BlockingParams) *ExternalSorter {
+	if params == nil {
+		params = new(BlockingParams)
+	}
+
+	return &ExternalSorter{
+		params: params,
+		less:   less,
+		limit:  limit,
+	}
+}
+
+// Add adds a document to the sorter.
+//
+// It returns ErrMemoryLimitExceeded if the memory limit is exceeded and disk use is not allowed.
+func (s *ExternalSorter) Add(doc *types.Document) error {
+	s.docs = append(s.docs, doc)
+
+	if s.params.MaxMemoryBytes != 0 {
+		s.size += EstimateDocumentSize(doc)
+	}
+
+	// keep at most 2*limit documents, so sorting is amortized
+	if s.limit > 0 && len(s.docs)-s.limit >= s.limit {
+		s.truncate()
+	}
+
+	if s.params.MaxMemoryBytes == 0 || s.size <= s.params.MaxMemoryBytes {
+		return nil
+	}
+
+	if !s.params.AllowDiskUse {
+		return ErrMemoryLimitExceeded
+	}
+
+	return s.spill()
+}
+
+// Iterator returns an iterator of all added documents in sorted order.
+// Documents that are equal according to the less function are returned in the order they were added.
+//
+// Temporary files are removed when the returned iterator is closed.
+// The sorter should not be used after this call.
+func (s *ExternalSorter) Iterator() (types.DocumentsIterator, error) {
+	s.truncate()
+
+	if len(s.runs) == 0 {
+		return iterator.Values(iterator.ForSlice(s.docs)), nil
+	}
+
+	h := &runsHeap{less: s.less}
+
+	for i, f := range s.runs {
+		h.runs = append(h.runs, &sortedRun{r: bufio.NewReader(f), index: i})
+	}
+
+	// documents left in memory form the last run
+	h.runs = append(h.runs, &sortedRun{docs: s.docs, index: len(s.runs)})
+	s.docs = nil
+
+	runs := h.runs
+	h.runs = h.runs[:0]
+
+	for _, run := range runs {
+		ok, err := run.next()
+		if err != nil {
+			s.Close()
+			return nil, lazyerrors.Error(err)
+		}
+
+		if ok {
+			h.runs = append(h.runs, run)
+		}
+	}
+
+	heap.Init(h)
+
+	var n int
+
+	iter := iterator.ForFunc(func() (struct{}, *types.Document, error) {
+		if h.Len() == 0 || (s.limit > 0 && n >= s.limit) {
+			return struct{}{}, nil, iterator.ErrIteratorDone
+		}
+
+		n++
+
+		run := h.runs[0]
+		doc := run.head
+
+		ok, err := run.next()
+		if err != nil {
+			return struct{}{}, nil, lazyerrors.Error(err)
+		}
+
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+
+		return struct{}{}, doc, nil
+	})
+
+	return iterator.WithClose(iter, func() {
+		iter.Close()
+		s.Close()
+	}), nil
+}
+
+// Close closes and removes temporary files.
+func (s *ExternalSorter) Close() {
+	for _, f := range s.runs {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+
+	s.runs = nil
+}
+
+// sortDocs sorts documents kept in memory.
+func (s *ExternalSorter) sortDocs() {
+	sort.SliceStable(s.docs, func(i, j int) bool {
+		return s.less(s.docs[i], s.docs[j])
+	})
+}
+
+// truncate sorts documents kept in memory and keeps only the first limit documents, if limit is set.
+func (s *ExternalSorter) truncate() {
+	s.sortDocs()
+
+	if s.limit == 0 || len(s.docs) <= s.limit {
+		return
+	}
+
+	clear(s.docs[s.limit:])
+	s.docs = s.docs[:s.limit]
+
+	if s.params.MaxMemoryBytes == 0 {
+		return
+	}
+
+	s.size = 0
+	for _, doc := range s.docs {
+		s.size += EstimateDocumentSize(doc)
+	}
+}
+
+// spill writes documents kept in memory to a new temporary file as a sorted run.
+func (s *ExternalSorter) spill() error {
+	if s.params.Dir != "" {
+		if err := os.MkdirAll(s.params.Dir, 0o700); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	s.truncate()
+
+	f, err := os.CreateTemp(s.params.Dir, "ferretdb-sort-*")
+	if err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	// add file first, so Close closes it on error
+	s.runs = append(s.runs, f)
+
+	// the file is removed while it is still open, so it is not left behind if the process crashes;
+	// that fails on Windows, then Close removes it
+	_ = os.Remove(f.Name())
+
+	w := bufio.NewWriter(f)
+
+	for _, doc := range s.docs {
+		bd, err := bson.ConvertDocument(doc)
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		raw, err := bd.Encode()
+		if err != nil {
+			return lazyerrors.Error(err)
+		}
+
+		if _, err = w.Write(raw); err != nil {
+			return lazyerrors.Error(err)
+		}
+	}
+
+	if err = w.Flush(); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return lazyerrors.Error(err)
+	}
+
+	s.docs = nil
+	s.size = 0
+
+	return nil
+}
+
+// EstimateDocumentSize returns the size of BSON encoding of the given document
+// without encoding it or converting it to BSON types.
+//
+// It is used by blocking operations to track memory usage,
+// so unexpected types are counted as zero-sized instead of causing a panic.
+func EstimateDocumentSize(doc *types.Document) int {
+	size := 5
+
+	for _, k := range doc.Keys() {
+		size += 1 + len(k) + 1 + EstimateValueSize(must.NotFail(doc.Get(k)))
+	}
+
+	return size
+}
+
+// EstimateValueSize returns the size of BSON encoding of the given value, see EstimateDocumentSize.
+func EstimateValueSize(v any) int {
+	switch v := v.(type) {
+	case *types.Document:
+		return EstimateDocumentSize(v)
+
+	case *types.Array:
+		size := 5
+
+		for i := 0; i < v.Len(); i++ {
+			size += 1 + len(strconv.Itoa(i)) + 1 + EstimateValueSize(must.NotFail(v.Get(i)))
+		}
+
+		return size
+
+	case float64, int64, time.Time, types.Timestamp:
+		return 8
+
+	case string:
+		return 4 + len(v) + 1
+
+	case types.Binary:
+		return 4 + 1 + len(v.B)
+
+	case types.ObjectID:
+		return 12
+
+	case bool:
+		return 1
+
+	case types.NullType:
+		return 0
+
+	case types.Regex:
+		return len(v.Pattern) + 1 + len(v.Options) + 1
+
+	case int32:
+		return 4
+
+	default:
+		return 0
+	}
+}
+
+// sortedRun represents a sorted run of documents, written to a temporary file or kept in memory.
+type sortedRun struct {
+	r     *bufio.Reader // nil for documents kept in memory
+	docs  []*types.Document
+	head  *types.Document
+	index int
+}
+
+// next sets the next document of the run as its head.
+// It returns false if the run is exhausted.
+func (run *sortedRun) next() (bool, error) {
+	run.head = nil
+
+	if run.r == nil {
+		if len(run.docs) == 0 {
+			return false, nil
+		}
+
+		run.head, run.docs = run.docs[0], run.docs[1:]
+
+		return true, nil
+	}
+
+	var l [4]byte
+
+	if _, err := io.ReadFull(run.r, l[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+
+		return false, lazyerrors.Error(err)
+	}
+
+	b := make([]byte, binary.LittleEndian.Uint32(l[:]))
+	if len(b) < len(l) {
+		return false, lazyerrors.Errorf("invalid document length %d", len(b))
+	}
+
+	copy(b, l[:])
+
+	if _, err := io.ReadFull(run.r, b[len(l):]); err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	doc, err := bson.RawDocument(b).Convert()
+	if err != nil {
+		return false, lazyerrors.Error(err)
+	}
+
+	run.head = doc
+
+	return true, nil
+}
+
+// runsHeap implements heap.Interface for merging sorted runs.
+// Runs with equal heads are ordered by their index to keep the sort stable.
+type runsHeap struct {
+	less func(a, b *types.Document) bool
+	runs []*sortedRun
+}
+
+// Len implements heap.Interface.
+func (h *runsHeap) Len() int {
+	return len(h.runs)
+}
+
+// Less implements heap.Interface.
+func (h *runsHeap) Less(i, j int) bool {
+	a, b := h.runs[i], h.runs[j]
+
+	switch {
+	case h.less(a.head, b.head):
+		return true
+	case h.less(b.head, a.head):
+		return false
+	default:
+		return a.index < b.index
+	}
+}
+
+// Swap implements heap.Interface.
+func (h *runsHeap) Swap(i, j int) {
+	h.runs[i], h.runs[j] = h.runs[j], h.runs[i]
+}
+
+// Push implements heap.Interface.
+func (h *runsHeap) Push(x any) {
+	h.runs = append(h.runs, x.(*sortedRun))
+}
+
+// Pop implements heap.Interface.
+func (h *runsHeap) Pop() any {
+	n := len(h.runs)
+	run := h.runs[n-1]
+	h.runs = h.runs[:n-1]
+
+	return run
+}
+
+// check interfaces
+var (
+	_ heap.Interface = (*runsHeap)(nil)
+)
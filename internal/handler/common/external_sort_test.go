@@ -0,0 +1,106 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/bson"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/iterator"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestEstimateDocumentSize(t *testing.T) {
+	t.Parallel()
+
+	arr := types.MakeArray(12)
+	for i := 0; i < 12; i++ {
+		arr.Append(int32(i))
+	}
+
+	doc := must.NotFail(types.NewDocument(
+		"_id", types.ObjectID{0x62, 0x56, 0xc5, 0xba, 0x0b, 0xad, 0xc0, 0xff, 0xee, 0xff, 0xff, 0xff},
+		"double", 42.13,
+		"string", "foo",
+		"document", must.NotFail(types.NewDocument("foo", "bar", "null", types.Null)),
+		"array", arr,
+		"binary", types.Binary{Subtype: types.BinaryUser, B: []byte{42, 0, 13}},
+		"bool", true,
+		"datetime", time.Date(2021, 11, 1, 10, 18, 42, 123000000, time.UTC),
+		"regex", types.Regex{Pattern: "^foo", Options: "i"},
+		"int32", int32(42),
+		"timestamp", types.Timestamp(42),
+		"int64", int64(42),
+	))
+
+	bd, err := bson.ConvertDocument(doc)
+	require.NoError(t, err)
+
+	assert.Equal(t, bson.Size(bd), EstimateDocumentSize(doc))
+}
+
+func TestExternalSorterLimit(t *testing.T) {
+	t.Parallel()
+
+	less := func(a, b *types.Document) bool {
+		return must.NotFail(a.Get("v")).(int32) < must.NotFail(b.Get("v")).(int32)
+	}
+
+	for name, params := range map[string]*BlockingParams{
+		"Memory": nil,
+		"Disk":   {MaxMemoryBytes: 50, AllowDiskUse: true, Dir: t.TempDir()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sorter := NewExternalSorter(less, 3, params)
+			defer sorter.Close()
+
+			for i := int32(0); i < 20; i++ {
+				require.NoError(t, sorter.Add(must.NotFail(types.NewDocument("_id", i, "v", 3-i%4))))
+			}
+
+			if params != nil && runtime.GOOS != "windows" {
+				// temporary files are removed while they are open
+				entries, err := os.ReadDir(params.Dir)
+				require.NoError(t, err)
+				assert.Empty(t, entries)
+			}
+
+			iter, err := sorter.Iterator()
+			require.NoError(t, err)
+
+			defer iter.Close()
+
+			docs, err := iterator.ConsumeValues(iter)
+			require.NoError(t, err)
+
+			var ids []any
+			for _, doc := range docs {
+				ids = append(ids, must.NotFail(doc.Get("_id")))
+			}
+
+			// equal documents keep the order they were added
+			assert.Equal(t, []any{int32(3), int32(7), int32(11)}, ids)
+		})
+	}
+}
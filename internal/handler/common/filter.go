@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
 	"github.com/FerretDB/FerretDB/internal/handler/commonpath"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
@@ -36,6 +37,20 @@ import (
 //
 // Passed arguments must not be modified.
 func FilterDocument(doc, filter *types.Document) (bool, error) {
+	return filterDocument(doc, filter, nil)
+}
+
+// FilterDocumentWithVariables is like FilterDocument,
+// but also passes variables to $expr operator of the filter.
+//
+// Passed arguments must not be modified.
+func FilterDocumentWithVariables(doc, filter *types.Document, vars aggregations.Variables) (bool, error) {
+	return filterDocument(doc, filter, vars)
+}
+
+// filterDocument returns true if given document satisfies given filter expression.
+// Variables are used by $expr operator.
+func filterDocument(doc, filter *types.Document, vars aggregations.Variables) (bool, error) {
 	iter := filter.Iterator()
 	defer iter.Close()
 
@@ -50,7 +65,7 @@ func FilterDocument(doc, filter *types.Document) (bool, error) {
 		}
 
 		// top-level filters are ANDed together
-		matches, err := filterDocumentPair(doc, filterKey, filterValue)
+		matches, err := filterDocumentPair(doc, filterKey, filterValue, vars)
 		if err != nil {
 			return false, lazyerrors.Error(err)
 		}
@@ -61,7 +76,7 @@ func FilterDocument(doc, filter *types.Document) (bool, error) {
 }
 
 // filterDocumentPair handles a single filter element key/value pair {filterKey: filterValue}.
-func filterDocumentPair(doc *types.Document, filterKey string, filterValue any) (bool, error) {
+func filterDocumentPair(doc *types.Document, filterKey string, filterValue any, vars aggregations.Variables) (bool, error) {
 	var vals []any
 	filterSuffix := filterKey
 
@@ -89,7 +104,7 @@ func filterDocumentPair(doc *types.Document, filterKey string, filterValue any)
 
 	if strings.HasPrefix(filterKey, "$") {
 		// {$operator: filterValue}
-		return filterOperator(doc, filterKey, filterValue)
+		return filterOperator(doc, filterKey, filterValue, vars)
 	}
 
 	switch filterValue := filterValue.(type) {
@@ -150,7 +165,7 @@ func filterDocumentPair(doc *types.Document, filterKey string, filterValue any)
 }
 
 // filterOperator handles a top-level operator filter {$operator: filterValue}.
-func filterOperator(doc *types.Document, operator string, filterValue any) (bool, error) {
+func filterOperator(doc *types.Document, operator string, filterValue any, vars aggregations.Variables) (bool, error) {
 	switch operator {
 	case "$and":
 		// {$and: [{expr1}, {expr2}, ...]}
@@ -185,7 +200,7 @@ func filterOperator(doc *types.Document, operator string, filterValue any) (bool
 		for i := 0; i < exprs.Len(); i++ {
 			expr := must.NotFail(exprs.Get(i)).(*types.Document)
 
-			matches, err := FilterDocument(doc, expr)
+			matches, err := filterDocument(doc, expr, vars)
 			if err != nil {
 				return false, err
 			}
@@ -229,7 +244,7 @@ func filterOperator(doc *types.Document, operator string, filterValue any) (bool
 		for i := 0; i < exprs.Len(); i++ {
 			expr := must.NotFail(exprs.Get(i)).(*types.Document)
 
-			matches, err := FilterDocument(doc, expr)
+			matches, err := filterDocument(doc, expr, vars)
 			if err != nil {
 				return false, err
 			}
@@ -273,7 +288,7 @@ func filterOperator(doc *types.Document, operator string, filterValue any) (bool
 		for i := 0; i < exprs.Len(); i++ {
 			expr := must.NotFail(exprs.Get(i)).(*types.Document)
 
-			matches, err := FilterDocument(doc, expr)
+			matches, err := filterDocument(doc, expr, vars)
 			if err != nil {
 				return false, err
 			}
@@ -288,7 +303,7 @@ func filterOperator(doc *types.Document, operator string, filterValue any) (bool
 		return true, nil
 
 	case "$expr":
-		return filterExprOperator(doc, must.NotFail(types.NewDocument(operator, filterValue)))
+		return filterExprOperator(doc, must.NotFail(types.NewDocument(operator, filterValue)), vars)
 	default:
 		msg := fmt.Sprintf(
 			`unknown top level operator: %s. `+
@@ -306,14 +321,14 @@ func filterOperator(doc *types.Document, operator string, filterValue any) (bool
 // $expr is primary used by operators such as $gt and $cond which return boolean result.
 // However, if non-boolean result is returned from processing aggregation expression,
 // it returns false for null or zero value and true for all other values.
-func filterExprOperator(doc, filter *types.Document) (bool, error) {
+func filterExprOperator(doc, filter *types.Document, vars aggregations.Variables) (bool, error) {
 	// TODO https://github.com/FerretDB/FerretDB/issues/3170
-	op, err := operators.NewExpr(filter, "$expr")
+	op, err := operators.NewExpr(filter, "$expr", vars)
 	if err != nil {
 		return false, err
 	}
 
-	v, err := op.Process(doc)
+	v, err := op.Process(doc, vars)
 	if err != nil {
 		return false, lazyerrors.Error(err)
 	}
@@ -15,6 +15,7 @@
 package common
 
 import (
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
@@ -22,14 +23,16 @@ import (
 
 // FilterIterator returns an iterator that filters out documents that don't match the filter.
 // It will be added to the given closer.
+// Variables are used by $expr operator of the filter, they may be nil.
 //
 // Next method returns the next document that matches the filter.
 //
 // Close method closes the underlying iterator.
-func FilterIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, filter *types.Document) types.DocumentsIterator {
+func FilterIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, filter *types.Document, vars aggregations.Variables) types.DocumentsIterator { //nolint:lll // for readability
 	res := &filterIterator{
 		iter:   iter,
 		filter: filter,
+		vars:   vars,
 	}
 	closer.Add(res)
 
@@ -40,6 +43,7 @@ func FilterIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser,
 type filterIterator struct {
 	iter   types.DocumentsIterator
 	filter *types.Document
+	vars   aggregations.Variables
 }
 
 // Next implements iterator.Interface. See FilterIterator for details.
@@ -52,7 +56,7 @@ func (iter *filterIterator) Next() (struct{}, *types.Document, error) {
 			return unused, nil, lazyerrors.Error(err)
 		}
 
-		matches, err := FilterDocument(doc, iter.filter)
+		matches, err := filterDocument(doc, iter.filter, iter.vars)
 		if err != nil {
 			return unused, nil, lazyerrors.Error(err)
 		}
@@ -17,6 +17,7 @@ package common
 import (
 	"log/slog"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
 	"github.com/FerretDB/FerretDB/internal/types"
@@ -40,11 +41,13 @@ type FindParams struct {
 	ShowRecordId bool            `ferretdb:"showRecordId,opt"`
 	Tailable     bool            `ferretdb:"tailable,opt"`
 	AwaitData    bool            `ferretdb:"awaitData,opt"`
+	AllowDiskUse bool            `ferretdb:"allowDiskUse,opt"`
+
+	Let       *types.Document        `ferretdb:"let,opt"`
+	Variables aggregations.Variables `ferretdb:"-"`
 
 	Collation *types.Document `ferretdb:"collation,unimplemented"`
-	Let       *types.Document `ferretdb:"let,unimplemented"`
 
-	AllowDiskUse     bool            `ferretdb:"allowDiskUse,ignored"`
 	ReadConcern      *types.Document `ferretdb:"readConcern,ignored"`
 	Max              *types.Document `ferretdb:"max,ignored"`
 	Min              *types.Document `ferretdb:"min,ignored"`
@@ -82,5 +85,10 @@ func GetFindParams(doc *types.Document, l *slog.Logger) (*FindParams, error) {
 		)
 	}
 
+	var err error
+	if params.Variables, err = CommandVariables(params.Let, "find"); err != nil {
+		return nil, err
+	}
+
 	return &params, nil
 }
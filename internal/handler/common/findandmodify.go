@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"log/slog"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
 	"github.com/FerretDB/FerretDB/internal/types"
@@ -43,7 +44,9 @@ type FindAndModifyParams struct {
 
 	HasUpdateOperators bool `ferretdb:"-"`
 
-	Let          *types.Document `ferretdb:"let,unimplemented"`
+	Let       *types.Document        `ferretdb:"let,opt"`
+	Variables aggregations.Variables `ferretdb:"-"`
+
 	Collation    *types.Document `ferretdb:"collation,unimplemented"`
 	Fields       *types.Document `ferretdb:"fields,unimplemented"`
 	ArrayFilters *types.Array    `ferretdb:"arrayFilters,unimplemented"`
@@ -127,5 +130,9 @@ func GetFindAndModifyParams(doc *types.Document, l *slog.Logger) (*FindAndModify
 
 	params.HasUpdateOperators = hasUpdateOperators
 
+	if params.Variables, err = CommandVariables(params.Let, "findAndModify"); err != nil {
+		return nil, err
+	}
+
 	return &params, nil
 }
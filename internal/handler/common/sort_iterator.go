@@ -15,6 +15,9 @@
 package common
 
 import (
+	"errors"
+	"math"
+
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
 	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
@@ -23,24 +26,51 @@ import (
 // SortIterator returns an iterator of sorted documents.
 // It will be added to the given closer.
 //
-// Since sorting iterator is impossible, this function fully consumes and closes the underlying iterator,
-// sorts documents in memory and returns a new iterator over the sorted slice.
-func SortIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, sort *types.Document) (types.DocumentsIterator, error) { //nolint:lll // for readability
+// Since sorting iterator is impossible, this function fully consumes and closes the underlying iterator
+// and returns a new iterator over sorted documents.
+// If limit is not 0, only that number of the first sorted documents is kept in memory and returned.
+// Documents are sorted in memory, unless the memory limit of the given params is exceeded
+// and disk use is allowed; in that case, sorted runs are written to temporary files and merged.
+// If disk use is not allowed, ErrMemoryLimitExceeded is returned.
+// If params is nil, there is no memory limit.
+func SortIterator(iter types.DocumentsIterator, closer *iterator.MultiCloser, sort *types.Document, limit int64, params *BlockingParams) (types.DocumentsIterator, error) { //nolint:lll // for readability
 	// don't consume all documents if there is no sort
 	if sort.Len() == 0 {
 		return iter, nil
 	}
 
-	docs, err := iterator.ConsumeValues(iter)
+	defer iter.Close()
+
+	sortFuncs, err := sortFuncsForDocument(sort)
 	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
 
-	if err = SortDocuments(docs, sort); err != nil {
+	sorter := NewExternalSorter(func(a, b *types.Document) bool {
+		return lessDocuments(sortFuncs, a, b)
+	}, int(min(limit, math.MaxInt)), params)
+
+	for {
+		_, doc, err := iter.Next()
+		if errors.Is(err, iterator.ErrIteratorDone) {
+			break
+		}
+
+		if err == nil {
+			err = sorter.Add(doc)
+		}
+
+		if err != nil {
+			sorter.Close()
+			return nil, lazyerrors.Error(err)
+		}
+	}
+
+	res, err := sorter.Iterator()
+	if err != nil {
 		return nil, lazyerrors.Error(err)
 	}
 
-	res := iterator.Values(iterator.ForSlice(docs))
 	closer.Add(res)
 
 	return res, nil
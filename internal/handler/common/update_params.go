@@ -17,6 +17,7 @@ package common
 import (
 	"log/slog"
 
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/handler/handlerparams"
 	"github.com/FerretDB/FerretDB/internal/types"
@@ -34,7 +35,8 @@ type UpdateParams struct {
 	Comment   string `ferretdb:"comment,opt"`
 	MaxTimeMS int64  `ferretdb:"maxTimeMS,ignored"`
 
-	Let *types.Document `ferretdb:"let,unimplemented"`
+	Let       *types.Document        `ferretdb:"let,opt"`
+	Variables aggregations.Variables `ferretdb:"-"`
 
 	Ordered                  bool            `ferretdb:"ordered,ignored"`
 	BypassDocumentValidation bool            `ferretdb:"bypassDocumentValidation,ignored"`
@@ -121,5 +123,9 @@ func GetUpdateParams(document *types.Document, l *slog.Logger) (*UpdateParams, e
 		}
 	}
 
+	if params.Variables, err = CommandVariables(params.Let, "update"); err != nil {
+		return nil, err
+	}
+
 	return &params, nil
 }
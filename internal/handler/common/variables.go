@@ -0,0 +1,118 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"strings"
+
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations/operators"
+	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/lazyerrors"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+// CommandVariables returns variables available to all expressions of the command:
+// system variables, such as `$$NOW`, and user variables defined by the command's let option.
+//
+// Let variables are evaluated once before any document is examined,
+// so their expressions cannot access document fields. Let may be nil.
+func CommandVariables(let *types.Document, command string) (aggregations.Variables, error) {
+	vars := aggregations.NewVariables()
+
+	if let == nil {
+		return vars, nil
+	}
+
+	for _, name := range let.Keys() {
+		if err := aggregations.ValidateUserVariableName(name); err != nil {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(handlererrors.ErrFailedToParse, err.Error(), command)
+		}
+
+		if accessesDocument(must.NotFail(let.Get(name))) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrLetFieldPath,
+				"Command let Expression tried to access a field, but this is not allowed because "+
+					"command let expressions run before the query examines any documents.",
+				command,
+			)
+		}
+	}
+
+	op, err := operators.NewExpr(must.NotFail(types.NewDocument("$expr", let)), command, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// evaluate on an empty document, as nil document is used for validation only
+	v, err := op.Process(new(types.Document), vars)
+	if err != nil {
+		return nil, err
+	}
+
+	evaluated, ok := v.(*types.Document)
+	if !ok {
+		return nil, lazyerrors.Errorf("unexpected type %T", v)
+	}
+
+	for _, name := range let.Keys() {
+		// variables evaluated to missing values are set to null
+		v, err := evaluated.Get(name)
+		if err != nil {
+			v = types.Null
+		}
+
+		vars[name] = v
+	}
+
+	return vars, nil
+}
+
+// accessesDocument returns true if the given expression contains field paths,
+// `$$ROOT` or `$$CURRENT` variables. Values of `$literal` are not evaluated.
+func accessesDocument(expr any) bool {
+	switch expr := expr.(type) {
+	case *types.Document:
+		if expr.Len() == 1 && expr.Has("$literal") {
+			return false
+		}
+
+		for _, k := range expr.Keys() {
+			if accessesDocument(must.NotFail(expr.Get(k))) {
+				return true
+			}
+		}
+
+	case *types.Array:
+		for i := 0; i < expr.Len(); i++ {
+			if accessesDocument(must.NotFail(expr.Get(i))) {
+				return true
+			}
+		}
+
+	case string:
+		name, isVariable := strings.CutPrefix(expr, "$$")
+		if !isVariable {
+			return strings.HasPrefix(expr, "$")
+		}
+
+		name, _, _ = strings.Cut(name, ".")
+
+		return name == "ROOT" || name == "CURRENT"
+	}
+
+	return false
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/types"
+	"github.com/FerretDB/FerretDB/internal/util/must"
+)
+
+func TestOperations(t *testing.T) {
+	t.Parallel()
+
+	ops := NewOperations()
+
+	op1 := &Operation{DB: "db", NS: "db.foo"}
+	op2 := &Operation{DB: "db", NS: "db.bar"}
+
+	done1 := ops.Start(op1)
+	done2 := ops.Start(op2)
+
+	assert.Equal(t, []*Operation{op1, op2}, ops.List())
+
+	done1()
+	assert.Equal(t, []*Operation{op2}, ops.List())
+
+	done2()
+	assert.Empty(t, ops.List())
+}
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	s := NewSessions(time.Hour)
+
+	s.Use([]byte{2}, "user", "admin")
+	s.Use([]byte{1}, "user", "admin")
+	s.Use([]byte{2}, "other", "admin")
+
+	list := s.List()
+	require.Len(t, list, 2)
+	assert.Equal(t, []byte{1}, list[0].ID)
+	assert.Equal(t, "user", list[0].Username)
+	assert.Equal(t, []byte{2}, list[1].ID)
+	assert.Equal(t, "other", list[1].Username)
+
+	expired := NewSessions(0)
+	expired.Use([]byte{1}, "user", "admin")
+	time.Sleep(time.Millisecond)
+	assert.Empty(t, expired.List())
+
+	expired.Use([]byte{1}, "user", "admin")
+	time.Sleep(time.Millisecond)
+	expired.Use([]byte{2}, "user", "admin")
+
+	expired.rw.RLock()
+	assert.Len(t, expired.m, 1)
+	expired.rw.RUnlock()
+}
+
+func TestIndexUsage(t *testing.T) {
+	t.Parallel()
+
+	iu := NewIndexUsage()
+
+	id := backends.IndexInfo{Name: "_id_", Key: []backends.IndexKeyPair{{Field: "_id"}}}
+	vw := backends.IndexInfo{Name: "v_1_w_1", Key: []backends.IndexKeyPair{{Field: "v"}, {Field: "w"}}}
+	w := backends.IndexInfo{Name: "w_1", Key: []backends.IndexKeyPair{{Field: "w"}}}
+
+	iu.Record("db", "foo", must.NotFail(types.NewDocument("_id", int32(1), "v", "a")))
+	iu.Record("db", "foo", must.NotFail(types.NewDocument("v", "b", "w", "c", "$comment", "c")))
+	iu.Record("db", "foo", nil)
+
+	ops, since := iu.Accesses("db", "foo", id)
+	assert.Equal(t, int64(1), ops)
+	assert.Equal(t, iu.since, since)
+
+	ops, _ = iu.Accesses("db", "foo", vw)
+	assert.Equal(t, int64(2), ops)
+
+	ops, _ = iu.Accesses("db", "bar", vw)
+	assert.Equal(t, int64(0), ops)
+
+	// filters recorded before the index creation are not counted
+	iu.IndexesCreated("db", "foo", []backends.IndexInfo{w})
+
+	ops, since = iu.Accesses("db", "foo", w)
+	assert.Equal(t, int64(0), ops)
+	assert.False(t, since.Before(iu.since))
+
+	iu.Record("db", "foo", must.NotFail(types.NewDocument("w", "d")))
+
+	ops, _ = iu.Accesses("db", "foo", w)
+	assert.Equal(t, int64(1), ops)
+
+	iu.IndexesDropped("db", "foo", []string{w.Name})
+
+	ops, since = iu.Accesses("db", "foo", w)
+	assert.Equal(t, int64(2), ops)
+	assert.Equal(t, iu.since, since)
+
+	iu.CollectionDropped("db", "foo")
+
+	ops, _ = iu.Accesses("db", "foo", vw)
+	assert.Equal(t, int64(0), ops)
+
+	iu.Record("db", "foo", must.NotFail(types.NewDocument("v", "e")))
+	iu.Record("db", "bar", must.NotFail(types.NewDocument("v", "f")))
+	iu.Record("other", "foo", must.NotFail(types.NewDocument("v", "g")))
+	iu.DatabaseDropped("db")
+
+	ops, _ = iu.Accesses("db", "foo", vw)
+	assert.Equal(t, int64(0), ops)
+
+	ops, _ = iu.Accesses("db", "bar", vw)
+	assert.Equal(t, int64(0), ops)
+
+	ops, _ = iu.Accesses("other", "foo", vw)
+	assert.Equal(t, int64(1), ops)
+}
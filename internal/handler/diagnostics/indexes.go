@@ -0,0 +1,178 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/backends"
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// IndexUsage stores usage counters of collection indexes by query filters.
+//
+// The handler does not know which index the backend uses for a query,
+// so the index access counter is approximated by the number of filters
+// that use the first field of the index key.
+//
+// Queries record only top-level fields of their filters without listing indexes;
+// fields are resolved to indexes when counters are read.
+type IndexUsage struct {
+	rw      sync.RWMutex
+	fields  map[string]map[string]int64          // namespace -> filter field -> number of filters
+	indexes map[string]map[string]*indexAccesses // namespace -> index name -> accesses
+	since   time.Time
+}
+
+// indexAccesses stores the number of filters that used the first field of the index key
+// before the index was created, and the creation time.
+type indexAccesses struct {
+	field string
+	base  int64
+	since time.Time
+}
+
+// NewIndexUsage creates a new IndexUsage registry.
+func NewIndexUsage() *IndexUsage {
+	return &IndexUsage{
+		fields:  map[string]map[string]int64{},
+		indexes: map[string]map[string]*indexAccesses{},
+		since:   time.Now(),
+	}
+}
+
+// Record increments usage counters for top-level fields of the given filter.
+//
+// Operators like $and and $or are not inspected.
+func (iu *IndexUsage) Record(db, collection string, filter *types.Document) {
+	if filter == nil || filter.Len() == 0 {
+		return
+	}
+
+	ns := db + "." + collection
+
+	iu.rw.Lock()
+	defer iu.rw.Unlock()
+
+	fields := iu.fields[ns]
+	if fields == nil {
+		fields = map[string]int64{}
+		iu.fields[ns] = fields
+	}
+
+	for _, key := range filter.Keys() {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+
+		fields[key]++
+	}
+}
+
+// IndexesCreated starts counting accesses of the given new collection indexes from zero.
+func (iu *IndexUsage) IndexesCreated(db, collection string, indexes []backends.IndexInfo) {
+	ns := db + "." + collection
+	now := time.Now()
+
+	iu.rw.Lock()
+	defer iu.rw.Unlock()
+
+	for _, index := range indexes {
+		if len(index.Key) == 0 {
+			continue
+		}
+
+		if iu.indexes[ns] == nil {
+			iu.indexes[ns] = map[string]*indexAccesses{}
+		}
+
+		field := index.Key[0].Field
+
+		iu.indexes[ns][index.Name] = &indexAccesses{
+			field: field,
+			base:  iu.fields[ns][field],
+			since: now,
+		}
+	}
+}
+
+// IndexesDropped removes counters of the given collection indexes.
+func (iu *IndexUsage) IndexesDropped(db, collection string, names []string) {
+	ns := db + "." + collection
+
+	iu.rw.Lock()
+	defer iu.rw.Unlock()
+
+	for _, name := range names {
+		delete(iu.indexes[ns], name)
+	}
+}
+
+// CollectionDropped removes all counters of the given collection.
+func (iu *IndexUsage) CollectionDropped(db, collection string) {
+	ns := db + "." + collection
+
+	iu.rw.Lock()
+	defer iu.rw.Unlock()
+
+	delete(iu.fields, ns)
+	delete(iu.indexes, ns)
+}
+
+// DatabaseDropped removes all counters of collections in the given database.
+func (iu *IndexUsage) DatabaseDropped(db string) {
+	prefix := db + "."
+
+	iu.rw.Lock()
+	defer iu.rw.Unlock()
+
+	for ns := range iu.fields {
+		if strings.HasPrefix(ns, prefix) {
+			delete(iu.fields, ns)
+		}
+	}
+
+	for ns := range iu.indexes {
+		if strings.HasPrefix(ns, prefix) {
+			delete(iu.indexes, ns)
+		}
+	}
+}
+
+// Accesses returns the usage counter of the given collection index,
+// and the time since which it is collected.
+func (iu *IndexUsage) Accesses(db, collection string, index backends.IndexInfo) (int64, time.Time) {
+	if len(index.Key) == 0 {
+		return 0, iu.since
+	}
+
+	ns := db + "." + collection
+	field := index.Key[0].Field
+
+	iu.rw.RLock()
+	defer iu.rw.RUnlock()
+
+	ops := iu.fields[ns][field]
+
+	// indexes existing before the start or created implicitly are counted since the start
+	a := iu.indexes[ns][index.Name]
+	if a == nil || a.field != field {
+		return ops, iu.since
+	}
+
+	return ops - a.base, a.since
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diagnostics provides registries of in-flight operations, sessions and index usage
+// tracked by the handler for diagnostic aggregation stages.
+package diagnostics
+
+import (
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/FerretDB/FerretDB/internal/types"
+)
+
+// Operation represents a single in-flight command.
+//
+//nolint:vet // for readability
+type Operation struct {
+	ID       int64
+	Command  *types.Document
+	DB       string
+	NS       string
+	Client   netip.AddrPort
+	Username string
+	UserDB   string
+	LSID     *types.Document
+	Started  time.Time
+}
+
+// Operations stores in-flight commands of all client connections.
+type Operations struct {
+	rw     sync.RWMutex
+	m      map[int64]*Operation
+	lastID atomic.Int64
+}
+
+// NewOperations creates a new Operations registry.
+func NewOperations() *Operations {
+	return &Operations{
+		m: map[int64]*Operation{},
+	}
+}
+
+// Start registers a new operation and returns a function that removes it from the registry.
+//
+// The ID and Started fields of the given operation are set by this method.
+func (ops *Operations) Start(op *Operation) func() {
+	op.ID = ops.lastID.Add(1)
+	op.Started = time.Now()
+
+	ops.rw.Lock()
+	ops.m[op.ID] = op
+	ops.rw.Unlock()
+
+	return func() {
+		ops.rw.Lock()
+		delete(ops.m, op.ID)
+		ops.rw.Unlock()
+	}
+}
+
+// List returns all in-flight operations sorted by ID.
+//
+// Returned operations should not be modified.
+func (ops *Operations) List() []*Operation {
+	ops.rw.RLock()
+
+	res := make([]*Operation, 0, len(ops.m))
+	for _, op := range ops.m {
+		res = append(res, op)
+	}
+
+	ops.rw.RUnlock()
+
+	slices.SortFunc(res, func(a, b *Operation) int {
+		return int(a.ID - b.ID)
+	})
+
+	return res
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 FerretDB Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diagnostics
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Session represents a logical session known to the handler.
+type Session struct {
+	ID       []byte
+	Username string
+	DB       string
+	LastUse  time.Time
+}
+
+// Sessions stores logical sessions used by clients.
+type Sessions struct {
+	rw        sync.RWMutex
+	m         map[string]*Session
+	timeout   time.Duration
+	lastEvict time.Time
+}
+
+// NewSessions creates a new Sessions registry.
+//
+// Sessions that were not used for the given timeout are expired.
+func NewSessions(timeout time.Duration) *Sessions {
+	return &Sessions{
+		m:         map[string]*Session{},
+		timeout:   timeout,
+		lastEvict: time.Now(),
+	}
+}
+
+// Use records the usage of the session with the given ID by the given user.
+//
+// Expired sessions are removed from the registry at most once per timeout,
+// so the registry does not grow if List is never called.
+func (s *Sessions) Use(id []byte, username, db string) {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	now := time.Now()
+
+	if now.Sub(s.lastEvict) >= s.timeout {
+		s.evict(now)
+	}
+
+	s.m[string(id)] = &Session{
+		ID:       slices.Clone(id),
+		Username: username,
+		DB:       db,
+		LastUse:  now,
+	}
+}
+
+// List returns all non-expired sessions sorted by ID.
+//
+// Expired sessions are removed from the registry.
+func (s *Sessions) List() []*Session {
+	s.rw.Lock()
+	defer s.rw.Unlock()
+
+	s.evict(time.Now())
+
+	res := make([]*Session, 0, len(s.m))
+
+	for _, sess := range s.m {
+		res = append(res, sess)
+	}
+
+	slices.SortFunc(res, func(a, b *Session) int {
+		return bytes.Compare(a.ID, b.ID)
+	})
+
+	return res
+}
+
+// evict removes sessions expired at the given time.
+//
+// It should be called with the write lock held.
+func (s *Sessions) evict(now time.Time) {
+	for key, sess := range s.m {
+		if now.Sub(sess.LastUse) > s.timeout {
+			delete(s.m, key)
+		}
+	}
+
+	s.lastEvict = now
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/FerretDB/FerretDB/internal/clientconn/conninfo"
 	"github.com/FerretDB/FerretDB/internal/clientconn/connmetrics"
 	"github.com/FerretDB/FerretDB/internal/clientconn/cursor"
+	"github.com/FerretDB/FerretDB/internal/handler/diagnostics"
 	"github.com/FerretDB/FerretDB/internal/handler/users"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/ctxutil"
@@ -70,7 +71,11 @@ type Handler struct {
 
 	cursors  *cursor.Registry
 	commands map[string]*command
-	wg       sync.WaitGroup
+
+	operations *diagnostics.Operations
+	sessions   *diagnostics.Sessions
+	indexUsage *diagnostics.IndexUsage
+	wg         sync.WaitGroup
 
 	cappedCleanupStop             chan struct{}
 	cleanupCappedCollectionsDocs  *prometheus.CounterVec
@@ -84,6 +89,7 @@ type NewOpts struct {
 	Backend     backends.Backend
 	TCPHost     string
 	ReplSetName string
+	SpillDir    string
 
 	SetupDatabase string
 	SetupUsername string
@@ -95,13 +101,15 @@ type NewOpts struct {
 	StateProvider *state.Provider
 
 	// test options
-	DisablePushdown         bool
-	EnableNestedPushdown    bool
-	CappedCleanupInterval   time.Duration
-	CappedCleanupPercentage uint8
-	EnableNewAuth           bool
-	BatchSize               int
-	MaxBsonObjectSizeBytes  int
+	DisablePushdown             bool
+	EnableNestedPushdown        bool
+	CappedCleanupInterval       time.Duration
+	CappedCleanupPercentage     uint8
+	EnableNewAuth               bool
+	BatchSize                   int
+	MaxBsonObjectSizeBytes      int
+	GraphLookupMaxMemoryBytes   int
+	BlockingStageMaxMemoryBytes int
 }
 
 // New returns a new handler.
@@ -121,6 +129,14 @@ func New(opts *NewOpts) (*Handler, error) {
 		opts.MaxBsonObjectSizeBytes = types.MaxDocumentLen
 	}
 
+	if opts.GraphLookupMaxMemoryBytes == 0 {
+		opts.GraphLookupMaxMemoryBytes = 100 * 1024 * 1024
+	}
+
+	if opts.BlockingStageMaxMemoryBytes == 0 {
+		opts.BlockingStageMaxMemoryBytes = 100 * 1024 * 1024
+	}
+
 	b := oplog.NewBackend(opts.Backend, logging.WithName(opts.L, "oplog"))
 
 	h := &Handler{
@@ -128,6 +144,10 @@ func New(opts *NewOpts) (*Handler, error) {
 		NewOpts: opts,
 		cursors: cursor.NewRegistry(logging.WithName(opts.L, "cursors")),
 
+		operations: diagnostics.NewOperations(),
+		sessions:   diagnostics.NewSessions(time.Duration(logicalSessionTimeoutMinutes) * time.Minute),
+		indexUsage: diagnostics.NewIndexUsage(),
+
 		cappedCleanupStop: make(chan struct{}),
 		cleanupCappedCollectionsDocs: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -123,21 +123,402 @@ const (
 	// ErrClientMetadataCannotBeMutated indicates that client metadata cannot be mutated.
 	ErrClientMetadataCannotBeMutated = ErrorCode(186) // ClientMetadataCannotBeMutated
 
+	// ErrQueryFeatureNotAllowed indicates that the query feature is not allowed in the given context.
+	ErrQueryFeatureNotAllowed = ErrorCode(224) // QueryFeatureNotAllowed
+
 	// ErrNotImplemented indicates that a flag or command is not implemented.
 	ErrNotImplemented = ErrorCode(238) // NotImplemented
 
+	// ErrConversionFailure indicates that the value cannot be converted.
+	ErrConversionFailure = ErrorCode(241) // ConversionFailure
+
+	// ErrQueryExceededMemoryLimitNoDiskUseAllowed indicates that a blocking stage exceeded its memory limit without allowDiskUse.
+	ErrQueryExceededMemoryLimitNoDiskUseAllowed = ErrorCode(292) // QueryExceededMemoryLimitNoDiskUseAllowed
+
 	// ErrMechanismUnavailable indicates that the authentication mechanism is unavailable.
 	ErrMechanismUnavailable = ErrorCode(334)
 
 	// ErrUnsupportedOpQueryCommand indicates that given op query is not supported.
 	ErrUnsupportedOpQueryCommand = ErrorCode(352) // UnsupportedOpQueryCommand
 
-	// ErrIndexesWrongType indicates that indexes parameter has wrong type.
+	// ErrIndexesWrongType indicates that indexes parameter or another parameter expected to be an object has wrong type.
 	ErrIndexesWrongType = ErrorCode(10065) // Location10065
 
 	// ErrDuplicateKeyInsert indicates duplicate key violation on inserting document.
 	ErrDuplicateKeyInsert = ErrorCode(11000) // DuplicateKey
 
+	// ErrStageMergeNoMatch indicates that $merge stage found no matching document with whenNotMatched: fail.
+	ErrStageMergeNoMatch = ErrorCode(13113) // Location13113
+
+	// ErrDateConversion indicates that the value cannot be converted to date.
+	ErrDateConversion = ErrorCode(16006) // Location16006
+
+	// ErrStringConversion indicates that the value cannot be converted to string.
+	ErrStringConversion = ErrorCode(16007) // Location16007
+
+	// ErrSubstrBytesStartNotNumeric indicates that $substrBytes starting index is not a number.
+	ErrSubstrBytesStartNotNumeric = ErrorCode(16034) // Location16034
+
+	// ErrSubstrBytesLengthNotNumeric indicates that $substrBytes length is not a number.
+	ErrSubstrBytesLengthNotNumeric = ErrorCode(16035) // Location16035
+
+	// ErrMultiplyInvalidType indicates that $multiply argument is not a number.
+	ErrMultiplyInvalidType = ErrorCode(16555) // Location16555
+
+	// ErrSubtractInvalidType indicates that $subtract arguments have unsupported types.
+	ErrSubtractInvalidType = ErrorCode(16556) // Location16556
+
+	// ErrDivideByZero indicates that $divide divisor is zero.
+	ErrDivideByZero = ErrorCode(16608) // Location16608
+
+	// ErrDivideInvalidType indicates that $divide arguments are not numbers.
+	ErrDivideInvalidType = ErrorCode(16609) // Location16609
+
+	// ErrModByZero indicates that $mod divisor is zero.
+	ErrModByZero = ErrorCode(16610) // Location16610
+
+	// ErrModInvalidType indicates that $mod arguments are not numbers.
+	ErrModInvalidType = ErrorCode(16611) // Location16611
+
+	// ErrAddMultipleDates indicates that $add expression contains more than one date.
+	ErrAddMultipleDates = ErrorCode(16612) // Location16612
+
+	// ErrConcatInvalidType indicates that $concat argument is not a string.
+	ErrConcatInvalidType = ErrorCode(16702) // Location16702
+
+	// ErrLetInvalidSpec indicates that $let operator argument is not a document.
+	ErrLetInvalidSpec = ErrorCode(16874) // Location16874
+
+	// ErrLetUnknownParameter indicates that $let operator has unknown parameter.
+	ErrLetUnknownParameter = ErrorCode(16875) // Location16875
+
+	// ErrLetMissingVars indicates that $let operator is missing vars parameter.
+	ErrLetMissingVars = ErrorCode(16876) // Location16876
+
+	// ErrLetMissingIn indicates that $let operator is missing in parameter.
+	ErrLetMissingIn = ErrorCode(16877) // Location16877
+
+	// ErrMapInvalidSpec indicates that the argument of $map is not a document.
+	ErrMapInvalidSpec = ErrorCode(16878) // Location16878
+
+	// ErrMapUnknownParameter indicates that $map has an unknown parameter.
+	ErrMapUnknownParameter = ErrorCode(16879) // Location16879
+
+	// ErrMapMissingInput indicates that $map input parameter is missing.
+	ErrMapMissingInput = ErrorCode(16880) // Location16880
+
+	// ErrMapMissingIn indicates that $map in parameter is missing.
+	ErrMapMissingIn = ErrorCode(16882) // Location16882
+
+	// ErrMapInputNotArray indicates that $map input is not an array.
+	ErrMapInputNotArray = ErrorCode(16883) // Location16883
+
+	// ErrAllElementsTrueNotArray indicates that the argument of $allElementsTrue is not an array.
+	ErrAllElementsTrueNotArray = ErrorCode(17040) // Location17040
+
+	// ErrAnyElementTrueNotArray indicates that the argument of $anyElementTrue is not an array.
+	ErrAnyElementTrueNotArray = ErrorCode(17041) // Location17041
+
+	// ErrSetIsSubsetSecondNotArray indicates that the second argument of $setIsSubset is not an array.
+	ErrSetIsSubsetSecondNotArray = ErrorCode(17042) // Location17042
+
+	// ErrSetUnionNotArray indicates that an argument of $setUnion is not an array.
+	ErrSetUnionNotArray = ErrorCode(17043) // Location17043
+
+	// ErrSetEqualsNotArray indicates that an argument of $setEquals is not an array.
+	ErrSetEqualsNotArray = ErrorCode(17044) // Location17044
+
+	// ErrSetEqualsArgsInvalidLen indicates that $setEquals has less than two arguments.
+	ErrSetEqualsArgsInvalidLen = ErrorCode(17045) // Location17045
+
+	// ErrSetIsSubsetFirstNotArray indicates that the first argument of $setIsSubset is not an array.
+	ErrSetIsSubsetFirstNotArray = ErrorCode(17046) // Location17046
+
+	// ErrSetIntersectionNotArray indicates that an argument of $setIntersection is not an array.
+	ErrSetIntersectionNotArray = ErrorCode(17047) // Location17047
+
+	// ErrSetDifferenceFirstNotArray indicates that the first argument of $setDifference is not an array.
+	ErrSetDifferenceFirstNotArray = ErrorCode(17048) // Location17048
+
+	// ErrSetDifferenceSecondNotArray indicates that the second argument of $setDifference is not an array.
+	ErrSetDifferenceSecondNotArray = ErrorCode(17049) // Location17049
+
+	// ErrSizeNotArray indicates that the argument of $size aggregation operator is not an array.
+	ErrSizeNotArray = ErrorCode(17124) // Location17124
+
+	// ErrDateToStringFormatNotString indicates that $dateToString format is not a string.
+	ErrDateToStringFormatNotString = ErrorCode(18533) // Location18533
+
+	// ErrDateToStringUnknownArgument indicates that $dateToString has an unknown argument.
+	ErrDateToStringUnknownArgument = ErrorCode(18534) // Location18534
+
+	// ErrDateFormatUnmatchedPercent indicates that the date format string ends with unmatched percent sign.
+	ErrDateFormatUnmatchedPercent = ErrorCode(18535) // Location18535
+
+	// ErrDateFormatInvalidCharacter indicates that the date format string contains invalid format specifier.
+	ErrDateFormatInvalidCharacter = ErrorCode(18536) // Location18536
+
+	// ErrDateToStringYearOutOfRange indicates that $dateToString year is outside of 0-9999 range.
+	ErrDateToStringYearOutOfRange = ErrorCode(18537) // Location18537
+
+	// ErrDateToStringMissingDate indicates that $dateToString has no date argument.
+	ErrDateToStringMissingDate = ErrorCode(18628) // Location18628
+
+	// ErrDateToStringInvalidSpec indicates that $dateToString argument is not a document.
+	ErrDateToStringInvalidSpec = ErrorCode(18629) // Location18629
+
+	// ErrFilterInvalidSpec indicates that the argument of $filter is not a document.
+	ErrFilterInvalidSpec = ErrorCode(28646) // Location28646
+
+	// ErrFilterUnknownParameter indicates that $filter has an unknown parameter.
+	ErrFilterUnknownParameter = ErrorCode(28647) // Location28647
+
+	// ErrFilterMissingInput indicates that $filter input parameter is missing.
+	ErrFilterMissingInput = ErrorCode(28648) // Location28648
+
+	// ErrFilterMissingCond indicates that $filter cond parameter is missing.
+	ErrFilterMissingCond = ErrorCode(28650) // Location28650
+
+	// ErrFilterInputNotArray indicates that $filter input is not an array.
+	ErrFilterInputNotArray = ErrorCode(28651) // Location28651
+
+	// ErrSubstrBytesStartContinuation indicates that $substrBytes starting index is a UTF-8 continuation byte.
+	ErrSubstrBytesStartContinuation = ErrorCode(28656) // Location28656
+
+	// ErrSubstrBytesEndContinuation indicates that $substrBytes ending index is in the middle of a UTF-8 character.
+	ErrSubstrBytesEndContinuation = ErrorCode(28657) // Location28657
+
+	// ErrConcatArraysNotArray indicates that an argument of $concatArrays is not an array.
+	ErrConcatArraysNotArray = ErrorCode(28664) // Location28664
+
+	// ErrAbsLongMin indicates that $abs argument is the minimal long value.
+	ErrAbsLongMin = ErrorCode(28680) // Location28680
+
+	// ErrArrayElemAtArrayNotArray indicates that the first argument of $arrayElemAt, $first or $last is not an array.
+	ErrArrayElemAtArrayNotArray = ErrorCode(28689) // Location28689
+
+	// ErrArrayElemAtIndexNotNumber indicates that the index argument of $arrayElemAt is not a number.
+	ErrArrayElemAtIndexNotNumber = ErrorCode(28690) // Location28690
+
+	// ErrArrayElemAtIndexNotInt32 indicates that the index argument of $arrayElemAt is not representable as 32-bit integer.
+	ErrArrayElemAtIndexNotInt32 = ErrorCode(28691) // Location28691
+
+	// ErrSqrtNegative indicates that $sqrt argument is negative.
+	ErrSqrtNegative = ErrorCode(28714) // Location28714
+
+	// ErrSlicePositionNotNumber indicates that the second argument of $slice is not a number.
+	ErrSlicePositionNotNumber = ErrorCode(28725) // Location28725
+
+	// ErrSlicePositionNotInt32 indicates that the second argument of $slice is not representable as 32-bit integer.
+	ErrSlicePositionNotInt32 = ErrorCode(28726) // Location28726
+
+	// ErrSliceLengthNotNumber indicates that the third argument of $slice is not a number.
+	ErrSliceLengthNotNumber = ErrorCode(28727) // Location28727
+
+	// ErrSliceLengthNotInt32 indicates that the third argument of $slice is not representable as 32-bit integer.
+	ErrSliceLengthNotInt32 = ErrorCode(28728) // Location28728
+
+	// ErrSliceLengthNotPositive indicates that the third argument of $slice is not positive.
+	ErrSliceLengthNotPositive = ErrorCode(28729) // Location28729
+
+	// ErrLogArgNotNumeric indicates that $log argument is not a number.
+	ErrLogArgNotNumeric = ErrorCode(28756) // Location28756
+
+	// ErrLogBaseNotNumeric indicates that $log base is not a number.
+	ErrLogBaseNotNumeric = ErrorCode(28757) // Location28757
+
+	// ErrLogArgNotPositive indicates that $log argument is not positive.
+	ErrLogArgNotPositive = ErrorCode(28758) // Location28758
+
+	// ErrLogBaseInvalid indicates that $log base is not positive or equal to 1.
+	ErrLogBaseInvalid = ErrorCode(28759) // Location28759
+
+	// ErrLog10NotPositive indicates that $log10 argument is not positive.
+	ErrLog10NotPositive = ErrorCode(28761) // Location28761
+
+	// ErrPowBaseNotNumeric indicates that $pow base is not a number.
+	ErrPowBaseNotNumeric = ErrorCode(28762) // Location28762
+
+	// ErrPowExponentNotNumeric indicates that $pow exponent is not a number.
+	ErrPowExponentNotNumeric = ErrorCode(28763) // Location28763
+
+	// ErrPowZeroNegativeExponent indicates that $pow base is zero and exponent is negative.
+	ErrPowZeroNegativeExponent = ErrorCode(28764) // Location28764
+
+	// ErrExpressionNotNumeric indicates that the argument of a numeric expression such as $abs is not a number.
+	ErrExpressionNotNumeric = ErrorCode(28765) // Location28765
+
+	// ErrLnNotPositive indicates that $ln argument is not positive.
+	ErrLnNotPositive = ErrorCode(28766) // Location28766
+
+	// ErrRegexOpMissingInput indicates that input argument of the regular expression operator is missing.
+	ErrRegexOpMissingInput = ErrorCode(31022) // Location31022
+
+	// ErrRegexOpMissingRegex indicates that regex argument of the regular expression operator is missing.
+	ErrRegexOpMissingRegex = ErrorCode(31023) // Location31023
+
+	// ErrRegexOpUnknownArgument indicates that the regular expression operator has an unknown argument.
+	ErrRegexOpUnknownArgument = ErrorCode(31024) // Location31024
+
+	// ErrDateFromPartsOutOfRange indicates that $dateFromParts value is outside of the supported range.
+	ErrDateFromPartsOutOfRange = ErrorCode(31034) // Location31034
+
+	// ErrDateFromPartsIsoWeekYearOutOfRange indicates that $dateFromParts isoWeekYear is outside of 1-9999 range.
+	ErrDateFromPartsIsoWeekYearOutOfRange = ErrorCode(31095) // Location31095
+
+	// ErrBSONSizeNotDocument indicates that the argument of $bsonSize is not a document.
+	ErrBSONSizeNotDocument = ErrorCode(31393) // Location31393
+
+	// ErrReverseArrayNotArray indicates that the argument of $reverseArray is not an array.
+	ErrReverseArrayNotArray = ErrorCode(34435) // Location34435
+
+	// ErrRangeStartNotNumber indicates that $range starting value is not a number.
+	ErrRangeStartNotNumber = ErrorCode(34443) // Location34443
+
+	// ErrRangeStartNotInt32 indicates that $range starting value is not representable as 32-bit integer.
+	ErrRangeStartNotInt32 = ErrorCode(34444) // Location34444
+
+	// ErrRangeEndNotNumber indicates that $range ending value is not a number.
+	ErrRangeEndNotNumber = ErrorCode(34445) // Location34445
+
+	// ErrRangeEndNotInt32 indicates that $range ending value is not representable as 32-bit integer.
+	ErrRangeEndNotInt32 = ErrorCode(34446) // Location34446
+
+	// ErrRangeStepNotNumber indicates that $range step value is not a number.
+	ErrRangeStepNotNumber = ErrorCode(34447) // Location34447
+
+	// ErrRangeStepNotInt32 indicates that $range step value is not representable as 32-bit integer.
+	ErrRangeStepNotInt32 = ErrorCode(34448) // Location34448
+
+	// ErrRangeStepZero indicates that $range step value is zero.
+	ErrRangeStepZero = ErrorCode(34449) // Location34449
+
+	// ErrSubstrCPStartNotNumeric indicates that $substrCP starting index is not a number.
+	ErrSubstrCPStartNotNumeric = ErrorCode(34450) // Location34450
+
+	// ErrSubstrCPStartNotInt32 indicates that $substrCP starting index is not a 32-bit integral value.
+	ErrSubstrCPStartNotInt32 = ErrorCode(34451) // Location34451
+
+	// ErrSubstrCPLengthNotNumeric indicates that $substrCP length is not a number.
+	ErrSubstrCPLengthNotNumeric = ErrorCode(34452) // Location34452
+
+	// ErrSubstrCPLengthNotInt32 indicates that $substrCP length is not a 32-bit integral value.
+	ErrSubstrCPLengthNotInt32 = ErrorCode(34453) // Location34453
+
+	// ErrSubstrCPLengthNegative indicates that $substrCP length is negative.
+	ErrSubstrCPLengthNegative = ErrorCode(34454) // Location34454
+
+	// ErrSubstrCPStartNegative indicates that $substrCP starting index is negative.
+	ErrSubstrCPStartNegative = ErrorCode(34455) // Location34455
+
+	// ErrZipInvalidSpec indicates that the argument of $zip is not a document.
+	ErrZipInvalidSpec = ErrorCode(34460) // Location34460
+
+	// ErrZipInputsNotArray indicates that $zip inputs argument is not an array.
+	ErrZipInputsNotArray = ErrorCode(34461) // Location34461
+
+	// ErrZipDefaultsNotArray indicates that $zip defaults argument is not an array.
+	ErrZipDefaultsNotArray = ErrorCode(34462) // Location34462
+
+	// ErrZipUseLongestLengthNotBool indicates that $zip useLongestLength argument is not a boolean.
+	ErrZipUseLongestLengthNotBool = ErrorCode(34463) // Location34463
+
+	// ErrZipUnknownArgument indicates that $zip has an unknown argument.
+	ErrZipUnknownArgument = ErrorCode(34464) // Location34464
+
+	// ErrZipMissingInputs indicates that $zip inputs argument is missing or empty.
+	ErrZipMissingInputs = ErrorCode(34465) // Location34465
+
+	// ErrZipDefaultsWithoutLongestLength indicates that $zip defaults are set without useLongestLength.
+	ErrZipDefaultsWithoutLongestLength = ErrorCode(34466) // Location34466
+
+	// ErrZipDefaultsLengthMismatch indicates that $zip defaults and inputs have different lengths.
+	ErrZipDefaultsLengthMismatch = ErrorCode(34467) // Location34467
+
+	// ErrZipInputNotArray indicates that $zip input is not an array.
+	ErrZipInputNotArray = ErrorCode(34468) // Location34468
+
+	// ErrStrLenCPInvalidType indicates that $strLenCP argument is not a string.
+	ErrStrLenCPInvalidType = ErrorCode(34471) // Location34471
+
+	// ErrStrLenBytesInvalidType indicates that $strLenBytes argument is not a string.
+	ErrStrLenBytesInvalidType = ErrorCode(34473) // Location34473
+
+	// ErrSwitchInvalidSpec indicates that the argument of $switch is not a document.
+	ErrSwitchInvalidSpec = ErrorCode(40060) // Location40060
+
+	// ErrSwitchBranchesNotArray indicates that $switch branches is not an array.
+	ErrSwitchBranchesNotArray = ErrorCode(40061) // Location40061
+
+	// ErrSwitchBranchNotDocument indicates that $switch branch is not a document.
+	ErrSwitchBranchNotDocument = ErrorCode(40062) // Location40062
+
+	// ErrSwitchBranchUnknownArgument indicates that $switch branch has an unknown argument.
+	ErrSwitchBranchUnknownArgument = ErrorCode(40063) // Location40063
+
+	// ErrSwitchBranchMissingCase indicates that $switch branch does not have case expression.
+	ErrSwitchBranchMissingCase = ErrorCode(40064) // Location40064
+
+	// ErrSwitchBranchMissingThen indicates that $switch branch does not have then expression.
+	ErrSwitchBranchMissingThen = ErrorCode(40065) // Location40065
+
+	// ErrSwitchUnknownArgument indicates that $switch has an unknown argument.
+	ErrSwitchUnknownArgument = ErrorCode(40067) // Location40067
+
+	// ErrSwitchMissingBranches indicates that $switch does not have any branches.
+	ErrSwitchMissingBranches = ErrorCode(40068) // Location40068
+
+	// ErrReduceInvalidSpec indicates that the argument of $reduce is not a document.
+	ErrReduceInvalidSpec = ErrorCode(40075) // Location40075
+
+	// ErrReduceUnknownArgument indicates that $reduce has an unknown argument.
+	ErrReduceUnknownArgument = ErrorCode(40076) // Location40076
+
+	// ErrReduceMissingInput indicates that $reduce input argument is missing.
+	ErrReduceMissingInput = ErrorCode(40077) // Location40077
+
+	// ErrReduceMissingInitialValue indicates that $reduce initialValue argument is missing.
+	ErrReduceMissingInitialValue = ErrorCode(40078) // Location40078
+
+	// ErrReduceMissingIn indicates that $reduce in argument is missing.
+	ErrReduceMissingIn = ErrorCode(40079) // Location40079
+
+	// ErrReduceInputNotArray indicates that $reduce input is not an array.
+	ErrReduceInputNotArray = ErrorCode(40080) // Location40080
+
+	// ErrInArrayNotArray indicates that the second argument of $in aggregation operator is not an array.
+	ErrInArrayNotArray = ErrorCode(40081) // Location40081
+
+	// ErrSplitInputNotString indicates that $split input is not a string.
+	ErrSplitInputNotString = ErrorCode(40085) // Location40085
+
+	// ErrSplitDelimiterNotString indicates that $split delimiter is not a string.
+	ErrSplitDelimiterNotString = ErrorCode(40086) // Location40086
+
+	// ErrSplitEmptyDelimiter indicates that $split delimiter is empty.
+	ErrSplitEmptyDelimiter = ErrorCode(40087) // Location40087
+
+	// ErrIndexOfArrayNotArray indicates that the first argument of $indexOfArray is not an array.
+	ErrIndexOfArrayNotArray = ErrorCode(40090) // Location40090
+
+	// ErrIndexOfBytesInputNotString indicates that $indexOfBytes input is not a string.
+	ErrIndexOfBytesInputNotString = ErrorCode(40091) // Location40091
+
+	// ErrIndexOfBytesSubstringNotString indicates that $indexOfBytes substring is not a string.
+	ErrIndexOfBytesSubstringNotString = ErrorCode(40092) // Location40092
+
+	// ErrIndexOfCPInputNotString indicates that $indexOfCP input is not a string.
+	ErrIndexOfCPInputNotString = ErrorCode(40093) // Location40093
+
+	// ErrIndexOfCPSubstringNotString indicates that $indexOfCP substring is not a string.
+	ErrIndexOfCPSubstringNotString = ErrorCode(40094) // Location40094
+
+	// ErrIndexOfIndexNotIntegral indicates that $indexOfBytes or $indexOfCP index is not an integral value.
+	ErrIndexOfIndexNotIntegral = ErrorCode(40096) // Location40096
+
+	// ErrIndexOfIndexNegative indicates that $indexOfBytes or $indexOfCP index is negative.
+	ErrIndexOfIndexNegative = ErrorCode(40097) // Location40097
+
 	// ErrSetBadExpression indicates set expression is not object.
 	ErrSetBadExpression = ErrorCode(40272) // Location40272
 
@@ -190,11 +571,41 @@ const (
 	// ErrFieldPathInvalidName indicates that FieldPath is invalid.
 	ErrFieldPathInvalidName = ErrorCode(16410) // Location16410
 
+	// ErrFieldPathContainsDot indicates that FieldPath field name contains '.'.
+	ErrFieldPathContainsDot = ErrorCode(16412) // Location16412
+
 	// ErrGroupInvalidFieldPath indicates invalid path is given for group _id.
 	ErrGroupInvalidFieldPath = ErrorCode(16872) // Location16872
 
-	// ErrGroupUndefinedVariable indicates the variable is not defined.
-	ErrGroupUndefinedVariable = ErrorCode(17276) // Location17276
+	// ErrStageOutInvalidArg indicates that $out stage argument is not a string or an object.
+	ErrStageOutInvalidArg = ErrorCode(16990) // Location16990
+
+	// ErrStageOutInvalidNamespace indicates that $out stage object argument does not have both db and coll fields.
+	ErrStageOutInvalidNamespace = ErrorCode(16994) // Location16994
+
+	// ErrStageRedactInvalidReturn indicates that $redact expression returned a value other than $$DESCEND, $$PRUNE or $$KEEP.
+	ErrStageRedactInvalidReturn = ErrorCode(17053) // Location17053
+
+	// ErrCondMissingIf indicates that $cond operator is missing 'if' parameter.
+	ErrCondMissingIf = ErrorCode(17080) // Location17080
+
+	// ErrCondMissingThen indicates that $cond operator is missing 'then' parameter.
+	ErrCondMissingThen = ErrorCode(17081) // Location17081
+
+	// ErrCondMissingElse indicates that $cond operator is missing 'else' parameter.
+	ErrCondMissingElse = ErrorCode(17082) // Location17082
+
+	// ErrCondUnknownParameter indicates that $cond operator has unknown parameter.
+	ErrCondUnknownParameter = ErrorCode(17083) // Location17083
+
+	// ErrStageOutCappedCollection indicates that $out stage target collection is capped.
+	ErrStageOutCappedCollection = ErrorCode(17152) // Location17152
+
+	// ErrUndefinedVariable indicates that the variable is not defined.
+	ErrUndefinedVariable = ErrorCode(17276) // Location17276
+
+	// ErrStageOutSpecialCollection indicates that $out stage target collection is a system collection.
+	ErrStageOutSpecialCollection = ErrorCode(17385) // Location17385
 
 	// ErrInvalidArg indicates invalid argument in projection document.
 	ErrInvalidArg = ErrorCode(28667) // Location28667
@@ -202,6 +613,21 @@ const (
 	// ErrSliceFirstArg for $slice indicates that the first argument is not an array.
 	ErrSliceFirstArg = ErrorCode(28724) // Location28724
 
+	// ErrStageSampleInvalidSpec indicates that $sample stage specification is not a document.
+	ErrStageSampleInvalidSpec = ErrorCode(28745) // Location28745
+
+	// ErrStageSampleSizeNotNumber indicates that $sample stage size is not a number.
+	ErrStageSampleSizeNotNumber = ErrorCode(28746) // Location28746
+
+	// ErrStageSampleSizeNegative indicates that $sample stage size is negative.
+	ErrStageSampleSizeNegative = ErrorCode(28747) // Location28747
+
+	// ErrStageSampleUnknownOption indicates that $sample stage contains an unknown option.
+	ErrStageSampleUnknownOption = ErrorCode(28748) // Location28748
+
+	// ErrStageSampleMissingSize indicates that $sample stage size is not specified.
+	ErrStageSampleMissingSize = ErrorCode(28749) // Location28749
+
 	// ErrStageUnsetNoPath indicates that $unwind aggregation stage is empty.
 	ErrStageUnsetNoPath = ErrorCode(31119) // Location31119
 
@@ -211,6 +637,12 @@ const (
 	// ErrStageUnsetInvalidType indicates that $unset stage arguments has unexpected type.
 	ErrStageUnsetInvalidType = ErrorCode(31002) // Location31002
 
+	// ErrStageMergeSpecialCollection indicates that $merge stage target collection is a system collection.
+	ErrStageMergeSpecialCollection = ErrorCode(31319) // Location31319
+
+	// ErrStageIndexStatsInvalidSpec indicates that $indexStats stage specification is not an empty document.
+	ErrStageIndexStatsInvalidSpec = ErrorCode(28803) // Location28803
+
 	// ErrStageUnwindNoPath indicates that $unwind aggregation stage is empty.
 	ErrStageUnwindNoPath = ErrorCode(28812) // Location28812
 
@@ -244,6 +676,43 @@ const (
 	// ErrExclusionPositionalProjection indicates that exclusion cannot use positional projection.
 	ErrExclusionPositionalProjection = ErrorCode(31395) // Location31395
 
+	// ErrStageUnionWithNotAllowedStage indicates that the stage is not allowed within $unionWith stage.
+	ErrStageUnionWithNotAllowedStage = ErrorCode(31441) // Location31441
+
+	// ErrSwitchNoMatchingBranch indicates that $switch could not find a matching branch
+	// and no default was specified, it is also returned by $bucket without default.
+	ErrSwitchNoMatchingBranch = ErrorCode(40066) // Location40066
+
+	// ErrStageGraphLookupMaxMemory indicates that $graphLookup stage exceeded its memory limit.
+	ErrStageGraphLookupMaxMemory = ErrorCode(40099) // Location40099
+
+	// ErrStageGraphLookupMaxDepthNotNumber indicates that $graphLookup maxDepth is not a number.
+	ErrStageGraphLookupMaxDepthNotNumber = ErrorCode(40100) // Location40100
+
+	// ErrStageGraphLookupMaxDepthNegative indicates that $graphLookup maxDepth is negative.
+	ErrStageGraphLookupMaxDepthNegative = ErrorCode(40101) // Location40101
+
+	// ErrStageGraphLookupMaxDepthNotInteger indicates that $graphLookup maxDepth is not an integer.
+	ErrStageGraphLookupMaxDepthNotInteger = ErrorCode(40102) // Location40102
+
+	// ErrStageGraphLookupNotString indicates that $graphLookup argument expected to be a string is not.
+	ErrStageGraphLookupNotString = ErrorCode(40103) // Location40103
+
+	// ErrStageGraphLookupUnknownArgument indicates that $graphLookup stage contains an unknown argument.
+	ErrStageGraphLookupUnknownArgument = ErrorCode(40104) // Location40104
+
+	// ErrStageGraphLookupMissingRequired indicates that $graphLookup stage is missing a required argument.
+	ErrStageGraphLookupMissingRequired = ErrorCode(40105) // Location40105
+
+	// ErrStageSortByCountInvalidObject indicates that $sortByCount stage contains an object that is not an expression.
+	ErrStageSortByCountInvalidObject = ErrorCode(40147) // Location40147
+
+	// ErrStageSortByCountInvalidPath indicates that $sortByCount stage contains a string that is not a path.
+	ErrStageSortByCountInvalidPath = ErrorCode(40148) // Location40148
+
+	// ErrStageSortByCountInvalidType indicates that $sortByCount stage contains a value of invalid type.
+	ErrStageSortByCountInvalidType = ErrorCode(40149) // Location40149
+
 	// ErrStageCountNonString indicates that $count aggregation stage expected string.
 	ErrStageCountNonString = ErrorCode(40156) // Location40156
 
@@ -256,6 +725,57 @@ const (
 	// ErrStageCountBadValue indicates that $count stage contains invalid value.
 	ErrStageCountBadValue = ErrorCode(40160) // Location40160
 
+	// ErrStageGraphLookupInvalidMatch indicates that $graphLookup restrictSearchWithMatch is not an object.
+	ErrStageGraphLookupInvalidMatch = ErrorCode(40185) // Location40185
+
+	// ErrStageReplaceRootNotDocument indicates that $replaceRoot or $replaceWith expression is not evaluated to a document.
+	ErrStageReplaceRootNotDocument = ErrorCode(40228) // Location40228
+
+	// ErrStageBucketBoundariesNotConstant indicates that $bucket boundaries contain non-constant value.
+	ErrStageBucketBoundariesNotConstant = ErrorCode(40191) // Location40191
+
+	// ErrStageBucketBoundariesTooFew indicates that $bucket boundaries contain less than two values.
+	ErrStageBucketBoundariesTooFew = ErrorCode(40192) // Location40192
+
+	// ErrStageBucketBoundariesMixedTypes indicates that $bucket boundaries have different types.
+	ErrStageBucketBoundariesMixedTypes = ErrorCode(40193) // Location40193
+
+	// ErrStageBucketBoundariesNotSorted indicates that $bucket boundaries are not sorted in ascending order.
+	ErrStageBucketBoundariesNotSorted = ErrorCode(40194) // Location40194
+
+	// ErrStageBucketDefaultNotConstant indicates that $bucket default is not a constant value.
+	ErrStageBucketDefaultNotConstant = ErrorCode(40195) // Location40195
+
+	// ErrStageBucketInvalidOutput indicates that $bucket output is not an object.
+	ErrStageBucketInvalidOutput = ErrorCode(40196) // Location40196
+
+	// ErrStageBucketUnknownOption indicates that $bucket stage contains unknown option.
+	ErrStageBucketUnknownOption = ErrorCode(40197) // Location40197
+
+	// ErrStageBucketMissingRequired indicates that $bucket groupBy or boundaries are missing.
+	ErrStageBucketMissingRequired = ErrorCode(40198) // Location40198
+
+	// ErrStageBucketDefaultInRange indicates that $bucket default falls within boundaries.
+	ErrStageBucketDefaultInRange = ErrorCode(40199) // Location40199
+
+	// ErrStageBucketBoundariesNotArray indicates that $bucket boundaries is not an array.
+	ErrStageBucketBoundariesNotArray = ErrorCode(40200) // Location40200
+
+	// ErrStageBucketInvalidSpec indicates that $bucket stage argument is not an object.
+	ErrStageBucketInvalidSpec = ErrorCode(40201) // Location40201
+
+	// ErrStageBucketInvalidGroupBy indicates that $bucket groupBy is not a path or an expression.
+	ErrStageBucketInvalidGroupBy = ErrorCode(40202) // Location40202
+
+	// ErrStageFacetInvalidSpec indicates that $facet stage specification is not a non-empty object.
+	ErrStageFacetInvalidSpec = ErrorCode(40169) // Location40169
+
+	// ErrStageFacetInvalidArgs indicates that $facet stage argument is not an array.
+	ErrStageFacetInvalidArgs = ErrorCode(40170) // Location40170
+
+	// ErrStageFacetInvalidSubPipeline indicates that $facet sub-pipeline contains an element that is not an object.
+	ErrStageFacetInvalidSubPipeline = ErrorCode(40171) // Location40171
+
 	// ErrAddFieldsExpressionWrongAmountOfArgs indicates that $addFields stage expression contain invalid
 	// amount of arguments.
 	ErrAddFieldsExpressionWrongAmountOfArgs = ErrorCode(40181) // Location40181
@@ -269,6 +789,45 @@ const (
 	// ErrStageGroupInvalidAccumulator indicates invalid accumulator field.
 	ErrStageGroupInvalidAccumulator = ErrorCode(40234) // Location40234
 
+	// ErrStageBucketAutoInvalidGroupBy indicates that $bucketAuto groupBy is not a path or an expression.
+	ErrStageBucketAutoInvalidGroupBy = ErrorCode(40239) // Location40239
+
+	// ErrStageBucketAutoInvalidSpec indicates that $bucketAuto stage argument is not an object.
+	ErrStageBucketAutoInvalidSpec = ErrorCode(40240) // Location40240
+
+	// ErrStageBucketAutoBucketsNotNumeric indicates that $bucketAuto buckets is not a number.
+	ErrStageBucketAutoBucketsNotNumeric = ErrorCode(40241) // Location40241
+
+	// ErrStageBucketAutoBucketsNotInt32 indicates that $bucketAuto buckets is not representable as int32.
+	ErrStageBucketAutoBucketsNotInt32 = ErrorCode(40242) // Location40242
+
+	// ErrStageBucketAutoBucketsNotPositive indicates that $bucketAuto buckets is not positive.
+	ErrStageBucketAutoBucketsNotPositive = ErrorCode(40243) // Location40243
+
+	// ErrStageBucketAutoInvalidOutput indicates that $bucketAuto output is not an object.
+	ErrStageBucketAutoInvalidOutput = ErrorCode(40244) // Location40244
+
+	// ErrStageBucketAutoUnknownOption indicates that $bucketAuto stage contains unknown option.
+	ErrStageBucketAutoUnknownOption = ErrorCode(40245) // Location40245
+
+	// ErrStageBucketAutoMissingRequired indicates that $bucketAuto groupBy or buckets are missing.
+	ErrStageBucketAutoMissingRequired = ErrorCode(40246) // Location40246
+
+	// ErrStageBucketAutoUnknownGranularity indicates that $bucketAuto granularity is unknown.
+	ErrStageBucketAutoUnknownGranularity = ErrorCode(40257) // Location40257
+
+	// ErrStageBucketAutoGranularityNonNumeric indicates that $bucketAuto granularity is used with non-numeric value.
+	ErrStageBucketAutoGranularityNonNumeric = ErrorCode(40258) // Location40258
+
+	// ErrStageBucketAutoGranularityNaN indicates that $bucketAuto granularity is used with NaN value.
+	ErrStageBucketAutoGranularityNaN = ErrorCode(40259) // Location40259
+
+	// ErrStageBucketAutoGranularityNegative indicates that $bucketAuto granularity is used with negative value.
+	ErrStageBucketAutoGranularityNegative = ErrorCode(40260) // Location40260
+
+	// ErrStageBucketAutoGranularityNotString indicates that $bucketAuto granularity is not a string.
+	ErrStageBucketAutoGranularityNotString = ErrorCode(40261) // Location40261
+
 	// ErrStageInvalid indicates invalid aggregation pipeline stage.
 	ErrStageInvalid = ErrorCode(40323) // Location40323
 
@@ -278,40 +837,226 @@ const (
 	// ErrInvalidFieldPath indicates that the field path is not valid.
 	ErrInvalidFieldPath = ErrorCode(40353) // Location40353
 
+	// ErrArrayToObjectNotArray indicates that the argument of $arrayToObject is not an array.
+	ErrArrayToObjectNotArray = ErrorCode(40386) // Location40386
+
+	// ErrObjectToArrayNotDocument indicates that the argument of $objectToArray is not a document.
+	ErrObjectToArrayNotDocument = ErrorCode(40390) // Location40390
+
+	// ErrArrayToObjectExpectedDocument indicates that $arrayToObject expects all elements to be documents.
+	ErrArrayToObjectExpectedDocument = ErrorCode(40391) // Location40391
+
+	// ErrArrayToObjectInvalidKeys indicates that $arrayToObject document element does not have exactly two keys.
+	ErrArrayToObjectInvalidKeys = ErrorCode(40392) // Location40392
+
+	// ErrArrayToObjectMissingKeys indicates that $arrayToObject document element does not have k and v keys.
+	ErrArrayToObjectMissingKeys = ErrorCode(40393) // Location40393
+
+	// ErrArrayToObjectKeyNotString indicates that $arrayToObject document element key is not a string.
+	ErrArrayToObjectKeyNotString = ErrorCode(40394) // Location40394
+
+	// ErrArrayToObjectPairKeyNotString indicates that $arrayToObject pair key is not a string.
+	ErrArrayToObjectPairKeyNotString = ErrorCode(40395) // Location40395
+
+	// ErrArrayToObjectExpectedArray indicates that $arrayToObject expects all elements to be arrays.
+	ErrArrayToObjectExpectedArray = ErrorCode(40396) // Location40396
+
+	// ErrArrayToObjectInvalidPair indicates that $arrayToObject array element does not have exactly two elements.
+	ErrArrayToObjectInvalidPair = ErrorCode(40397) // Location40397
+
+	// ErrArrayToObjectInvalidElement indicates that $arrayToObject element is neither an array nor a document.
+	ErrArrayToObjectInvalidElement = ErrorCode(40398) // Location40398
+
+	// ErrMergeObjectsNotDocument indicates that an argument of $mergeObjects is not a document.
+	ErrMergeObjectsNotDocument = ErrorCode(40400) // Location40400
+
 	// ErrMissingField indicates that the required field in document is missing.
 	ErrMissingField = ErrorCode(40414) // Location40414
 
 	// ErrFailedToParseInput indicates invalid input (absent or malformed fields).
 	ErrFailedToParseInput = ErrorCode(40415) // Location40415
 
+	// ErrTimezoneUnrecognized indicates that the timezone is not recognized.
+	ErrTimezoneUnrecognized = ErrorCode(40485) // Location40485
+
+	// ErrDateFromPartsMixedDates indicates that $dateFromParts mixes natural and ISO dates.
+	ErrDateFromPartsMixedDates = ErrorCode(40489) // Location40489
+
+	// ErrDateFromPartsNotInteger indicates that $dateFromParts value is not an integer.
+	ErrDateFromPartsNotInteger = ErrorCode(40515) // Location40515
+
+	// ErrDateFromPartsMissingYear indicates that $dateFromParts has neither year nor isoWeekYear argument.
+	ErrDateFromPartsMissingYear = ErrorCode(40516) // Location40516
+
+	// ErrTimezoneNotString indicates that the timezone is not a string.
+	ErrTimezoneNotString = ErrorCode(40517) // Location40517
+
+	// ErrDateFromPartsUnknownArgument indicates that $dateFromParts has an unknown argument.
+	ErrDateFromPartsUnknownArgument = ErrorCode(40518) // Location40518
+
+	// ErrDateFromPartsInvalidSpec indicates that $dateFromParts argument is not a document.
+	ErrDateFromPartsInvalidSpec = ErrorCode(40519) // Location40519
+
+	// ErrDateToPartsUnknownArgument indicates that $dateToParts has an unknown argument.
+	ErrDateToPartsUnknownArgument = ErrorCode(40520) // Location40520
+
+	// ErrDateToPartsIso8601NotBool indicates that $dateToParts iso8601 is not a boolean.
+	ErrDateToPartsIso8601NotBool = ErrorCode(40521) // Location40521
+
+	// ErrDateToPartsMissingDate indicates that $dateToParts has no date argument.
+	ErrDateToPartsMissingDate = ErrorCode(40522) // Location40522
+
+	// ErrDateFromPartsYearOutOfRange indicates that $dateFromParts year is outside of 1-9999 range.
+	ErrDateFromPartsYearOutOfRange = ErrorCode(40523) // Location40523
+
+	// ErrDateToPartsInvalidSpec indicates that $dateToParts argument is not a document.
+	ErrDateToPartsInvalidSpec = ErrorCode(40524) // Location40524
+
+	// ErrDateOperatorUnknownArgument indicates that date operator such as $year has an unknown argument.
+	ErrDateOperatorUnknownArgument = ErrorCode(40535) // Location40535
+
+	// ErrDateOperatorInvalidArrayLen indicates that date operator such as $year has more than one argument.
+	ErrDateOperatorInvalidArrayLen = ErrorCode(40536) // Location40536
+
+	// ErrDateOperatorMissingDate indicates that date operator such as $year has no date argument.
+	ErrDateOperatorMissingDate = ErrorCode(40539) // Location40539
+
+	// ErrDateFromStringInvalidSpec indicates that $dateFromString argument is not a document.
+	ErrDateFromStringInvalidSpec = ErrorCode(40540) // Location40540
+
+	// ErrDateFromStringUnknownArgument indicates that $dateFromString has an unknown argument.
+	ErrDateFromStringUnknownArgument = ErrorCode(40541) // Location40541
+
+	// ErrDateFromStringMissingDateString indicates that $dateFromString has no dateString argument.
+	ErrDateFromStringMissingDateString = ErrorCode(40542) // Location40542
+
+	// ErrDateFromStringTimezoneConflict indicates that $dateFromString date string and timezone argument both contain timezone.
+	ErrDateFromStringTimezoneConflict = ErrorCode(40554) // Location40554
+
+	// ErrStageFacetNotAllowedStage indicates that the stage is not allowed within $facet stage.
+	ErrStageFacetNotAllowedStage = ErrorCode(40600) // Location40600
+
+	// ErrStageNotLast indicates that the stage, such as $out, must be the last stage in the pipeline.
+	ErrStageNotLast = ErrorCode(40601) // Location40601
+
 	// ErrCollStatsIsNotFirstStage indicates that $collStats must be the first stage in the pipeline.
 	ErrCollStatsIsNotFirstStage = ErrorCode(40602) // Location40602
 
+	// ErrDateFromStringFormatNotString indicates that $dateFromString format is not a string.
+	ErrDateFromStringFormatNotString = ErrorCode(40684) // Location40684
+
 	// ErrSetEmptyPassword indicates that a password must not be empty.
 	ErrSetEmptyPassword = ErrorCode(50687) // Location50687
 
 	// ErrStringProhibited indicates that a password contains prohibited runes.
 	ErrStringProhibited = ErrorCode(50692) // Location50692
 
+	// ErrTrimUnknownArgument indicates that $trim, $ltrim or $rtrim has an unknown argument.
+	ErrTrimUnknownArgument = ErrorCode(50694) // Location50694
+
+	// ErrTrimMissingInput indicates that $trim, $ltrim or $rtrim has no input argument.
+	ErrTrimMissingInput = ErrorCode(50695) // Location50695
+
+	// ErrTrimInvalidSpec indicates that $trim, $ltrim or $rtrim argument is not a document.
+	ErrTrimInvalidSpec = ErrorCode(50696) // Location50696
+
+	// ErrTrimInputNotString indicates that $trim, $ltrim or $rtrim input is not a string.
+	ErrTrimInputNotString = ErrorCode(50699) // Location50699
+
+	// ErrTrimCharsNotString indicates that $trim, $ltrim or $rtrim chars is not a string.
+	ErrTrimCharsNotString = ErrorCode(50700) // Location50700
+
 	// ErrFreeMonitoringDisabled indicates that free monitoring is disabled
 	// by command-line or config file.
 	ErrFreeMonitoringDisabled = ErrorCode(50840) // Location50840
 
+	// ErrTrigonometricOutOfBounds indicates that the argument of a trigonometric expression such as $acos is out of bounds.
+	ErrTrigonometricOutOfBounds = ErrorCode(50989) // Location50989
+
 	// ErrUserAlreadyExists indicates that user already exists.
 	ErrUserAlreadyExists = ErrorCode(51003) // Location51003
 
 	// ErrValueNegative indicates that value must not be negative.
 	ErrValueNegative = ErrorCode(51024) // Location51024
 
+	// ErrAtan2YNotNumeric indicates that the first argument of $atan2 is not a number.
+	ErrAtan2YNotNumeric = ErrorCode(51044) // Location51044
+
+	// ErrAtan2XNotNumeric indicates that the second argument of $atan2 is not a number.
+	ErrAtan2XNotNumeric = ErrorCode(51045) // Location51045
+
+	// ErrStageLookupNotAllowedStage indicates that the stage is not allowed within $lookup stage.
+	ErrStageLookupNotAllowedStage = ErrorCode(51047) // Location51047
+
 	// ErrRegexOptions indicates regex options error.
 	ErrRegexOptions = ErrorCode(51075) // Location51075
 
+	// ErrRoundInvalidType indicates that $round or $trunc argument is not a number.
+	ErrRoundInvalidType = ErrorCode(51081) // Location51081
+
+	// ErrRoundPlaceNotIntegral indicates that $round or $trunc precision is not an integral number.
+	ErrRoundPlaceNotIntegral = ErrorCode(51082) // Location51082
+
+	// ErrRoundPlaceOutOfRange indicates that $round or $trunc precision is out of range.
+	ErrRoundPlaceOutOfRange = ErrorCode(51083) // Location51083
+
 	// ErrRegexMissingParen indicates missing parentheses in regex expression.
 	ErrRegexMissingParen = ErrorCode(51091) // Location51091
 
+	// ErrRegexOpNotDocument indicates that the argument of the regular expression operator is not a document.
+	ErrRegexOpNotDocument = ErrorCode(51103) // Location51103
+
+	// ErrRegexOpInputNotString indicates that input of the regular expression operator is not a string.
+	ErrRegexOpInputNotString = ErrorCode(51104) // Location51104
+
+	// ErrRegexOpRegexInvalidType indicates that regex of the regular expression operator is not a string or regex.
+	ErrRegexOpRegexInvalidType = ErrorCode(51105) // Location51105
+
+	// ErrRegexOpOptionsNotString indicates that options of the regular expression operator is not a string.
+	ErrRegexOpOptionsNotString = ErrorCode(51106) // Location51106
+
+	// ErrRegexOpOptionsConflict indicates that options are specified in both regex and options arguments.
+	ErrRegexOpOptionsConflict = ErrorCode(51107) // Location51107
+
 	// ErrBadRegexOption indicates bad regex option value passed.
 	ErrBadRegexOption = ErrorCode(51108) // Location51108
 
+	// ErrRegexOpNullByte indicates that regex of the regular expression operator contains a null byte.
+	ErrRegexOpNullByte = ErrorCode(51109) // Location51109
+
+	// ErrRegexOpOptionsNullByte indicates that options of the regular expression operator contains a null byte.
+	ErrRegexOpOptionsNullByte = ErrorCode(51110) // Location51110
+
+	// ErrRegexOpInvalid indicates that regex of the regular expression operator is invalid.
+	ErrRegexOpInvalid = ErrorCode(51111) // Location51111
+
+	// ErrStageMergeInvalidOnValue indicates that $merge stage 'on' field value is missing, null, undefined or an array.
+	ErrStageMergeInvalidOnValue = ErrorCode(51132) // Location51132
+
+	// ErrStageMergeOnNotString indicates that $merge stage 'on' array contains a non-string element.
+	ErrStageMergeOnNotString = ErrorCode(51134) // Location51134
+
+	// ErrStageMergeInvalidInto indicates that $merge stage 'into' field is not a string or an object.
+	ErrStageMergeInvalidInto = ErrorCode(51178) // Location51178
+
+	// ErrStageMergeInvalidArg indicates that $merge stage argument is not a string or an object.
+	ErrStageMergeInvalidArg = ErrorCode(51182) // Location51182
+
+	// ErrStageMergeOnNotUnique indicates that $merge stage 'on' fields have no unique index.
+	ErrStageMergeOnNotUnique = ErrorCode(51183) // Location51183
+
+	// ErrStageMergeInvalidOn indicates that $merge stage 'on' field is not a string or an array.
+	ErrStageMergeInvalidOn = ErrorCode(51186) // Location51186
+
+	// ErrStageMergeOnEmpty indicates that $merge stage 'on' array is empty.
+	ErrStageMergeOnEmpty = ErrorCode(51187) // Location51187
+
+	// ErrStageMergeInvalidWhenMatched indicates that $merge stage 'whenMatched' field is not a string or an array.
+	ErrStageMergeInvalidWhenMatched = ErrorCode(51191) // Location51191
+
+	// ErrStageMergeLetNotAllowed indicates that $merge stage 'let' is used without a 'whenMatched' pipeline.
+	ErrStageMergeLetNotAllowed = ErrorCode(51199) // Location51199
+
 	// ErrBadPositionalProjection indicates that positional operator could not find a matching element in the array.
 	ErrBadPositionalProjection = ErrorCode(51246) // Location51246
 
@@ -324,21 +1069,263 @@ const (
 	// ErrEmptyProject indicates that projection specification must have at least one field.
 	ErrEmptyProject = ErrorCode(51272) // Location51272
 
+	// ErrBinarySizeInvalidType indicates that the argument of $binarySize is not a string or binary data.
+	ErrBinarySizeInvalidType = ErrorCode(51276) // Location51276
+
+	// ErrReplaceReplacementNotString indicates that $replaceOne or $replaceAll replacement is not a string.
+	ErrReplaceReplacementNotString = ErrorCode(51744) // Location51744
+
+	// ErrReplaceFindNotString indicates that $replaceOne or $replaceAll find is not a string.
+	ErrReplaceFindNotString = ErrorCode(51745) // Location51745
+
+	// ErrReplaceInputNotString indicates that $replaceOne or $replaceAll input is not a string.
+	ErrReplaceInputNotString = ErrorCode(51746) // Location51746
+
+	// ErrReplaceMissingReplacement indicates that $replaceOne or $replaceAll has no replacement argument.
+	ErrReplaceMissingReplacement = ErrorCode(51747) // Location51747
+
+	// ErrReplaceMissingFind indicates that $replaceOne or $replaceAll has no find argument.
+	ErrReplaceMissingFind = ErrorCode(51748) // Location51748
+
+	// ErrReplaceMissingInput indicates that $replaceOne or $replaceAll has no input argument.
+	ErrReplaceMissingInput = ErrorCode(51749) // Location51749
+
+	// ErrReplaceUnknownArgument indicates that $replaceOne or $replaceAll has an unknown argument.
+	ErrReplaceUnknownArgument = ErrorCode(51750) // Location51750
+
+	// ErrReplaceInvalidSpec indicates that $replaceOne or $replaceAll argument is not a document.
+	ErrReplaceInvalidSpec = ErrorCode(51751) // Location51751
+
+	// ErrFilterLimitNotInt32 indicates that $filter limit is not representable as 32-bit integer.
+	ErrFilterLimitNotInt32 = ErrorCode(327391) // Location327391
+
+	// ErrFilterLimitNotPositive indicates that $filter limit is not positive.
+	ErrFilterLimitNotPositive = ErrorCode(327392) // Location327392
+
+	// ErrIfNullArgsInvalidLen indicates that $ifNull has less than two arguments.
+	ErrIfNullArgsInvalidLen = ErrorCode(1257300) // Location1257300
+
+	// ErrSortArrayInvalidSpec indicates that the argument of $sortArray is not a document.
+	ErrSortArrayInvalidSpec = ErrorCode(2942500) // Location2942500
+
+	// ErrSortArrayUnknownArgument indicates that $sortArray has an unknown argument.
+	ErrSortArrayUnknownArgument = ErrorCode(2942501) // Location2942501
+
+	// ErrSortArrayMissingInput indicates that $sortArray input argument is missing.
+	ErrSortArrayMissingInput = ErrorCode(2942502) // Location2942502
+
+	// ErrSortArrayMissingSortBy indicates that $sortArray sortBy argument is missing.
+	ErrSortArrayMissingSortBy = ErrorCode(2942503) // Location2942503
+
+	// ErrSortArrayInputNotArray indicates that $sortArray input is not an array.
+	ErrSortArrayInputNotArray = ErrorCode(2942504) // Location2942504
+
+	// ErrSortArrayInvalidSortBy indicates that $sortArray sortBy is not 1, -1 or a document.
+	ErrSortArrayInvalidSortBy = ErrorCode(2942505) // Location2942505
+
+	// ErrRandInvalidArgument indicates that $rand has arguments.
+	ErrRandInvalidArgument = ErrorCode(3040500) // Location3040500
+
+	// ErrGetFieldUnknownArgument indicates that $getField has an unknown argument.
+	ErrGetFieldUnknownArgument = ErrorCode(3041701) // Location3041701
+
+	// ErrGetFieldMissingField indicates that $getField field argument is missing.
+	ErrGetFieldMissingField = ErrorCode(3041702) // Location3041702
+
+	// ErrStageFacetOutputTooLarge indicates that document constructed by $facet stage is too large.
+	ErrStageFacetOutputTooLarge = ErrorCode(4031700) // Location4031700
+
+	// ErrSetFieldNotDocument indicates that the argument of $setField or $unsetField is not a document.
+	ErrSetFieldNotDocument = ErrorCode(4161100) // Location4161100
+
+	// ErrSetFieldUnknownArgument indicates that $setField or $unsetField has an unknown argument.
+	ErrSetFieldUnknownArgument = ErrorCode(4161101) // Location4161101
+
+	// ErrSetFieldMissingField indicates that $setField or $unsetField field argument is missing.
+	ErrSetFieldMissingField = ErrorCode(4161102) // Location4161102
+
+	// ErrSetFieldMissingValue indicates that $setField value argument is missing.
+	ErrSetFieldMissingValue = ErrorCode(4161103) // Location4161103
+
+	// ErrSetFieldInputNotDocument indicates that $setField or $unsetField input is not a document.
+	ErrSetFieldInputNotDocument = ErrorCode(4161105) // Location4161105
+
+	// ErrSetFieldFieldNotString indicates that $setField or $unsetField field argument is not a string.
+	ErrSetFieldFieldNotString = ErrorCode(4161106) // Location4161106
+
+	// ErrSetFieldFieldNotConstant indicates that $setField or $unsetField field argument is not a constant.
+	ErrSetFieldFieldNotConstant = ErrorCode(4161107) // Location4161107
+
+	// ErrSetFieldFieldPath indicates that $setField or $unsetField field argument is a field path.
+	ErrSetFieldFieldPath = ErrorCode(4161108) // Location4161108
+
+	// ErrSetFieldMissingInput indicates that $setField or $unsetField input argument is missing.
+	ErrSetFieldMissingInput = ErrorCode(4161109) // Location4161109
+
 	// ErrDuplicateField indicates duplicate field is specified.
 	ErrDuplicateField = ErrorCode(4822819) // Location4822819
 
+	// ErrLetFieldPath indicates that command let option accesses document fields.
+	ErrLetFieldPath = ErrorCode(4890500) // Location4890500
+
 	// ErrStageSkipBadValue indicates that $skip stage contains invalid value.
 	ErrStageSkipBadValue = ErrorCode(5107200) // Location5107200
 
 	// ErrStageLimitInvalidArg indicates invalid argument for the aggregation $limit stage.
 	ErrStageLimitInvalidArg = ErrorCode(5107201) // Location5107201
 
+	// ErrDateAddInvalidSpec indicates that $dateAdd or $dateSubtract argument is not a document.
+	ErrDateAddInvalidSpec = ErrorCode(5166400) // Location5166400
+
+	// ErrDateAddUnknownArgument indicates that $dateAdd or $dateSubtract has an unknown argument.
+	ErrDateAddUnknownArgument = ErrorCode(5166401) // Location5166401
+
+	// ErrDateAddMissingArgument indicates that $dateAdd or $dateSubtract has no required argument.
+	ErrDateAddMissingArgument = ErrorCode(5166402) // Location5166402
+
+	// ErrDateAddStartDateInvalid indicates that $dateAdd or $dateSubtract startDate is not a date.
+	ErrDateAddStartDateInvalid = ErrorCode(5166403) // Location5166403
+
+	// ErrDateAddAmountNotInteger indicates that $dateAdd or $dateSubtract amount is not an integer.
+	ErrDateAddAmountNotInteger = ErrorCode(5166404) // Location5166404
+
+	// ErrDateAddOverflow indicates that $dateAdd or $dateSubtract result overflows.
+	ErrDateAddOverflow = ErrorCode(5166406) // Location5166406
+
+	// ErrStageSetWindowFieldsInvalidBounds indicates that lower bound of $setWindowFields window exceeds upper bound.
+	ErrStageSetWindowFieldsInvalidBounds = ErrorCode(5339900) // Location5339900
+
+	// ErrStageSetWindowFieldsSortBy indicates that $setWindowFields window operator requires sortBy
+	// with exactly one field.
+	ErrStageSetWindowFieldsSortBy = ErrorCode(5371602) // Location5371602
+
+	// ErrStageSetWindowFieldsInvalidRangeValue indicates that sortBy value can't be used
+	// with range-based window or time unit of $setWindowFields.
+	ErrStageSetWindowFieldsInvalidRangeValue = ErrorCode(5429414) // Location5429414
+
+	// ErrDateDiffInvalidSpec indicates that $dateDiff argument is not a document.
+	ErrDateDiffInvalidSpec = ErrorCode(5439001) // Location5439001
+
+	// ErrDateDiffUnknownArgument indicates that $dateDiff has an unknown argument.
+	ErrDateDiffUnknownArgument = ErrorCode(5439002) // Location5439002
+
+	// ErrDateDiffMissingStartDate indicates that $dateDiff has no startDate argument.
+	ErrDateDiffMissingStartDate = ErrorCode(5439003) // Location5439003
+
+	// ErrDateDiffMissingEndDate indicates that $dateDiff has no endDate argument.
+	ErrDateDiffMissingEndDate = ErrorCode(5439004) // Location5439004
+
+	// ErrDateDiffMissingUnit indicates that $dateDiff has no unit argument.
+	ErrDateDiffMissingUnit = ErrorCode(5439005) // Location5439005
+
+	// ErrDateTruncInvalidSpec indicates that $dateTrunc argument is not a document.
+	ErrDateTruncInvalidSpec = ErrorCode(5439007) // Location5439007
+
+	// ErrDateTruncUnknownArgument indicates that $dateTrunc has an unknown argument.
+	ErrDateTruncUnknownArgument = ErrorCode(5439008) // Location5439008
+
+	// ErrDateTruncMissingDate indicates that $dateTrunc has no date argument.
+	ErrDateTruncMissingDate = ErrorCode(5439009) // Location5439009
+
+	// ErrDateTruncMissingUnit indicates that $dateTrunc has no unit argument.
+	ErrDateTruncMissingUnit = ErrorCode(5439010) // Location5439010
+
+	// ErrDateArgumentNotDate indicates that $dateDiff or $dateTrunc date argument is not a date.
+	ErrDateArgumentNotDate = ErrorCode(5439012) // Location5439012
+
+	// ErrTimeUnitNotString indicates that time unit argument is not a string.
+	ErrTimeUnitNotString = ErrorCode(5439013) // Location5439013
+
+	// ErrStartOfWeekNotString indicates that startOfWeek argument is not a string.
+	ErrStartOfWeekNotString = ErrorCode(5439015) // Location5439015
+
+	// ErrStartOfWeekInvalid indicates that startOfWeek argument is not a day of a week.
+	ErrStartOfWeekInvalid = ErrorCode(5439016) // Location5439016
+
+	// ErrDateTruncBinSizeNotInteger indicates that $dateTrunc binSize is not an integer.
+	ErrDateTruncBinSizeNotInteger = ErrorCode(5439017) // Location5439017
+
+	// ErrDateTruncBinSizeNotPositive indicates that $dateTrunc binSize is not positive.
+	ErrDateTruncBinSizeNotPositive = ErrorCode(5439018) // Location5439018
+
 	// ErrStageCollStatsInvalidArg indicates invalid argument for the aggregation $collStats stage.
 	ErrStageCollStatsInvalidArg = ErrorCode(5447000) // Location5447000
 
+	// ErrGetFieldFieldPath indicates that $getField field argument is a field path.
+	ErrGetFieldFieldPath = ErrorCode(5654600) // Location5654600
+
+	// ErrGetFieldFieldNotConstant indicates that $getField field argument is not a constant.
+	ErrGetFieldFieldNotConstant = ErrorCode(5654601) // Location5654601
+
+	// ErrGetFieldFieldNotString indicates that $getField field argument is not a string.
+	ErrGetFieldFieldNotString = ErrorCode(5654602) // Location5654602
+
+	// ErrStageDensifyInvalidValue indicates that $densify field value is not numeric or date.
+	ErrStageDensifyInvalidValue = ErrorCode(5733201) // Location5733201
+
+	// ErrStageDensifyInvalidStep indicates that $densify step is not positive.
+	ErrStageDensifyInvalidStep = ErrorCode(5733401) // Location5733401
+
+	// ErrStageDensifyInvalidBounds indicates that lower bound of $densify range exceeds upper bound.
+	ErrStageDensifyInvalidBounds = ErrorCode(5733402) // Location5733402
+
+	// ErrStageDensifyBoundsLength indicates that $densify bounds array does not contain exactly two elements.
+	ErrStageDensifyBoundsLength = ErrorCode(5733403) // Location5733403
+
+	// ErrStageDensifyPartitionBounds indicates that $densify partition bounds are used without partitionByFields.
+	ErrStageDensifyPartitionBounds = ErrorCode(5733408) // Location5733408
+
 	// ErrOpQueryCollectionSuffixMissing indicates that op query collection does not contain .$cmd suffix.
 	ErrOpQueryCollectionSuffixMissing = ErrorCode(5739101) // Location5739101
 
+	// ErrAccumulatorNNotDocument indicates that the specification of n-accumulator is not a document.
+	ErrAccumulatorNNotDocument = ErrorCode(5787801) // Location5787801
+
+	// ErrAccumulatorNUnknownArgument indicates that n-accumulator has an unknown argument.
+	ErrAccumulatorNUnknownArgument = ErrorCode(5787901) // Location5787901
+
+	// ErrAccumulatorNNotNumeric indicates that n of n-accumulator is not a number.
+	ErrAccumulatorNNotNumeric = ErrorCode(5787902) // Location5787902
+
+	// ErrAccumulatorNNotIntegral indicates that n of n-accumulator is not an integral number.
+	ErrAccumulatorNNotIntegral = ErrorCode(5787903) // Location5787903
+
+	// ErrAccumulatorNMissingN indicates that n-accumulator is missing n.
+	ErrAccumulatorNMissingN = ErrorCode(5787906) // Location5787906
+
+	// ErrAccumulatorNMissingInput indicates that n-accumulator is missing input.
+	ErrAccumulatorNMissingInput = ErrorCode(5787907) // Location5787907
+
+	// ErrAccumulatorNNotPositive indicates that n of n-accumulator is not positive.
+	ErrAccumulatorNNotPositive = ErrorCode(5787908) // Location5787908
+
+	// ErrTopBottomNotDocument indicates that the specification of $top or $bottom accumulator is not a document.
+	ErrTopBottomNotDocument = ErrorCode(5788001) // Location5788001
+
+	// ErrTopBottomUnknownArgument indicates that $top or $bottom accumulator has an unknown argument.
+	ErrTopBottomUnknownArgument = ErrorCode(5788002) // Location5788002
+
+	// ErrTopBottomMissingN indicates that $topN or $bottomN accumulator is missing n.
+	ErrTopBottomMissingN = ErrorCode(5788003) // Location5788003
+
+	// ErrTopBottomMissingOutput indicates that $top or $bottom accumulator is missing output.
+	ErrTopBottomMissingOutput = ErrorCode(5788004) // Location5788004
+
+	// ErrTopBottomMissingSortBy indicates that $top or $bottom accumulator is missing sortBy.
+	ErrTopBottomMissingSortBy = ErrorCode(5788005) // Location5788005
+
+	// ErrStageDocumentsNotArray indicates that $documents expression is not evaluated to an array.
+	ErrStageDocumentsNotArray = ErrorCode(5858203) // Location5858203
+
+	// ErrStageDensifyInvalidBoundsString indicates that $densify bounds string is not "full" or "partition".
+	ErrStageDensifyInvalidBoundsString = ErrorCode(5946802) // Location5946802
+
+	// ErrStageFillPartitionByConflict indicates that both partitionBy and partitionByFields are set in $fill.
+	ErrStageFillPartitionByConflict = ErrorCode(6050204) // Location6050204
+
+	// ErrStageDensifyStepNotInteger indicates that $densify step is not a whole number for dates.
+	ErrStageDensifyStepNotInteger = ErrorCode(6586400) // Location6586400
+
 	// ErrStageIndexedStringVectorDuplicate indicates that input to IndexedStringVector contained duplicate values.
 	ErrStageIndexedStringVectorDuplicate = ErrorCode(7582300) // Location7582300
 )
@@ -40,11 +40,138 @@ func _() {
 	_ = x[ErrInvalidIndexSpecificationOption-197]
 	_ = x[ErrInvalidPipelineOperator-168]
 	_ = x[ErrClientMetadataCannotBeMutated-186]
+	_ = x[ErrQueryFeatureNotAllowed-224]
 	_ = x[ErrNotImplemented-238]
+	_ = x[ErrConversionFailure-241]
+	_ = x[ErrQueryExceededMemoryLimitNoDiskUseAllowed-292]
 	_ = x[ErrMechanismUnavailable-334]
 	_ = x[ErrUnsupportedOpQueryCommand-352]
 	_ = x[ErrIndexesWrongType-10065]
 	_ = x[ErrDuplicateKeyInsert-11000]
+	_ = x[ErrStageMergeNoMatch-13113]
+	_ = x[ErrDateConversion-16006]
+	_ = x[ErrStringConversion-16007]
+	_ = x[ErrSubstrBytesStartNotNumeric-16034]
+	_ = x[ErrSubstrBytesLengthNotNumeric-16035]
+	_ = x[ErrMultiplyInvalidType-16555]
+	_ = x[ErrSubtractInvalidType-16556]
+	_ = x[ErrDivideByZero-16608]
+	_ = x[ErrDivideInvalidType-16609]
+	_ = x[ErrModByZero-16610]
+	_ = x[ErrModInvalidType-16611]
+	_ = x[ErrAddMultipleDates-16612]
+	_ = x[ErrConcatInvalidType-16702]
+	_ = x[ErrLetInvalidSpec-16874]
+	_ = x[ErrLetUnknownParameter-16875]
+	_ = x[ErrLetMissingVars-16876]
+	_ = x[ErrLetMissingIn-16877]
+	_ = x[ErrMapInvalidSpec-16878]
+	_ = x[ErrMapUnknownParameter-16879]
+	_ = x[ErrMapMissingInput-16880]
+	_ = x[ErrMapMissingIn-16882]
+	_ = x[ErrMapInputNotArray-16883]
+	_ = x[ErrAllElementsTrueNotArray-17040]
+	_ = x[ErrAnyElementTrueNotArray-17041]
+	_ = x[ErrSetIsSubsetSecondNotArray-17042]
+	_ = x[ErrSetUnionNotArray-17043]
+	_ = x[ErrSetEqualsNotArray-17044]
+	_ = x[ErrSetEqualsArgsInvalidLen-17045]
+	_ = x[ErrSetIsSubsetFirstNotArray-17046]
+	_ = x[ErrSetIntersectionNotArray-17047]
+	_ = x[ErrSetDifferenceFirstNotArray-17048]
+	_ = x[ErrSetDifferenceSecondNotArray-17049]
+	_ = x[ErrSizeNotArray-17124]
+	_ = x[ErrDateToStringFormatNotString-18533]
+	_ = x[ErrDateToStringUnknownArgument-18534]
+	_ = x[ErrDateFormatUnmatchedPercent-18535]
+	_ = x[ErrDateFormatInvalidCharacter-18536]
+	_ = x[ErrDateToStringYearOutOfRange-18537]
+	_ = x[ErrDateToStringMissingDate-18628]
+	_ = x[ErrDateToStringInvalidSpec-18629]
+	_ = x[ErrFilterInvalidSpec-28646]
+	_ = x[ErrFilterUnknownParameter-28647]
+	_ = x[ErrFilterMissingInput-28648]
+	_ = x[ErrFilterMissingCond-28650]
+	_ = x[ErrFilterInputNotArray-28651]
+	_ = x[ErrSubstrBytesStartContinuation-28656]
+	_ = x[ErrSubstrBytesEndContinuation-28657]
+	_ = x[ErrConcatArraysNotArray-28664]
+	_ = x[ErrAbsLongMin-28680]
+	_ = x[ErrArrayElemAtArrayNotArray-28689]
+	_ = x[ErrArrayElemAtIndexNotNumber-28690]
+	_ = x[ErrArrayElemAtIndexNotInt32-28691]
+	_ = x[ErrSqrtNegative-28714]
+	_ = x[ErrSlicePositionNotNumber-28725]
+	_ = x[ErrSlicePositionNotInt32-28726]
+	_ = x[ErrSliceLengthNotNumber-28727]
+	_ = x[ErrSliceLengthNotInt32-28728]
+	_ = x[ErrSliceLengthNotPositive-28729]
+	_ = x[ErrLogArgNotNumeric-28756]
+	_ = x[ErrLogBaseNotNumeric-28757]
+	_ = x[ErrLogArgNotPositive-28758]
+	_ = x[ErrLogBaseInvalid-28759]
+	_ = x[ErrLog10NotPositive-28761]
+	_ = x[ErrPowBaseNotNumeric-28762]
+	_ = x[ErrPowExponentNotNumeric-28763]
+	_ = x[ErrPowZeroNegativeExponent-28764]
+	_ = x[ErrExpressionNotNumeric-28765]
+	_ = x[ErrLnNotPositive-28766]
+	_ = x[ErrRegexOpMissingInput-31022]
+	_ = x[ErrRegexOpMissingRegex-31023]
+	_ = x[ErrRegexOpUnknownArgument-31024]
+	_ = x[ErrDateFromPartsOutOfRange-31034]
+	_ = x[ErrDateFromPartsIsoWeekYearOutOfRange-31095]
+	_ = x[ErrBSONSizeNotDocument-31393]
+	_ = x[ErrReverseArrayNotArray-34435]
+	_ = x[ErrRangeStartNotNumber-34443]
+	_ = x[ErrRangeStartNotInt32-34444]
+	_ = x[ErrRangeEndNotNumber-34445]
+	_ = x[ErrRangeEndNotInt32-34446]
+	_ = x[ErrRangeStepNotNumber-34447]
+	_ = x[ErrRangeStepNotInt32-34448]
+	_ = x[ErrRangeStepZero-34449]
+	_ = x[ErrSubstrCPStartNotNumeric-34450]
+	_ = x[ErrSubstrCPStartNotInt32-34451]
+	_ = x[ErrSubstrCPLengthNotNumeric-34452]
+	_ = x[ErrSubstrCPLengthNotInt32-34453]
+	_ = x[ErrSubstrCPLengthNegative-34454]
+	_ = x[ErrSubstrCPStartNegative-34455]
+	_ = x[ErrZipInvalidSpec-34460]
+	_ = x[ErrZipInputsNotArray-34461]
+	_ = x[ErrZipDefaultsNotArray-34462]
+	_ = x[ErrZipUseLongestLengthNotBool-34463]
+	_ = x[ErrZipUnknownArgument-34464]
+	_ = x[ErrZipMissingInputs-34465]
+	_ = x[ErrZipDefaultsWithoutLongestLength-34466]
+	_ = x[ErrZipDefaultsLengthMismatch-34467]
+	_ = x[ErrZipInputNotArray-34468]
+	_ = x[ErrStrLenCPInvalidType-34471]
+	_ = x[ErrStrLenBytesInvalidType-34473]
+	_ = x[ErrSwitchInvalidSpec-40060]
+	_ = x[ErrSwitchBranchesNotArray-40061]
+	_ = x[ErrSwitchBranchNotDocument-40062]
+	_ = x[ErrSwitchBranchUnknownArgument-40063]
+	_ = x[ErrSwitchBranchMissingCase-40064]
+	_ = x[ErrSwitchBranchMissingThen-40065]
+	_ = x[ErrSwitchUnknownArgument-40067]
+	_ = x[ErrSwitchMissingBranches-40068]
+	_ = x[ErrReduceInvalidSpec-40075]
+	_ = x[ErrReduceUnknownArgument-40076]
+	_ = x[ErrReduceMissingInput-40077]
+	_ = x[ErrReduceMissingInitialValue-40078]
+	_ = x[ErrReduceMissingIn-40079]
+	_ = x[ErrReduceInputNotArray-40080]
+	_ = x[ErrInArrayNotArray-40081]
+	_ = x[ErrSplitInputNotString-40085]
+	_ = x[ErrSplitDelimiterNotString-40086]
+	_ = x[ErrSplitEmptyDelimiter-40087]
+	_ = x[ErrIndexOfArrayNotArray-40090]
+	_ = x[ErrIndexOfBytesInputNotString-40091]
+	_ = x[ErrIndexOfBytesSubstringNotString-40092]
+	_ = x[ErrIndexOfCPInputNotString-40093]
+	_ = x[ErrIndexOfCPSubstringNotString-40094]
+	_ = x[ErrIndexOfIndexNotIntegral-40096]
+	_ = x[ErrIndexOfIndexNegative-40097]
 	_ = x[ErrSetBadExpression-40272]
 	_ = x[ErrStageGroupInvalidFields-15947]
 	_ = x[ErrStageGroupID-15948]
@@ -62,13 +189,30 @@ func _() {
 	_ = x[ErrPathContainsEmptyElement-15998]
 	_ = x[ErrOperatorWrongLenOfArgs-16020]
 	_ = x[ErrFieldPathInvalidName-16410]
+	_ = x[ErrFieldPathContainsDot-16412]
 	_ = x[ErrGroupInvalidFieldPath-16872]
-	_ = x[ErrGroupUndefinedVariable-17276]
+	_ = x[ErrStageOutInvalidArg-16990]
+	_ = x[ErrStageOutInvalidNamespace-16994]
+	_ = x[ErrStageRedactInvalidReturn-17053]
+	_ = x[ErrCondMissingIf-17080]
+	_ = x[ErrCondMissingThen-17081]
+	_ = x[ErrCondMissingElse-17082]
+	_ = x[ErrCondUnknownParameter-17083]
+	_ = x[ErrStageOutCappedCollection-17152]
+	_ = x[ErrUndefinedVariable-17276]
+	_ = x[ErrStageOutSpecialCollection-17385]
 	_ = x[ErrInvalidArg-28667]
 	_ = x[ErrSliceFirstArg-28724]
+	_ = x[ErrStageSampleInvalidSpec-28745]
+	_ = x[ErrStageSampleSizeNotNumber-28746]
+	_ = x[ErrStageSampleSizeNegative-28747]
+	_ = x[ErrStageSampleUnknownOption-28748]
+	_ = x[ErrStageSampleMissingSize-28749]
 	_ = x[ErrStageUnsetNoPath-31119]
 	_ = x[ErrStageUnsetArrElementInvalidType-31120]
 	_ = x[ErrStageUnsetInvalidType-31002]
+	_ = x[ErrStageMergeSpecialCollection-31319]
+	_ = x[ErrStageIndexStatsInvalidSpec-28803]
 	_ = x[ErrStageUnwindNoPath-28812]
 	_ = x[ErrStageUnwindNoPrefix-28818]
 	_ = x[ErrUnsetPathCollision-31249]
@@ -79,41 +223,225 @@ func _() {
 	_ = x[ErrAggregateInvalidExpression-31325]
 	_ = x[ErrWrongPositionalOperatorLocation-31394]
 	_ = x[ErrExclusionPositionalProjection-31395]
+	_ = x[ErrStageUnionWithNotAllowedStage-31441]
+	_ = x[ErrSwitchNoMatchingBranch-40066]
+	_ = x[ErrStageGraphLookupMaxMemory-40099]
+	_ = x[ErrStageGraphLookupMaxDepthNotNumber-40100]
+	_ = x[ErrStageGraphLookupMaxDepthNegative-40101]
+	_ = x[ErrStageGraphLookupMaxDepthNotInteger-40102]
+	_ = x[ErrStageGraphLookupNotString-40103]
+	_ = x[ErrStageGraphLookupUnknownArgument-40104]
+	_ = x[ErrStageGraphLookupMissingRequired-40105]
+	_ = x[ErrStageSortByCountInvalidObject-40147]
+	_ = x[ErrStageSortByCountInvalidPath-40148]
+	_ = x[ErrStageSortByCountInvalidType-40149]
 	_ = x[ErrStageCountNonString-40156]
 	_ = x[ErrStageCountNonEmptyString-40157]
 	_ = x[ErrStageCountBadPrefix-40158]
 	_ = x[ErrStageCountBadValue-40160]
+	_ = x[ErrStageGraphLookupInvalidMatch-40185]
+	_ = x[ErrStageReplaceRootNotDocument-40228]
+	_ = x[ErrStageBucketBoundariesNotConstant-40191]
+	_ = x[ErrStageBucketBoundariesTooFew-40192]
+	_ = x[ErrStageBucketBoundariesMixedTypes-40193]
+	_ = x[ErrStageBucketBoundariesNotSorted-40194]
+	_ = x[ErrStageBucketDefaultNotConstant-40195]
+	_ = x[ErrStageBucketInvalidOutput-40196]
+	_ = x[ErrStageBucketUnknownOption-40197]
+	_ = x[ErrStageBucketMissingRequired-40198]
+	_ = x[ErrStageBucketDefaultInRange-40199]
+	_ = x[ErrStageBucketBoundariesNotArray-40200]
+	_ = x[ErrStageBucketInvalidSpec-40201]
+	_ = x[ErrStageBucketInvalidGroupBy-40202]
+	_ = x[ErrStageFacetInvalidSpec-40169]
+	_ = x[ErrStageFacetInvalidArgs-40170]
+	_ = x[ErrStageFacetInvalidSubPipeline-40171]
 	_ = x[ErrAddFieldsExpressionWrongAmountOfArgs-40181]
 	_ = x[ErrStageGroupUnaryOperator-40237]
 	_ = x[ErrStageGroupMultipleAccumulator-40238]
 	_ = x[ErrStageGroupInvalidAccumulator-40234]
+	_ = x[ErrStageBucketAutoInvalidGroupBy-40239]
+	_ = x[ErrStageBucketAutoInvalidSpec-40240]
+	_ = x[ErrStageBucketAutoBucketsNotNumeric-40241]
+	_ = x[ErrStageBucketAutoBucketsNotInt32-40242]
+	_ = x[ErrStageBucketAutoBucketsNotPositive-40243]
+	_ = x[ErrStageBucketAutoInvalidOutput-40244]
+	_ = x[ErrStageBucketAutoUnknownOption-40245]
+	_ = x[ErrStageBucketAutoMissingRequired-40246]
+	_ = x[ErrStageBucketAutoUnknownGranularity-40257]
+	_ = x[ErrStageBucketAutoGranularityNonNumeric-40258]
+	_ = x[ErrStageBucketAutoGranularityNaN-40259]
+	_ = x[ErrStageBucketAutoGranularityNegative-40260]
+	_ = x[ErrStageBucketAutoGranularityNotString-40261]
 	_ = x[ErrStageInvalid-40323]
 	_ = x[ErrEmptyFieldPath-40352]
 	_ = x[ErrInvalidFieldPath-40353]
+	_ = x[ErrArrayToObjectNotArray-40386]
+	_ = x[ErrObjectToArrayNotDocument-40390]
+	_ = x[ErrArrayToObjectExpectedDocument-40391]
+	_ = x[ErrArrayToObjectInvalidKeys-40392]
+	_ = x[ErrArrayToObjectMissingKeys-40393]
+	_ = x[ErrArrayToObjectKeyNotString-40394]
+	_ = x[ErrArrayToObjectPairKeyNotString-40395]
+	_ = x[ErrArrayToObjectExpectedArray-40396]
+	_ = x[ErrArrayToObjectInvalidPair-40397]
+	_ = x[ErrArrayToObjectInvalidElement-40398]
+	_ = x[ErrMergeObjectsNotDocument-40400]
 	_ = x[ErrMissingField-40414]
 	_ = x[ErrFailedToParseInput-40415]
+	_ = x[ErrTimezoneUnrecognized-40485]
+	_ = x[ErrDateFromPartsMixedDates-40489]
+	_ = x[ErrDateFromPartsNotInteger-40515]
+	_ = x[ErrDateFromPartsMissingYear-40516]
+	_ = x[ErrTimezoneNotString-40517]
+	_ = x[ErrDateFromPartsUnknownArgument-40518]
+	_ = x[ErrDateFromPartsInvalidSpec-40519]
+	_ = x[ErrDateToPartsUnknownArgument-40520]
+	_ = x[ErrDateToPartsIso8601NotBool-40521]
+	_ = x[ErrDateToPartsMissingDate-40522]
+	_ = x[ErrDateFromPartsYearOutOfRange-40523]
+	_ = x[ErrDateToPartsInvalidSpec-40524]
+	_ = x[ErrDateOperatorUnknownArgument-40535]
+	_ = x[ErrDateOperatorInvalidArrayLen-40536]
+	_ = x[ErrDateOperatorMissingDate-40539]
+	_ = x[ErrDateFromStringInvalidSpec-40540]
+	_ = x[ErrDateFromStringUnknownArgument-40541]
+	_ = x[ErrDateFromStringMissingDateString-40542]
+	_ = x[ErrDateFromStringTimezoneConflict-40554]
+	_ = x[ErrStageFacetNotAllowedStage-40600]
+	_ = x[ErrStageNotLast-40601]
 	_ = x[ErrCollStatsIsNotFirstStage-40602]
+	_ = x[ErrDateFromStringFormatNotString-40684]
 	_ = x[ErrSetEmptyPassword-50687]
 	_ = x[ErrStringProhibited-50692]
+	_ = x[ErrTrimUnknownArgument-50694]
+	_ = x[ErrTrimMissingInput-50695]
+	_ = x[ErrTrimInvalidSpec-50696]
+	_ = x[ErrTrimInputNotString-50699]
+	_ = x[ErrTrimCharsNotString-50700]
 	_ = x[ErrFreeMonitoringDisabled-50840]
+	_ = x[ErrTrigonometricOutOfBounds-50989]
 	_ = x[ErrUserAlreadyExists-51003]
 	_ = x[ErrValueNegative-51024]
+	_ = x[ErrAtan2YNotNumeric-51044]
+	_ = x[ErrAtan2XNotNumeric-51045]
+	_ = x[ErrStageLookupNotAllowedStage-51047]
 	_ = x[ErrRegexOptions-51075]
+	_ = x[ErrRoundInvalidType-51081]
+	_ = x[ErrRoundPlaceNotIntegral-51082]
+	_ = x[ErrRoundPlaceOutOfRange-51083]
 	_ = x[ErrRegexMissingParen-51091]
+	_ = x[ErrRegexOpNotDocument-51103]
+	_ = x[ErrRegexOpInputNotString-51104]
+	_ = x[ErrRegexOpRegexInvalidType-51105]
+	_ = x[ErrRegexOpOptionsNotString-51106]
+	_ = x[ErrRegexOpOptionsConflict-51107]
 	_ = x[ErrBadRegexOption-51108]
+	_ = x[ErrRegexOpNullByte-51109]
+	_ = x[ErrRegexOpOptionsNullByte-51110]
+	_ = x[ErrRegexOpInvalid-51111]
+	_ = x[ErrStageMergeInvalidOnValue-51132]
+	_ = x[ErrStageMergeOnNotString-51134]
+	_ = x[ErrStageMergeInvalidInto-51178]
+	_ = x[ErrStageMergeInvalidArg-51182]
+	_ = x[ErrStageMergeOnNotUnique-51183]
+	_ = x[ErrStageMergeInvalidOn-51186]
+	_ = x[ErrStageMergeOnEmpty-51187]
+	_ = x[ErrStageMergeInvalidWhenMatched-51191]
+	_ = x[ErrStageMergeLetNotAllowed-51199]
 	_ = x[ErrBadPositionalProjection-51246]
 	_ = x[ErrElementMismatchPositionalProjection-51247]
 	_ = x[ErrEmptySubProject-51270]
 	_ = x[ErrEmptyProject-51272]
+	_ = x[ErrBinarySizeInvalidType-51276]
+	_ = x[ErrReplaceReplacementNotString-51744]
+	_ = x[ErrReplaceFindNotString-51745]
+	_ = x[ErrReplaceInputNotString-51746]
+	_ = x[ErrReplaceMissingReplacement-51747]
+	_ = x[ErrReplaceMissingFind-51748]
+	_ = x[ErrReplaceMissingInput-51749]
+	_ = x[ErrReplaceUnknownArgument-51750]
+	_ = x[ErrReplaceInvalidSpec-51751]
+	_ = x[ErrFilterLimitNotInt32-327391]
+	_ = x[ErrFilterLimitNotPositive-327392]
+	_ = x[ErrIfNullArgsInvalidLen-1257300]
+	_ = x[ErrSortArrayInvalidSpec-2942500]
+	_ = x[ErrSortArrayUnknownArgument-2942501]
+	_ = x[ErrSortArrayMissingInput-2942502]
+	_ = x[ErrSortArrayMissingSortBy-2942503]
+	_ = x[ErrSortArrayInputNotArray-2942504]
+	_ = x[ErrSortArrayInvalidSortBy-2942505]
+	_ = x[ErrRandInvalidArgument-3040500]
+	_ = x[ErrGetFieldUnknownArgument-3041701]
+	_ = x[ErrGetFieldMissingField-3041702]
+	_ = x[ErrStageFacetOutputTooLarge-4031700]
+	_ = x[ErrSetFieldNotDocument-4161100]
+	_ = x[ErrSetFieldUnknownArgument-4161101]
+	_ = x[ErrSetFieldMissingField-4161102]
+	_ = x[ErrSetFieldMissingValue-4161103]
+	_ = x[ErrSetFieldInputNotDocument-4161105]
+	_ = x[ErrSetFieldFieldNotString-4161106]
+	_ = x[ErrSetFieldFieldNotConstant-4161107]
+	_ = x[ErrSetFieldFieldPath-4161108]
+	_ = x[ErrSetFieldMissingInput-4161109]
 	_ = x[ErrDuplicateField-4822819]
+	_ = x[ErrLetFieldPath-4890500]
 	_ = x[ErrStageSkipBadValue-5107200]
 	_ = x[ErrStageLimitInvalidArg-5107201]
+	_ = x[ErrDateAddInvalidSpec-5166400]
+	_ = x[ErrDateAddUnknownArgument-5166401]
+	_ = x[ErrDateAddMissingArgument-5166402]
+	_ = x[ErrDateAddStartDateInvalid-5166403]
+	_ = x[ErrDateAddAmountNotInteger-5166404]
+	_ = x[ErrDateAddOverflow-5166406]
+	_ = x[ErrStageSetWindowFieldsInvalidBounds-5339900]
+	_ = x[ErrStageSetWindowFieldsSortBy-5371602]
+	_ = x[ErrStageSetWindowFieldsInvalidRangeValue-5429414]
+	_ = x[ErrDateDiffInvalidSpec-5439001]
+	_ = x[ErrDateDiffUnknownArgument-5439002]
+	_ = x[ErrDateDiffMissingStartDate-5439003]
+	_ = x[ErrDateDiffMissingEndDate-5439004]
+	_ = x[ErrDateDiffMissingUnit-5439005]
+	_ = x[ErrDateTruncInvalidSpec-5439007]
+	_ = x[ErrDateTruncUnknownArgument-5439008]
+	_ = x[ErrDateTruncMissingDate-5439009]
+	_ = x[ErrDateTruncMissingUnit-5439010]
+	_ = x[ErrDateArgumentNotDate-5439012]
+	_ = x[ErrTimeUnitNotString-5439013]
+	_ = x[ErrStartOfWeekNotString-5439015]
+	_ = x[ErrStartOfWeekInvalid-5439016]
+	_ = x[ErrDateTruncBinSizeNotInteger-5439017]
+	_ = x[ErrDateTruncBinSizeNotPositive-5439018]
 	_ = x[ErrStageCollStatsInvalidArg-5447000]
+	_ = x[ErrGetFieldFieldPath-5654600]
+	_ = x[ErrGetFieldFieldNotConstant-5654601]
+	_ = x[ErrGetFieldFieldNotString-5654602]
+	_ = x[ErrStageDensifyInvalidValue-5733201]
+	_ = x[ErrStageDensifyInvalidStep-5733401]
+	_ = x[ErrStageDensifyInvalidBounds-5733402]
+	_ = x[ErrStageDensifyBoundsLength-5733403]
+	_ = x[ErrStageDensifyPartitionBounds-5733408]
 	_ = x[ErrOpQueryCollectionSuffixMissing-5739101]
+	_ = x[ErrAccumulatorNNotDocument-5787801]
+	_ = x[ErrAccumulatorNUnknownArgument-5787901]
+	_ = x[ErrAccumulatorNNotNumeric-5787902]
+	_ = x[ErrAccumulatorNNotIntegral-5787903]
+	_ = x[ErrAccumulatorNMissingN-5787906]
+	_ = x[ErrAccumulatorNMissingInput-5787907]
+	_ = x[ErrAccumulatorNNotPositive-5787908]
+	_ = x[ErrTopBottomNotDocument-5788001]
+	_ = x[ErrTopBottomUnknownArgument-5788002]
+	_ = x[ErrTopBottomMissingN-5788003]
+	_ = x[ErrTopBottomMissingOutput-5788004]
+	_ = x[ErrTopBottomMissingSortBy-5788005]
+	_ = x[ErrStageDocumentsNotArray-5858203]
+	_ = x[ErrStageDensifyInvalidBoundsString-5946802]
+	_ = x[ErrStageFillPartitionByConflict-6050204]
+	_ = x[ErrStageDensifyStepNotInteger-6586400]
 	_ = x[ErrStageIndexedStringVectorDuplicate-7582300]
 }
 
-const _ErrorCode_name = "UnsetInternalErrorBadValueFailedToParseUserNotFoundUnauthorizedTypeMismatchAuthenticationFailedIllegalOperationNamespaceNotFoundIndexNotFoundPathNotViableConflictingUpdateOperatorsCursorNotFoundNamespaceExistsMaxTimeMSExpiredDollarPrefixedFieldNameInvalidIdFieldEmptyFieldNameCommandNotFoundImmutableFieldCannotCreateIndexIndexAlreadyExistsInvalidOptionsInvalidNamespaceIndexOptionsConflictIndexKeySpecsConflictOperationFailedDocumentValidationFailureInvalidPipelineOperatorClientMetadataCannotBeMutatedInvalidIndexSpecificationOptionNotImplementedErrMechanismUnavailableUnsupportedOpQueryCommandLocation10065DuplicateKeyLocation15947Location15948Location15955Location15958Location15959Location15969Location15973Location15974Location15975Location15976Location15981Location15983Location15998Location16020Location16406Location16410Location16872Location17276Location28667Location28724Location28812Location28818Location31002Location31119Location31120Location31249Location31250Location31253Location31254Location31324Location31325Location31394Location31395Location40156Location40157Location40158Location40160Location40181Location40234Location40237Location40238Location40272Location40323Location40352Location40353Location40414Location40415Location40602Location50687Location50692Location50840Location51003Location51024Location51075Location51091Location51108Location51246Location51247Location51270Location51272Location4822819Location5107200Location5107201Location5447000Location5739101Location7582300"
+const _ErrorCode_name = "UnsetInternalErrorBadValueFailedToParseUserNotFoundUnauthorizedTypeMismatchAuthenticationFailedIllegalOperationNamespaceNotFoundIndexNotFoundPathNotViableConflictingUpdateOperatorsCursorNotFoundNamespaceExistsMaxTimeMSExpiredDollarPrefixedFieldNameInvalidIdFieldEmptyFieldNameCommandNotFoundImmutableFieldCannotCreateIndexIndexAlreadyExistsInvalidOptionsInvalidNamespaceIndexOptionsConflictIndexKeySpecsConflictOperationFailedDocumentValidationFailureInvalidPipelineOperatorClientMetadataCannotBeMutatedInvalidIndexSpecificationOptionQueryFeatureNotAllowedNotImplementedConversionFailureQueryExceededMemoryLimitNoDiskUseAllowedErrMechanismUnavailableUnsupportedOpQueryCommandLocation10065DuplicateKeyLocation13113Location15947Location15948Location15955Location15958Location15959Location15969Location15973Location15974Location15975Location15976Location15981Location15983Location15998Location16006Location16007Location16020Location16034Location16035Location16406Location16410Location16412Location16555Location16556Location16608Location16609Location16610Location16611Location16612Location16702Location16872Location16874Location16875Location16876Location16877Location16878Location16879Location16880Location16882Location16883Location16990Location16994Location17040Location17041Location17042Location17043Location17044Location17045Location17046Location17047Location17048Location17049Location17053Location17080Location17081Location17082Location17083Location17124Location17152Location17276Location17385Location18533Location18534Location18535Location18536Location18537Location18628Location18629Location28646Location28647Location28648Location28650Location28651Location28656Location28657Location28664Location28667Location28680Location28689Location28690Location28691Location28714Location28724Location28725Location28726Location28727Location28728Location28729Location28745Location28746Location28747Location28748Location28749Location28756Location28757Location28758Location28759Location28761Location28762Location28763Location28764Location28765Location28766Location28803Location28812Location28818Location31002Location31022Location31023Location31024Location31034Location31095Location31119Location31120Location31249Location31250Location31253Location31254Location31319Location31324Location31325Location31393Location31394Location31395Location31441Location34435Location34443Location34444Location34445Location34446Location34447Location34448Location34449Location34450Location34451Location34452Location34453Location34454Location34455Location34460Location34461Location34462Location34463Location34464Location34465Location34466Location34467Location34468Location34471Location34473Location40060Location40061Location40062Location40063Location40064Location40065Location40066Location40067Location40068Location40075Location40076Location40077Location40078Location40079Location40080Location40081Location40085Location40086Location40087Location40090Location40091Location40092Location40093Location40094Location40096Location40097Location40099Location40100Location40101Location40102Location40103Location40104Location40105Location40147Location40148Location40149Location40156Location40157Location40158Location40160Location40169Location40170Location40171Location40181Location40185Location40191Location40192Location40193Location40194Location40195Location40196Location40197Location40198Location40199Location40200Location40201Location40202Location40228Location40234Location40237Location40238Location40239Location40240Location40241Location40242Location40243Location40244Location40245Location40246Location40257Location40258Location40259Location40260Location40261Location40272Location40323Location40352Location40353Location40386Location40390Location40391Location40392Location40393Location40394Location40395Location40396Location40397Location40398Location40400Location40414Location40415Location40485Location40489Location40515Location40516Location40517Location40518Location40519Location40520Location40521Location40522Location40523Location40524Location40535Location40536Location40539Location40540Location40541Location40542Location40554Location40600Location40601Location40602Location40684Location50687Location50692Location50694Location50695Location50696Location50699Location50700Location50840Location50989Location51003Location51024Location51044Location51045Location51047Location51075Location51081Location51082Location51083Location51091Location51103Location51104Location51105Location51106Location51107Location51108Location51109Location51110Location51111Location51132Location51134Location51178Location51182Location51183Location51186Location51187Location51191Location51199Location51246Location51247Location51270Location51272Location51276Location51744Location51745Location51746Location51747Location51748Location51749Location51750Location51751Location327391Location327392Location1257300Location2942500Location2942501Location2942502Location2942503Location2942504Location2942505Location3040500Location3041701Location3041702Location4031700Location4161100Location4161101Location4161102Location4161103Location4161105Location4161106Location4161107Location4161108Location4161109Location4822819Location4890500Location5107200Location5107201Location5166400Location5166401Location5166402Location5166403Location5166404Location5166406Location5339900Location5371602Location5429414Location5439001Location5439002Location5439003Location5439004Location5439005Location5439007Location5439008Location5439009Location5439010Location5439012Location5439013Location5439015Location5439016Location5439017Location5439018Location5447000Location5654600Location5654601Location5654602Location5733201Location5733401Location5733402Location5733403Location5733408Location5739101Location5787801Location5787901Location5787902Location5787903Location5787906Location5787907Location5787908Location5788001Location5788002Location5788003Location5788004Location5788005Location5858203Location5946802Location6050204Location6586400Location7582300"
 
 var _ErrorCode_map = map[ErrorCode]string{
 	0:       _ErrorCode_name[0:5],
@@ -148,77 +476,405 @@ var _ErrorCode_map = map[ErrorCode]string{
 	168:     _ErrorCode_name[451:474],
 	186:     _ErrorCode_name[474:503],
 	197:     _ErrorCode_name[503:534],
-	238:     _ErrorCode_name[534:548],
-	334:     _ErrorCode_name[548:571],
-	352:     _ErrorCode_name[571:596],
-	10065:   _ErrorCode_name[596:609],
-	11000:   _ErrorCode_name[609:621],
-	15947:   _ErrorCode_name[621:634],
-	15948:   _ErrorCode_name[634:647],
-	15955:   _ErrorCode_name[647:660],
-	15958:   _ErrorCode_name[660:673],
-	15959:   _ErrorCode_name[673:686],
-	15969:   _ErrorCode_name[686:699],
-	15973:   _ErrorCode_name[699:712],
-	15974:   _ErrorCode_name[712:725],
-	15975:   _ErrorCode_name[725:738],
-	15976:   _ErrorCode_name[738:751],
-	15981:   _ErrorCode_name[751:764],
-	15983:   _ErrorCode_name[764:777],
-	15998:   _ErrorCode_name[777:790],
-	16020:   _ErrorCode_name[790:803],
-	16406:   _ErrorCode_name[803:816],
-	16410:   _ErrorCode_name[816:829],
-	16872:   _ErrorCode_name[829:842],
-	17276:   _ErrorCode_name[842:855],
-	28667:   _ErrorCode_name[855:868],
-	28724:   _ErrorCode_name[868:881],
-	28812:   _ErrorCode_name[881:894],
-	28818:   _ErrorCode_name[894:907],
-	31002:   _ErrorCode_name[907:920],
-	31119:   _ErrorCode_name[920:933],
-	31120:   _ErrorCode_name[933:946],
-	31249:   _ErrorCode_name[946:959],
-	31250:   _ErrorCode_name[959:972],
-	31253:   _ErrorCode_name[972:985],
-	31254:   _ErrorCode_name[985:998],
-	31324:   _ErrorCode_name[998:1011],
-	31325:   _ErrorCode_name[1011:1024],
-	31394:   _ErrorCode_name[1024:1037],
-	31395:   _ErrorCode_name[1037:1050],
-	40156:   _ErrorCode_name[1050:1063],
-	40157:   _ErrorCode_name[1063:1076],
-	40158:   _ErrorCode_name[1076:1089],
-	40160:   _ErrorCode_name[1089:1102],
-	40181:   _ErrorCode_name[1102:1115],
-	40234:   _ErrorCode_name[1115:1128],
-	40237:   _ErrorCode_name[1128:1141],
-	40238:   _ErrorCode_name[1141:1154],
-	40272:   _ErrorCode_name[1154:1167],
-	40323:   _ErrorCode_name[1167:1180],
-	40352:   _ErrorCode_name[1180:1193],
-	40353:   _ErrorCode_name[1193:1206],
-	40414:   _ErrorCode_name[1206:1219],
-	40415:   _ErrorCode_name[1219:1232],
-	40602:   _ErrorCode_name[1232:1245],
-	50687:   _ErrorCode_name[1245:1258],
-	50692:   _ErrorCode_name[1258:1271],
-	50840:   _ErrorCode_name[1271:1284],
-	51003:   _ErrorCode_name[1284:1297],
-	51024:   _ErrorCode_name[1297:1310],
-	51075:   _ErrorCode_name[1310:1323],
-	51091:   _ErrorCode_name[1323:1336],
-	51108:   _ErrorCode_name[1336:1349],
-	51246:   _ErrorCode_name[1349:1362],
-	51247:   _ErrorCode_name[1362:1375],
-	51270:   _ErrorCode_name[1375:1388],
-	51272:   _ErrorCode_name[1388:1401],
-	4822819: _ErrorCode_name[1401:1416],
-	5107200: _ErrorCode_name[1416:1431],
-	5107201: _ErrorCode_name[1431:1446],
-	5447000: _ErrorCode_name[1446:1461],
-	5739101: _ErrorCode_name[1461:1476],
-	7582300: _ErrorCode_name[1476:1491],
+	224:     _ErrorCode_name[534:556],
+	238:     _ErrorCode_name[556:570],
+	241:     _ErrorCode_name[570:587],
+	292:     _ErrorCode_name[587:627],
+	334:     _ErrorCode_name[627:650],
+	352:     _ErrorCode_name[650:675],
+	10065:   _ErrorCode_name[675:688],
+	11000:   _ErrorCode_name[688:700],
+	13113:   _ErrorCode_name[700:713],
+	15947:   _ErrorCode_name[713:726],
+	15948:   _ErrorCode_name[726:739],
+	15955:   _ErrorCode_name[739:752],
+	15958:   _ErrorCode_name[752:765],
+	15959:   _ErrorCode_name[765:778],
+	15969:   _ErrorCode_name[778:791],
+	15973:   _ErrorCode_name[791:804],
+	15974:   _ErrorCode_name[804:817],
+	15975:   _ErrorCode_name[817:830],
+	15976:   _ErrorCode_name[830:843],
+	15981:   _ErrorCode_name[843:856],
+	15983:   _ErrorCode_name[856:869],
+	15998:   _ErrorCode_name[869:882],
+	16006:   _ErrorCode_name[882:895],
+	16007:   _ErrorCode_name[895:908],
+	16020:   _ErrorCode_name[908:921],
+	16034:   _ErrorCode_name[921:934],
+	16035:   _ErrorCode_name[934:947],
+	16406:   _ErrorCode_name[947:960],
+	16410:   _ErrorCode_name[960:973],
+	16412:   _ErrorCode_name[973:986],
+	16555:   _ErrorCode_name[986:999],
+	16556:   _ErrorCode_name[999:1012],
+	16608:   _ErrorCode_name[1012:1025],
+	16609:   _ErrorCode_name[1025:1038],
+	16610:   _ErrorCode_name[1038:1051],
+	16611:   _ErrorCode_name[1051:1064],
+	16612:   _ErrorCode_name[1064:1077],
+	16702:   _ErrorCode_name[1077:1090],
+	16872:   _ErrorCode_name[1090:1103],
+	16874:   _ErrorCode_name[1103:1116],
+	16875:   _ErrorCode_name[1116:1129],
+	16876:   _ErrorCode_name[1129:1142],
+	16877:   _ErrorCode_name[1142:1155],
+	16878:   _ErrorCode_name[1155:1168],
+	16879:   _ErrorCode_name[1168:1181],
+	16880:   _ErrorCode_name[1181:1194],
+	16882:   _ErrorCode_name[1194:1207],
+	16883:   _ErrorCode_name[1207:1220],
+	16990:   _ErrorCode_name[1220:1233],
+	16994:   _ErrorCode_name[1233:1246],
+	17040:   _ErrorCode_name[1246:1259],
+	17041:   _ErrorCode_name[1259:1272],
+	17042:   _ErrorCode_name[1272:1285],
+	17043:   _ErrorCode_name[1285:1298],
+	17044:   _ErrorCode_name[1298:1311],
+	17045:   _ErrorCode_name[1311:1324],
+	17046:   _ErrorCode_name[1324:1337],
+	17047:   _ErrorCode_name[1337:1350],
+	17048:   _ErrorCode_name[1350:1363],
+	17049:   _ErrorCode_name[1363:1376],
+	17053:   _ErrorCode_name[1376:1389],
+	17080:   _ErrorCode_name[1389:1402],
+	17081:   _ErrorCode_name[1402:1415],
+	17082:   _ErrorCode_name[1415:1428],
+	17083:   _ErrorCode_name[1428:1441],
+	17124:   _ErrorCode_name[1441:1454],
+	17152:   _ErrorCode_name[1454:1467],
+	17276:   _ErrorCode_name[1467:1480],
+	17385:   _ErrorCode_name[1480:1493],
+	18533:   _ErrorCode_name[1493:1506],
+	18534:   _ErrorCode_name[1506:1519],
+	18535:   _ErrorCode_name[1519:1532],
+	18536:   _ErrorCode_name[1532:1545],
+	18537:   _ErrorCode_name[1545:1558],
+	18628:   _ErrorCode_name[1558:1571],
+	18629:   _ErrorCode_name[1571:1584],
+	28646:   _ErrorCode_name[1584:1597],
+	28647:   _ErrorCode_name[1597:1610],
+	28648:   _ErrorCode_name[1610:1623],
+	28650:   _ErrorCode_name[1623:1636],
+	28651:   _ErrorCode_name[1636:1649],
+	28656:   _ErrorCode_name[1649:1662],
+	28657:   _ErrorCode_name[1662:1675],
+	28664:   _ErrorCode_name[1675:1688],
+	28667:   _ErrorCode_name[1688:1701],
+	28680:   _ErrorCode_name[1701:1714],
+	28689:   _ErrorCode_name[1714:1727],
+	28690:   _ErrorCode_name[1727:1740],
+	28691:   _ErrorCode_name[1740:1753],
+	28714:   _ErrorCode_name[1753:1766],
+	28724:   _ErrorCode_name[1766:1779],
+	28725:   _ErrorCode_name[1779:1792],
+	28726:   _ErrorCode_name[1792:1805],
+	28727:   _ErrorCode_name[1805:1818],
+	28728:   _ErrorCode_name[1818:1831],
+	28729:   _ErrorCode_name[1831:1844],
+	28745:   _ErrorCode_name[1844:1857],
+	28746:   _ErrorCode_name[1857:1870],
+	28747:   _ErrorCode_name[1870:1883],
+	28748:   _ErrorCode_name[1883:1896],
+	28749:   _ErrorCode_name[1896:1909],
+	28756:   _ErrorCode_name[1909:1922],
+	28757:   _ErrorCode_name[1922:1935],
+	28758:   _ErrorCode_name[1935:1948],
+	28759:   _ErrorCode_name[1948:1961],
+	28761:   _ErrorCode_name[1961:1974],
+	28762:   _ErrorCode_name[1974:1987],
+	28763:   _ErrorCode_name[1987:2000],
+	28764:   _ErrorCode_name[2000:2013],
+	28765:   _ErrorCode_name[2013:2026],
+	28766:   _ErrorCode_name[2026:2039],
+	28803:   _ErrorCode_name[2039:2052],
+	28812:   _ErrorCode_name[2052:2065],
+	28818:   _ErrorCode_name[2065:2078],
+	31002:   _ErrorCode_name[2078:2091],
+	31022:   _ErrorCode_name[2091:2104],
+	31023:   _ErrorCode_name[2104:2117],
+	31024:   _ErrorCode_name[2117:2130],
+	31034:   _ErrorCode_name[2130:2143],
+	31095:   _ErrorCode_name[2143:2156],
+	31119:   _ErrorCode_name[2156:2169],
+	31120:   _ErrorCode_name[2169:2182],
+	31249:   _ErrorCode_name[2182:2195],
+	31250:   _ErrorCode_name[2195:2208],
+	31253:   _ErrorCode_name[2208:2221],
+	31254:   _ErrorCode_name[2221:2234],
+	31319:   _ErrorCode_name[2234:2247],
+	31324:   _ErrorCode_name[2247:2260],
+	31325:   _ErrorCode_name[2260:2273],
+	31393:   _ErrorCode_name[2273:2286],
+	31394:   _ErrorCode_name[2286:2299],
+	31395:   _ErrorCode_name[2299:2312],
+	31441:   _ErrorCode_name[2312:2325],
+	34435:   _ErrorCode_name[2325:2338],
+	34443:   _ErrorCode_name[2338:2351],
+	34444:   _ErrorCode_name[2351:2364],
+	34445:   _ErrorCode_name[2364:2377],
+	34446:   _ErrorCode_name[2377:2390],
+	34447:   _ErrorCode_name[2390:2403],
+	34448:   _ErrorCode_name[2403:2416],
+	34449:   _ErrorCode_name[2416:2429],
+	34450:   _ErrorCode_name[2429:2442],
+	34451:   _ErrorCode_name[2442:2455],
+	34452:   _ErrorCode_name[2455:2468],
+	34453:   _ErrorCode_name[2468:2481],
+	34454:   _ErrorCode_name[2481:2494],
+	34455:   _ErrorCode_name[2494:2507],
+	34460:   _ErrorCode_name[2507:2520],
+	34461:   _ErrorCode_name[2520:2533],
+	34462:   _ErrorCode_name[2533:2546],
+	34463:   _ErrorCode_name[2546:2559],
+	34464:   _ErrorCode_name[2559:2572],
+	34465:   _ErrorCode_name[2572:2585],
+	34466:   _ErrorCode_name[2585:2598],
+	34467:   _ErrorCode_name[2598:2611],
+	34468:   _ErrorCode_name[2611:2624],
+	34471:   _ErrorCode_name[2624:2637],
+	34473:   _ErrorCode_name[2637:2650],
+	40060:   _ErrorCode_name[2650:2663],
+	40061:   _ErrorCode_name[2663:2676],
+	40062:   _ErrorCode_name[2676:2689],
+	40063:   _ErrorCode_name[2689:2702],
+	40064:   _ErrorCode_name[2702:2715],
+	40065:   _ErrorCode_name[2715:2728],
+	40066:   _ErrorCode_name[2728:2741],
+	40067:   _ErrorCode_name[2741:2754],
+	40068:   _ErrorCode_name[2754:2767],
+	40075:   _ErrorCode_name[2767:2780],
+	40076:   _ErrorCode_name[2780:2793],
+	40077:   _ErrorCode_name[2793:2806],
+	40078:   _ErrorCode_name[2806:2819],
+	40079:   _ErrorCode_name[2819:2832],
+	40080:   _ErrorCode_name[2832:2845],
+	40081:   _ErrorCode_name[2845:2858],
+	40085:   _ErrorCode_name[2858:2871],
+	40086:   _ErrorCode_name[2871:2884],
+	40087:   _ErrorCode_name[2884:2897],
+	40090:   _ErrorCode_name[2897:2910],
+	40091:   _ErrorCode_name[2910:2923],
+	40092:   _ErrorCode_name[2923:2936],
+	40093:   _ErrorCode_name[2936:2949],
+	40094:   _ErrorCode_name[2949:2962],
+	40096:   _ErrorCode_name[2962:2975],
+	40097:   _ErrorCode_name[2975:2988],
+	40099:   _ErrorCode_name[2988:3001],
+	40100:   _ErrorCode_name[3001:3014],
+	40101:   _ErrorCode_name[3014:3027],
+	40102:   _ErrorCode_name[3027:3040],
+	40103:   _ErrorCode_name[3040:3053],
+	40104:   _ErrorCode_name[3053:3066],
+	40105:   _ErrorCode_name[3066:3079],
+	40147:   _ErrorCode_name[3079:3092],
+	40148:   _ErrorCode_name[3092:3105],
+	40149:   _ErrorCode_name[3105:3118],
+	40156:   _ErrorCode_name[3118:3131],
+	40157:   _ErrorCode_name[3131:3144],
+	40158:   _ErrorCode_name[3144:3157],
+	40160:   _ErrorCode_name[3157:3170],
+	40169:   _ErrorCode_name[3170:3183],
+	40170:   _ErrorCode_name[3183:3196],
+	40171:   _ErrorCode_name[3196:3209],
+	40181:   _ErrorCode_name[3209:3222],
+	40185:   _ErrorCode_name[3222:3235],
+	40191:   _ErrorCode_name[3235:3248],
+	40192:   _ErrorCode_name[3248:3261],
+	40193:   _ErrorCode_name[3261:3274],
+	40194:   _ErrorCode_name[3274:3287],
+	40195:   _ErrorCode_name[3287:3300],
+	40196:   _ErrorCode_name[3300:3313],
+	40197:   _ErrorCode_name[3313:3326],
+	40198:   _ErrorCode_name[3326:3339],
+	40199:   _ErrorCode_name[3339:3352],
+	40200:   _ErrorCode_name[3352:3365],
+	40201:   _ErrorCode_name[3365:3378],
+	40202:   _ErrorCode_name[3378:3391],
+	40228:   _ErrorCode_name[3391:3404],
+	40234:   _ErrorCode_name[3404:3417],
+	40237:   _ErrorCode_name[3417:3430],
+	40238:   _ErrorCode_name[3430:3443],
+	40239:   _ErrorCode_name[3443:3456],
+	40240:   _ErrorCode_name[3456:3469],
+	40241:   _ErrorCode_name[3469:3482],
+	40242:   _ErrorCode_name[3482:3495],
+	40243:   _ErrorCode_name[3495:3508],
+	40244:   _ErrorCode_name[3508:3521],
+	40245:   _ErrorCode_name[3521:3534],
+	40246:   _ErrorCode_name[3534:3547],
+	40257:   _ErrorCode_name[3547:3560],
+	40258:   _ErrorCode_name[3560:3573],
+	40259:   _ErrorCode_name[3573:3586],
+	40260:   _ErrorCode_name[3586:3599],
+	40261:   _ErrorCode_name[3599:3612],
+	40272:   _ErrorCode_name[3612:3625],
+	40323:   _ErrorCode_name[3625:3638],
+	40352:   _ErrorCode_name[3638:3651],
+	40353:   _ErrorCode_name[3651:3664],
+	40386:   _ErrorCode_name[3664:3677],
+	40390:   _ErrorCode_name[3677:3690],
+	40391:   _ErrorCode_name[3690:3703],
+	40392:   _ErrorCode_name[3703:3716],
+	40393:   _ErrorCode_name[3716:3729],
+	40394:   _ErrorCode_name[3729:3742],
+	40395:   _ErrorCode_name[3742:3755],
+	40396:   _ErrorCode_name[3755:3768],
+	40397:   _ErrorCode_name[3768:3781],
+	40398:   _ErrorCode_name[3781:3794],
+	40400:   _ErrorCode_name[3794:3807],
+	40414:   _ErrorCode_name[3807:3820],
+	40415:   _ErrorCode_name[3820:3833],
+	40485:   _ErrorCode_name[3833:3846],
+	40489:   _ErrorCode_name[3846:3859],
+	40515:   _ErrorCode_name[3859:3872],
+	40516:   _ErrorCode_name[3872:3885],
+	40517:   _ErrorCode_name[3885:3898],
+	40518:   _ErrorCode_name[3898:3911],
+	40519:   _ErrorCode_name[3911:3924],
+	40520:   _ErrorCode_name[3924:3937],
+	40521:   _ErrorCode_name[3937:3950],
+	40522:   _ErrorCode_name[3950:3963],
+	40523:   _ErrorCode_name[3963:3976],
+	40524:   _ErrorCode_name[3976:3989],
+	40535:   _ErrorCode_name[3989:4002],
+	40536:   _ErrorCode_name[4002:4015],
+	40539:   _ErrorCode_name[4015:4028],
+	40540:   _ErrorCode_name[4028:4041],
+	40541:   _ErrorCode_name[4041:4054],
+	40542:   _ErrorCode_name[4054:4067],
+	40554:   _ErrorCode_name[4067:4080],
+	40600:   _ErrorCode_name[4080:4093],
+	40601:   _ErrorCode_name[4093:4106],
+	40602:   _ErrorCode_name[4106:4119],
+	40684:   _ErrorCode_name[4119:4132],
+	50687:   _ErrorCode_name[4132:4145],
+	50692:   _ErrorCode_name[4145:4158],
+	50694:   _ErrorCode_name[4158:4171],
+	50695:   _ErrorCode_name[4171:4184],
+	50696:   _ErrorCode_name[4184:4197],
+	50699:   _ErrorCode_name[4197:4210],
+	50700:   _ErrorCode_name[4210:4223],
+	50840:   _ErrorCode_name[4223:4236],
+	50989:   _ErrorCode_name[4236:4249],
+	51003:   _ErrorCode_name[4249:4262],
+	51024:   _ErrorCode_name[4262:4275],
+	51044:   _ErrorCode_name[4275:4288],
+	51045:   _ErrorCode_name[4288:4301],
+	51047:   _ErrorCode_name[4301:4314],
+	51075:   _ErrorCode_name[4314:4327],
+	51081:   _ErrorCode_name[4327:4340],
+	51082:   _ErrorCode_name[4340:4353],
+	51083:   _ErrorCode_name[4353:4366],
+	51091:   _ErrorCode_name[4366:4379],
+	51103:   _ErrorCode_name[4379:4392],
+	51104:   _ErrorCode_name[4392:4405],
+	51105:   _ErrorCode_name[4405:4418],
+	51106:   _ErrorCode_name[4418:4431],
+	51107:   _ErrorCode_name[4431:4444],
+	51108:   _ErrorCode_name[4444:4457],
+	51109:   _ErrorCode_name[4457:4470],
+	51110:   _ErrorCode_name[4470:4483],
+	51111:   _ErrorCode_name[4483:4496],
+	51132:   _ErrorCode_name[4496:4509],
+	51134:   _ErrorCode_name[4509:4522],
+	51178:   _ErrorCode_name[4522:4535],
+	51182:   _ErrorCode_name[4535:4548],
+	51183:   _ErrorCode_name[4548:4561],
+	51186:   _ErrorCode_name[4561:4574],
+	51187:   _ErrorCode_name[4574:4587],
+	51191:   _ErrorCode_name[4587:4600],
+	51199:   _ErrorCode_name[4600:4613],
+	51246:   _ErrorCode_name[4613:4626],
+	51247:   _ErrorCode_name[4626:4639],
+	51270:   _ErrorCode_name[4639:4652],
+	51272:   _ErrorCode_name[4652:4665],
+	51276:   _ErrorCode_name[4665:4678],
+	51744:   _ErrorCode_name[4678:4691],
+	51745:   _ErrorCode_name[4691:4704],
+	51746:   _ErrorCode_name[4704:4717],
+	51747:   _ErrorCode_name[4717:4730],
+	51748:   _ErrorCode_name[4730:4743],
+	51749:   _ErrorCode_name[4743:4756],
+	51750:   _ErrorCode_name[4756:4769],
+	51751:   _ErrorCode_name[4769:4782],
+	327391:  _ErrorCode_name[4782:4796],
+	327392:  _ErrorCode_name[4796:4810],
+	1257300: _ErrorCode_name[4810:4825],
+	2942500: _ErrorCode_name[4825:4840],
+	2942501: _ErrorCode_name[4840:4855],
+	2942502: _ErrorCode_name[4855:4870],
+	2942503: _ErrorCode_name[4870:4885],
+	2942504: _ErrorCode_name[4885:4900],
+	2942505: _ErrorCode_name[4900:4915],
+	3040500: _ErrorCode_name[4915:4930],
+	3041701: _ErrorCode_name[4930:4945],
+	3041702: _ErrorCode_name[4945:4960],
+	4031700: _ErrorCode_name[4960:4975],
+	4161100: _ErrorCode_name[4975:4990],
+	4161101: _ErrorCode_name[4990:5005],
+	4161102: _ErrorCode_name[5005:5020],
+	4161103: _ErrorCode_name[5020:5035],
+	4161105: _ErrorCode_name[5035:5050],
+	4161106: _ErrorCode_name[5050:5065],
+	4161107: _ErrorCode_name[5065:5080],
+	4161108: _ErrorCode_name[5080:5095],
+	4161109: _ErrorCode_name[5095:5110],
+	4822819: _ErrorCode_name[5110:5125],
+	4890500: _ErrorCode_name[5125:5140],
+	5107200: _ErrorCode_name[5140:5155],
+	5107201: _ErrorCode_name[5155:5170],
+	5166400: _ErrorCode_name[5170:5185],
+	5166401: _ErrorCode_name[5185:5200],
+	5166402: _ErrorCode_name[5200:5215],
+	5166403: _ErrorCode_name[5215:5230],
+	5166404: _ErrorCode_name[5230:5245],
+	5166406: _ErrorCode_name[5245:5260],
+	5339900: _ErrorCode_name[5260:5275],
+	5371602: _ErrorCode_name[5275:5290],
+	5429414: _ErrorCode_name[5290:5305],
+	5439001: _ErrorCode_name[5305:5320],
+	5439002: _ErrorCode_name[5320:5335],
+	5439003: _ErrorCode_name[5335:5350],
+	5439004: _ErrorCode_name[5350:5365],
+	5439005: _ErrorCode_name[5365:5380],
+	5439007: _ErrorCode_name[5380:5395],
+	5439008: _ErrorCode_name[5395:5410],
+	5439009: _ErrorCode_name[5410:5425],
+	5439010: _ErrorCode_name[5425:5440],
+	5439012: _ErrorCode_name[5440:5455],
+	5439013: _ErrorCode_name[5455:5470],
+	5439015: _ErrorCode_name[5470:5485],
+	5439016: _ErrorCode_name[5485:5500],
+	5439017: _ErrorCode_name[5500:5515],
+	5439018: _ErrorCode_name[5515:5530],
+	5447000: _ErrorCode_name[5530:5545],
+	5654600: _ErrorCode_name[5545:5560],
+	5654601: _ErrorCode_name[5560:5575],
+	5654602: _ErrorCode_name[5575:5590],
+	5733201: _ErrorCode_name[5590:5605],
+	5733401: _ErrorCode_name[5605:5620],
+	5733402: _ErrorCode_name[5620:5635],
+	5733403: _ErrorCode_name[5635:5650],
+	5733408: _ErrorCode_name[5650:5665],
+	5739101: _ErrorCode_name[5665:5680],
+	5787801: _ErrorCode_name[5680:5695],
+	5787901: _ErrorCode_name[5695:5710],
+	5787902: _ErrorCode_name[5710:5725],
+	5787903: _ErrorCode_name[5725:5740],
+	5787906: _ErrorCode_name[5740:5755],
+	5787907: _ErrorCode_name[5755:5770],
+	5787908: _ErrorCode_name[5770:5785],
+	5788001: _ErrorCode_name[5785:5800],
+	5788002: _ErrorCode_name[5800:5815],
+	5788003: _ErrorCode_name[5815:5830],
+	5788004: _ErrorCode_name[5830:5845],
+	5788005: _ErrorCode_name[5845:5860],
+	5858203: _ErrorCode_name[5860:5875],
+	5946802: _ErrorCode_name[5875:5890],
+	6050204: _ErrorCode_name[5890:5905],
+	6586400: _ErrorCode_name[5905:5920],
+	7582300: _ErrorCode_name[5920:5935],
 }
 
 func (i ErrorCode) String() string {
@@ -50,13 +50,13 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 
 	common.Ignored(document, h.L, "lsid")
 
-	if err = common.Unimplemented(document, "explain", "collation", "let"); err != nil {
+	if err = common.Unimplemented(document, "explain", "collation"); err != nil {
 		return nil, err
 	}
 
 	common.Ignored(
 		document, h.L,
-		"allowDiskUse", "bypassDocumentValidation", "readConcern", "hint", "comment", "writeConcern",
+		"bypassDocumentValidation", "readConcern", "hint", "comment", "writeConcern",
 	)
 
 	var dbName string
@@ -71,16 +71,26 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 	}
 
 	// handle collection-agnostic pipelines ({aggregate: 1})
-	// TODO https://github.com/FerretDB/FerretDB/issues/1890
 	var ok bool
 	var cName string
+	var agnostic bool
 
 	if cName, ok = collectionParam.(string); !ok {
-		return nil, handlererrors.NewCommandErrorMsgWithArgument(
-			handlererrors.ErrFailedToParse,
-			"Invalid command format: the 'aggregate' field must specify a collection name or 1",
-			document.Command(),
-		)
+		switch collectionParam.(type) {
+		case int32, int64, float64:
+			agnostic = types.Compare(collectionParam, int32(1)) == types.Equal
+		}
+
+		if !agnostic {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrFailedToParse,
+				"Invalid command format: the 'aggregate' field must specify a collection name or 1",
+				document.Command(),
+			)
+		}
+
+		// namespace of the cursor used by getMore
+		cName = "$cmd.aggregate"
 	}
 
 	db, err := h.b.Database(dbName)
@@ -93,14 +103,18 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 		return nil, lazyerrors.Error(err)
 	}
 
-	c, err := db.Collection(cName)
-	if err != nil {
-		if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
-			msg := fmt.Sprintf("Invalid collection name: %s", cName)
-			return nil, handlererrors.NewCommandErrorMsgWithArgument(handlererrors.ErrInvalidNamespace, msg, document.Command())
-		}
+	// c is nil for collection-agnostic pipelines
+	var c backends.Collection
 
-		return nil, lazyerrors.Error(err)
+	if !agnostic {
+		if c, err = db.Collection(cName); err != nil {
+			if backends.ErrorCodeIs(err, backends.ErrorCodeCollectionNameIsInvalid) {
+				msg := fmt.Sprintf("Invalid collection name: %s", cName)
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(handlererrors.ErrInvalidNamespace, msg, document.Command())
+			}
+
+			return nil, lazyerrors.Error(err)
+		}
 	}
 
 	username := conninfo.Get(connCtx).Username()
@@ -180,9 +194,58 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 	}
 
 	aggregationStages := must.NotFail(iterator.ConsumeValues(pipeline.Iterator()))
+
+	var allowDiskUse bool
+
+	if v, _ := document.Get("allowDiskUse"); v != nil {
+		if allowDiskUse, err = handlerparams.GetBoolOptionalParam("allowDiskUse", v); err != nil {
+			return nil, err
+		}
+	}
+
+	let, err := common.GetOptionalParam[*types.Document](document, "let", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := common.CommandVariables(let, document.Command())
+	if err != nil {
+		return nil, err
+	}
+
+	if agnostic {
+		if err = validateAgnosticPipeline(aggregationStages); err != nil {
+			return nil, err
+		}
+	}
+
 	stagesDocuments := make([]aggregations.Stage, 0, len(aggregationStages))
 	collStatsDocuments := make([]aggregations.Stage, 0, len(aggregationStages))
 
+	stageParams := &stages.NewStageParams{
+		Backend:                   h.b,
+		DB:                        db,
+		DBName:                    dbName,
+		MaxBsonObjectSizeBytes:    h.MaxBsonObjectSizeBytes,
+		GraphLookupMaxMemoryBytes: h.GraphLookupMaxMemoryBytes,
+		BlockingMaxMemoryBytes:    h.BlockingStageMaxMemoryBytes,
+		AllowDiskUse:              allowDiskUse,
+		SpillDir:                  h.SpillDir,
+		Operations:                h.operations,
+		Sessions:                  h.sessions,
+		IndexUsage:                h.indexUsage,
+		AllUsersAllowed:           h.allUsersAllowed(connCtx),
+		Variables:                 vars,
+	}
+
+	if !agnostic {
+		stageParams.CollectionName = cName
+	}
+
+	// producer is true if documents are produced by the first stage, such as $documents,
+	// instead of being fetched from the collection
+	var producer bool
+
 	for i, v := range aggregationStages {
 		var d *types.Document
 
@@ -196,7 +259,7 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 
 		var s aggregations.Stage
 
-		if s, err = stages.NewStage(d); err != nil {
+		if s, err = stages.NewStage(d, stageParams); err != nil {
 			return nil, err
 		}
 
@@ -210,6 +273,34 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 				)
 			}
 
+			collStatsDocuments = append(collStatsDocuments, s)
+		case "$currentOp", "$documents", "$indexStats", "$listLocalSessions":
+			if i > 0 {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrCollStatsIsNotFirstStage,
+					fmt.Sprintf("%s is only valid as the first stage in a pipeline", d.Command()),
+					document.Command(),
+				)
+			}
+
+			if err = validateProducerNamespace(d.Command(), dbName, agnostic); err != nil {
+				return nil, err
+			}
+
+			producer = true
+
+			stagesDocuments = append(stagesDocuments, s)
+			collStatsDocuments = append(collStatsDocuments, s)
+		case "$merge", "$out":
+			if i < len(aggregationStages)-1 {
+				return nil, handlererrors.NewCommandErrorMsgWithArgument(
+					handlererrors.ErrStageNotLast,
+					fmt.Sprintf("%s can only be the final stage in the pipeline", d.Command()),
+					document.Command(),
+				)
+			}
+
+			stagesDocuments = append(stagesDocuments, s)
 			collStatsDocuments = append(collStatsDocuments, s)
 		default:
 			stagesDocuments = append(stagesDocuments, s)
@@ -217,6 +308,9 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 		}
 	}
 
+	stages.SetSortLimits(stagesDocuments)
+	stages.SetSortLimits(collStatsDocuments)
+
 	// validate cursor after validating pipeline stages to keep compatibility
 	v, _ = document.Get("cursor")
 	if v == nil {
@@ -275,9 +369,15 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 
 	var iter iterator.Interface[struct{}, *types.Document]
 
-	if len(collStatsDocuments) == len(stagesDocuments) {
+	switch {
+	case producer:
+		iter, err = processStagesProducer(ctx, closer, stagesDocuments)
+
+	case len(collStatsDocuments) == len(stagesDocuments):
 		filter, sort := aggregations.GetPushdownQuery(aggregationStages)
 
+		h.indexUsage.Record(dbName, cName, filter)
+
 		// only documents stages or no stages - fetch documents from the DB and apply stages to them
 		qp := new(backends.QueryParams)
 
@@ -349,8 +449,16 @@ func (h *Handler) MsgAggregate(connCtx context.Context, msg *wire.OpMsg) (*wire.
 			qp.Sort = sort
 		}
 
+		// pushdown $sample first stage, random documents are selected in any order
+		if !h.DisablePushdown {
+			if qp.Sample = stages.GetPushdownSample(aggregationStages); qp.Sample != 0 {
+				qp.Sort = nil
+			}
+		}
+
 		iter, err = processStagesDocuments(ctx, closer, &stagesDocumentsParams{c, qp, stagesDocuments})
-	} else {
+
+	default:
 		// TODO https://github.com/FerretDB/FerretDB/issues/2423
 		statistics := stages.GetStatistics(collStatsDocuments)
 
@@ -444,6 +552,79 @@ func processStagesDocuments(ctx context.Context, closer *iterator.MultiCloser, p
 	return iter, nil
 }
 
+// validateAgnosticPipeline checks that the collection-agnostic pipeline ({aggregate: 1})
+// starts with a stage producing documents.
+func validateAgnosticPipeline(aggregationStages []any) error {
+	if len(aggregationStages) == 0 {
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrInvalidNamespace,
+			"{aggregate: 1} is not valid for an empty pipeline.",
+			"aggregate",
+		)
+	}
+
+	d, ok := aggregationStages[0].(*types.Document)
+	if !ok || d.Len() != 1 {
+		// invalid stage is reported by stage validation
+		return nil
+	}
+
+	switch name := d.Command(); name {
+	case "$currentOp", "$documents", "$listLocalSessions":
+		return nil
+	default:
+		return handlererrors.NewCommandErrorMsgWithArgument(
+			handlererrors.ErrInvalidNamespace,
+			fmt.Sprintf("{aggregate: 1} is not valid for '%s'; a collection is required.", name),
+			"aggregate",
+		)
+	}
+}
+
+// validateProducerNamespace returns error if the producer stage with the given name
+// can't be run against the given namespace.
+func validateProducerNamespace(name, dbName string, agnostic bool) error {
+	var msg string
+
+	switch name {
+	case "$currentOp":
+		if !agnostic || dbName != "admin" {
+			msg = "$currentOp must be run against the 'admin' database with {aggregate: 1}"
+		}
+	case "$documents":
+		if !agnostic {
+			msg = "$documents' can only be run with {aggregate: 1}"
+		}
+	case "$listLocalSessions":
+		if !agnostic {
+			msg = "$listLocalSessions must be run against the database with {aggregate: 1}, not a collection"
+		}
+	}
+
+	if msg == "" {
+		return nil
+	}
+
+	return handlererrors.NewCommandErrorMsgWithArgument(handlererrors.ErrInvalidNamespace, msg, "aggregate")
+}
+
+// processStagesProducer processes documents produced by the first stage of the pipeline, such as $documents,
+// through the stages.
+func processStagesProducer(ctx context.Context, closer *iterator.MultiCloser, stages []aggregations.Stage) (types.DocumentsIterator, error) { //nolint:lll // for readability
+	iter := iterator.Values(iterator.ForSlice([]*types.Document{}))
+	closer.Add(iter)
+
+	var err error
+
+	for _, s := range stages {
+		if iter, err = s.Process(ctx, iter, closer); err != nil {
+			return nil, err
+		}
+	}
+
+	return iter, nil
+}
+
 // stagesStatsParams contains the parameters for processStagesStats.
 type stagesStatsParams struct {
 	c          backends.Collection
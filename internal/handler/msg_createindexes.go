@@ -154,6 +154,8 @@ func (h *Handler) MsgCreateIndexes(connCtx context.Context, msg *wire.OpMsg) (*w
 		return nil, lazyerrors.Error(err)
 	}
 
+	h.indexUsage.IndexesCreated(dbName, collection, toCreate)
+
 	resp := new(types.Document)
 
 	resp.Set("numIndexesBefore", int32(numIndexesBefore))
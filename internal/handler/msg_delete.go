@@ -23,6 +23,7 @@ import (
 
 	"github.com/FerretDB/FerretDB/internal/backends"
 	"github.com/FerretDB/FerretDB/internal/handler/common"
+	"github.com/FerretDB/FerretDB/internal/handler/common/aggregations"
 	"github.com/FerretDB/FerretDB/internal/handler/handlererrors"
 	"github.com/FerretDB/FerretDB/internal/types"
 	"github.com/FerretDB/FerretDB/internal/util/iterator"
@@ -70,7 +71,7 @@ func (h *Handler) MsgDelete(connCtx context.Context, msg *wire.OpMsg) (*wire.OpM
 
 	for i, p := range params.Deletes {
 		var d int32
-		d, err = h.execDelete(connCtx, c, &p)
+		d, err = h.execDelete(connCtx, c, &p, params.Variables)
 
 		deleted += d
 
@@ -118,7 +119,9 @@ func (h *Handler) MsgDelete(connCtx context.Context, msg *wire.OpMsg) (*wire.OpM
 //
 // It returns a number of deleted documents or error.
 // The error is either a (wrapped) *handlererrors.CommandError or something fatal.
-func (h *Handler) execDelete(ctx context.Context, c backends.Collection, p *common.Delete) (int32, error) {
+//
+//nolint:lll // for readability
+func (h *Handler) execDelete(ctx context.Context, c backends.Collection, p *common.Delete, vars aggregations.Variables) (int32, error) {
 	var qp backends.QueryParams
 	if !h.DisablePushdown {
 		qp.Filter = p.Filter
@@ -144,7 +147,7 @@ func (h *Handler) execDelete(ctx context.Context, c backends.Collection, p *comm
 
 		var matches bool
 
-		if matches, err = common.FilterDocument(doc, p.Filter); err != nil {
+		if matches, err = common.FilterDocumentWithVariables(doc, p.Filter, vars); err != nil {
 			q.Iter.Close()
 			return 0, lazyerrors.Error(err)
 		}
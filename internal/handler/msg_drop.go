@@ -77,6 +77,8 @@ func (h *Handler) MsgDrop(connCtx context.Context, msg *wire.OpMsg) (*wire.OpMsg
 
 	switch {
 	case err == nil, backends.ErrorCodeIs(err, backends.ErrorCodeCollectionDoesNotExist):
+		h.indexUsage.CollectionDropped(dbName, collectionName)
+
 		var reply wire.OpMsg
 		must.NoError(reply.SetSections(wire.MakeOpMsgSection(
 			must.NotFail(types.NewDocument(
@@ -60,6 +60,7 @@ func (h *Handler) MsgDropDatabase(connCtx context.Context, msg *wire.OpMsg) (*wi
 
 	switch {
 	case err == nil:
+		h.indexUsage.DatabaseDropped(dbName)
 		res.Set("dropped", dbName)
 	case backends.ErrorCodeIs(err, backends.ErrorCodeDatabaseNameIsInvalid):
 		// nothing?
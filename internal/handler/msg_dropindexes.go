@@ -103,6 +103,8 @@ func (h *Handler) MsgDropIndexes(connCtx context.Context, msg *wire.OpMsg) (*wir
 		return nil, lazyerrors.Error(err)
 	}
 
+	h.indexUsage.IndexesDropped(dbName, collection, toDrop)
+
 	replyDoc := must.NotFail(types.NewDocument(
 		"nIndexesWas", int32(len(beforeDrop.Indexes)),
 	))
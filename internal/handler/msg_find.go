@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"strings"
 	"time"
 
@@ -99,6 +100,8 @@ func (h *Handler) MsgFind(connCtx context.Context, msg *wire.OpMsg) (*wire.OpMsg
 		return nil, err
 	}
 
+	h.indexUsage.Record(params.DB, params.Collection, params.Filter)
+
 	ctx := connCtx
 	cancel := func() {}
 
@@ -293,6 +296,8 @@ func (h *Handler) makeFindQueryParams(ctx context.Context, params *common.FindPa
 // makeFindIter creates an iterator chain for the find command.
 //
 // Iter is passed from the backend's query.
+// Sorting is subject to the memory limit of blocking stages, and uses temporary files if allowDiskUse is set;
+// with limit, only skip+limit documents are kept in memory while sorting.
 // All iterators, including the initial one, are added to the passed closer,
 // and the returned iterator is wrapped with it.
 //
@@ -300,12 +305,36 @@ func (h *Handler) makeFindQueryParams(ctx context.Context, params *common.FindPa
 func (h *Handler) makeFindIter(iter types.DocumentsIterator, closer *iterator.MultiCloser, params *common.FindParams) (types.DocumentsIterator, error) {
 	closer.Add(iter)
 
-	iter = common.FilterIterator(iter, closer, params.Filter)
+	iter = common.FilterIterator(iter, closer, params.Filter, params.Variables)
+
+	blocking := &common.BlockingParams{
+		MaxMemoryBytes: h.BlockingStageMaxMemoryBytes,
+		AllowDiskUse:   params.AllowDiskUse,
+		Dir:            h.SpillDir,
+	}
+
+	// skipped documents are sorted too
+	var sortLimit int64
+	if params.Limit != 0 && params.Skip <= math.MaxInt64-params.Limit {
+		sortLimit = params.Skip + params.Limit
+	}
 
-	iter, err := common.SortIterator(iter, closer, params.Sort)
+	iter, err := common.SortIterator(iter, closer, params.Sort, sortLimit, blocking)
 	if err != nil {
 		closer.Close()
 
+		if errors.Is(err, common.ErrMemoryLimitExceeded) {
+			return nil, handlererrors.NewCommandErrorMsgWithArgument(
+				handlererrors.ErrQueryExceededMemoryLimitNoDiskUseAllowed,
+				fmt.Sprintf(
+					"Executor error during find command :: caused by :: Sort exceeded memory limit of %d bytes, "+
+						"but did not opt in to external sorting.",
+					blocking.MaxMemoryBytes,
+				),
+				"find",
+			)
+		}
+
 		var pathErr *types.PathError
 		if errors.As(err, &pathErr) && pathErr.Code() == types.ErrPathElementEmpty {
 			return nil, handlererrors.NewCommandErrorMsgWithArgument(
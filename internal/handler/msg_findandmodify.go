@@ -129,6 +129,8 @@ func (h *Handler) findAndModifyDocument(ctx context.Context, params *common.Find
 	closer := iterator.NewMultiCloser(iterator.CloserFunc(cancel))
 	defer closer.Close()
 
+	h.indexUsage.Record(params.DB, params.Collection, params.Query)
+
 	var qp backends.QueryParams
 	if !h.DisablePushdown {
 		qp.Filter = params.Query
@@ -141,9 +143,10 @@ func (h *Handler) findAndModifyDocument(ctx context.Context, params *common.Find
 
 	closer.Add(queryRes.Iter)
 
-	iter := common.FilterIterator(queryRes.Iter, closer, params.Query)
+	iter := common.FilterIterator(queryRes.Iter, closer, params.Query, params.Variables)
 
-	iter, err = common.SortIterator(iter, closer, params.Sort)
+	// only the first document is used, so only one document is kept in memory while sorting
+	iter, err = common.SortIterator(iter, closer, params.Sort, 1, nil)
 	if err != nil {
 		var pathErr *types.PathError
 		if errors.As(err, &pathErr) && pathErr.Code() == types.ErrPathElementEmpty {
@@ -163,6 +163,9 @@ func (h *Handler) MsgRenameCollection(connCtx context.Context, msg *wire.OpMsg)
 		return nil, lazyerrors.Error(err)
 	}
 
+	h.indexUsage.CollectionDropped(oldDBName, oldCName)
+	h.indexUsage.CollectionDropped(newDBName, newCName)
+
 	var reply wire.OpMsg
 	must.NoError(reply.SetSections(wire.MakeOpMsgSection(
 		must.NotFail(types.NewDocument(
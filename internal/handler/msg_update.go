@@ -124,7 +124,7 @@ func (h *Handler) updateDocument(ctx context.Context, params *common.UpdateParam
 
 		closer.Add(res.Iter)
 
-		iter := common.FilterIterator(res.Iter, closer, u.Filter)
+		iter := common.FilterIterator(res.Iter, closer, u.Filter, params.Variables)
 
 		if !u.Multi {
 			iter = common.LimitIterator(iter, closer, 1)
@@ -42,6 +42,7 @@ func init() {
 			Backend:     b,
 			TCPHost:     opts.TCPHost,
 			ReplSetName: opts.ReplSetName,
+			SpillDir:    opts.SpillDir,
 
 			SetupDatabase: opts.SetupDatabase,
 			SetupUsername: opts.SetupUsername,
@@ -52,12 +53,14 @@ func init() {
 			ConnMetrics:   opts.ConnMetrics,
 			StateProvider: opts.StateProvider,
 
-			DisablePushdown:         opts.DisablePushdown,
-			CappedCleanupPercentage: opts.CappedCleanupPercentage,
-			CappedCleanupInterval:   opts.CappedCleanupInterval,
-			EnableNewAuth:           opts.EnableNewAuth,
-			BatchSize:               opts.BatchSize,
-			MaxBsonObjectSizeBytes:  opts.MaxBsonObjectSizeBytes,
+			DisablePushdown:             opts.DisablePushdown,
+			CappedCleanupPercentage:     opts.CappedCleanupPercentage,
+			CappedCleanupInterval:       opts.CappedCleanupInterval,
+			EnableNewAuth:               opts.EnableNewAuth,
+			BatchSize:                   opts.BatchSize,
+			MaxBsonObjectSizeBytes:      opts.MaxBsonObjectSizeBytes,
+			GraphLookupMaxMemoryBytes:   opts.GraphLookupMaxMemoryBytes,
+			BlockingStageMaxMemoryBytes: opts.BlockingStageMaxMemoryBytes,
 		}
 
 		h, err := handler.New(handlerOpts)
@@ -46,6 +46,7 @@ type NewHandlerOpts struct {
 	StateProvider *state.Provider
 	TCPHost       string
 	ReplSetName   string
+	SpillDir      string
 	SetupDatabase string
 	SetupUsername string
 	SetupPassword password.Password
@@ -70,14 +71,16 @@ type NewHandlerOpts struct {
 
 // TestOpts represents experimental configuration options.
 type TestOpts struct {
-	DisablePushdown         bool
-	EnableNestedPushdown    bool
-	CappedCleanupInterval   time.Duration
-	CappedCleanupPercentage uint8
-	EnableNewAuth           bool
-	BatchSize               int
-	MaxBsonObjectSizeBytes  int
-	_                       struct{} // prevent unkeyed literals
+	DisablePushdown             bool
+	EnableNestedPushdown        bool
+	CappedCleanupInterval       time.Duration
+	CappedCleanupPercentage     uint8
+	EnableNewAuth               bool
+	BatchSize                   int
+	MaxBsonObjectSizeBytes      int
+	GraphLookupMaxMemoryBytes   int
+	BlockingStageMaxMemoryBytes int
+	_                           struct{} // prevent unkeyed literals
 }
 
 // NewHandler constructs a new handler.
@@ -40,6 +40,7 @@ func init() {
 			Backend:     b,
 			TCPHost:     opts.TCPHost,
 			ReplSetName: opts.ReplSetName,
+			SpillDir:    opts.SpillDir,
 
 			SetupDatabase: opts.SetupDatabase,
 			SetupUsername: opts.SetupUsername,
@@ -50,13 +51,15 @@ func init() {
 			ConnMetrics:   opts.ConnMetrics,
 			StateProvider: opts.StateProvider,
 
-			DisablePushdown:         opts.DisablePushdown,
-			EnableNestedPushdown:    opts.EnableNestedPushdown,
-			CappedCleanupPercentage: opts.CappedCleanupPercentage,
-			CappedCleanupInterval:   opts.CappedCleanupInterval,
-			EnableNewAuth:           opts.EnableNewAuth,
-			BatchSize:               opts.BatchSize,
-			MaxBsonObjectSizeBytes:  opts.MaxBsonObjectSizeBytes,
+			DisablePushdown:             opts.DisablePushdown,
+			EnableNestedPushdown:        opts.EnableNestedPushdown,
+			CappedCleanupPercentage:     opts.CappedCleanupPercentage,
+			CappedCleanupInterval:       opts.CappedCleanupInterval,
+			EnableNewAuth:               opts.EnableNewAuth,
+			BatchSize:                   opts.BatchSize,
+			MaxBsonObjectSizeBytes:      opts.MaxBsonObjectSizeBytes,
+			GraphLookupMaxMemoryBytes:   opts.GraphLookupMaxMemoryBytes,
+			BlockingStageMaxMemoryBytes: opts.BlockingStageMaxMemoryBytes,
 		}
 
 		h, err := handler.New(handlerOpts)